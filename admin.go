@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	pb "grpc"
+	config "node/config"
+	"node/node"
+	"strings"
+	"time"
+)
+
+// Runs an admin operation of the node (please, go to `admin.go` in the node package), or only reports
+// the documents and files it would touch with --dry-run. Gives the exit code of the command
+func runAdmin(ctx context.Context, args []string) int {
+	if len(args) < 1 || strings.HasPrefix(args[0], "-") {
+		fmt.Println("usage: meander admin <operation> [arguments] [--dry-run] [flags]")
+		for _, operation := range node.AdminOperations() {
+			fmt.Printf("  %-14s %s\n", operation.Name, operation.Usage)
+		}
+		return 2
+	}
+
+	// The arguments of the operation come before the flags shared by all the commands
+	name, operands, flags, dryRun := args[0], []string{}, []string{}, false
+	for _, arg := range args[1:] {
+		switch {
+		case arg == "--dry-run" || arg == "-dry-run":
+			dryRun = true
+		case len(flags) == 0 && !strings.HasPrefix(arg, "-"):
+			operands = append(operands, arg)
+		default:
+			flags = append(flags, arg)
+		}
+	}
+
+	cfg := parseFlags(flags)
+	if err := config.Validate(); err != nil {
+		fmt.Printf("Invalid configuration: %v\n", err)
+		return 1
+	}
+
+	local, err := node.NewLocalNode(ctx, cfg)
+	if err != nil {
+		fmt.Printf("failed to create the node: %v\n", err)
+		return 1
+	}
+
+	if err := local.Initialize(ctx); err != nil {
+		fmt.Printf("failed to initialize the backlog: %v\n", err)
+		return 1
+	}
+
+	// The resync reconciles the node against its mirror
+	node.RegisterPeerTransport(pb.PeerClient{Port: cfg.Port, Timeout: 10 * time.Second})
+
+	report, err := local.RunAdminOperation(ctx, name, operands, dryRun)
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+
+	if !report.DryRun {
+		fmt.Println(report.Summary)
+		return 0
+	}
+
+	for _, operation := range report.Operations {
+		fmt.Printf("[%s] %s/%s\n", operation.Kind, operation.Index, operation.Id)
+	}
+
+	for _, file := range report.Files {
+		fmt.Printf("[file] %s\n", file)
+	}
+
+	fmt.Printf("Dry run: %s (%d document(s) and %d file(s) would be touched)\n", report.Summary, len(report.Operations), len(report.Files))
+	return 0
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	pb "grpc"
+	"net"
+	config "node/config"
+	"node/node"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// Performs a gRPC handshake with the mirror node and compares its build with the current one
+func diagnoseMirror(mirror, port string) node.Finding {
+	if mirror == "" || mirror == "0.0.0.0" {
+		return node.Finding{Check: "mirror", Ok: true, Detail: "no mirror configured"}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	address := net.JoinHostPort(mirror, port)
+	conn, err := grpc.DialContext(ctx, address, grpc.WithTransportCredentials(insecure.NewCredentials()), grpc.WithBlock())
+	if err != nil {
+		return node.Finding{
+			Check:  "mirror",
+			Ok:     false,
+			Detail: fmt.Sprintf("handshake with %s failed: %v", address, err),
+			Hint:   "check the --mirror address and if the mirror node is running",
+		}
+	}
+	defer conn.Close()
+
+	// The mirrors that don't serve the stats yet still pass the handshake
+	stats, err := pb.NewMeanderClientIOClient(conn).GetNodeStats(ctx, &pb.NodeStatsPayload{})
+	if err != nil {
+		return node.Finding{Check: "mirror", Ok: true, Detail: fmt.Sprintf("handshake with %s succeeded", address)}
+	}
+
+	build := node.CurrentBuild()
+	if stats.Build != nil && stats.Build.Version != build.Version {
+		return node.Finding{
+			Check:  "mirror",
+			Ok:     true,
+			Detail: fmt.Sprintf("handshake with %s succeeded, but the mirror runs %s and this node %s", address, stats.Version, build),
+			Hint:   "roll the same version out to every node of the network",
+		}
+	}
+
+	features := strings.Join(config.EnabledFeatures(), ",")
+	if mirrorFeatures := strings.Join(stats.Features, ","); mirrorFeatures != features {
+		return node.Finding{
+			Check:  "mirror",
+			Ok:     true,
+			Detail: fmt.Sprintf("handshake with %s succeeded, but the mirror enables the features [%s] and this node [%s]", address, mirrorFeatures, features),
+			Hint:   "it's expected while a feature is rolled out, otherwise align the FEATURES of both nodes",
+		}
+	}
+
+	return node.Finding{Check: "mirror", Ok: true, Detail: fmt.Sprintf("handshake with %s succeeded (the mirror runs %s)", address, stats.Version)}
+}
+
+// Runs the startup self-check and prints the findings. Gives the exit code of the command
+func runDoctor(ctx context.Context, cfg *config.Config) int {
+	findings := append(node.Diagnose(ctx, cfg.Port), diagnoseMirror(cfg.Mirror, cfg.Port))
+	failures := 0
+
+	for _, finding := range findings {
+		mark := "ok"
+		if !finding.Ok {
+			mark = "FAIL"
+			failures++
+		}
+
+		fmt.Printf("[%4s] %-24s %s\n", mark, finding.Check, finding.Detail)
+		if finding.Hint != "" {
+			fmt.Printf("       %-24s hint: %s\n", "", finding.Hint)
+		}
+	}
+
+	if failures > 0 {
+		fmt.Printf("\n%d check(s) failed\n", failures)
+		return 1
+	}
+
+	fmt.Println("\nAll checks passed")
+	return 0
+}
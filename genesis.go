@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	config "node/config"
+	"node/node"
+)
+
+// Bootstraps the chain of a new network, creating its genesis block with the allocation of the config
+// (please, go to `genesis.go` in the node package). Gives the exit code of the command
+func runInit(ctx context.Context, cfg *config.Config) int {
+	if err := config.Validate(); err != nil {
+		fmt.Printf("Invalid configuration: %v\n", err)
+		return 1
+	}
+
+	n, err := node.NewLocalNode(ctx, cfg)
+	if err != nil {
+		fmt.Printf("failed to create the node: %v\n", err)
+		return 1
+	}
+	defer n.Close()
+
+	if err := n.Initialize(ctx); err != nil {
+		fmt.Printf("failed to initialize the backlog: %v\n", err)
+		return 1
+	}
+
+	if err := n.CheckSchema(ctx); err != nil {
+		fmt.Printf("Incompatible backlog: %v\n", err)
+		return 1
+	}
+
+	genesis, err := n.InitChain(ctx)
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+
+	fmt.Printf("Genesis %s with %d allocation(s)\n", genesis.Hash, len(genesis.Transactions))
+	fmt.Printf("The nodes that join the network must set %s=%s\n", config.GenesisHashEnv, genesis.Hash)
+	return 0
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	config "node/config"
+	"node/node"
+	"os"
+)
+
+// Imports the clients of a manifest (CSV or JSON) into the node, printing the progress after every
+// batch. Gives the exit code of the command
+func runImport(ctx context.Context, args []string) int {
+	if len(args) < 1 {
+		fmt.Println("usage: meander import <manifest file> [flags]")
+		return 2
+	}
+
+	content, err := os.ReadFile(args[0])
+	if err != nil {
+		fmt.Printf("failed to read the manifest: %v\n", err)
+		return 1
+	}
+
+	entries, err := node.ParseClientManifest(args[0], content)
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+
+	// The key pairs are written to the key paths of the node
+	cfg := parseFlags(args[1:])
+	if err := config.Validate(); err != nil {
+		fmt.Printf("Invalid configuration: %v\n", err)
+		return 1
+	}
+
+	local, err := node.NewLocalNode(ctx, cfg)
+	if err != nil {
+		fmt.Printf("failed to create the node: %v\n", err)
+		return 1
+	}
+
+	if err := local.Initialize(ctx); err != nil {
+		fmt.Printf("failed to initialize the backlog: %v\n", err)
+		return 1
+	}
+
+	failed := 0
+	err = local.ImportClients(ctx, entries, func(progress node.ImportProgress) error {
+		for _, result := range progress.Results {
+			if result.Error != "" {
+				fmt.Printf("[FAIL] %-24s %s\n", result.Alias, result.Error)
+			}
+		}
+
+		fmt.Printf("Imported %d of %d client(s), %d failed\n", progress.Done, progress.Total, progress.Failed)
+		failed = progress.Failed
+		return nil
+	})
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+
+	if failed > 0 {
+		return 1
+	}
+
+	return 0
+}
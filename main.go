@@ -1,76 +1,278 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	pb "grpc"
 	"log"
-	"net"
+	config "node/config"
 	"node/node"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
+	"time"
 
 	"google.golang.org/grpc"
 )
 
-func registerExitHandler(f func()) {
+// Dumps a diagnostic bundle whenever the process receives SIGUSR1
+func registerDiagnosticsHandler(ctx context.Context, n *node.Node) {
 	c := make(chan os.Signal, 1)
-	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+	signal.Notify(c, syscall.SIGUSR1)
 
 	go func() {
-		<-c
-		f()
-		os.Exit(0)
+		for range c {
+			dir, err := n.DumpDiagnostics(ctx)
+			if err != nil {
+				fmt.Printf("failed to dump the diagnostics: %v\n", err)
+				continue
+			}
+
+			fmt.Printf("Diagnostics dumped to \"%s\"\n", dir)
+		}
 	}()
 }
 
-func waitForSignal() {
-	select {}
+// Blocks until the process is asked to exit or the server stops serving. Gives the failure of the
+// server (nil when the process was asked to exit)
+func waitForSignal(serving <-chan error) error {
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(c)
+
+	select {
+	case <-c:
+		return nil
+	case err := <-serving:
+		return err
+	}
 }
 
-func main() {
-	var basePath string
+const (
+	rpcDrainTimeout        = 15 * time.Second // How long the shutdown waits for the running calls (and streams) to finish
+	subsystemsDrainTimeout = 10 * time.Second // How long the shutdown waits for the background subsystems to finish their work
+)
 
-	flag.StringVar(&basePath, "path", "", "The path to store the server resources")
-	flag.Parse()
+// Parses the command line flags shared by all the commands and gives the settings of the node: the
+// flags override the config file and the environment (please, go to `file.go` in the config package)
+func parseFlags(args []string) *config.Config {
+	flags := flag.NewFlagSet("meander", flag.ExitOnError)
+	configFile := flags.String("config", os.Getenv(config.ConfigFileEnv), "The config file with the settings of the node")
+	flags.String("path", "", "The path to store the server resources")
+	flags.String("keys", "", "The paths (separated by the OS list separator) to split the client keys across")
+	flags.String("mirror", "", "The host address from the peer that serves as mirror")
+	flags.String("port", "", "The port where the node and its peers serve the gRPC API")
+	flags.String("listen", "", "The address of the interface where the node listens (all of them by default)")
+	flags.String("backlog", "", "The address of the ElasticSearch that holds the backlog")
+	flags.Parse(args)
 
-	os.Setenv("BASE_PATH", basePath)
+	cfg, err := config.Load(*configFile)
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
 
-	if _, err := os.Stat(basePath); os.IsNotExist(err) {
-		err := os.Mkdir(node.BasePath, 0755)
-		if err != nil {
-			log.Fatalf("Failed to create path \"%s\": %v", basePath, err)
-		} else {
-			fmt.Printf("Path \"%s\" successfully created\n", basePath)
+	// Only the flags given in the command line override the loaded settings
+	flags.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "path":
+			cfg.BasePath = f.Value.String()
+		case "keys":
+			cfg.KeyPaths = f.Value.String()
+		case "mirror":
+			cfg.Mirror = f.Value.String()
+		case "port":
+			cfg.Port = f.Value.String()
+		case "listen":
+			cfg.ListenAddress = f.Value.String()
+		case "backlog":
+			cfg.BacklogAddress = f.Value.String()
 		}
+	})
+
+	cfg.Apply()
+	return cfg
+}
+
+func main() {
+	// The context of the node lifetime, done when the process is asked to exit
+	ctx, cancel := context.WithCancel(context.Background())
+
+	if len(os.Args) > 1 && os.Args[1] == "doctor" {
+		os.Exit(runDoctor(ctx, parseFlags(os.Args[2:])))
+	}
 
-	} else {
-		fmt.Printf("Path \"%s\" already created\n", basePath)
+	// The genesis is written straight to the backlog, before any node of the network serves
+	if len(os.Args) > 1 && os.Args[1] == "--init" {
+		os.Exit(runInit(ctx, parseFlags(os.Args[2:])))
 	}
 
-	node := node.NewLocalNode("0.0.0.0")
-	node.Initialize()
-	node.Attach()
-	registerExitHandler(node.Dettach)
+	// The custody bundles are checked offline, so the command needs no node
+	if len(os.Args) > 1 && os.Args[1] == "verify" {
+		os.Exit(runVerify(os.Args[2:]))
+	}
+
+	// The clients are imported straight to the backlog, so the command doesn't need the node running
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		os.Exit(runImport(ctx, os.Args[2:]))
+	}
+
+	// The admin operations run straight against the backlog, taking the leases of their duties
+	if len(os.Args) > 1 && os.Args[1] == "admin" {
+		os.Exit(runAdmin(ctx, os.Args[2:]))
+	}
+
+	// The scripts are published straight to the backlog, so the command doesn't need the node running
+	if len(os.Args) > 1 && os.Args[1] == "script" {
+		os.Exit(runScript(ctx, os.Args[2:]))
+	}
+
+	cfg := parseFlags(os.Args[1:])
+
+	if err := config.Validate(); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
 
-	const port string = "1313"
-	listener, err := net.Listen("tcp", ":"+port)
+	node.RegisterPeerTransport(pb.PeerClient{Port: cfg.Port, Timeout: 10 * time.Second})
+	node.RegisterScriptRuntime(node.NewWasmRuntime())
 
+	node, err := node.NewLocalNode(ctx, cfg)
+	if err != nil {
+		log.Fatalf("Failed to create the node: %v", err)
+	}
+
+	if err := node.Initialize(ctx); err != nil {
+		log.Fatalf("Failed to initialize the backlog: %v", err)
+	}
+
+	if err := node.CheckSchema(ctx); err != nil {
+		log.Fatalf("Incompatible backlog: %v", err)
+	}
+
+	listener, inherited, err := listen(cfg)
 	if err != nil {
 		log.Fatalf("net.Listen: %v", err)
 	}
 
-	server := grpc.NewServer()
+	// A successor shares the backlog with the running former process, so nothing was left behind
+	if !inherited {
+		repairs, err := node.Recover(ctx)
+		for _, repair := range repairs {
+			fmt.Printf("Recovery: %s\n", repair)
+		}
+		if err != nil {
+			log.Fatalf("Recovery failed: %v", err)
+		}
+	}
+
+	node.Attach(ctx)
+
+	// The subsystems finish their work after the node context is done, while they're drained
+	node.StartSubsystems(context.Background())
+
+	if pulled, err := node.PullBlocks(ctx); err != nil {
+		fmt.Printf("failed to pull the blocks from the peers: %v\n", err)
+	} else if pulled > 0 {
+		fmt.Printf("Pulled %d block(s) from the peers\n", pulled)
+	}
+
+	for _, err := range []error{
+		node.StartMirrorSync("@every 1m"),
+		node.StartAntiEntropy("@every 15m"),
+		node.StartPeerManager("@every 30s"),
+		node.StartClientGossip("@every 30s"),
+		node.StartDiskMonitor("@every 1m"),
+		node.StartMaintenanceMonitor("@every 15s"),
+		node.StartAddressMonitor("*/5 * * * *"),
+		node.StartMetricsRecorder("@every 1m"),
+		node.StartJanitor("@hourly"),
+		node.StartScriptLoader(ctx, "@every 1m"),
+		node.StartAnchoring("@hourly"),
+		node.StartMining("@every 30s"),
+		node.StartHealthMonitor("@every 15s"),
+		node.StartBackpressureMonitor("@every 5s"),
+	} {
+		if err != nil {
+			log.Fatalf("Failed to schedule the jobs: %v", err)
+		}
+	}
+	node.StartScheduler(ctx)
+	registerDiagnosticsHandler(ctx, node)
+
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(pb.CorrelationInterceptor, pb.MetricsInterceptor, pb.RateLimitInterceptor, pb.ConcurrencyInterceptor, pb.AuthInterceptor, pb.DedupInterceptor),
+		grpc.ChainStreamInterceptor(pb.RateLimitStreamInterceptor, pb.ConcurrencyStreamInterceptor, pb.AuthStreamInterceptor),
+	)
 	service := &pb.MeanderServer{}
 
 	pb.RegisterMeanderClientIOServer(server, service)
+	pb.RegisterMeanderPeerIOServer(server, &pb.MeanderPeerServer{})
+	pb.RegisterMeanderAdminIOServer(server, &pb.MeanderAdminServer{})
+	pb.RegisterHealthProbe(server)
+	registerRestartHandler(ctx, node, listener, server, cancel)
+
+	if inherited {
+		// The former processes built before the tokens await the record under the process id
+		token := config.InheritedHandoverToken()
+		if token == "" {
+			token = strconv.Itoa(os.Getpid())
+		}
+
+		if err := node.CompleteHandover(ctx, token, os.Getpid()); err != nil {
+			log.Fatalf("Handover failed: %v", err)
+		}
+	}
+
+	serving := make(chan error, 1)
+	go func() {
+		serving <- server.Serve(listener)
+	}()
+	fmt.Printf("Server listening on %s\n", listener.Addr())
+
+	failure := waitForSignal(serving)
+	shutdown(node, server, cancel)
+
+	if failure != nil {
+		log.Fatalf("The server stopped: %v", failure)
+	}
+}
+
+// Stops the server letting the running calls finish, until the drain timeout
+func stopServer(server *grpc.Server) {
+	stopped := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(stopped)
+	}()
+
+	// The streams (e.g. the transaction feeds) never finish by themselves, so they're cut
+	select {
+	case <-stopped:
+	case <-time.After(rpcDrainTimeout):
+		server.Stop()
+	}
+}
+
+// Stops the node: the running calls finish, the node leaves its duties and detaches itself, the
+// background work is drained and the backlog connections are closed
+func shutdown(n *node.Node, server *grpc.Server, stop func()) {
+	stop()
+	stopServer(server)
+
+	if err := n.ReleaseLeases(context.Background()); err != nil {
+		fmt.Printf("failed to release the leases: %v\n", err)
+	}
+
+	// The end signal must reach the backlog even though the node context is done
+	n.Dettach(context.Background(), config.ExpectedDowntime())
+
+	drain, cancelDrain := context.WithTimeout(context.Background(), subsystemsDrainTimeout)
+	defer cancelDrain()
 
-	if err = server.Serve(listener); err != nil {
-		log.Fatal(err)
-	} else {
-		fmt.Println("Server started listening the port ", port)
+	if err := n.StopSubsystems(drain); err != nil {
+		fmt.Printf("%v\n", err)
 	}
 
-	waitForSignal()
+	n.Close()
 }
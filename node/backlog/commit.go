@@ -0,0 +1,235 @@
+package node
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	timeutil "node/timeutil"
+
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+	"github.com/google/uuid"
+)
+
+type OperationKind string
+
+const (
+	OperationPut    OperationKind = "put"    // Writes the whole document
+	OperationUpdate OperationKind = "update" // Merges the fields into the existing document
+	OperationDelete OperationKind = "delete" // Removes the document
+)
+
+// A change of a single document inside an atomic commit
+type Operation struct {
+	Kind     OperationKind          `json:"kind"`
+	Index    string                 `json:"index"`
+	Id       string                 `json:"id"`
+	Document map[string]interface{} `json:"document,omitempty"` // The new content (empty for deletions)
+	Previous map[string]interface{} `json:"previous,omitempty"` // The content before the commit (empty when it didn't exist)
+}
+
+/*
+The ElasticSearch has no transactions across documents, so the changes that must happen together
+(e.g. a block and the confirmation of its transactions) go through an atomic commit.
+
+Before touching any document, the commit writes an intent to the `intents` index with every
+operation and the previous content of every target document. Then the operations are applied in
+order. When one of them fails, the applied ones are compensated in the reverse order by restoring
+the previous contents, and the intent is removed. When all of them succeed, the intent is removed.
+
+An intent left in the backlog means the process died in the middle of a commit. It's rolled back
+by `RecoverIntents` on startup, so the commit either happens completely or doesn't happen at all.
+*/
+type Commit struct {
+	*Backlog   `json:"-"`
+	Id         string      `json:"id"`
+	Started    int64       `json:"started"`
+	Operations []Operation `json:"operations"`
+}
+
+// Starts a new atomic commit in the backlog
+func (b *Backlog) Begin() *Commit {
+	id, _ := uuid.NewUUID()
+	return &Commit{Backlog: b, Id: id.String()}
+}
+
+// Schedules the writing of a whole document
+func (c *Commit) Put(index, id string, document map[string]interface{}) *Commit {
+	c.Operations = append(c.Operations, Operation{Kind: OperationPut, Index: index, Id: id, Document: document})
+	return c
+}
+
+// Schedules the merging of some fields into an existing document
+func (c *Commit) Update(index, id string, fields map[string]interface{}) *Commit {
+	c.Operations = append(c.Operations, Operation{Kind: OperationUpdate, Index: index, Id: id, Document: fields})
+	return c
+}
+
+// Schedules the removal of a document
+func (c *Commit) Delete(index, id string) *Commit {
+	c.Operations = append(c.Operations, Operation{Kind: OperationDelete, Index: index, Id: id})
+	return c
+}
+
+// Gives the priority of the most important document of the commit
+func (c *Commit) priority(ctx context.Context) Priority {
+	priority := PriorityStats
+	for _, operation := range c.Operations {
+		if operationPriority := priorityOf(ctx, operation.Index); operationPriority > priority {
+			priority = operationPriority
+		}
+	}
+
+	return priority
+}
+
+// Writes the intent, applies the operations and compensates them when any of them fails
+func (c *Commit) Apply(ctx context.Context) error {
+	if recordDryRun(ctx, c.Operations...) {
+		return nil
+	}
+
+	ctx = WithPriority(ctx, c.priority(ctx))
+
+	for i := range c.Operations {
+		operation := &c.Operations[i]
+
+		previous, err := c.GetDocument(ctx, operation.Index, operation.Id)
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			return fmt.Errorf("failed to read the document %s/%s: %v", operation.Index, operation.Id, err)
+		}
+
+		operation.Previous = previous
+	}
+
+	c.Started = timeutil.Now()
+	if err := c.ReplaceDocument(ctx, "intents", c.Id, c.document()); err != nil {
+		return fmt.Errorf("failed to write the commit intent: %v", err)
+	}
+
+	for i, operation := range c.Operations {
+		if err := c.apply(ctx, operation); err != nil {
+			if rollbackErr := c.rollback(ctx, i); rollbackErr != nil {
+				return fmt.Errorf("failed to apply the commit: %v (%v)", err, rollbackErr)
+			}
+
+			return fmt.Errorf("failed to apply the commit: %v", err)
+		}
+	}
+
+	if err := c.DeleteDocument(ctx, "intents", c.Id); err != nil {
+		return fmt.Errorf("failed to remove the commit intent: %v", err)
+	}
+
+	return nil
+}
+
+func (c *Commit) apply(ctx context.Context, operation Operation) error {
+	switch operation.Kind {
+	case OperationPut:
+		return c.ReplaceDocument(ctx, operation.Index, operation.Id, operation.Document)
+	case OperationUpdate:
+		return c.UpdateDocument(ctx, operation.Index, operation.Id, operation.Document)
+	case OperationDelete:
+		return c.DeleteDocument(ctx, operation.Index, operation.Id)
+	default:
+		return fmt.Errorf("unknown operation %s", operation.Kind)
+	}
+}
+
+// Restores the previous contents of the operations up to the given one (inclusive), in the
+// reverse order, and removes the intent
+func (c *Commit) rollback(ctx context.Context, last int) error {
+	for i := last; i >= 0; i-- {
+		operation := c.Operations[i]
+
+		var err error
+		if operation.Previous == nil {
+			err = c.DeleteDocument(ctx, operation.Index, operation.Id)
+		} else {
+			err = c.ReplaceDocument(ctx, operation.Index, operation.Id, operation.Previous)
+		}
+
+		if err != nil {
+			return fmt.Errorf("failed to restore the document %s/%s: %v", operation.Index, operation.Id, err)
+		}
+	}
+
+	if err := c.DeleteDocument(ctx, "intents", c.Id); err != nil {
+		return fmt.Errorf("failed to remove the commit intent: %v", err)
+	}
+
+	return nil
+}
+
+func (c *Commit) document() map[string]interface{} {
+	commitBytes, _ := json.Marshal(c)
+
+	var document map[string]interface{}
+	json.Unmarshal(commitBytes, &document)
+
+	return document
+}
+
+// Rolls back the commits interrupted by a crash, among the ones started before the timestamp (the
+// later ones may still be in progress in another process). Gives the ids of the rolled back commits
+func (b *Backlog) RecoverIntents(ctx context.Context, before int64) ([]string, error) {
+	documents, err := b.ListDocuments(ctx, "intents", ListOptions{All: true, Query: Range("started", nil, before)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the commit intents: %v", err)
+	}
+
+	var recovered []string
+	for _, document := range documents {
+		intentBytes, err := json.Marshal(document)
+		if err != nil {
+			return recovered, fmt.Errorf("failed to marshal the commit intent: %v", err)
+		}
+
+		commit := Commit{Backlog: b}
+		if err := json.Unmarshal(intentBytes, &commit); err != nil {
+			return recovered, fmt.Errorf("failed to unmarshal the commit intent: %v", err)
+		}
+
+		if err := commit.rollback(ctx, len(commit.Operations)-1); err != nil {
+			return recovered, err
+		}
+
+		recovered = append(recovered, commit.Id)
+	}
+
+	return recovered, nil
+}
+
+// Writes the whole document, replacing the existing one instead of merging into it
+func (b Backlog) ReplaceDocument(ctx context.Context, index, id string, document map[string]interface{}) error {
+	if recordDryRun(ctx, Operation{Kind: OperationPut, Index: index, Id: id, Document: document}) {
+		return nil
+	}
+
+	jsonDocument, err := json.Marshal(document)
+	if err != nil {
+		return err
+	}
+
+	req := esapi.IndexRequest{
+		Index:      index,
+		DocumentID: id,
+		Body:       bytes.NewBuffer(jsonDocument),
+		Refresh:    refreshPolicy(ctx),
+	}
+
+	res, err := b.write(ctx, index, req)
+	if err != nil {
+		return err
+	}
+
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return fmt.Errorf("failed to index the document: %s", res.String())
+	}
+
+	return nil
+}
@@ -0,0 +1,65 @@
+package node
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+/*
+A dry run performs an operation without changing anything: the writes made in a dry-run context are
+recorded in its plan instead of being sent to the ElasticSearch, so the operators see exactly which
+documents a destructive operation would touch (please, go to `admin.go` in the node package). The
+reads are real, so the plan is computed against the current state of the backlog.
+
+The writes out of the backlog (e.g. the key files) check the context for themselves and record the
+files they would touch in the plan too.
+*/
+type Plan struct {
+	mutex      sync.Mutex
+	Operations []Operation `json:"operations"` // The writes of documents, in the order they were made
+	Files      []string    `json:"files"`      // The files that would be written or removed, sorted
+}
+
+type dryRunKey struct{}
+
+// Gives a context whose writes are only recorded in the plan
+func WithDryRun(ctx context.Context) (context.Context, *Plan) {
+	plan := &Plan{}
+	return context.WithValue(ctx, dryRunKey{}, plan), plan
+}
+
+// Gives the plan of a dry-run context (nil when the writes are real)
+func DryRunOf(ctx context.Context) *Plan {
+	plan, _ := ctx.Value(dryRunKey{}).(*Plan)
+	return plan
+}
+
+// Records the writes of documents instead of making them. Gives whether the context is a dry run
+func recordDryRun(ctx context.Context, operations ...Operation) bool {
+	plan := DryRunOf(ctx)
+	if plan == nil {
+		return false
+	}
+
+	plan.mutex.Lock()
+	defer plan.mutex.Unlock()
+
+	plan.Operations = append(plan.Operations, operations...)
+	return true
+}
+
+// Records a file that would be written or removed
+func (p *Plan) RecordFile(path string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	for _, file := range p.Files {
+		if file == path {
+			return
+		}
+	}
+
+	p.Files = append(p.Files, path)
+	sort.Strings(p.Files)
+}
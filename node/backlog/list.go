@@ -0,0 +1,210 @@
+package node
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+)
+
+const (
+	scrollPageSize  int           = 500         // The number of documents fetched at once while scrolling
+	scrollKeepAlive time.Duration = time.Minute // How long ElasticSearch keeps a scroll between two pages
+)
+
+/*
+The options of a document listing. Without options, the listing gives the first documents of the
+index (ElasticSearch gives 10 by default), so the callers that need all the documents (e.g. the chain
+validation or the exports) must set `All`, which walks the index with a scroll, page by page.
+*/
+type ListOptions struct {
+	From  int      // The number of documents skipped (ignored by `All`)
+	Size  int      // The maximum number of documents, or the page size with `All` (the default when zero)
+	Sort  []string // The sort fields, as "field" or "field:desc"
+	Query Query    // A query that filters the documents (all of them when nil)
+	All   bool     // Whether to give all the documents, instead of a single page
+}
+
+// Gives the search body of the options (nil when there's no query)
+func (o ListOptions) body() (io.Reader, error) {
+	if o.Query == nil {
+		return nil, nil
+	}
+
+	jsonBody, err := json.Marshal(map[string]interface{}{"query": o.Query})
+	if err != nil {
+		return nil, err
+	}
+
+	return bytes.NewBuffer(jsonBody), nil
+}
+
+// A page of documents decoded from a search (or scroll) response
+type searchPage struct {
+	scrollId  string                   // The id to fetch the next page of a scroll
+	documents []map[string]interface{} // The documents of the page, with their ids in `_id`
+	total     int64                    // The total of documents that match the search
+}
+
+// Decodes the hits of a search (or scroll) response
+func decodeHits(res *esapi.Response) (*searchPage, error) {
+	if res.IsError() {
+		return nil, fmt.Errorf("failed to list documents: %s", res.String())
+	}
+
+	var response map[string]interface{}
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("failed to decode JSON response: %s", err)
+	}
+
+	page := searchPage{}
+	page.scrollId, _ = response["_scroll_id"].(string)
+
+	outer, _ := response["hits"].(map[string]interface{})
+	if total, ok := outer["total"].(map[string]interface{}); ok {
+		value, _ := total["value"].(float64)
+		page.total = int64(value)
+	}
+
+	hits, _ := outer["hits"].([]interface{})
+	for _, hit := range hits {
+		hitMap, _ := hit.(map[string]interface{})
+		id, _ := hitMap["_id"].(string)
+		source, ok := hitMap["_source"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		source["_id"] = id
+
+		page.documents = append(page.documents, source)
+	}
+
+	return &page, nil
+}
+
+// Searches a single page of the documents, counting all the matches
+func (b Backlog) searchPage(ctx context.Context, index string, opts ListOptions) (*searchPage, error) {
+	body, err := opts.body()
+	if err != nil {
+		return nil, err
+	}
+
+	req := esapi.SearchRequest{
+		Index:          []string{index},
+		Sort:           opts.Sort,
+		Body:           body,
+		TrackTotalHits: true,
+	}
+
+	if opts.From > 0 {
+		req.From = &opts.From
+	}
+
+	if opts.Size > 0 {
+		req.Size = &opts.Size
+	}
+
+	res, err := req.Do(ctx, b)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	return decodeHits(res)
+}
+
+// An util implementation of document listing process in ElasticSearch. A single page is given,
+// unless the options ask for all the documents
+func (b Backlog) ListDocuments(ctx context.Context, index string, opts ListOptions) ([]map[string]interface{}, error) {
+	var results []map[string]interface{}
+
+	if opts.All {
+		err := b.ScrollDocuments(ctx, index, opts, func(document map[string]interface{}) error {
+			results = append(results, document)
+			return nil
+		})
+
+		return results, err
+	}
+
+	page, err := b.searchPage(ctx, index, opts)
+	if err != nil {
+		return results, err
+	}
+
+	return page.documents, nil
+}
+
+// Walks all the documents of an index (that match the query of the options) with a scroll, calling
+// the function for each one, in the order of the options. An error of the function stops the walk
+func (b Backlog) ScrollDocuments(ctx context.Context, index string, opts ListOptions, fn func(document map[string]interface{}) error) error {
+	size := opts.Size
+	if size <= 0 {
+		size = scrollPageSize
+	}
+
+	body, err := opts.body()
+	if err != nil {
+		return err
+	}
+
+	req := esapi.SearchRequest{
+		Index:  []string{index},
+		Scroll: scrollKeepAlive,
+		Size:   &size,
+		Sort:   opts.Sort,
+		Body:   body,
+	}
+
+	res, err := req.Do(ctx, b)
+	if err != nil {
+		return err
+	}
+
+	page, err := decodeHits(res)
+	res.Body.Close()
+	if err != nil {
+		return err
+	}
+
+	scrollId, documents := page.scrollId, page.documents
+
+	defer func() {
+		if scrollId == "" {
+			return
+		}
+
+		clear := esapi.ClearScrollRequest{ScrollID: []string{scrollId}}
+		if res, err := clear.Do(context.Background(), b); err == nil {
+			res.Body.Close()
+		}
+	}()
+
+	for len(documents) > 0 {
+		for _, document := range documents {
+			if err := fn(document); err != nil {
+				return err
+			}
+		}
+
+		next := esapi.ScrollRequest{ScrollID: scrollId, Scroll: scrollKeepAlive}
+		res, err := next.Do(ctx, b)
+		if err != nil {
+			return err
+		}
+
+		page, err := decodeHits(res)
+		res.Body.Close()
+		if err != nil {
+			return err
+		}
+
+		scrollId, documents = page.scrollId, page.documents
+	}
+
+	return nil
+}
@@ -4,8 +4,14 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"net/http"
+	config "node/config"
+	timeutil "node/timeutil"
+	"sort"
+	"sync/atomic"
+	"time"
 
 	"github.com/elastic/go-elasticsearch/v8"
 	"github.com/elastic/go-elasticsearch/v8/esapi"
@@ -20,58 +26,297 @@ new documents or indices into the node database.
 
 The backlog is flexible and can be created anytime. To create a new backlog, you must to
 call the `NewBacklog` method. If you need to connect to an external database, just pass its
-address as `string` argument. If nothing is passed, the function will try to connect to the
-default address `http://localhost:9200`
+address as `string` argument. If nothing is passed, the function will connect to the configured
+address (please, go to `file.go` in the config package), `http://localhost:9200` by default
+
+Every method takes the context of its caller, so the deadlines and cancellations of the RPCs
+reach the ElasticSearch requests.
 */
 type Backlog struct {
 	*elasticsearch.Client
 }
 
-func NewBacklog(address ...string) *Backlog {
-	const BaseURI string = "http://localhost:9200"
+// The connections to the ElasticSearch, shared by all the backlogs of the process
+var transport = http.DefaultTransport.(*http.Transport).Clone()
+
+// Logs the requests made to the ElasticSearch, when the node debugs the backlog
+var tracer func(ctx context.Context, format string, args ...interface{})
+
+// Registers the function that logs the requests made to the ElasticSearch
+func RegisterTracer(fn func(ctx context.Context, format string, args ...interface{})) {
+	tracer = fn
+}
+
+// The transport that measures every request to the ElasticSearch, and logs it with its result and
+// duration
+type measuredTransport struct {
+	*http.Transport
+}
+
+func (t measuredTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	res, err := t.Transport.RoundTrip(req)
+	recordLatency(time.Since(start))
+
+	if tracer != nil {
+		if err != nil {
+			tracer(req.Context(), "%s %s failed after %v: %v", req.Method, req.URL.Path, time.Since(start), err)
+		} else {
+			tracer(req.Context(), "%s %s gave %d after %v", req.Method, req.URL.Path, res.StatusCode, time.Since(start))
+		}
+	}
+
+	return res, err
+}
+
+// The weight of every request in the latency average: the average follows a change of the latency
+// within some tens of requests, but isn't moved by a single slow one
+const latencyWeight = 0.1
+
+// The moving average of the requests latency, in nanoseconds
+var latency atomic.Int64
 
+func recordLatency(d time.Duration) {
+	for {
+		average := latency.Load()
+		next := int64(d)
+		if average != 0 {
+			next = average + int64(latencyWeight*float64(int64(d)-average))
+		}
+
+		if latency.CompareAndSwap(average, next) {
+			return
+		}
+	}
+}
+
+// Gives the moving average of the latency of the requests to the ElasticSearch (zero before the
+// first request)
+func Latency() time.Duration {
+	return time.Duration(latency.Load())
+}
+
+func NewBacklog(address ...string) (*Backlog, error) {
 	if len(address) == 0 {
-		address = append(address, BaseURI)
+		address = append(address, config.BacklogAddress())
 	}
 
 	cfg := elasticsearch.Config{
 		Addresses: []string{
 			address[0],
 		},
+		Transport: measuredTransport{transport},
 	}
 
 	es, err := elasticsearch.NewClient(cfg)
 
 	if err != nil {
-		log.Fatalf("Failed to create elasticsearch client: %s", err)
+		return nil, fmt.Errorf("failed to create elasticsearch client: %v", err)
 	}
 
 	nodeStorage := Backlog{Client: es}
-	return &nodeStorage
+	return &nodeStorage, nil
 }
 
-// This method creates the essential indices of the node backlog
-func (b Backlog) Initialize() {
-	indexes := []string{"peers", "local_clients", "clients", "transactions", "blockchain", "node", "cache"}
+// Closes the idle connections to the ElasticSearch. It's called on shutdown, after the last request
+func (b Backlog) Close() {
+	transport.CloseIdleConnections()
+}
+
+// Given when a document can't be created because there is another one with the same id, or can't
+// be replaced because it changed since it was read
+var ErrConflict = errors.New("the document already exists")
+
+// Given when a document doesn't exist
+var ErrNotFound = errors.New("the document doesn't exist")
+
+// The essential indices of the node backlog
+var Indices = []string{"peers", "local_clients", "clients", "transactions", "blockchain", "node", "cache", "node_metrics", "devices", "events", "sequences", "aliases", "pending_clients", "intents", "addresses", "handovers", "identity", "keys", "leases", "balances", "jobs", "job_runs", "scripts", "anchors", "erasures", "consents", "stealth", "key_store", "key_log", "client_conflicts", "admin_requests", "maintenance", "requests"}
+
+// The mapping of the fields matched as a whole (e.g. ids and hashes)
+var keyword = map[string]interface{}{"type": "keyword"}
+
+// The mapping of the fields that are only kept, never searched (e.g. the keys)
+var stored = map[string]interface{}{"type": "keyword", "index": false, "doc_values": false}
+
+// The mapping of the objects that are only kept, never searched (e.g. the evidence of a conflict)
+var unindexed = map[string]interface{}{"type": "object", "enabled": false}
+
+// The mapping of the objects with arbitrary keys (e.g. the client metadata), so their keys don't
+// grow the mapping of the index
+var flattened = map[string]interface{}{"type": "flattened"}
+
+// The explicit mappings of the indices fields. The other fields are dynamically mapped
+var Mappings = map[string]map[string]interface{}{
+	"local_clients": {
+		"uid":        keyword,
+		"alias":      keyword,
+		"account_id": keyword,
+		"node":       keyword,
+		"address":    keyword,
+		"client_id":  keyword,
+		"password":   keyword,
+		"metadata":   flattened,
+	},
+	"clients":          {"client_id": keyword, "node": keyword, "address": keyword, "rotated_to": keyword, "metadata": flattened, "updated_at": timeutil.Mapping},
+	"sequences":        {"client_id": keyword, "last": map[string]interface{}{"type": "long"}},
+	"node":             {"node_id": keyword, "host": keyword},
+	"peers":            {"node_id": keyword, "host": keyword, "resumes_at": timeutil.Mapping, "last_seen_at": timeutil.Mapping, "learned_at": timeutil.Mapping, "left_at": timeutil.Mapping},
+	"addresses":        {"node_id": keyword, "previous": keyword, "host": keyword, "changed_at": timeutil.Mapping},
+	"cache":            {"timestamp": timeutil.Mapping, "expires_at": timeutil.Mapping},
+	"transactions":     {"Sender.client_id": keyword, "Recipient.client_id": keyword, "Timestamp": timeutil.Mapping},
+	"node_metrics":     {"timestamp": timeutil.Mapping},
+	"devices":          {"client_id": keyword, "registered_at": timeutil.Mapping},
+	"events":           {"timestamp": timeutil.Mapping},
+	"aliases":          {"reserved_at": timeutil.Mapping},
+	"pending_clients":  {"started": timeutil.Mapping},
+	"handovers":        {"ready_at": timeutil.Mapping},
+	"leases":           {"holder": keyword, "expires_at": timeutil.Mapping},
+	"jobs":             {"name": keyword, "instance": keyword, "next_run": timeutil.Mapping, "updated_at": timeutil.Mapping},
+	"job_runs":         {"job": keyword, "instance": keyword, "started": timeutil.Mapping, "finished": timeutil.Mapping},
+	"balances":         {"client_id": keyword, "confirmed": map[string]interface{}{"type": "double"}, "height": map[string]interface{}{"type": "long"}, "updated_at": timeutil.Mapping},
+	"scripts":          {"name": keyword, "version": map[string]interface{}{"type": "long"}, "digest": keyword, "module": stored, "published_at": timeutil.Mapping},
+	"anchors":          {"anchor": keyword, "height": map[string]interface{}{"type": "long"}, "block_hash": keyword, "proof": stored, "anchored_at": timeutil.Mapping},
+	"erasures":         {"client_id": keyword, "node_id": keyword, "erased_at": timeutil.Mapping},
+	"consents":         {"uid": keyword, "client_id": keyword, "version": keyword, "accepted_at": timeutil.Mapping},
+	"stealth":          {"client_id": keyword, "transaction_id": keyword, "claimed_at": timeutil.Mapping},
+	"identity":         {"node_id": keyword, "key": stored},
+	"keys":             {"private": stored, "public": stored},
+	"key_store":        {"sealed": stored, "stored_at": timeutil.Mapping},
+	"client_conflicts": {"client_id": keyword, "kept": unindexed, "rejected": unindexed, "evidence": stored, "detected_at": timeutil.Mapping},
+	"key_log":          {"sequence": map[string]interface{}{"type": "long"}, "kind": keyword, "client_id": keyword, "previous_id": keyword, "node_id": keyword, "hash": keyword, "previous_hash": keyword, "logged_at": timeutil.Mapping},
+	"maintenance":      {"starts_at": timeutil.Mapping, "ends_at": timeutil.Mapping, "scheduled_at": timeutil.Mapping},
+	"requests":         {"fingerprint": keyword, "status": keyword, "created_at": timeutil.Mapping, "response": stored},
+	"admin_requests":   {"operation": keyword, "status": keyword, "requested_by": keyword, "requested_at": timeutil.Mapping, "expires_at": timeutil.Mapping, "args": keyword},
+	"intents":          {"started": timeutil.Mapping, "operations": map[string]interface{}{"type": "object", "enabled": false}},
+	"blockchain":       {"timestamp": timeutil.Mapping, "height": map[string]interface{}{"type": "long"}},
+}
 
-	for _, index := range indexes {
-		err := b.IndexExists(index)
+// This method creates the essential indices of the node backlog. The indices that already exist
+// (e.g. dynamically created by an early write) receive the explicit mappings they miss
+func (b Backlog) Initialize(ctx context.Context) error {
+	for _, index := range Indices {
+		err := b.IndexExists(ctx, index)
 
 		if err != nil {
-			err := b.CreateIndex(index)
+			err := b.CreateIndex(ctx, index, Mappings[index])
 			if err != nil {
-				log.Fatalf("Failed to create index %s: %v", index, err)
+				return fmt.Errorf("failed to create index %s: %v", index, err)
 			}
 		} else {
 			fmt.Printf("Index %s already exists\n", index)
+
+			if err := b.UpdateMapping(ctx, index, Mappings[index]); err != nil {
+				fmt.Printf("Index %s keeps its current mappings: %v\n", index, err)
+			}
 		}
 	}
+
+	return nil
+}
+
+// Checks if the ElasticSearch is reachable and gives the current time in its host. The time
+// is zero when the ElasticSearch doesn't report it
+func (b Backlog) ServerTime(ctx context.Context) (time.Time, error) {
+	req := esapi.InfoRequest{}
+
+	res, err := req.Do(ctx, b)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return time.Time{}, fmt.Errorf("failed to get the cluster info: %s", res.String())
+	}
+
+	date := res.Header.Get("Date")
+	if date == "" {
+		return time.Time{}, nil
+	}
+
+	return http.ParseTime(date)
 }
 
-// An util implementation of index existance verification process in ElasticSearch
-func (b Backlog) IndexExists(index string) error {
-	ctx := context.Background()
+// Adds the fields mappings to an existing index. The fields that are already mapped with another
+// type can't be changed, so the update fails for them
+func (b Backlog) UpdateMapping(ctx context.Context, index string, properties map[string]interface{}) error {
+	if len(properties) == 0 {
+		return nil
+	}
+
+	jsonBody, err := json.Marshal(map[string]interface{}{"properties": properties})
+	if err != nil {
+		return err
+	}
+
+	req := esapi.IndicesPutMappingRequest{
+		Index: []string{index},
+		Body:  bytes.NewBuffer(jsonBody),
+	}
+
+	res, err := req.Do(ctx, b)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return fmt.Errorf("failed to update the mappings: %s", res.String())
+	}
 
+	return nil
+}
+
+// Gives the essential indices that are missing and the explicit mappings that they lack, as
+// "index" and "index.field", in one request. It's cheap enough to be polled by the health checks
+func (b Backlog) MissingMappings(ctx context.Context) ([]string, error) {
+	ignoreUnavailable := true
+	req := esapi.IndicesGetMappingRequest{
+		Index:             Indices,
+		IgnoreUnavailable: &ignoreUnavailable,
+	}
+
+	res, err := req.Do(ctx, b)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, fmt.Errorf("failed to get the mappings: %s", res.String())
+	}
+
+	var response map[string]struct {
+		Mappings struct {
+			Properties map[string]interface{} `json:"properties"`
+		} `json:"mappings"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("failed to decode JSON response: %s", err)
+	}
+
+	var missing []string
+	for _, index := range Indices {
+		mapping, ok := response[index]
+		if !ok {
+			missing = append(missing, index)
+			continue
+		}
+
+		for field := range Mappings[index] {
+			if _, ok := mapping.Mappings.Properties[field]; !ok {
+				missing = append(missing, index+"."+field)
+			}
+		}
+	}
+	sort.Strings(missing)
+
+	return missing, nil
+}
+
+// An util implementation of index existance verification process in ElasticSearch
+func (b Backlog) IndexExists(ctx context.Context, index string) error {
 	req := esapi.IndicesGetRequest{
 		Index: []string{index},
 	}
@@ -94,14 +339,23 @@ func (b Backlog) IndexExists(index string) error {
 	return nil
 }
 
-// An util implementation of index creating process in ElasticSearch
-func (b Backlog) CreateIndex(index string) error {
-	ctx := context.Background()
-
+// An util implementation of index creating process in ElasticSearch. The fields mapping is optional
+func (b Backlog) CreateIndex(ctx context.Context, index string, properties ...map[string]interface{}) error {
 	req := esapi.IndicesCreateRequest{
 		Index: index,
 	}
 
+	if len(properties) > 0 && len(properties[0]) > 0 {
+		jsonBody, err := json.Marshal(map[string]interface{}{
+			"mappings": map[string]interface{}{"properties": properties[0]},
+		})
+		if err != nil {
+			return err
+		}
+
+		req.Body = bytes.NewBuffer(jsonBody)
+	}
+
 	res, err := req.Do(ctx, b)
 	if err != nil {
 		return err
@@ -117,11 +371,13 @@ func (b Backlog) CreateIndex(index string) error {
 }
 
 // An util implementation of document indexing process in ElasticSearch
-func (b Backlog) IndexDocument(index, id string, document map[string]interface{}) error {
-	ctx := context.Background()
+func (b Backlog) IndexDocument(ctx context.Context, index, id string, document map[string]interface{}) error {
+	if recordDryRun(ctx, Operation{Kind: OperationPut, Index: index, Id: id, Document: document}) {
+		return nil
+	}
 
-	if _, err := b.GetDocument(index, id); err == nil {
-		return b.UpdateDocument(index, id, document)
+	if _, err := b.GetDocument(ctx, index, id); err == nil {
+		return b.UpdateDocument(ctx, index, id, document)
 	}
 
 	jsonDocument, err := json.Marshal(document)
@@ -133,10 +389,10 @@ func (b Backlog) IndexDocument(index, id string, document map[string]interface{}
 		Index:      index,
 		DocumentID: id,
 		Body:       bytes.NewBuffer(jsonDocument),
-		Refresh:    "true",
+		Refresh:    refreshPolicy(ctx),
 	}
 
-	res, err := req.Do(ctx, b)
+	res, err := b.write(ctx, index, req)
 	if err != nil {
 		return err
 	}
@@ -150,9 +406,74 @@ func (b Backlog) IndexDocument(index, id string, document map[string]interface{}
 	return nil
 }
 
+// An util implementation of document creating process in ElasticSearch. Unlike IndexDocument,
+// it never overwrites: it gives ErrConflict when there is a document with the same id
+func (b Backlog) CreateDocument(ctx context.Context, index, id string, document map[string]interface{}) error {
+	if recordDryRun(ctx, Operation{Kind: OperationPut, Index: index, Id: id, Document: document}) {
+		return nil
+	}
+
+	jsonDocument, err := json.Marshal(document)
+	if err != nil {
+		return err
+	}
+
+	req := esapi.CreateRequest{
+		Index:      index,
+		DocumentID: id,
+		Body:       bytes.NewBuffer(jsonDocument),
+		Refresh:    refreshPolicy(ctx),
+	}
+
+	res, err := b.write(ctx, index, req)
+	if err != nil {
+		return err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusConflict {
+		return ErrConflict
+	}
+
+	if res.IsError() {
+		return fmt.Errorf("failed to create the document: %s", res.String())
+	}
+
+	return nil
+}
+
+// An util implementation of document deleting process in ElasticSearch
+func (b Backlog) DeleteDocument(ctx context.Context, index, id string) error {
+	if recordDryRun(ctx, Operation{Kind: OperationDelete, Index: index, Id: id}) {
+		return nil
+	}
+
+	req := esapi.DeleteRequest{
+		Index:      index,
+		DocumentID: id,
+		Refresh:    refreshPolicy(ctx),
+	}
+
+	res, err := b.write(ctx, index, req)
+	if err != nil {
+		return err
+	}
+
+	defer res.Body.Close()
+
+	if res.IsError() && res.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("failed to delete the document: %s", res.String())
+	}
+
+	return nil
+}
+
 // An util implementation of document updating process in ElasticSearch
-func (b Backlog) UpdateDocument(index, id string, document map[string]interface{}) error {
-	ctx := context.Background()
+func (b Backlog) UpdateDocument(ctx context.Context, index, id string, document map[string]interface{}) error {
+	if recordDryRun(ctx, Operation{Kind: OperationUpdate, Index: index, Id: id, Document: document}) {
+		return nil
+	}
 
 	jsonDocument, err := json.Marshal(map[string]interface{}{
 		"doc": document,
@@ -166,10 +487,10 @@ func (b Backlog) UpdateDocument(index, id string, document map[string]interface{
 		Index:      index,
 		DocumentID: id,
 		Body:       bytes.NewBuffer(jsonDocument),
-		Refresh:    "true",
+		Refresh:    refreshPolicy(ctx),
 	}
 
-	res, err := req.Do(ctx, b)
+	res, err := b.write(ctx, index, req)
 	if err != nil {
 		return err
 	}
@@ -183,13 +504,148 @@ func (b Backlog) UpdateDocument(index, id string, document map[string]interface{
 	return nil
 }
 
-// An util implementation of document listing process in ElasticSearch
-func (b Backlog) ListDocuments(index string, uri ...string) ([]map[string]interface{}, error) {
+// An util implementation of the update by query process in ElasticSearch, running a painless
+// script over every document of the index
+func (b Backlog) UpdateByQuery(ctx context.Context, index, script string) error {
+	// Every document of the index is touched
+	if recordDryRun(ctx, Operation{Kind: OperationUpdate, Index: index, Id: "*", Document: map[string]interface{}{"script": script}}) {
+		return nil
+	}
+
+	jsonBody, err := json.Marshal(map[string]interface{}{
+		"script": map[string]interface{}{
+			"source": script,
+			"lang":   "painless",
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	refresh := true
+	req := esapi.UpdateByQueryRequest{
+		Index:   []string{index},
+		Body:    bytes.NewBuffer(jsonBody),
+		Refresh: &refresh,
+	}
+
+	res, err := b.write(ctx, index, req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return fmt.Errorf("failed to update by query: %s", res.String())
+	}
+
+	return nil
+}
+
+// Gives the type that a field is mapped with in an index (empty when it isn't mapped)
+func (b Backlog) FieldType(ctx context.Context, index, field string) (string, error) {
+	req := esapi.IndicesGetFieldMappingRequest{
+		Index:  []string{index},
+		Fields: []string{field},
+	}
+
+	res, err := req.Do(ctx, b)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return "", fmt.Errorf("failed to get the mapping of %s: %s", field, res.String())
+	}
+
+	var response map[string]struct {
+		Mappings map[string]struct {
+			Mapping map[string]struct {
+				Type string `json:"type"`
+			} `json:"mapping"`
+		} `json:"mappings"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		return "", fmt.Errorf("failed to decode JSON response: %s", err)
+	}
+
+	mapping := response[index].Mappings[field].Mapping
+	for _, properties := range mapping {
+		return properties.Type, nil
+	}
+
+	return "", nil
+}
+
+// An util implementation of index deleting process in ElasticSearch. A missing index is ignored
+func (b Backlog) DeleteIndex(ctx context.Context, index string) error {
+	if recordDryRun(ctx, Operation{Kind: OperationDelete, Index: index, Id: "*"}) {
+		return nil
+	}
+
+	req := esapi.IndicesDeleteRequest{
+		Index: []string{index},
+	}
+
+	res, err := req.Do(ctx, b)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.IsError() && res.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("failed to delete index: %s", res.String())
+	}
+
+	return nil
+}
+
+// An util implementation of the reindex process in ElasticSearch, copying every document of an
+// index into another one (overwriting the documents with the same ids)
+func (b Backlog) Reindex(ctx context.Context, source, destination string) error {
+	if recordDryRun(ctx, Operation{Kind: OperationPut, Index: destination, Id: "*", Document: map[string]interface{}{"source": source}}) {
+		return nil
+	}
+
+	jsonBody, err := json.Marshal(map[string]interface{}{
+		"source": map[string]interface{}{"index": source},
+		"dest":   map[string]interface{}{"index": destination},
+	})
+	if err != nil {
+		return err
+	}
+
+	refresh := true
+	req := esapi.ReindexRequest{
+		Body:    bytes.NewBuffer(jsonBody),
+		Refresh: &refresh,
+	}
+
+	res, err := b.write(ctx, destination, req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return fmt.Errorf("failed to reindex %s into %s: %s", source, destination, res.String())
+	}
+
+	return nil
+}
+
+// An util implementation of document searching process in ElasticSearch using a raw query body
+func (b Backlog) SearchDocuments(ctx context.Context, index string, body map[string]interface{}) ([]map[string]interface{}, error) {
 	var results []map[string]interface{}
-	ctx := context.Background()
+	jsonBody, err := json.Marshal(body)
+	if err != nil {
+		return results, err
+	}
 
 	req := esapi.SearchRequest{
 		Index: []string{index},
+		Body:  bytes.NewBuffer(jsonBody),
 	}
 
 	res, err := req.Do(ctx, b)
@@ -199,7 +655,7 @@ func (b Backlog) ListDocuments(index string, uri ...string) ([]map[string]interf
 	defer res.Body.Close()
 
 	if res.IsError() {
-		return results, fmt.Errorf("failed to list documents: %s", res.String())
+		return results, fmt.Errorf("failed to search documents: %s", res.String())
 	}
 
 	var response map[string]interface{}
@@ -220,11 +676,43 @@ func (b Backlog) ListDocuments(index string, uri ...string) ([]map[string]interf
 	return results, nil
 }
 
+// An util implementation of document counting process in ElasticSearch. The query is optional
+func (b Backlog) CountDocuments(ctx context.Context, index string, query ...map[string]interface{}) (int64, error) {
+	req := esapi.CountRequest{
+		Index: []string{index},
+	}
+
+	if len(query) > 0 {
+		jsonQuery, err := json.Marshal(map[string]interface{}{"query": query[0]})
+		if err != nil {
+			return 0, err
+		}
+
+		req.Body = bytes.NewBuffer(jsonQuery)
+	}
+
+	res, err := req.Do(ctx, b)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return 0, fmt.Errorf("failed to count documents: %s", res.String())
+	}
+
+	var response map[string]interface{}
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		return 0, fmt.Errorf("failed to decode JSON response: %s", err)
+	}
+
+	count, _ := response["count"].(float64)
+	return int64(count), nil
+}
+
 // An util implementation of document text-based searching process in ElasticSearch
-func (b Backlog) FindDocument(index, key, value string) (map[string]interface{}, error) {
+func (b Backlog) FindDocument(ctx context.Context, index, key, value string) (map[string]interface{}, error) {
 	var document map[string]interface{}
-	ctx := context.Background()
-
 	query := map[string]interface{}{
 		"query": map[string]interface{}{
 			"match": map[string]interface{}{
@@ -270,10 +758,8 @@ func (b Backlog) FindDocument(index, key, value string) (map[string]interface{},
 }
 
 // An util implementation of document finding by id process in ElasticSearch
-func (b Backlog) GetDocument(index, id string) (map[string]interface{}, error) {
+func (b Backlog) GetDocument(ctx context.Context, index, id string) (map[string]interface{}, error) {
 	var document map[string]interface{}
-	ctx := context.Background()
-
 	req := esapi.GetRequest{
 		Index:      index,
 		DocumentID: id,
@@ -285,6 +771,10 @@ func (b Backlog) GetDocument(index, id string) (map[string]interface{}, error) {
 	}
 	defer res.Body.Close()
 
+	if res.StatusCode == http.StatusNotFound {
+		return document, fmt.Errorf("failed to get document %s/%s: %w", index, id, ErrNotFound)
+	}
+
 	if res.IsError() {
 		return document, fmt.Errorf("failed to get document: %s", res.String())
 	}
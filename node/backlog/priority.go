@@ -0,0 +1,190 @@
+package node
+
+import (
+	"context"
+	config "node/config"
+	"sync"
+
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+)
+
+/*
+The writes to the ElasticSearch go through priority lanes, so the consensus data is never starved by
+the housekeeping under load. There is a cap of concurrent writes by process (please, go to
+BACKLOG_WRITES in the config), and when it's reached, the writes wait in the lane of their priority:
+a freed slot goes to the oldest write of the highest lane.
+
+  - The chain lane has the blocks, the transactions and the data derived from them.
+  - The client lane has the clients, their keys and everything else not listed (the default).
+  - The stats lane has the metrics, the events and the job runs. It can't take more than half of
+    the slots, so a burst of housekeeping leaves room for the other lanes.
+
+A commit writes all its documents (and its intent) in the lane of its most important document.
+*/
+type Priority int
+
+const (
+	PriorityStats Priority = iota
+	PriorityClient
+	PriorityChain
+)
+
+var priorityNames = map[Priority]string{
+	PriorityStats:  "stats",
+	PriorityClient: "client",
+	PriorityChain:  "chain",
+}
+
+// Gives the name of the priority, e.g. "chain"
+func (p Priority) String() string {
+	return priorityNames[p]
+}
+
+// The priorities of the indices out of the client lane
+var indexPriorities = map[string]Priority{
+	"blockchain":   PriorityChain,
+	"transactions": PriorityChain,
+	"sequences":    PriorityChain,
+	"balances":     PriorityChain,
+	"anchors":      PriorityChain,
+	"stealth":      PriorityChain,
+	"node_metrics": PriorityStats,
+	"events":       PriorityStats,
+	"job_runs":     PriorityStats,
+	"cache":        PriorityStats,
+}
+
+type priorityKey struct{}
+
+// Gives a context whose writes go in the lane of the priority, whatever their indices are
+func WithPriority(ctx context.Context, priority Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, priority)
+}
+
+// Gives the priority of a write to the index, in the context
+func priorityOf(ctx context.Context, index string) Priority {
+	if priority, ok := ctx.Value(priorityKey{}).(Priority); ok {
+		return priority
+	}
+
+	if priority, ok := indexPriorities[index]; ok {
+		return priority
+	}
+
+	return PriorityClient
+}
+
+// The slots of the concurrent writes and the writes waiting for them, by lane
+var writeLanes = struct {
+	sync.Mutex
+	once    sync.Once
+	slots   int
+	used    int
+	stats   int // The slots used by the stats lane
+	waiting [PriorityChain + 1][]chan struct{}
+}{}
+
+// Checks if a write of the priority can take a free slot. The lanes must be locked
+func slotFree(priority Priority) bool {
+	if writeLanes.used >= writeLanes.slots {
+		return false
+	}
+
+	return priority != PriorityStats || writeLanes.stats < (writeLanes.slots+1)/2
+}
+
+// Takes a slot of the lanes. The lanes must be locked
+func takeWriteSlot(priority Priority) {
+	writeLanes.used++
+	if priority == PriorityStats {
+		writeLanes.stats++
+	}
+}
+
+// Takes a slot for a write of the priority, waiting in its lane when the writes are at the cap.
+// Gives the function that frees the slot
+func acquireWrite(ctx context.Context, priority Priority) (func(), error) {
+	writeLanes.once.Do(func() {
+		writeLanes.slots = config.BacklogWrites()
+	})
+
+	release := func() { releaseWrite(priority) }
+
+	writeLanes.Lock()
+	if slotFree(priority) && !queuedFrom(priority) {
+		takeWriteSlot(priority)
+		writeLanes.Unlock()
+		return release, nil
+	}
+
+	granted := make(chan struct{})
+	writeLanes.waiting[priority] = append(writeLanes.waiting[priority], granted)
+	writeLanes.Unlock()
+
+	select {
+	case <-granted:
+		return release, nil
+	case <-ctx.Done():
+	}
+
+	writeLanes.Lock()
+	for i, waiting := range writeLanes.waiting[priority] {
+		if waiting == granted {
+			writeLanes.waiting[priority] = append(writeLanes.waiting[priority][:i], writeLanes.waiting[priority][i+1:]...)
+			writeLanes.Unlock()
+			return nil, ctx.Err()
+		}
+	}
+	writeLanes.Unlock()
+
+	// The slot was granted while the context was canceled, so it's passed on
+	releaseWrite(priority)
+	return nil, ctx.Err()
+}
+
+// Checks if some write of the priority (or above it) is waiting. The lanes must be locked
+func queuedFrom(priority Priority) bool {
+	for lane := PriorityChain; lane >= priority; lane-- {
+		if len(writeLanes.waiting[lane]) > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Frees a slot and gives it to the oldest write of the highest lane that can take it
+func releaseWrite(priority Priority) {
+	writeLanes.Lock()
+	defer writeLanes.Unlock()
+
+	writeLanes.used--
+	if priority == PriorityStats {
+		writeLanes.stats--
+	}
+
+	for lane := PriorityChain; lane >= PriorityStats; lane-- {
+		if len(writeLanes.waiting[lane]) == 0 || !slotFree(lane) {
+			continue
+		}
+
+		granted := writeLanes.waiting[lane][0]
+		writeLanes.waiting[lane] = writeLanes.waiting[lane][1:]
+		takeWriteSlot(lane)
+		close(granted)
+		return
+	}
+}
+
+// Sends a write request to the index through the lane of its priority
+func (b Backlog) write(ctx context.Context, index string, req esapi.Request) (*esapi.Response, error) {
+	priority := priorityOf(ctx, index)
+
+	release, err := acquireWrite(ctx, priority)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	return req.Do(ctx, b)
+}
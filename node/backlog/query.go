@@ -0,0 +1,118 @@
+package node
+
+import (
+	"context"
+	"fmt"
+)
+
+/*
+A query of the ElasticSearch query DSL. The queries are built with the functions below and combined
+with `Bool`, e.g. all the transactions of a client in a time range:
+
+	Bool().
+		Should(Term("Sender.client_id", id), Term("Recipient.client_id", id)).
+		Must(Range("Timestamp", from, to)).
+		Query()
+*/
+type Query map[string]interface{}
+
+// Gives the documents whose field is exactly the value (use the keyword fields for texts)
+func Term(field string, value interface{}) Query {
+	return Query{"term": map[string]interface{}{field: value}}
+}
+
+// Gives the documents whose field matches the analyzed value
+func Match(field string, value interface{}) Query {
+	return Query{"match": map[string]interface{}{field: value}}
+}
+
+// Gives the documents whose field is between the bounds (inclusive). A nil bound is left open
+func Range(field string, from, to interface{}) Query {
+	bounds := map[string]interface{}{}
+	if from != nil {
+		bounds["gte"] = from
+	}
+
+	if to != nil {
+		bounds["lte"] = to
+	}
+
+	return Query{"range": map[string]interface{}{field: bounds}}
+}
+
+// Gives the documents with the ids
+func Ids(ids ...string) Query {
+	return Query{"ids": map[string]interface{}{"values": ids}}
+}
+
+// Gives the documents that have some value in the field
+func Exists(field string) Query {
+	return Query{"exists": map[string]interface{}{"field": field}}
+}
+
+// A builder of a bool query, that combines other queries
+type BoolQuery struct {
+	must    []Query
+	should  []Query
+	mustNot []Query
+}
+
+// Starts a bool query
+func Bool() *BoolQuery {
+	return &BoolQuery{}
+}
+
+// Adds queries that all the documents must match
+func (q *BoolQuery) Must(queries ...Query) *BoolQuery {
+	q.must = append(q.must, queries...)
+	return q
+}
+
+// Adds queries of which the documents must match at least one
+func (q *BoolQuery) Should(queries ...Query) *BoolQuery {
+	q.should = append(q.should, queries...)
+	return q
+}
+
+// Adds queries that the documents must not match
+func (q *BoolQuery) MustNot(queries ...Query) *BoolQuery {
+	q.mustNot = append(q.mustNot, queries...)
+	return q
+}
+
+// Gives the built query
+func (q *BoolQuery) Query() Query {
+	clauses := map[string]interface{}{}
+	if len(q.must) > 0 {
+		clauses["must"] = q.must
+	}
+
+	if len(q.should) > 0 {
+		clauses["should"] = q.should
+		clauses["minimum_should_match"] = 1
+	}
+
+	if len(q.mustNot) > 0 {
+		clauses["must_not"] = q.mustNot
+	}
+
+	return Query{"bool": clauses}
+}
+
+// Gives all the documents that match the query, with the total of matches. The options choose the
+// page (the total still counts all the matches) or ask for all the documents
+func (b Backlog) FindDocuments(ctx context.Context, index string, query Query, opts ListOptions) ([]map[string]interface{}, int64, error) {
+	opts.Query = query
+
+	if opts.All {
+		documents, err := b.ListDocuments(ctx, index, opts)
+		return documents, int64(len(documents)), err
+	}
+
+	page, err := b.searchPage(ctx, index, opts)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to find documents: %v", err)
+	}
+
+	return page.documents, page.total, nil
+}
@@ -0,0 +1,43 @@
+package node
+
+import (
+	"context"
+	config "node/config"
+)
+
+/*
+Every write asks the ElasticSearch to refresh the index, so the next search finds it. The refresh is
+costly under load, so it can be relaxed (please, go to BACKLOG_REFRESH in the config): "wait_for"
+holds the write until the next periodic refresh makes it visible, and "false" doesn't wait at all.
+
+A client expects to find what it just wrote (e.g. its transaction, right after submitting it), so the
+writes made inside a session never go unseen: when the refresh is "false", they still wait for the
+refresh. The relaxed writes are left to the work of the node itself (e.g. the gossip, the metrics),
+that doesn't read right after writing.
+
+The server opens the session of every call of the clients API (please, go to `authenticate`), tied
+to the authenticated client, or anonymous for the public methods (e.g. CreateClient).
+*/
+type sessionKey struct{}
+
+// Gives a context whose writes are visible to the reads that follow them. The id is the uid of the
+// client (empty for an anonymous session)
+func WithSession(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, sessionKey{}, id)
+}
+
+// Gives the id of the session of the context, and whether there is one
+func SessionOf(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(sessionKey{}).(string)
+	return id, ok
+}
+
+// Gives the refresh of a write in the context
+func refreshPolicy(ctx context.Context) string {
+	refresh := config.BacklogRefresh()
+	if _, ok := SessionOf(ctx); ok && refresh == "false" {
+		return "wait_for"
+	}
+
+	return refresh
+}
@@ -0,0 +1,93 @@
+package node
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+)
+
+/*
+The version of a document, as the ElasticSearch sequence number and primary term of its last write.
+Several processes may share the backlog, so the writes that depend on what was read (e.g. accepting
+the next sequence of a sender) are made only if the document still has the version that was read,
+instead of relying on a lock of the process.
+*/
+type Version struct {
+	SeqNo       int // The sequence number of the last write of the document
+	PrimaryTerm int // The primary term of the last write of the document
+}
+
+// Gives a document together with its version
+func (b Backlog) GetVersionedDocument(ctx context.Context, index, id string) (map[string]interface{}, Version, error) {
+	req := esapi.GetRequest{
+		Index:      index,
+		DocumentID: id,
+	}
+
+	res, err := req.Do(ctx, b)
+	if err != nil {
+		return nil, Version{}, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return nil, Version{}, fmt.Errorf("failed to get document %s/%s: %w", index, id, ErrNotFound)
+	}
+
+	if res.IsError() {
+		return nil, Version{}, fmt.Errorf("failed to get document: %s", res.String())
+	}
+
+	var response struct {
+		SeqNo       int                    `json:"_seq_no"`
+		PrimaryTerm int                    `json:"_primary_term"`
+		Source      map[string]interface{} `json:"_source"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		return nil, Version{}, fmt.Errorf("failed to decode JSON response: %s", err)
+	}
+
+	return response.Source, Version{SeqNo: response.SeqNo, PrimaryTerm: response.PrimaryTerm}, nil
+}
+
+// Overwrites a document only if it still has the given version. Gives ErrConflict when the document
+// was written by someone else since it was read
+func (b Backlog) ReplaceDocumentIf(ctx context.Context, index, id string, document map[string]interface{}, version Version) error {
+	if recordDryRun(ctx, Operation{Kind: OperationPut, Index: index, Id: id, Document: document}) {
+		return nil
+	}
+
+	jsonDocument, err := json.Marshal(document)
+	if err != nil {
+		return err
+	}
+
+	req := esapi.IndexRequest{
+		Index:         index,
+		DocumentID:    id,
+		Body:          bytes.NewBuffer(jsonDocument),
+		IfSeqNo:       &version.SeqNo,
+		IfPrimaryTerm: &version.PrimaryTerm,
+		Refresh:       refreshPolicy(ctx),
+	}
+
+	res, err := b.write(ctx, index, req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusConflict {
+		return ErrConflict
+	}
+
+	if res.IsError() {
+		return fmt.Errorf("failed to replace the document: %s", res.String())
+	}
+
+	return nil
+}
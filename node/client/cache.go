@@ -39,6 +39,7 @@ type Cache struct {
 	ComputedKeyA string `json:"computed_key_a"` // A key generated by random computations above the account id
 	ComputedKeyP string `json:"computed_key_p"` // A key generated by random computations above the password hash
 	Timestamp    int64  `json:"timestamp"`      // The timestamp when the credentials were computed
+	ExpiresAt    int64  `json:"expires_at"`     // The timestamp when the session expires (extended by the client activity)
 	Alias        string `json:"-"`              // Alias from the client
 	Password     string `json:"-"`              // Password hex hash from the client
 	PublicKey    []byte `json:"-"`              // RSA public key
@@ -6,15 +6,13 @@ import (
 	"crypto/rsa"
 	"crypto/sha256"
 	"crypto/x509"
+	"encoding/base64"
 	"encoding/hex"
 	"encoding/pem"
 	"fmt"
-	"log"
-	"os"
+	config "node/config"
 )
 
-var BasePath string = os.Getenv("BASE_PATH")
-
 /*
 Every client has a pair of private and public key to assign the transactions.
 
@@ -31,12 +29,16 @@ type CryptoResource struct {
 	PublicKey  *rsa.PublicKey
 }
 
+// A document that can be signed: its canonical encoding, made of every field except the signatures
+// themselves, is what the key signs. The encoding of a document must never change, since the
+// signatures made before would stop matching it. A document that can't be encoded (e.g. holding a
+// NaN) is never signed nor verified
 type Signable interface {
-	ToBytes() []byte
+	ToBytes() ([]byte, error)
 }
 
 func NewCryptoResource() (*CryptoResource, error) {
-	privateKey, err := rsa.GenerateKey(rand.Reader, 4096)
+	privateKey, err := rsa.GenerateKey(rand.Reader, config.KeySize())
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate private key: %v", err)
@@ -53,30 +55,62 @@ func NewCryptoResource() (*CryptoResource, error) {
 }
 
 // This is a identifier based on the public key. It's used to represent the client in its transactions
-func (c CryptoResource) Identity() string {
+func (c CryptoResource) Identity() (string, error) {
 	derPkix, err := x509.MarshalPKIXPublicKey(c.PublicKey)
 	if err != nil {
-		log.Fatalf("failed do generate the crypto identity: %v", err)
-		return ""
+		return "", fmt.Errorf("failed do generate the crypto identity: %v", err)
 	}
 
 	hexString := hex.EncodeToString(derPkix)
-	return hexString
+	return hexString, nil
 }
 
 // Assigns the client transactions using the private key. The signature grants that the transaction was included in a valid block.
-func (c CryptoResource) CreateSignature(t Signable) string {
+func (c CryptoResource) CreateSignature(t Signable) (string, error) {
+	content, err := t.ToBytes()
+	if err != nil {
+		return "", fmt.Errorf("failed to create signature: %v", err)
+	}
+
 	hasher := sha256.New()
-	hasher.Write(t.ToBytes())
+	hasher.Write(content)
 	hashed := hasher.Sum(nil)
 
 	signature, err := rsa.SignPKCS1v15(rand.Reader, c.PrivateKey, crypto.SHA256, hashed)
 
 	if err != nil {
-		log.Fatalf("Failed to create signature: %v\n", err)
+		return "", fmt.Errorf("failed to create signature: %v", err)
 	}
 
-	return string(signature)
+	return EncodeSignature(signature), nil
+}
+
+/*
+The signatures are kept and sent as base64 text, since the raw bytes of a signature aren't valid
+UTF-8 and the JSON round-trips (e.g. into the ElasticSearch) mangle them.
+
+The signatures made before the encoding (the legacy ones) were the raw bytes cast to a string. They
+can't be re-encoded where they're hashed (the transactions inside the blocks), so they're still read:
+a legacy signature isn't valid base64, and it's taken as it is.
+*/
+func EncodeSignature(raw []byte) string {
+	return base64.StdEncoding.EncodeToString(raw)
+}
+
+// Gives the raw bytes of a signature, either encoded or legacy
+func DecodeSignature(signature string) []byte {
+	raw, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return []byte(signature)
+	}
+
+	return raw
+}
+
+// Checks if a signature was made before the signatures were encoded
+func IsLegacySignature(signature string) bool {
+	_, err := base64.StdEncoding.DecodeString(signature)
+	return signature != "" && err != nil
 }
 
 // Converts the private key to a byte array and, eventually, a string
@@ -91,11 +125,36 @@ func (c CryptoResource) ImpersonatePrivateKey() []byte {
 	return pemPrivate
 }
 
+// Gives the key pair of a PEM private key (the inverse of the `ImpersonatePrivateKey` method). The
+// PKCS #8 keys are accepted too, as long as they're RSA keys
+func ParsePrivateKeyPEM(priv string) (*CryptoResource, error) {
+	block, _ := pem.Decode([]byte(priv))
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode PEM bytes")
+	}
+
+	privateKey, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	if err != nil {
+		parsed, pkcs8Err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if pkcs8Err != nil {
+			return nil, fmt.Errorf("failed to analyze RSA private key: %v", err)
+		}
+
+		rsaKey, ok := parsed.(*rsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("unknown private key type")
+		}
+		privateKey = rsaKey
+	}
+
+	return &CryptoResource{PrivateKey: privateKey, PublicKey: &privateKey.PublicKey}, nil
+}
+
 // Converts the public key to a byte array and, eventually, a string
-func (c CryptoResource) ImpersonatePublicKey() []byte {
+func (c CryptoResource) ImpersonatePublicKey() ([]byte, error) {
 	publicKeyBytes, err := x509.MarshalPKIXPublicKey(c.PublicKey)
 	if err != nil {
-		log.Fatalf("Failed to convert public key: %v\n", err)
+		return nil, fmt.Errorf("failed to convert public key: %v", err)
 	}
 
 	pemPublic := pem.EncodeToMemory(
@@ -105,109 +164,94 @@ func (c CryptoResource) ImpersonatePublicKey() []byte {
 		},
 	)
 
-	return pemPublic
+	return pemPublic, nil
 }
 
-// Writes the byte array from private key to an I/O stream
-func (c CryptoResource) UploadPrivateKey(secret string, uid string) error {
-	privBytes, err := x509.MarshalPKCS8PrivateKey(c.PrivateKey)
+// Gives the public key represented by an identity (the inverse of the `Identity` method)
+func ParseIdentity(identity string) (*rsa.PublicKey, error) {
+	derPkix, err := hex.DecodeString(identity)
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("failed to decode the identity: %v", err)
 	}
 
-	block, err := x509.EncryptPEMBlock(
-		rand.Reader,
-		"ENCRYPTED PRIVATE KEY",
-		privBytes,
-		[]byte(secret),
-		x509.PEMCipherAES256,
-	)
+	publicKey, err := x509.ParsePKIXPublicKey(derPkix)
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("failed to parse the identity public key: %v", err)
 	}
 
-	file, err := os.Create(fmt.Sprintf("%s/%s/private.pem", os.Getenv("BASE_PATH"), uid))
-	if err != nil {
-		return err
+	rsaKey, ok := publicKey.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("the identity public key isn't a RSA key")
 	}
-	defer file.Close()
 
-	return pem.Encode(file, block)
+	return rsaKey, nil
 }
 
-// Writes the byte array from public key to an I/O stream
-func (c CryptoResource) UploadPublicKey(uid string) error {
-	publicKeyBytes, err := x509.MarshalPKIXPublicKey(c.PublicKey)
-	if err != nil {
-		return err
+// Checks that the signature was made over the signable by the private key of the public key
+func VerifySignature(publicKey *rsa.PublicKey, t Signable, signature string) error {
+	return CryptoResource{PublicKey: publicKey}.VerifySignature(t, DecodeSignature(signature))
+}
+
+// Checks that the signature was made over the signable by the private key of the resource. Only the
+// public key is needed, so it can be checked by a resource built from the key of someone else
+func (c CryptoResource) VerifySignature(s Signable, signature []byte) error {
+	if c.PublicKey == nil {
+		return fmt.Errorf("failed to verify signature: there is no public key")
 	}
 
-	file, err := os.Create(fmt.Sprintf("%s/%s/public.pem", os.Getenv("BASE_PATH"), uid))
+	content, err := s.ToBytes()
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to verify signature: %v", err)
 	}
-	defer file.Close()
 
-	return pem.Encode(file, &pem.Block{
-		Type:  "RSA PUBLIC KEY",
-		Bytes: publicKeyBytes,
-	})
-}
-
-// Converts the byte array from a I/O stream to a private key
-func DownloadPrivateKey(secret string, uid string) (*rsa.PrivateKey, error) {
-	file, err := os.ReadFile(fmt.Sprintf("%s/%s/private.pem", os.Getenv("BASE_PATH"), uid))
+	hasher := sha256.New()
+	hasher.Write(content)
+	hashed := hasher.Sum(nil)
 
-	if err != nil {
-		return nil, fmt.Errorf("failed to read file private.pem: %v", err)
+	if err := rsa.VerifyPKCS1v15(c.PublicKey, crypto.SHA256, hashed, signature); err != nil {
+		return fmt.Errorf("invalid signature: %v", err)
 	}
 
-	block, _ := pem.Decode(file)
+	return nil
+}
 
+// Gives the public key of a PEM (the inverse of the `ImpersonatePublicKey` method)
+func ParsePublicKeyPEM(pub string) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode([]byte(pub))
 	if block == nil {
 		return nil, fmt.Errorf("failed to decode PEM bytes")
 	}
 
-	decryptedBytes, err := x509.DecryptPEMBlock(block, []byte(secret))
-
-	if err != nil {
-		return nil, fmt.Errorf("failed to decrypt pem block: %v", err)
-	}
-
-	priv, err := x509.ParsePKCS8PrivateKey(decryptedBytes)
+	publicKey, err := x509.ParsePKIXPublicKey(block.Bytes)
 	if err != nil {
-		return nil, fmt.Errorf("failed to analyze RSA private key: %v", err)
+		return nil, fmt.Errorf("failed to analyze RSA public key: %v", err)
 	}
 
-	privateKey, ok := priv.(*rsa.PrivateKey)
+	rsaKey, ok := publicKey.(*rsa.PublicKey)
 	if !ok {
-		return nil, fmt.Errorf("unknown private key type")
+		return nil, fmt.Errorf("unknown public key type")
 	}
 
-	return privateKey, nil
+	return rsaKey, nil
 }
 
-// Converts the byte array from a I/O stream to a public key
-func DownloadPublicKey(uid string) (*rsa.PublicKey, error) {
-	file, err := os.ReadFile(fmt.Sprintf("%s/%s/public.pem", os.Getenv("BASE_PATH"), uid))
+// Checks that the signature (as encoded by `CreateSignature`) was made over the signable by the
+// private key of an identity, e.g. the client id of a client or the public key of a node
+func VerifyWithIdentity(identity string, s Signable, signature string) error {
+	publicKey, err := ParseIdentity(identity)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read file public.pem: %v", err)
+		return err
 	}
 
-	block, _ := pem.Decode(file)
-	if block == nil {
-		return nil, fmt.Errorf("failed to decode PEM bytes")
-	}
+	return CryptoResource{PublicKey: publicKey}.VerifySignature(s, DecodeSignature(signature))
+}
 
-	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+// Checks that the signature was made over the signable by the private key of a PEM public key
+func VerifyWithPublicKeyPEM(pub string, s Signable, signature []byte) error {
+	publicKey, err := ParsePublicKeyPEM(pub)
 	if err != nil {
-		return nil, fmt.Errorf("failed to analyze RSA public key: %v", err)
-	}
-
-	publicKey, ok := pub.(*rsa.PublicKey)
-	if !ok {
-		return nil, fmt.Errorf("unknown public key type")
+		return err
 	}
 
-	return publicKey, nil
+	return CryptoResource{PublicKey: publicKey}.VerifySignature(s, signature)
 }
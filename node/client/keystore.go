@@ -0,0 +1,330 @@
+package node
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+/*
+The KeyStore keeps the key pairs of the clients, by uid. The keys are kept as PEM, and the private
+key is always encrypted by the client secret, so no store can read it by itself.
+
+There are stores for the filesystem (the default), for the backlog (please, go to `keystore.go` in
+the node module), so the clients can be hosted on nodes without persistent disks, and for the memory,
+for the tests and the throwaway nodes.
+*/
+type KeyStore interface {
+	Put(ctx context.Context, uid string, keys KeyPair) error // Stores (or replaces) the key pair of a client
+	Get(ctx context.Context, uid string) (*KeyPair, error)   // Gives the key pair of a client (ErrNoKeys when there's none)
+	Delete(ctx context.Context, uid string) error            // Removes the key pair of a client (if there's one)
+	List(ctx context.Context) ([]string, error)              // Gives the uids of the clients with a key pair
+	Probe(ctx context.Context) error                         // Checks if the store can keep new key pairs
+}
+
+// A key pair as it's kept in the stores
+type KeyPair struct {
+	Private []byte `json:"private"` // The PEM of the private key, encrypted by the client secret
+	Public  []byte `json:"public"`  // The PEM of the public key
+}
+
+var ErrNoKeys = errors.New("the client has no keys in the store")
+
+// Encodes a key pair to be stored, encrypting the private key with the client secret
+func EncodeKeyPair(c CryptoResource, secret string) (*KeyPair, error) {
+	privBytes, err := x509.MarshalPKCS8PrivateKey(c.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := x509.EncryptPEMBlock(
+		rand.Reader,
+		"ENCRYPTED PRIVATE KEY",
+		privBytes,
+		[]byte(secret),
+		x509.PEMCipherAES256,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	publicKeyBytes, err := x509.MarshalPKIXPublicKey(c.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := KeyPair{
+		Private: pem.EncodeToMemory(block),
+		Public: pem.EncodeToMemory(&pem.Block{
+			Type:  "RSA PUBLIC KEY",
+			Bytes: publicKeyBytes,
+		}),
+	}
+
+	return &keys, nil
+}
+
+// Decodes a stored key pair, decrypting the private key with the client secret
+func (k KeyPair) Decode(secret string) (*CryptoResource, error) {
+	block, _ := pem.Decode(k.Private)
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode PEM bytes")
+	}
+
+	decryptedBytes, err := x509.DecryptPEMBlock(block, []byte(secret))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt pem block: %v", err)
+	}
+
+	priv, err := x509.ParsePKCS8PrivateKey(decryptedBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to analyze RSA private key: %v", err)
+	}
+
+	privateKey, ok := priv.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("unknown private key type")
+	}
+
+	block, _ = pem.Decode(k.Public)
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode PEM bytes")
+	}
+
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to analyze RSA public key: %v", err)
+	}
+
+	publicKey, ok := pub.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("unknown public key type")
+	}
+
+	return &CryptoResource{PrivateKey: privateKey, PublicKey: publicKey}, nil
+}
+
+/*
+The FileKeyStore keeps every key pair in a directory of the filesystem (`private.pem` and
+`public.pem`). It's built with its root directories, so it doesn't depend on the environment of the
+process: whoever builds it decides where the keys go (please, go to `NewLocalNode`).
+
+The keys can be split across several roots (e.g. one per disk). The directory of every client is
+placed deterministically: the same uid is always placed in the same root while the list of roots
+doesn't change.
+*/
+type FileKeyStore struct {
+	roots []string
+}
+
+// Creates a key store over the given root directories
+func NewFileKeyStore(roots ...string) (*FileKeyStore, error) {
+	var store FileKeyStore
+
+	for _, root := range roots {
+		if root != "" {
+			store.roots = append(store.roots, root)
+		}
+	}
+
+	if len(store.roots) == 0 {
+		return nil, fmt.Errorf("the key store requires at least one root directory")
+	}
+
+	return &store, nil
+}
+
+// Gives the directory that holds the keys of some client
+func (k FileKeyStore) Dir(uid string) string {
+	hasher := fnv.New32a()
+	hasher.Write([]byte(uid))
+	index := int(hasher.Sum32() % uint32(len(k.roots)))
+
+	return filepath.Join(k.roots[index], uid)
+}
+
+func (k FileKeyStore) Put(ctx context.Context, uid string, keys KeyPair) error {
+	dir := k.Dir(uid)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create path \"%s\": %v", dir, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "public.pem"), keys.Public, 0644); err != nil {
+		return fmt.Errorf("failed to write file public.pem: %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "private.pem"), keys.Private, 0600); err != nil {
+		return fmt.Errorf("failed to write file private.pem: %v", err)
+	}
+
+	return nil
+}
+
+func (k FileKeyStore) Get(ctx context.Context, uid string) (*KeyPair, error) {
+	dir := k.Dir(uid)
+
+	private, err := os.ReadFile(filepath.Join(dir, "private.pem"))
+	if os.IsNotExist(err) {
+		return nil, ErrNoKeys
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read file private.pem: %v", err)
+	}
+
+	public, err := os.ReadFile(filepath.Join(dir, "public.pem"))
+	if os.IsNotExist(err) {
+		return nil, ErrNoKeys
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read file public.pem: %v", err)
+	}
+
+	return &KeyPair{Private: private, Public: public}, nil
+}
+
+// Removes the key pair of a client, shredding the key files first, so their content can't be
+// recovered from the disk
+func (k FileKeyStore) Delete(ctx context.Context, uid string) error {
+	for _, name := range []string{"private.pem", "public.pem"} {
+		if err := shred(filepath.Join(k.Dir(uid), name)); err != nil {
+			return fmt.Errorf("failed to shred the keys of %s: %v", uid, err)
+		}
+	}
+
+	if err := os.RemoveAll(k.Dir(uid)); err != nil {
+		return fmt.Errorf("failed to remove the keys of %s: %v", uid, err)
+	}
+
+	return nil
+}
+
+// Overwrites a file with random bytes (nothing when the file doesn't exist)
+func shred(path string) error {
+	info, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	noise := make([]byte, info.Size())
+	if _, err := rand.Read(noise); err != nil {
+		return err
+	}
+
+	if _, err := file.Write(noise); err != nil {
+		return err
+	}
+
+	return file.Sync()
+}
+
+// Gives the uids of the directories with some key file, in every root
+func (k FileKeyStore) List(ctx context.Context) ([]string, error) {
+	var uids []string
+
+	for _, root := range k.roots {
+		entries, err := os.ReadDir(root)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the key path %s: %v", root, err)
+		}
+
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				continue
+			}
+
+			dir := filepath.Join(root, entry.Name())
+			_, privateErr := os.Stat(filepath.Join(dir, "private.pem"))
+			_, publicErr := os.Stat(filepath.Join(dir, "public.pem"))
+
+			if privateErr == nil || publicErr == nil {
+				uids = append(uids, entry.Name())
+			}
+		}
+	}
+
+	return uids, nil
+}
+
+// Checks if every root is writable, without leaving a key pair behind
+func (k FileKeyStore) Probe(ctx context.Context) error {
+	for _, root := range k.roots {
+		probe := filepath.Join(root, ".probe")
+		if err := os.WriteFile(probe, []byte{}, 0600); err != nil {
+			return fmt.Errorf("the key path %s isn't writable: %v", root, err)
+		}
+		os.Remove(probe)
+	}
+
+	return nil
+}
+
+// The MemoryKeyStore keeps the key pairs in the memory of the process, so they're lost when it stops
+type MemoryKeyStore struct {
+	mu   sync.RWMutex
+	keys map[string]KeyPair
+}
+
+// Creates an empty key store in memory
+func NewMemoryKeyStore() *MemoryKeyStore {
+	return &MemoryKeyStore{keys: map[string]KeyPair{}}
+}
+
+func (m *MemoryKeyStore) Put(ctx context.Context, uid string, keys KeyPair) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.keys[uid] = keys
+	return nil
+}
+
+func (m *MemoryKeyStore) Get(ctx context.Context, uid string) (*KeyPair, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	keys, ok := m.keys[uid]
+	if !ok {
+		return nil, ErrNoKeys
+	}
+
+	return &keys, nil
+}
+
+func (m *MemoryKeyStore) Delete(ctx context.Context, uid string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.keys, uid)
+	return nil
+}
+
+func (m *MemoryKeyStore) List(ctx context.Context) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	uids := make([]string, 0, len(m.keys))
+	for uid := range m.keys {
+		uids = append(uids, uid)
+	}
+	sort.Strings(uids)
+
+	return uids, nil
+}
+
+func (m *MemoryKeyStore) Probe(ctx context.Context) error {
+	return nil
+}
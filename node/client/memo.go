@@ -0,0 +1,127 @@
+package node
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+/*
+A memo travels with a transaction, but only its sender and its recipient can read it. The memo is
+encrypted with a random AES-GCM key, and that key is wrapped (RSA-OAEP) to the public key of each
+reader, so the sealed memo is opaque to the nodes and the peers that store and relay it.
+
+The sealed memo is part of the signed bytes of the transaction, so anyone can still verify that the
+sender committed to it, without reading it. The same sealing serves the other secrets that travel in
+the chain (e.g. the tags of the stealth addresses).
+*/
+type sealedMemo struct {
+	Keys       [][]byte `json:"keys"`       // The memo key wrapped to the public key of each reader
+	Nonce      []byte   `json:"nonce"`      // The nonce of the AES-GCM encryption
+	Ciphertext []byte   `json:"ciphertext"` // The encrypted memo
+}
+
+// The largest memo (before the encryption) in bytes
+const MaxMemoSize int = 1024
+
+var ErrNotMemoReader = errors.New("the memo isn't sealed to the key")
+
+// Encrypts a memo so only the owners of the given public keys can read it. Gives the sealed memo
+// encoded in base64
+func SealMemo(memo []byte, readers ...*rsa.PublicKey) (string, error) {
+	if len(memo) > MaxMemoSize {
+		return "", fmt.Errorf("the memo has %d bytes but the limit is %d", len(memo), MaxMemoSize)
+	}
+
+	return Seal(memo, readers...)
+}
+
+// Encrypts some content so only the owners of the given public keys can read it. Gives the sealed
+// content encoded in base64
+func Seal(content []byte, readers ...*rsa.PublicKey) (string, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return "", fmt.Errorf("failed to generate the memo key: %v", err)
+	}
+
+	gcm, err := memoCipher(key)
+	if err != nil {
+		return "", err
+	}
+
+	sealed := sealedMemo{Nonce: make([]byte, gcm.NonceSize())}
+	if _, err := rand.Read(sealed.Nonce); err != nil {
+		return "", fmt.Errorf("failed to generate the memo nonce: %v", err)
+	}
+	sealed.Ciphertext = gcm.Seal(nil, sealed.Nonce, content, nil)
+
+	for _, reader := range readers {
+		wrapped, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, reader, key, nil)
+		if err != nil {
+			return "", fmt.Errorf("failed to wrap the memo key: %v", err)
+		}
+		sealed.Keys = append(sealed.Keys, wrapped)
+	}
+
+	sealedBytes, err := json.Marshal(sealed)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal the sealed memo: %v", err)
+	}
+
+	return base64.StdEncoding.EncodeToString(sealedBytes), nil
+}
+
+// Decrypts some content sealed (among others) to the public key of the resource
+func (c CryptoResource) Open(content string) ([]byte, error) {
+	sealedBytes, err := base64.StdEncoding.DecodeString(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode the sealed memo: %v", err)
+	}
+
+	sealed := sealedMemo{}
+	if err := json.Unmarshal(sealedBytes, &sealed); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal the sealed memo: %v", err)
+	}
+
+	for _, wrapped := range sealed.Keys {
+		key, err := rsa.DecryptOAEP(sha256.New(), rand.Reader, c.PrivateKey, wrapped, nil)
+		if err != nil {
+			continue
+		}
+
+		gcm, err := memoCipher(key)
+		if err != nil {
+			return nil, err
+		}
+
+		plain, err := gcm.Open(nil, sealed.Nonce, sealed.Ciphertext, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt the memo: %v", err)
+		}
+
+		return plain, nil
+	}
+
+	return nil, ErrNotMemoReader
+}
+
+// Gives the AES-GCM cipher of a memo key
+func memoCipher(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the memo cipher: %v", err)
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the memo cipher: %v", err)
+	}
+
+	return gcm, nil
+}
@@ -0,0 +1,82 @@
+package node
+
+import (
+	"os"
+	"sort"
+	"strings"
+)
+
+/*
+The experimental subsystems are gated by feature flags, so the operators can roll them out
+gradually across the network: a flag is turned on in a few nodes first, and the flags of every node
+are reported in its stats (please, go to `GetNodeStats`).
+
+The FEATURES variable (or the `features` setting of the config file) is a list of flags separated by
+commas. A flag turns a feature on, and a flag prefixed by `-` turns it off, e.g.
+FEATURES="mining,-gossip". The features that aren't in the list keep their defaults.
+*/
+const FeaturesEnv string = "FEATURES"
+
+const (
+	FeatureMining      string = "mining"       // The node assembles and mines the pending transactions into blocks
+	FeatureGossip      string = "gossip"       // The node announces its blocks, erasures and clients to the peers
+	FeatureRESTGateway string = "rest_gateway" // Reserved for the REST gateway in front of the gRPC API
+)
+
+// The known features, with their defaults
+var defaultFeatures = map[string]bool{
+	FeatureMining:      false,
+	FeatureGossip:      true,
+	FeatureRESTGateway: false,
+}
+
+// Gives every known feature with whether it's enabled
+func Features() map[string]bool {
+	features := map[string]bool{}
+	for name, enabled := range defaultFeatures {
+		features[name] = enabled
+	}
+
+	for _, flag := range strings.Split(os.Getenv(FeaturesEnv), ",") {
+		flag = strings.TrimSpace(flag)
+		enabled := !strings.HasPrefix(flag, "-")
+		name := strings.TrimPrefix(flag, "-")
+
+		if _, known := defaultFeatures[name]; known {
+			features[name] = enabled
+		}
+	}
+
+	return features
+}
+
+// Checks if a feature is enabled
+func FeatureEnabled(name string) bool {
+	return Features()[name]
+}
+
+// Gives the names of the enabled features, sorted
+func EnabledFeatures() []string {
+	var names []string
+	for name, enabled := range Features() {
+		if enabled {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Gives the unknown flags of a list of features, so the typos are reported instead of ignored
+func unknownFeatures(list string) []string {
+	var unknown []string
+	for _, flag := range strings.Split(list, ",") {
+		name := strings.TrimPrefix(strings.TrimSpace(flag), "-")
+		if _, known := defaultFeatures[name]; name != "" && !known {
+			unknown = append(unknown, name)
+		}
+	}
+
+	return unknown
+}
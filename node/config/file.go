@@ -0,0 +1,248 @@
+package node
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+The settings of the node can be kept in a config file instead of the environment. The file has one
+`key = value` setting per line, in the flat subset of TOML (the strings are quoted, the numbers and
+the durations aren't required to be, and `#` starts a comment), e.g.:
+
+	backlog_address = "http://10.0.0.2:9200"
+	base_path = "/var/lib/meander"
+	port = 1313
+	token_ttl = "12h"
+
+The environment overrides the file, and the flags of the command line override both. The loaded
+settings are exported back to the environment, so the getters of this package (read whenever they're
+needed) always give the values in use.
+*/
+type Config struct {
+	BacklogAddress string        // The address of the ElasticSearch that holds the backlog
+	BasePath       string        // The directory where the server resources are stored
+	KeyPaths       string        // The directories that hold the client keys (please, go to `main.go`)
+	KeyStore       string        // Where the client keys are kept: "file", "backlog" or "memory"
+	Port           string        // The port where the node and its peers serve the gRPC API
+	ListenAddress  string        // The address of the interface where the node listens (all of them when it's empty)
+	Mirror         string        // The host address from the peer that serves as mirror
+	KeySize        int           // The size (in bits) of the RSA keys generated for the clients
+	TokenTTL       time.Duration // The age after which a token must be refreshed
+	Features       string        // The feature flags, separated by commas (please, go to `features.go`)
+}
+
+const (
+	ConfigFileEnv     string = "CONFIG_FILE"
+	BacklogAddressEnv string = "BACKLOG_ADDRESS"
+	PortEnv           string = "PORT"
+	ListenAddressEnv  string = "LISTEN_ADDRESS"
+	MirrorEnv         string = "MIRROR"
+	KeySizeEnv        string = "KEY_SIZE"
+	KeyStoreEnv       string = "KEY_STORE"
+)
+
+// The kinds of key stores
+const (
+	FileKeyStore    string = "file"
+	BacklogKeyStore string = "backlog"
+	MemoryKeyStore  string = "memory"
+)
+
+const (
+	defaultBacklogAddress string = "http://localhost:9200"
+	defaultPort           string = "1313"
+	defaultMirror         string = "0.0.0.0"
+	defaultKeySize        int    = 4096
+)
+
+// The settings of the file, by key, with the environment variable that overrides each one
+var configKeys = map[string]string{
+	"backlog_address": BacklogAddressEnv,
+	"base_path":       BasePathEnv,
+	"key_paths":       KeyPathsEnv,
+	"port":            PortEnv,
+	"listen_address":  ListenAddressEnv,
+	"mirror":          MirrorEnv,
+	"key_size":        KeySizeEnv,
+	"key_store":       KeyStoreEnv,
+	"token_ttl":       TokenTTLEnv,
+	"features":        FeaturesEnv,
+}
+
+// Loads the settings from a config file (none when the path is empty) and the environment. The
+// values are checked, but the paths are only checked by `Validate`
+func Load(path string) (*Config, error) {
+	values := map[string]string{}
+
+	if path != "" {
+		settings, err := readConfigFile(path)
+		if err != nil {
+			return nil, err
+		}
+		values = settings
+	}
+
+	for key, env := range configKeys {
+		if value, ok := os.LookupEnv(env); ok && value != "" {
+			values[key] = value
+		}
+	}
+
+	cfg := Config{
+		BacklogAddress: defaultBacklogAddress,
+		BasePath:       values["base_path"],
+		KeyPaths:       values["key_paths"],
+		Port:           defaultPort,
+		ListenAddress:  values["listen_address"],
+		Mirror:         defaultMirror,
+		KeySize:        defaultKeySize,
+		KeyStore:       FileKeyStore,
+		TokenTTL:       defaultTokenTTL,
+		Features:       values["features"],
+	}
+
+	if value := values["backlog_address"]; value != "" {
+		cfg.BacklogAddress = value
+	}
+
+	if value := values["port"]; value != "" {
+		if port, err := strconv.Atoi(value); err != nil || port <= 0 || port > 65535 {
+			return nil, fmt.Errorf("invalid port %q", value)
+		}
+		cfg.Port = value
+	}
+
+	if value := values["mirror"]; value != "" {
+		cfg.Mirror = value
+	}
+
+	if value := values["key_size"]; value != "" {
+		size, err := strconv.Atoi(value)
+		if err != nil || size < 2048 {
+			return nil, fmt.Errorf("invalid key size %q: the keys must have at least 2048 bits", value)
+		}
+		cfg.KeySize = size
+	}
+
+	switch value := values["key_store"]; value {
+	case "":
+	case FileKeyStore, BacklogKeyStore, MemoryKeyStore:
+		cfg.KeyStore = value
+	default:
+		return nil, fmt.Errorf("invalid key store %q: it must be %s, %s or %s", value, FileKeyStore, BacklogKeyStore, MemoryKeyStore)
+	}
+
+	if value := values["token_ttl"]; value != "" {
+		ttl, err := time.ParseDuration(value)
+		if err != nil || ttl <= 0 {
+			return nil, fmt.Errorf("invalid token TTL %q", value)
+		}
+		cfg.TokenTTL = ttl
+	}
+
+	if unknown := unknownFeatures(cfg.Features); len(unknown) > 0 {
+		return nil, fmt.Errorf("unknown features %s", strings.Join(unknown, ", "))
+	}
+
+	return &cfg, nil
+}
+
+// Reads the settings of a config file
+func readConfigFile(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the config file: %v", err)
+	}
+	defer file.Close()
+
+	values := map[string]string{}
+	scanner := bufio.NewScanner(file)
+
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		key, value, ok := strings.Cut(text, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid config file %s: line %d isn't a setting", path, line)
+		}
+
+		key = strings.TrimSpace(key)
+		if _, known := configKeys[key]; !known {
+			return nil, fmt.Errorf("invalid config file %s: unknown setting %q at line %d", path, key, line)
+		}
+
+		value = strings.TrimSpace(value)
+		if strings.HasPrefix(value, "\"") {
+			unquoted, err := strconv.Unquote(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid config file %s: bad string at line %d", path, line)
+			}
+			value = unquoted
+		} else if comment := strings.Index(value, "#"); comment >= 0 {
+			value = strings.TrimSpace(value[:comment])
+		}
+
+		values[key] = value
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read the config file: %v", err)
+	}
+
+	return values, nil
+}
+
+// Gives the directories that hold the client keys
+func (c Config) KeyRoots() []string {
+	return splitKeyPaths(c.KeyPaths, c.BasePath)
+}
+
+// Exports the settings to the environment, so the getters of the package give them
+func (c Config) Apply() {
+	os.Setenv(BacklogAddressEnv, c.BacklogAddress)
+	os.Setenv(BasePathEnv, c.BasePath)
+	os.Setenv(KeyPathsEnv, c.KeyPaths)
+	os.Setenv(PortEnv, c.Port)
+	os.Setenv(ListenAddressEnv, c.ListenAddress)
+	os.Setenv(MirrorEnv, c.Mirror)
+	os.Setenv(KeySizeEnv, strconv.Itoa(c.KeySize))
+	os.Setenv(KeyStoreEnv, c.KeyStore)
+	os.Setenv(TokenTTLEnv, c.TokenTTL.String())
+	os.Setenv(FeaturesEnv, c.Features)
+}
+
+// Gives the address of the ElasticSearch that holds the backlog
+func BacklogAddress() string {
+	if address := os.Getenv(BacklogAddressEnv); address != "" {
+		return address
+	}
+
+	return defaultBacklogAddress
+}
+
+// Gives the size (in bits) of the RSA keys generated for the clients
+func KeySize() int {
+	size, err := strconv.Atoi(os.Getenv(KeySizeEnv))
+	if err != nil || size < 2048 {
+		size = defaultKeySize
+	}
+
+	return size
+}
+
+// Gives where the client keys are kept
+func KeyStore() string {
+	if kind := os.Getenv(KeyStoreEnv); kind != "" {
+		return kind
+	}
+
+	return FileKeyStore
+}
@@ -0,0 +1,602 @@
+package node
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+The config centralizes the environment that the node depends on to store its resources.
+
+The BASE_PATH variable points to the directory where the server resources are stored. It's
+read whenever it's needed (and not at package init), so the value set by `main` is always
+the one in use.
+
+The client keys can be split across several directories (e.g. one per disk) with the
+KEY_PATHS variable, a list of paths separated by the OS list separator (`:` on unix).
+If KEY_PATHS is not set, every key is stored under BASE_PATH.
+*/
+
+const (
+	BasePathEnv string = "BASE_PATH"
+	KeyPathsEnv string = "KEY_PATHS"
+)
+
+// Gives the directory where the server resources are stored
+func BasePath() string {
+	return os.Getenv(BasePathEnv)
+}
+
+// Gives all the directories that can hold client keys
+func KeyPaths() []string {
+	return splitKeyPaths(os.Getenv(KeyPathsEnv), BasePath())
+}
+
+// Gives the directories of a list of key paths, or the base path when the list is empty
+func splitKeyPaths(list, basePath string) []string {
+	var paths []string
+
+	for _, path := range filepath.SplitList(list) {
+		if path = strings.TrimSpace(path); path != "" {
+			paths = append(paths, path)
+		}
+	}
+
+	if len(paths) == 0 {
+		paths = append(paths, basePath)
+	}
+
+	return paths
+}
+
+// Checks if the configured paths are usable, creating them when they don't exist yet
+func Validate() error {
+	if BasePath() == "" {
+		return fmt.Errorf("%s is not set: please provide the path to store the server resources", BasePathEnv)
+	}
+
+	paths := append([]string{BasePath()}, KeyPaths()...)
+	for _, path := range paths {
+		info, err := os.Stat(path)
+
+		if os.IsNotExist(err) {
+			if err := os.MkdirAll(path, 0755); err != nil {
+				return fmt.Errorf("failed to create path \"%s\": %v", path, err)
+			}
+			fmt.Printf("Path \"%s\" successfully created\n", path)
+			continue
+		} else if err != nil {
+			return fmt.Errorf("failed to stat path \"%s\": %v", path, err)
+		}
+
+		if !info.IsDir() {
+			return fmt.Errorf("path \"%s\" is not a directory", path)
+		}
+	}
+
+	return nil
+}
+
+const (
+	BacklogDataPathEnv string = "BACKLOG_DATA_PATH"
+	MinFreeDiskEnv     string = "MIN_FREE_DISK_MB"
+	WebhooksEnv        string = "WEBHOOK_URLS"
+	TrustedGatewaysEnv string = "TRUSTED_GATEWAYS"
+	SessionWindowEnv   string = "SESSION_WINDOW"
+	PushRelayEnv       string = "PUSH_RELAY_URL"
+	DowntimeEnv        string = "EXPECTED_DOWNTIME"
+	ListenerFdEnv      string = "LISTENER_FD"
+	HandoverTokenEnv   string = "HANDOVER_TOKEN"
+	WorkerPoolsEnv     string = "WORKER_POOLS"
+	TokenTTLEnv        string = "TOKEN_TTL"
+	ScriptsEnv         string = "VALIDATION_SCRIPTS"
+	ScriptMemoryEnv    string = "SCRIPT_MEMORY_MB"
+	ScriptFuelEnv      string = "SCRIPT_FUEL"
+	ScriptTimeoutEnv   string = "SCRIPT_TIMEOUT"
+	AnchorURLEnv       string = "ANCHOR_URL"
+	TermsVersionEnv    string = "TERMS_VERSION"
+	TermsEnforcedEnv   string = "TERMS_ENFORCED"
+	AdminTokenEnv      string = "ADMIN_TOKEN"
+	AdminTokensEnv     string = "ADMIN_TOKENS"
+	ApprovalWindowEnv  string = "ADMIN_APPROVAL_WINDOW"
+	DedupWindowEnv     string = "REQUEST_DEDUP_WINDOW"
+	MinFeeEnv          string = "MIN_FEE"
+	FeeRecipientEnv    string = "FEE_RECIPIENT"
+	RPCConcurrencyEnv  string = "RPC_CONCURRENCY"
+	RPCQueueTimeoutEnv string = "RPC_QUEUE_TIMEOUT"
+	BackpressureEnv    string = "BACKPRESSURE_LATENCY"
+	PressuredWritesEnv string = "PRESSURED_WRITES"
+	BacklogWritesEnv   string = "BACKLOG_WRITES"
+	BacklogRefreshEnv  string = "BACKLOG_REFRESH"
+	RateLimitsEnv      string = "RATE_LIMITS"
+	KeyCacheTTLEnv     string = "KEY_CACHE_TTL"
+	SeedPeersEnv       string = "SEED_PEERS"
+	GenesisAllocEnv    string = "GENESIS_ALLOCATION"
+	GenesisTimeEnv     string = "GENESIS_TIMESTAMP"
+	GenesisHashEnv     string = "GENESIS_HASH"
+)
+
+// The default time that a session stays valid since the last activity
+const defaultSessionWindow = 30 * time.Minute
+
+// The default age after which a token is refused, however active its session is
+const defaultTokenTTL = 24 * time.Hour
+
+// The default time that a second admin has to approve a destructive admin operation
+const defaultApprovalWindow = 15 * time.Minute
+
+// The default time a response is kept for the retries of a call with the same request id
+const defaultDedupWindow = 10 * time.Minute
+
+// The default limits of a validation script: the memory (in megabytes), the instructions it can
+// run and the time it can take to evaluate a transaction
+const (
+	defaultScriptMemory  int64         = 16
+	defaultScriptFuel    uint64        = 10_000_000
+	defaultScriptTimeout time.Duration = 50 * time.Millisecond
+)
+
+// The default caps of concurrent calls by RPC, for the calls that are expensive (e.g. the key
+// generation of CreateClient)
+var defaultRPCConcurrency = map[string]int{"CreateClient": 4}
+
+// The default rates of the RPCs that a single client could use to hammer the backlog
+var defaultRateLimits = map[string]RateLimit{
+	"CreateClient":  {Calls: 10, Period: time.Minute},
+	"ConnectClient": {Calls: 30, Period: time.Minute},
+}
+
+// The default time that the callers of GetPublicKey can keep a key without asking for it again
+const defaultKeyCacheTTL = 5 * time.Minute
+
+// The default timestamp of the genesis block (2026-01-01T00:00:00Z), so the nodes initialized with the
+// same allocation build the same genesis
+const defaultGenesisTimestamp int64 = 1767225600000
+
+// The default time that a call waits for a slot when its RPC is at the cap
+const defaultRPCQueueTimeout = 2 * time.Second
+
+// The default latency of the backlog above which the node sheds work, and the default cap of the
+// concurrent write calls meanwhile
+const (
+	defaultBackpressureLatency time.Duration = 500 * time.Millisecond
+	defaultPressuredWrites     int           = 4
+)
+
+// The default cap of the concurrent writes to the backlog, by process
+const defaultBacklogWrites int = 32
+
+// The default free space (in megabytes) under which the node enters the read-only mode
+const defaultMinFreeDisk int64 = 512
+
+// Gives the data path of the backlog when the Elasticsearch runs in the same host (empty otherwise)
+func BacklogDataPath() string {
+	return os.Getenv(BacklogDataPathEnv)
+}
+
+// Gives the minimum free space (in bytes) that the node must have to accept writes
+func MinFreeDisk() int64 {
+	megabytes, err := strconv.ParseInt(os.Getenv(MinFreeDiskEnv), 10, 64)
+	if err != nil || megabytes < 0 {
+		megabytes = defaultMinFreeDisk
+	}
+
+	return megabytes * 1024 * 1024
+}
+
+// Gives the addresses that must receive the node warnings
+func WebhookURLs() []string {
+	var urls []string
+
+	for _, url := range strings.Split(os.Getenv(WebhooksEnv), ",") {
+		if url = strings.TrimSpace(url); url != "" {
+			urls = append(urls, url)
+		}
+	}
+
+	return urls
+}
+
+// Gives the time that a session stays valid since the last activity (e.g. "45m" or "2h")
+func SessionWindow() time.Duration {
+	window, err := time.ParseDuration(os.Getenv(SessionWindowEnv))
+	if err != nil || window <= 0 {
+		window = defaultSessionWindow
+	}
+
+	return window
+}
+
+// Gives the time that the callers can cache a public key before checking it again (e.g. "10m").
+// A short time makes the rotations and the revocations reach the callers sooner
+func KeyCacheTTL() time.Duration {
+	ttl, err := time.ParseDuration(os.Getenv(KeyCacheTTLEnv))
+	if err != nil || ttl <= 0 {
+		ttl = defaultKeyCacheTTL
+	}
+
+	return ttl
+}
+
+// Gives the age after which a token is refused and must be refreshed (e.g. "12h")
+func TokenTTL() time.Duration {
+	ttl, err := time.ParseDuration(os.Getenv(TokenTTLEnv))
+	if err != nil || ttl <= 0 {
+		ttl = defaultTokenTTL
+	}
+
+	return ttl
+}
+
+// Gives the address of the relay that forwards the push notifications (empty if there is none)
+func PushRelayURL() string {
+	return os.Getenv(PushRelayEnv)
+}
+
+// Gives how long the node expects to stay down when it stops (e.g. "10m" for a planned restart).
+// It's zero when the downtime is unknown
+func ExpectedDowntime() time.Duration {
+	downtime, err := time.ParseDuration(os.Getenv(DowntimeEnv))
+	if err != nil || downtime < 0 {
+		return 0
+	}
+
+	return downtime
+}
+
+// Gives the file descriptor of the listening socket handed over by the former process of the node,
+// when the node is restarting without downtime. The variable is cleared, so it doesn't reach the
+// next restart
+func InheritedListenerFd() (uintptr, bool) {
+	value := os.Getenv(ListenerFdEnv)
+	os.Unsetenv(ListenerFdEnv)
+
+	fd, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	return uintptr(fd), true
+}
+
+// Gives the token under which the successor records that it took over the node (empty when the node
+// isn't restarting). The variable is cleared, so it doesn't reach the next restart
+func InheritedHandoverToken() string {
+	token := os.Getenv(HandoverTokenEnv)
+	os.Unsetenv(HandoverTokenEnv)
+
+	return token
+}
+
+// Gives the addresses of the gateways whose forwarded request metadata is trusted
+func TrustedGateways() []string {
+	var gateways []string
+
+	for _, gateway := range strings.Split(os.Getenv(TrustedGatewaysEnv), ",") {
+		if gateway = strings.TrimSpace(gateway); gateway != "" {
+			gateways = append(gateways, gateway)
+		}
+	}
+
+	return gateways
+}
+
+// Gives the hosts that the node exchanges its peers with until it knows them as peers, besides its
+// mirror (e.g. "10.0.0.2,10.0.0.3")
+func SeedPeers() []string {
+	var hosts []string
+
+	for _, host := range strings.Split(os.Getenv(SeedPeersEnv), ",") {
+		if host = strings.TrimSpace(host); host != "" {
+			hosts = append(hosts, host)
+		}
+	}
+
+	return hosts
+}
+
+// Gives the initial allocation of the chain, as set in GENESIS_ALLOCATION (a list of values by client
+// id, e.g. "3082010a...=1000,3082010b...=250")
+func GenesisAllocation() (map[string]float64, error) {
+	allocation := map[string]float64{}
+
+	for _, entry := range strings.Split(os.Getenv(GenesisAllocEnv), ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+
+		clientId, value, ok := strings.Cut(entry, "=")
+		parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if !ok || err != nil || parsed <= 0 {
+			return nil, fmt.Errorf("invalid %s entry \"%s\": expected a client id and a positive value", GenesisAllocEnv, entry)
+		}
+
+		allocation[strings.TrimSpace(clientId)] += parsed
+	}
+
+	return allocation, nil
+}
+
+// Gives the timestamp (in milliseconds) of the genesis block
+func GenesisTimestamp() int64 {
+	timestamp, err := strconv.ParseInt(os.Getenv(GenesisTimeEnv), 10, 64)
+	if err != nil || timestamp <= 0 {
+		timestamp = defaultGenesisTimestamp
+	}
+
+	return timestamp
+}
+
+// Gives the hash of the genesis block of the network that the node joins (empty when any genesis is
+// taken), so a node without a chain never syncs the chain of another network
+func GenesisHash() string {
+	return strings.TrimSpace(os.Getenv(GenesisHashEnv))
+}
+
+// Gives the number of workers of a background pool, as set in WORKER_POOLS (a list of pool sizes
+// by name, e.g. "gossip=8,webhook=2"), or the fallback when the pool isn't listed
+func WorkerPoolSize(pool string, fallback int) int {
+	for _, entry := range strings.Split(os.Getenv(WorkerPoolsEnv), ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok || strings.TrimSpace(name) != pool {
+			continue
+		}
+
+		if size, err := strconv.Atoi(strings.TrimSpace(value)); err == nil && size > 0 {
+			return size
+		}
+	}
+
+	return fallback
+}
+
+// Gives the cap of concurrent calls of an RPC, as set in RPC_CONCURRENCY (a list of caps by RPC
+// name, e.g. "CreateClient=2,ConnectClient=32"). It's zero when the RPC has no cap
+func RPCConcurrency(rpc string) int {
+	for _, entry := range strings.Split(os.Getenv(RPCConcurrencyEnv), ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok || strings.TrimSpace(name) != rpc {
+			continue
+		}
+
+		if limit, err := strconv.Atoi(strings.TrimSpace(value)); err == nil && limit >= 0 {
+			return limit
+		}
+	}
+
+	return defaultRPCConcurrency[rpc]
+}
+
+// The calls of an RPC that a caller can make in a period
+type RateLimit struct {
+	Calls  int
+	Period time.Duration
+}
+
+// Gives the rate of an RPC by caller (by peer address and by client), as set in RATE_LIMITS (a list
+// of rates by RPC name, e.g. "CreateClient=5/1m,ConnectClient=30/1m"). It has no calls when the RPC
+// isn't limited
+func RPCRateLimit(rpc string) RateLimit {
+	for _, entry := range strings.Split(os.Getenv(RateLimitsEnv), ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok || strings.TrimSpace(name) != rpc {
+			continue
+		}
+
+		calls, period, ok := strings.Cut(strings.TrimSpace(value), "/")
+		if !ok {
+			continue
+		}
+
+		limit, err := strconv.Atoi(calls)
+		duration, durationErr := time.ParseDuration(period)
+		if err == nil && durationErr == nil && limit >= 0 && duration > 0 {
+			return RateLimit{Calls: limit, Period: duration}
+		}
+	}
+
+	return defaultRateLimits[rpc]
+}
+
+// Gives how long a call waits for a slot when its RPC is at the cap, before it's rejected (e.g.
+// "500ms"). Zero rejects the calls beyond the cap right away
+func RPCQueueTimeout() time.Duration {
+	timeout, err := time.ParseDuration(os.Getenv(RPCQueueTimeoutEnv))
+	if err != nil || timeout < 0 {
+		timeout = defaultRPCQueueTimeout
+	}
+
+	return timeout
+}
+
+// Gives the latency of the backlog (e.g. "300ms") above which the node sheds the low-priority work
+// and tightens the write calls (please, go to `backpressure.go` in the node package)
+func BackpressureLatency() time.Duration {
+	latency, err := time.ParseDuration(os.Getenv(BackpressureEnv))
+	if err != nil || latency <= 0 {
+		latency = defaultBackpressureLatency
+	}
+
+	return latency
+}
+
+// Gives the cap of the concurrent write calls (of all the write RPCs together) while the node is
+// under backpressure
+func PressuredWrites() int {
+	writes, err := strconv.Atoi(os.Getenv(PressuredWritesEnv))
+	if err != nil || writes <= 0 {
+		writes = defaultPressuredWrites
+	}
+
+	return writes
+}
+
+// Gives the cap of the concurrent writes to the backlog by process, beyond which the writes wait in
+// their priority lanes (please, go to `priority.go` in the backlog package)
+func BacklogWrites() int {
+	writes, err := strconv.Atoi(os.Getenv(BacklogWritesEnv))
+	if err != nil || writes <= 0 {
+		writes = defaultBacklogWrites
+	}
+
+	return writes
+}
+
+// Gives the refresh asked by the writes to the backlog: "true" (the default) makes every write visible
+// right away, "wait_for" waits for the periodic refresh and "false" doesn't wait (please, go to
+// `session.go` in the backlog package)
+func BacklogRefresh() string {
+	switch refresh := os.Getenv(BacklogRefreshEnv); refresh {
+	case "wait_for", "false":
+		return refresh
+	default:
+		return "true"
+	}
+}
+
+// Gives the address of the service where the checkpoints of the chain are anchored (empty if there
+// is none)
+func AnchorURL() string {
+	return os.Getenv(AnchorURLEnv)
+}
+
+// Gives the current version of the terms that the clients must accept (empty if there are none)
+func TermsVersion() string {
+	return os.Getenv(TermsVersionEnv)
+}
+
+// Tells whether the clients that didn't accept the current terms are blocked from transacting
+func TermsEnforced() bool {
+	enforced, _ := strconv.ParseBool(os.Getenv(TermsEnforcedEnv))
+	return enforced
+}
+
+// Gives the validation scripts enforced by the node, as set in VALIDATION_SCRIPTS (a list of script
+// names, each one optionally pinned to a version, e.g. "limits,sanctions@3")
+func ValidationScripts() []string {
+	var scripts []string
+
+	for _, script := range strings.Split(os.Getenv(ScriptsEnv), ",") {
+		if script = strings.TrimSpace(script); script != "" {
+			scripts = append(scripts, script)
+		}
+	}
+
+	return scripts
+}
+
+// Gives the memory (in bytes) that a validation script can use
+func ScriptMemory() int64 {
+	megabytes, err := strconv.ParseInt(os.Getenv(ScriptMemoryEnv), 10, 64)
+	if err != nil || megabytes <= 0 {
+		megabytes = defaultScriptMemory
+	}
+
+	return megabytes * 1024 * 1024
+}
+
+// Gives the number of function calls that a validation script can make for a transaction
+func ScriptFuel() uint64 {
+	fuel, err := strconv.ParseUint(os.Getenv(ScriptFuelEnv), 10, 64)
+	if err != nil || fuel == 0 {
+		fuel = defaultScriptFuel
+	}
+
+	return fuel
+}
+
+// Gives the time that a validation script can take to evaluate a transaction (e.g. "100ms")
+func ScriptTimeout() time.Duration {
+	timeout, err := time.ParseDuration(os.Getenv(ScriptTimeoutEnv))
+	if err != nil || timeout <= 0 {
+		timeout = defaultScriptTimeout
+	}
+
+	return timeout
+}
+
+// Gives the token that authenticates the calls to the admin API (empty when the API is disabled)
+func AdminToken() string {
+	return os.Getenv(AdminTokenEnv)
+}
+
+// Gives the tokens of the admins by their names, from ADMIN_TOKENS (e.g. "alice=token1,bob=token2").
+// The single ADMIN_TOKEN belongs to the admin named "admin"
+func AdminTokens() map[string]string {
+	tokens := map[string]string{}
+	if token := AdminToken(); token != "" {
+		tokens["admin"] = token
+	}
+
+	for _, entry := range strings.Split(os.Getenv(AdminTokensEnv), ",") {
+		name, token, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if name, token = strings.TrimSpace(name), strings.TrimSpace(token); ok && name != "" && token != "" {
+			tokens[name] = token
+		}
+	}
+
+	return tokens
+}
+
+// Gives the time that a second admin has to approve a destructive admin operation (e.g. "30m")
+func ApprovalWindow() time.Duration {
+	window, err := time.ParseDuration(os.Getenv(ApprovalWindowEnv))
+	if err != nil || window <= 0 {
+		window = defaultApprovalWindow
+	}
+
+	return window
+}
+
+// Gives the minimum fee that a transaction must pay to be accepted by the node (zero by default)
+func MinFee() float64 {
+	fee, err := strconv.ParseFloat(os.Getenv(MinFeeEnv), 64)
+	if err != nil || fee < 0 {
+		fee = 0
+	}
+
+	return fee
+}
+
+// Gives the client id credited with the fees of the blocks produced by the node (empty when the
+// fees are burned)
+func FeeRecipient() string {
+	return strings.TrimSpace(os.Getenv(FeeRecipientEnv))
+}
+
+// Gives how long the response of a call is replayed to its retries (please, go to `dedup.go` in the
+// node)
+func DedupWindow() time.Duration {
+	window, err := time.ParseDuration(os.Getenv(DedupWindowEnv))
+	if err != nil || window <= 0 {
+		window = defaultDedupWindow
+	}
+
+	return window
+}
+
+// The environment variables that hold credentials and can't leave the node
+var secretEnvs = []string{"SECRET", WebhooksEnv, PushRelayEnv, AnchorURLEnv, AdminTokenEnv, AdminTokensEnv}
+
+// Gives the node environment with the secrets scrubbed, so it can be attached to bug reports
+func Snapshot() map[string]string {
+	snapshot := map[string]string{}
+
+	for _, env := range []string{BasePathEnv, KeyPathsEnv, BacklogDataPathEnv, MinFreeDiskEnv, TrustedGatewaysEnv, SessionWindowEnv, DowntimeEnv, WorkerPoolsEnv, TokenTTLEnv, ScriptsEnv, ScriptMemoryEnv, ScriptFuelEnv, ScriptTimeoutEnv, TermsVersionEnv, TermsEnforcedEnv, RPCConcurrencyEnv, RPCQueueTimeoutEnv, BackpressureEnv, PressuredWritesEnv, BacklogWritesEnv, BacklogRefreshEnv, RateLimitsEnv, KeyCacheTTLEnv, SeedPeersEnv, GenesisAllocEnv, GenesisTimeEnv, GenesisHashEnv, ApprovalWindowEnv, DedupWindowEnv, MinFeeEnv, FeeRecipientEnv, ConfigFileEnv, BacklogAddressEnv, PortEnv, ListenAddressEnv, MirrorEnv, KeySizeEnv, KeyStoreEnv, FeaturesEnv} {
+		snapshot[env] = os.Getenv(env)
+	}
+
+	for _, env := range secretEnvs {
+		if os.Getenv(env) != "" {
+			snapshot[env] = "[scrubbed]"
+		} else {
+			snapshot[env] = ""
+		}
+	}
+
+	return snapshot
+}
@@ -6,6 +6,8 @@ require (
 	github.com/dgrijalva/jwt-go v3.2.0+incompatible
 	github.com/elastic/go-elasticsearch/v8 v8.11.1
 	github.com/google/uuid v1.5.0
+	github.com/klauspost/compress v1.17.4
+	github.com/tetratelabs/wazero v1.6.0
 )
 
 require github.com/elastic/elastic-transport-go/v8 v8.3.0 // indirect
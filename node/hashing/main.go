@@ -0,0 +1,63 @@
+package node
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+/*
+The hashing standardizes how the node hashes the values that it keeps (or shares) only by their
+hash, e.g. the address of the node where a client was registered, the aliases of the clients and
+the client ids that key documents (an id can be longer than the 512 bytes of a document id).
+
+Every hash is the SHA-256 (hex encoded) of the value after a prefix of its domain, so the hash of a
+value in one domain never matches the hash of the same value in another one (e.g. an alias that
+reads as a host).
+
+The documents written before the domains existed carry the plain hash of the value (please, go to
+`LegacyHash`). The node addresses are shared with the peers, so they're matched in both formats
+(please, go to `MatchesAddress`) until every node writes the new one.
+*/
+const (
+	addressDomain string = "meander:address:"
+	aliasDomain   string = "meander:alias:"
+	clientDomain  string = "meander:client:"
+)
+
+// Gives the hex SHA-256 of the bytes
+func sum(value []byte) string {
+	hash := sha256.Sum256(value)
+	return hex.EncodeToString(hash[:])
+}
+
+// Gives the hash of a host or network address (e.g. the host of a node or the address of a client)
+func HashAddress(address string) string {
+	return sum([]byte(addressDomain + address))
+}
+
+// Gives the hash of an alias, normalized so aliases that only differ by case or surrounding spaces
+// have the same hash
+func HashAlias(alias string) string {
+	return sum([]byte(aliasDomain + NormalizeAlias(alias)))
+}
+
+// Gives the hash of a client id, to key the documents of the client
+func HashClientId(clientId string) string {
+	return sum([]byte(clientDomain + clientId))
+}
+
+// Normalizes an alias, so aliases that only differ by case or surrounding spaces collide
+func NormalizeAlias(alias string) string {
+	return strings.ToLower(strings.TrimSpace(alias))
+}
+
+// Gives the hash of a value without any domain, as the node wrote it before the domains existed
+func LegacyHash(value string) string {
+	return sum([]byte(value))
+}
+
+// Checks if a hash is the hash of the address, in either format
+func MatchesAddress(hash, address string) bool {
+	return hash == HashAddress(address) || hash == LegacyHash(address)
+}
@@ -0,0 +1,192 @@
+package node
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	client "node/client"
+	hashing "node/hashing"
+	timeutil "node/timeutil"
+)
+
+/*
+An acknowledgment is an optional receipt that the recipient of a transaction signs to prove that
+it was delivered. It's stored alongside the transaction (in its `Acknowledgment` field) by the node
+of the recipient and routed to the node of the sender, so the sender holds the proof of delivery.
+
+The acknowledgment covers the signature of the transaction, so it can't be moved to another
+transaction, and it's verified with the client id of the recipient (the identity of its public key).
+*/
+type Acknowledgment struct {
+	TransactionId string `json:"transaction_id"` // The id of the acknowledged transaction
+	Recipient     string `json:"recipient"`      // The client id of the recipient that signed the acknowledgment
+	Transaction   string `json:"transaction"`    // The signature of the acknowledged transaction
+	SignedAt      int64  `json:"signed_at"`      // The timestamp when the recipient signed the acknowledgment
+	Signature     string `json:"signature"`      // The signature made by the recipient
+}
+
+var (
+	ErrUnknownTransaction = errors.New("the transaction is unknown to the node")
+	ErrNotRecipient       = errors.New("the client isn't the recipient of the transaction")
+)
+
+// Converts the acknowledgment (except its signature) to a signable byte array
+func (a Acknowledgment) ToBytes() ([]byte, error) {
+	acknowledgment := map[string]interface{}{
+		"transaction_id": a.TransactionId,
+		"recipient":      a.Recipient,
+		"transaction":    a.Transaction,
+		"signed_at":      a.SignedAt,
+	}
+
+	acknowledgmentBytes, err := json.Marshal(acknowledgment)
+	return acknowledgmentBytes, err
+}
+
+// Verifies the acknowledgment against the transaction it claims to acknowledge
+func (a Acknowledgment) Verify(transaction TransactionRecord) error {
+	if a.Recipient != transaction.Recipient {
+		return fmt.Errorf("%w %s", ErrNotRecipient, a.TransactionId)
+	}
+
+	if a.Transaction != transaction.Signature {
+		return fmt.Errorf("the acknowledgment doesn't cover the signature of the transaction %s", a.TransactionId)
+	}
+
+	if _, err := client.ParseIdentity(a.Recipient); err != nil {
+		return fmt.Errorf("the recipient isn't a valid client id: %v", err)
+	}
+
+	if err := client.VerifyWithIdentity(a.Recipient, a, a.Signature); err != nil {
+		return fmt.Errorf("the acknowledgment isn't signed by the recipient of the transaction %s", a.TransactionId)
+	}
+
+	return nil
+}
+
+// Gives the signed transaction with the given id
+func (n Node) signedTransaction(ctx context.Context, transactionId string) (*TransactionRecord, error) {
+	transaction, err := n.Transactions().Get(ctx, transactionId)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnknownTransaction, err)
+	}
+
+	if transaction.Signature == "" {
+		return nil, fmt.Errorf("the transaction %s isn't signed", transactionId)
+	}
+
+	return transaction, nil
+}
+
+// Stores an acknowledgment alongside its transaction
+func (n Node) storeAcknowledgment(ctx context.Context, acknowledgment Acknowledgment) error {
+	document, err := toDocument(acknowledgment)
+	if err != nil {
+		return err
+	}
+
+	err = n.UpdateDocument(ctx, "transactions", acknowledgment.TransactionId, map[string]interface{}{
+		"Acknowledgment": document,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store the acknowledgment: %v", err)
+	}
+
+	return nil
+}
+
+// Signs the acknowledgment of a transaction received by the client, stores it and routes it to
+// the node of the sender
+func (c Client) AcknowledgeTransaction(ctx context.Context, transactionId string) (*Acknowledgment, error) {
+	transaction, err := c.Node.signedTransaction(ctx, transactionId)
+	if err != nil {
+		return nil, err
+	}
+
+	if transaction.Recipient != c.ClientId {
+		return nil, fmt.Errorf("%w %s", ErrNotRecipient, transactionId)
+	}
+
+	if transaction.Acknowledgment != nil {
+		return transaction.Acknowledgment, nil
+	}
+
+	acknowledgment := Acknowledgment{
+		TransactionId: transactionId,
+		Recipient:     c.ClientId,
+		Transaction:   transaction.Signature,
+		SignedAt:      timeutil.Now(),
+	}
+
+	signature, err := c.CreateSignature(acknowledgment)
+	if err != nil {
+		return nil, err
+	}
+	acknowledgment.Signature = signature
+
+	if err := c.Node.storeAcknowledgment(ctx, acknowledgment); err != nil {
+		return nil, err
+	}
+
+	c.Emit(ctx, "transaction.acknowledged", map[string]interface{}{
+		"transaction_id": transactionId,
+		"recipient":      c.ClientId,
+	})
+	outboxPool.Go(ctx, func(ctx context.Context) {
+		c.Node.routeAcknowledgment(ctx, *transaction, acknowledgment)
+	})
+
+	return &acknowledgment, nil
+}
+
+// Routes an acknowledgment to the home node of the sender, when it isn't the current node
+func (n Node) routeAcknowledgment(ctx context.Context, transaction TransactionRecord, acknowledgment Acknowledgment) {
+	if peerTransport == nil {
+		return
+	}
+
+	sender, err := n.Clients().GetForeign(ctx, transaction.Sender)
+	if err != nil {
+		Logf(ctx, "failed to route the acknowledgment of %s: %v", transaction.TransactionId, err)
+		return
+	}
+
+	if hashing.MatchesAddress(sender.NodeAddress, n.Host) {
+		return
+	}
+
+	host, err := n.homeNodeHost(ctx, sender.NodeAddress)
+	if err != nil {
+		Logf(ctx, "failed to route the acknowledgment of %s: %v", transaction.TransactionId, err)
+		return
+	}
+
+	if err := peerTransport.RouteAcknowledgment(ctx, host, acknowledgment); err != nil {
+		Logf(ctx, "failed to route the acknowledgment of %s to %s: %v", transaction.TransactionId, host, err)
+	}
+}
+
+// Stores an acknowledgment routed by the node of the recipient, once it's verified
+func (n Node) AcceptAcknowledgment(ctx context.Context, acknowledgment Acknowledgment) error {
+	transaction, err := n.signedTransaction(ctx, acknowledgment.TransactionId)
+	if err != nil {
+		return err
+	}
+
+	if err := acknowledgment.Verify(*transaction); err != nil {
+		return err
+	}
+
+	return n.storeAcknowledgment(ctx, acknowledgment)
+}
+
+// Gives the acknowledgment of a transaction (nil when the recipient didn't acknowledge it yet)
+func (n Node) TransactionAcknowledgment(ctx context.Context, transactionId string) (*Acknowledgment, error) {
+	transaction, err := n.signedTransaction(ctx, transactionId)
+	if err != nil {
+		return nil, err
+	}
+
+	return transaction.Acknowledgment, nil
+}
@@ -0,0 +1,192 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	backlog "node/backlog"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+/*
+The activity of a client is a single feed, the newest first, of what happened to it: its logins
+(and the refused ones), the transactions it sent and received, the rotations of its keys and the
+changes it made to its account. The transactions come from the `transactions` index (under the
+current client id and the former ones) and the rest from the journal (please, go to `events.go`),
+since the sessions index only keeps the current session of every client.
+
+The feed is paged with a cursor: the timestamp and the id of the last item of a page. Both sources
+are read in the same order after the cursor and merged, so the pages don't skip or repeat items even
+when many of them share a timestamp.
+*/
+type Activity struct {
+	Kind         string            // The kind of the activity, e.g. "login" or "sent" (please, go to `activityKinds`)
+	Timestamp    int64             // The timestamp when it happened
+	Reference    string            // The id of the transaction or of the event
+	Counterparty string            // The client id of the other party of a transaction
+	Value        float64           // The value of a transaction
+	Details      map[string]string // The details of an event
+}
+
+// The kinds of the activity that come from the journal, by the kind of their event
+var activityKinds = map[string]string{
+	"client.created":           "created",
+	"client.connected":         "login",
+	"client.connection_failed": "login_failed",
+	"client.rotated":           "key_rotation",
+	"client.updated":           "profile_update",
+	"terms.accepted":           "terms_accepted",
+}
+
+const (
+	defaultActivityPage int = 20  // The number of items in a page of the activity when it isn't set
+	maxActivityPage     int = 100 // The maximum number of items in a page of the activity
+)
+
+// Gives the cursor that continues the activity after an item
+func (a Activity) Cursor() string {
+	return strconv.FormatInt(a.Timestamp, 10) + ":" + a.Reference
+}
+
+// Gives the search_after of a cursor (nil when the cursor is empty)
+func parseActivityCursor(cursor string) ([]interface{}, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+
+	timestamp, reference, ok := strings.Cut(cursor, ":")
+	parsed, err := strconv.ParseInt(timestamp, 10, 64)
+	if !ok || err != nil {
+		return nil, fmt.Errorf("%w: invalid cursor %q", ErrInvalidFilter, cursor)
+	}
+
+	return []interface{}{parsed, reference}, nil
+}
+
+// Reads a page of the items of an index after the cursor, the newest first
+func (n Node) activityPage(ctx context.Context, index, timestamp, id string, query backlog.Query, after []interface{}, size int) ([]map[string]interface{}, error) {
+	body := map[string]interface{}{
+		"size": size,
+		"sort": []interface{}{
+			map[string]interface{}{timestamp: "desc"},
+			map[string]interface{}{id: "desc"},
+		},
+		"query": query,
+	}
+	if after != nil {
+		body["search_after"] = after
+	}
+
+	documents, err := n.SearchDocuments(ctx, index, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the %s: %v", index, err)
+	}
+
+	return documents, nil
+}
+
+// Gives a page of the activity of a local client after the cursor (from the newest when empty),
+// with the cursor of the next page (empty when it's the last one)
+func (n Node) ClientActivity(ctx context.Context, uid, cursor string, size int) ([]Activity, string, error) {
+	owner, err := n.Clients().Get(ctx, uid)
+	if err != nil {
+		return nil, "", err
+	}
+
+	after, err := parseActivityCursor(cursor)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if size <= 0 {
+		size = defaultActivityPage
+	} else if size > maxActivityPage {
+		size = maxActivityPage
+	}
+
+	owned, err := n.ownedIds(ctx, owner.ClientId)
+	if err != nil {
+		return nil, "", err
+	}
+
+	parties := backlog.Bool()
+	for id := range owned {
+		parties.Should(partyQuery(id, "Sender", "Recipient"))
+	}
+
+	transactions, err := n.activityPage(ctx, "transactions", "Timestamp", "TransactionId.keyword", parties.Query(), after, size)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var kinds []string
+	for kind := range activityKinds {
+		kinds = append(kinds, kind)
+	}
+
+	events, err := n.activityPage(ctx, "events", "timestamp", "id.keyword", backlog.Bool().
+		Must(backlog.Term("data.uid.keyword", uid), backlog.Query{"terms": map[string]interface{}{"kind.keyword": kinds}}).
+		Query(), after, size)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var activity []Activity
+	for _, document := range transactions {
+		id, _ := document["_id"].(string)
+
+		record, err := decodeTransaction(id, document)
+		if err != nil {
+			return nil, "", err
+		}
+
+		// The match is loose on the analyzed fields, so the parties are checked again
+		item := Activity{Timestamp: record.Timestamp, Reference: record.TransactionId, Value: record.Value}
+		switch {
+		case owned[record.Sender]:
+			item.Kind, item.Counterparty = "sent", record.Recipient
+		case owned[record.Recipient]:
+			item.Kind, item.Counterparty = "received", record.Sender
+		default:
+			continue
+		}
+
+		activity = append(activity, item)
+	}
+
+	for _, document := range events {
+		kind, _ := document["kind"].(string)
+		item := Activity{Kind: activityKinds[kind], Timestamp: documentTimestamp(document, "timestamp"), Details: map[string]string{}}
+		item.Reference, _ = document["id"].(string)
+
+		data, _ := document["data"].(map[string]interface{})
+		for key, value := range data {
+			if key != "uid" {
+				item.Details[key] = fmt.Sprint(value)
+			}
+		}
+
+		activity = append(activity, item)
+	}
+
+	// The same order as the sources: the newest first, and the greatest reference first in a tie
+	sort.Slice(activity, func(i, j int) bool {
+		if activity[i].Timestamp != activity[j].Timestamp {
+			return activity[i].Timestamp > activity[j].Timestamp
+		}
+
+		return activity[i].Reference > activity[j].Reference
+	})
+
+	next := ""
+	if len(activity) > size {
+		activity = activity[:size]
+	}
+
+	if len(transactions) == size || len(events) == size || len(activity) == size {
+		next = activity[len(activity)-1].Cursor()
+	}
+
+	return activity, next, nil
+}
@@ -0,0 +1,229 @@
+package node
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	client "node/client"
+	timeutil "node/timeutil"
+)
+
+/*
+The public address of a node may change while it runs (e.g. DHCP or cloud reassignments). The node
+resolves its address periodically and, when it changes, updates its documents, records the change
+in the `addresses` index and announces it to the peers.
+
+The announcement is signed by the node key, so the peers only accept address changes made by the
+node itself (they verify the signature with the public key they know from the node).
+*/
+type AddressChange struct {
+	NodeId    string `json:"node_id"`    // The id of the node whose address changed
+	Previous  string `json:"previous"`   // The former host address
+	Host      string `json:"host"`       // The new host address
+	ChangedAt int64  `json:"changed_at"` // The timestamp when the change was detected
+	Signature string `json:"signature"`  // The signature made by the node key
+}
+
+// Converts the address change (except the signature) to a signable byte array
+func (a AddressChange) ToBytes() ([]byte, error) {
+	change := map[string]interface{}{
+		"node_id":    a.NodeId,
+		"previous":   a.Previous,
+		"host":       a.Host,
+		"changed_at": a.ChangedAt,
+	}
+
+	changeBytes, err := json.Marshal(change)
+	return changeBytes, err
+}
+
+// Verifies the signature of the change with the public key (identity) of the node
+func (a AddressChange) Verify(publicKey string) error {
+	if err := client.VerifyWithIdentity(publicKey, a, a.Signature); err != nil {
+		return fmt.Errorf("the address change isn't signed by the node %s", a.NodeId)
+	}
+
+	return nil
+}
+
+// Records an address change in the history
+func (n Node) recordAddressChange(ctx context.Context, change AddressChange) error {
+	document, err := toDocument(change)
+	if err != nil {
+		return err
+	}
+
+	id := fmt.Sprintf("%s-%d", change.NodeId, change.ChangedAt)
+	if err := n.IndexDocument(ctx, "addresses", id, document); err != nil {
+		return fmt.Errorf("failed to record the address change: %v", err)
+	}
+
+	return nil
+}
+
+// Gives the address history of a node, from the oldest change
+func (n Node) AddressHistory(ctx context.Context, nodeId string) ([]AddressChange, error) {
+	documents, err := n.SearchDocuments(ctx, "addresses", map[string]interface{}{
+		"size":  1000,
+		"sort":  []interface{}{map[string]interface{}{"changed_at": "asc"}},
+		"query": map[string]interface{}{"term": map[string]interface{}{"node_id": nodeId}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the address history: %v", err)
+	}
+
+	var history []AddressChange
+	for _, document := range documents {
+		change := AddressChange{}
+		change.NodeId, _ = document["node_id"].(string)
+		change.Previous, _ = document["previous"].(string)
+		change.Host, _ = document["host"].(string)
+		change.Signature, _ = document["signature"].(string)
+
+		if changedAt, ok := document["changed_at"].(float64); ok {
+			change.ChangedAt = int64(changedAt)
+		}
+
+		history = append(history, change)
+	}
+
+	return history, nil
+}
+
+// Moves the node to a new host address: updates its documents, records the change and announces
+// it (signed) to the peers
+func (n *Node) ChangeAddress(ctx context.Context, host string) error {
+	change := AddressChange{
+		NodeId:    n.Id,
+		Previous:  n.Host,
+		Host:      host,
+		ChangedAt: timeutil.Now(),
+	}
+
+	signature, err := n.Key.CreateSignature(change)
+	if err != nil {
+		return err
+	}
+	change.Signature = signature
+
+	n.Host = host
+	if err := n.SyncWithBacklog(ctx, "peers"); err != nil {
+		return err
+	}
+
+	if err := n.SyncWithBacklog(ctx, "node"); err != nil {
+		return err
+	}
+
+	if err := n.recordAddressChange(ctx, change); err != nil {
+		return err
+	}
+
+	n.Emit(ctx, "node.address_changed", map[string]interface{}{
+		"previous": change.Previous,
+		"host":     change.Host,
+	})
+
+	if peerTransport != nil {
+		hosts, err := n.AlivePeers(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, peer := range hosts {
+			if err := peerTransport.AnnounceAddress(ctx, peer, change); err != nil {
+				Warnf(ctx, "failed to announce the address change to %s: %v", peer, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Gives the timestamp of the last address change recorded for a node (zero when there is none)
+func (n Node) lastAddressChange(ctx context.Context, nodeId string) (int64, error) {
+	documents, err := n.SearchDocuments(ctx, "addresses", map[string]interface{}{
+		"size":  1,
+		"sort":  []interface{}{map[string]interface{}{"changed_at": "desc"}},
+		"query": map[string]interface{}{"term": map[string]interface{}{"node_id": nodeId}},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to read the address history: %v", err)
+	}
+
+	if len(documents) == 0 {
+		return 0, nil
+	}
+
+	changedAt, _ := documents[0]["changed_at"].(float64)
+	return int64(changedAt), nil
+}
+
+// Accepts the address change announced by a peer, after verifying that the peer signed it. A signed
+// change can be announced again by anyone, so only the changes that move the peer from its current
+// host, and are newer than the last one recorded, are accepted
+func (n Node) AcceptAddressChange(ctx context.Context, change AddressChange) error {
+	document, err := n.GetDocument(ctx, "peers", change.NodeId)
+	if err != nil {
+		return fmt.Errorf("the node %s isn't a known peer", change.NodeId)
+	}
+
+	publicKey, _ := document["public_key"].(string)
+	if publicKey == "" {
+		return fmt.Errorf("the peer %s has no known public key", change.NodeId)
+	}
+
+	if err := change.Verify(publicKey); err != nil {
+		return err
+	}
+
+	if host, _ := document["host"].(string); change.Previous != host {
+		return fmt.Errorf("the address change of %s doesn't move it from its current host", change.NodeId)
+	}
+
+	lastChange, err := n.lastAddressChange(ctx, change.NodeId)
+	if err != nil {
+		return err
+	}
+
+	if change.ChangedAt <= lastChange {
+		return fmt.Errorf("the address change of %s isn't newer than its last one", change.NodeId)
+	}
+
+	if err := n.UpdateDocument(ctx, "peers", change.NodeId, map[string]interface{}{"host": change.Host}); err != nil {
+		return fmt.Errorf("failed to update the peer address: %v", err)
+	}
+
+	return n.recordAddressChange(ctx, change)
+}
+
+// Resolves the public address of the node and moves the node when it changed
+func (n *Node) CheckAddress(ctx context.Context) error {
+	host, err := getLocalAddress()
+	if err != nil {
+		return fmt.Errorf("failed to resolve the address: %v", err)
+	}
+
+	if host == n.Host {
+		return nil
+	}
+
+	Logf(ctx, "address changed from %s to %s", n.Host, host)
+	return n.ChangeAddress(ctx, host)
+}
+
+// Schedules the job that resolves the node address
+func (n *Node) StartAddressMonitor(schedule string) error {
+	return ScheduleJob(Job{
+		Name:      "address_monitor",
+		Schedule:  schedule,
+		Singleton: true,
+		Run: func(ctx context.Context, lease *Lease) error {
+			if err := n.CheckAddress(ctx); err != nil {
+				return fmt.Errorf("failed to check the address: %v", err)
+			}
+
+			return nil
+		},
+	})
+}
@@ -0,0 +1,162 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	backlog "node/backlog"
+	"sort"
+	"time"
+)
+
+// How long an admin operation holds the lease of its duty
+const adminLeaseTTL = 10 * time.Minute
+
+/*
+The admin operations are the destructive maintenance that the operators run by hand (please, go to
+`admin.go` in the meander command). All of them go through RunAdminOperation, that can run any of
+them as a dry run: the writes are recorded instead of made (please, go to `dryrun.go` in the backlog
+package) and the report lists exactly which documents and files the operation would touch.
+
+The operations with a duty take its lease first, so they never run together with the job of the
+duty in another process.
+*/
+type AdminOperation struct {
+	Name  string // The name given in the command line
+	Usage string // The arguments of the operation
+	Duty  string // The duty whose lease the operation holds (empty when it needs none)
+	Args  int    // The number of arguments
+	Run   func(ctx context.Context, n Node, lease *Lease, args []string) (string, error)
+}
+
+var adminOperations = map[string]AdminOperation{
+	"resync": {
+		Name:  "resync",
+		Usage: "Reconciles every mirrored document against the mirror, from the start",
+		Duty:  "mirror_sync",
+		Run: func(ctx context.Context, n Node, lease *Lease, args []string) (string, error) {
+			if n.Mirror == "" || n.Mirror == "0.0.0.0" || peerTransport == nil {
+				return "", fmt.Errorf("the node has no mirror to resync with")
+			}
+
+			if _, err := n.Handshake(ctx, n.Mirror); err != nil {
+				return "", err
+			}
+
+			count, err := n.reconcileMirror(ctx, *lease, map[string]int64{})
+			if err != nil {
+				return "", err
+			}
+
+			return fmt.Sprintf("reconciled %d document(s) with the mirror %s", count, n.Mirror), nil
+		},
+	},
+	"prune": {
+		Name:  "prune",
+		Usage: "Deletes the expired leases, the old handovers, the expired credentials and the old history of the jobs",
+		Duty:  "janitor",
+		Run: func(ctx context.Context, n Node, lease *Lease, args []string) (string, error) {
+			count, err := n.sweep(ctx, *lease)
+			if err != nil {
+				return "", err
+			}
+
+			return fmt.Sprintf("pruned %d document(s)", count), nil
+		},
+	},
+	"liquidate": {
+		Name:  "liquidate",
+		Usage: "<uid> Closes a local client for good, keeping its data",
+		Args:  1,
+		Run: func(ctx context.Context, n Node, lease *Lease, args []string) (string, error) {
+			owner, err := n.Clients().Get(ctx, args[0])
+			if err != nil {
+				return "", err
+			}
+
+			if owner.Liquidated {
+				return "", ErrClientLiquidated
+			}
+
+			if err := n.liquidate(ctx, owner); err != nil {
+				return "", err
+			}
+
+			return fmt.Sprintf("liquidated the client %s", owner.ClientId), nil
+		},
+	},
+	"erase-client": {
+		Name:  "erase-client",
+		Usage: "<uid> Erases the personal data of a local client",
+		Args:  1,
+		Run: func(ctx context.Context, n Node, lease *Lease, args []string) (string, error) {
+			erasure, err := n.EraseClient(ctx, args[0])
+			if err != nil {
+				return "", err
+			}
+
+			return fmt.Sprintf("erased the client %s", erasure.ClientId), nil
+		},
+	},
+}
+
+// Gives the admin operations sorted by name
+func AdminOperations() []AdminOperation {
+	operations := make([]AdminOperation, 0, len(adminOperations))
+	for _, operation := range adminOperations {
+		operations = append(operations, operation)
+	}
+
+	sort.Slice(operations, func(i, j int) bool { return operations[i].Name < operations[j].Name })
+	return operations
+}
+
+// The outcome of an admin operation, with the writes it made (or would make, in a dry run)
+type AdminReport struct {
+	Operation  string              `json:"operation"`
+	DryRun     bool                `json:"dry_run"`
+	Summary    string              `json:"summary"`
+	Operations []backlog.Operation `json:"operations,omitempty"` // The writes of documents (only recorded in a dry run)
+	Files      []string            `json:"files,omitempty"`      // The files written or removed (only recorded in a dry run)
+}
+
+// Runs an admin operation, or only plans it when dryRun is set
+func (n Node) RunAdminOperation(ctx context.Context, name string, args []string, dryRun bool) (*AdminReport, error) {
+	operation, ok := adminOperations[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown operation %s", ErrInvalidOperation, name)
+	}
+
+	if len(args) != operation.Args {
+		return nil, fmt.Errorf("%w: the %s operation takes %d argument(s), not %d", ErrInvalidOperation, name, operation.Args, len(args))
+	}
+
+	var plan *backlog.Plan
+	if dryRun {
+		ctx, plan = backlog.WithDryRun(ctx)
+	}
+
+	var lease *Lease
+	if operation.Duty != "" {
+		held, err := n.AcquireLease(ctx, operation.Duty, adminLeaseTTL)
+		if err != nil {
+			return nil, err
+		}
+
+		if held == nil {
+			return nil, fmt.Errorf("the %s duty is held by another process, try again once it's done", operation.Duty)
+		}
+		lease = held
+	}
+
+	summary, err := operation.Run(ctx, n, lease, args)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run the %s operation: %v", name, err)
+	}
+
+	report := &AdminReport{Operation: name, DryRun: dryRun, Summary: summary}
+	if plan != nil {
+		report.Operations, report.Files = plan.Operations, plan.Files
+	}
+
+	return report, nil
+}
@@ -0,0 +1,55 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	backlog "node/backlog"
+	hashing "node/hashing"
+	timeutil "node/timeutil"
+)
+
+// Given when the alias was already reserved by another client
+var ErrAliasTaken = errors.New("the alias was already taken")
+
+// Normalizes an alias, so aliases that only differ by case or surrounding spaces collide
+func NormalizeAlias(alias string) string {
+	return hashing.NormalizeAlias(alias)
+}
+
+// Gives the deterministic id of the alias document
+func aliasId(alias string) string {
+	return hashing.HashAlias(alias)
+}
+
+// Reserves an alias in the `aliases` index. The document is created only if there isn't another
+// one with the same id, so concurrent reservations of the same alias can't both succeed
+func (n Node) ReserveAlias(ctx context.Context, alias string) error {
+	// The aliases of the erased clients keep the id they had before the ids were domain-separated,
+	// since the alias they hash can't be read anymore to migrate them
+	if _, err := n.GetDocument(ctx, "aliases", hashing.LegacyHash(NormalizeAlias(alias))); err == nil {
+		return ErrAliasTaken
+	}
+
+	err := n.CreateDocument(ctx, "aliases", aliasId(alias), map[string]interface{}{
+		"alias":       NormalizeAlias(alias),
+		"reserved_at": timeutil.Now(),
+	})
+
+	if errors.Is(err, backlog.ErrConflict) {
+		return ErrAliasTaken
+	} else if err != nil {
+		return fmt.Errorf("failed to reserve the alias: %v", err)
+	}
+
+	return nil
+}
+
+// Releases a reserved alias (e.g. when the client creation fails)
+func (n Node) ReleaseAlias(ctx context.Context, alias string) error {
+	if err := n.DeleteDocument(ctx, "aliases", aliasId(alias)); err != nil {
+		return fmt.Errorf("failed to release the alias: %v", err)
+	}
+
+	return nil
+}
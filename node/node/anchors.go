@@ -0,0 +1,254 @@
+package node
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	backlog "node/backlog"
+	config "node/config"
+	timeutil "node/timeutil"
+	"strconv"
+	"time"
+)
+
+/*
+Anchoring publishes the latest checkpoint of the chain (the height and the hash of its last block) to
+an external system, such as a timestamping service or another chain, and keeps the proof it gives
+back in the `anchors` index. Rewriting an anchored block then needs the cooperation of the external
+system too, so the tamper-evidence of the chain doesn't rest on the node alone.
+
+The anchors are registered with `RegisterAnchor`. When ANCHOR_URL is set, an HTTP anchor posts the
+checkpoints there and keeps the response as the proof. Without any anchor, the module stays off.
+*/
+type Anchor interface {
+	Name() string                                                       // The name of the external system, used in the records
+	Publish(ctx context.Context, checkpoint Checkpoint) ([]byte, error) // Gives the proof of the publication
+}
+
+// The state of the chain that's published to the anchors
+type Checkpoint struct {
+	NodeId    string `json:"node_id"`    // The node that owns the chain
+	Height    int64  `json:"height"`     // The height of the last block
+	BlockHash string `json:"block_hash"` // The hash of the last block
+	Timestamp int64  `json:"timestamp"`  // The timestamp when the checkpoint was taken
+}
+
+// A checkpoint published to an anchor, as stored in the backlog
+type AnchorRecord struct {
+	Anchor     string `json:"anchor"`      // The name of the anchor
+	Height     int64  `json:"height"`      // The height of the anchored block
+	BlockHash  string `json:"block_hash"`  // The hash of the anchored block
+	Proof      string `json:"proof"`       // The proof given by the anchor, encoded in base64
+	AnchoredAt int64  `json:"anchored_at"` // The timestamp when the checkpoint was published
+}
+
+var anchors []Anchor
+
+// Registers an external system where the checkpoints are published
+func RegisterAnchor(anchor Anchor) {
+	anchors = append(anchors, anchor)
+}
+
+// An anchor that posts the checkpoints to an HTTP service (e.g. a timestamping service)
+type HTTPAnchor struct {
+	URL string
+}
+
+func (h HTTPAnchor) Name() string {
+	return "http"
+}
+
+func (h HTTPAnchor) Publish(ctx context.Context, checkpoint Checkpoint) ([]byte, error) {
+	payload, err := json.Marshal(checkpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal the checkpoint: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.URL, bytes.NewBuffer(payload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := http.Client{Timeout: 10 * time.Second}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to post the checkpoint: %v", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 300 {
+		return nil, fmt.Errorf("failed to post the checkpoint: %s", res.Status)
+	}
+
+	proof, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the proof: %v", err)
+	}
+
+	return proof, nil
+}
+
+// Gives the registered anchors, with the HTTP anchor when it's configured
+func configuredAnchors() []Anchor {
+	if url := config.AnchorURL(); url != "" {
+		return append(anchors[:len(anchors):len(anchors)], HTTPAnchor{URL: url})
+	}
+
+	return anchors
+}
+
+// Schedules the anchoring, a singleton job that publishes the latest checkpoint to the anchors. It
+// isn't scheduled when there are no anchors
+func (n Node) StartAnchoring(schedule string) error {
+	if len(configuredAnchors()) == 0 {
+		return nil
+	}
+
+	return ScheduleJob(Job{
+		Name:      "anchoring",
+		Schedule:  schedule,
+		Singleton: true,
+		Retries:   3,
+		Run: func(ctx context.Context, lease *Lease) error {
+			return n.AnchorCheckpoint(ctx, lease)
+		},
+	})
+}
+
+// Publishes the latest checkpoint to the anchors that don't have it yet
+func (n Node) AnchorCheckpoint(ctx context.Context, lease *Lease) error {
+	block, err := NewBlockchain(n.Backlog).LastBlock(ctx)
+	if err != nil {
+		return err
+	}
+
+	if block == nil {
+		return nil
+	}
+
+	checkpoint := Checkpoint{
+		NodeId:    n.Id,
+		Height:    block.Height,
+		BlockHash: block.Hash,
+		Timestamp: timeutil.Now(),
+	}
+
+	var failures []error
+	for _, anchor := range configuredAnchors() {
+		latest, err := n.latestAnchor(ctx, anchor.Name())
+		if err != nil {
+			return err
+		}
+
+		if latest != nil && latest.Height >= checkpoint.Height {
+			continue
+		}
+
+		proof, err := anchor.Publish(ctx, checkpoint)
+		if err != nil {
+			failures = append(failures, fmt.Errorf("failed to anchor the block %d to %s: %v", checkpoint.Height, anchor.Name(), err))
+			continue
+		}
+
+		if lease != nil {
+			if err := n.CheckLease(ctx, *lease); err != nil {
+				return err
+			}
+		}
+
+		record := AnchorRecord{
+			Anchor:     anchor.Name(),
+			Height:     checkpoint.Height,
+			BlockHash:  checkpoint.BlockHash,
+			Proof:      base64.StdEncoding.EncodeToString(proof),
+			AnchoredAt: timeutil.Now(),
+		}
+
+		document, err := toDocument(record)
+		if err != nil {
+			return err
+		}
+
+		if err := n.IndexDocument(ctx, "anchors", record.Anchor+"@"+strconv.FormatInt(record.Height, 10), document); err != nil {
+			return fmt.Errorf("failed to store the proof of the block %d: %v", record.Height, err)
+		}
+
+		n.Emit(ctx, "chain.anchored", map[string]interface{}{
+			"anchor": record.Anchor,
+			"height": record.Height,
+			"hash":   record.BlockHash,
+		})
+	}
+
+	return errors.Join(failures...)
+}
+
+// Gives the latest checkpoint published to an anchor (nil when none was)
+func (n Node) latestAnchor(ctx context.Context, anchor string) (*AnchorRecord, error) {
+	records, err := n.AnchorHistory(ctx, anchor, 1)
+	if err != nil || len(records) == 0 {
+		return nil, err
+	}
+
+	return &records[0], nil
+}
+
+// Gives the latest checkpoints published to an anchor (or to all of them when it's empty), from the
+// newest
+func (n Node) AnchorHistory(ctx context.Context, anchor string, size int) ([]AnchorRecord, error) {
+	query := backlog.Exists("anchor")
+	if anchor != "" {
+		query = backlog.Term("anchor", anchor)
+	}
+
+	documents, _, err := n.FindDocuments(ctx, "anchors", query, backlog.ListOptions{
+		Size: size,
+		Sort: []string{"height:desc"},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the anchored checkpoints: %v", err)
+	}
+
+	var records []AnchorRecord
+	for _, document := range documents {
+		id, _ := document["_id"].(string)
+
+		record := AnchorRecord{}
+		if err := fromDocument("anchors", id, document, &record, "anchor", "height", "block_hash"); err != nil {
+			return nil, err
+		}
+
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+// Compares the latest anchored checkpoints with the chain. Gives the first one whose block was
+// rewritten or dropped since it was anchored (nil when all of them match)
+func (n Node) verifyAnchors(ctx context.Context) (*AnchorRecord, error) {
+	records, err := n.AnchorHistory(ctx, "", validationPageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	bc := NewBlockchain(n.Backlog)
+	for i := range records {
+		block, err := bc.BlockAt(ctx, records[i].Height)
+		if err != nil {
+			return nil, err
+		}
+
+		if block == nil || block.Hash != records[i].BlockHash {
+			return &records[i], nil
+		}
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,306 @@
+package node
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	backlog "node/backlog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	summaryFanout    int           = 16                 // The subranges of a range, one by hex digit of the id hash
+	summaryMaxDepth  int           = 6                  // The longest prefix of a range, whose documents are always fetched whole
+	summaryCacheTTL  time.Duration = 30 * time.Second   // How long the summary of an index is reused across the calls of a comparison
+	summaryHexDigits string        = "0123456789abcdef" // The digits of the prefixes of the ranges
+)
+
+/*
+The watermarks of the mirror sync (please, go to `mirror.go`) only fetch the documents changed since
+the last round, so a document missed in between (e.g. written with an older timestamp, or lost in
+a restore of the backlog) is never fetched again. The anti-entropy finds them without shipping the
+whole index: every document is a leaf keyed by the hash of its id and holding its version (its
+timestamp and, for the transactions, how far along they are), and a range of the index is every
+leaf whose key starts with some hex prefix. The summary of a range is the hash of its leaves, so two
+nodes with the same range have the same summary.
+
+The node asks the peer for the summaries of the subranges of a range, compares them with its own
+and only descends into the ones that differ, fetching the documents of a range once it's small
+(at most a page of the mirror) or as deep as it goes. A divergent document is found in O(log n)
+exchanges, and the documents fetched are reconciled as the ones of the mirror.
+*/
+type RangeSummary struct {
+	Prefix string // The hex prefix of the id hashes in the range
+	Hash   string // The hash of the leaves of the range (empty when it has none)
+	Count  int    // The number of documents in the range
+}
+
+// A document of an index, as seen by the anti-entropy
+type summaryLeaf struct {
+	key     string // The hex hash of the document id
+	id      string
+	version string
+}
+
+// The leaves of the mirrored indices, sorted by key, cached by index
+var summaries = struct {
+	sync.Mutex
+	leaves  map[string][]summaryLeaf
+	builtAt map[string]time.Time
+}{leaves: map[string][]summaryLeaf{}, builtAt: map[string]time.Time{}}
+
+// Gives the version of a document of a mirrored index
+func documentVersion(mirrored mirroredIndex, document map[string]interface{}) string {
+	version := fmt.Sprintf("%d", documentTimestamp(document, mirrored.timestamp))
+	if mirrored.progress != nil {
+		version += fmt.Sprintf("/%d", mirrored.progress(document))
+	}
+
+	return version
+}
+
+// Gives the leaves of a mirrored index, sorted by key. The leaves built lately are reused, unless
+// they must be fresh
+func (n Node) summaryLeaves(ctx context.Context, index string, fresh bool) ([]summaryLeaf, error) {
+	mirrored, ok := mirroredIndices[index]
+	if !ok {
+		return nil, fmt.Errorf("the index %s isn't mirrored", index)
+	}
+
+	summaries.Lock()
+	leaves, builtAt := summaries.leaves[index], summaries.builtAt[index]
+	summaries.Unlock()
+
+	if !fresh && time.Since(builtAt) < summaryCacheTTL {
+		return leaves, nil
+	}
+
+	leaves = nil
+	err := n.ScrollDocuments(ctx, index, backlog.ListOptions{}, func(document map[string]interface{}) error {
+		id, _ := document["_id"].(string)
+		key := sha256.Sum256([]byte(id))
+		leaves = append(leaves, summaryLeaf{key: hex.EncodeToString(key[:]), id: id, version: documentVersion(mirrored, document)})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the index %s: %v", index, err)
+	}
+
+	sort.Slice(leaves, func(i, j int) bool { return leaves[i].key < leaves[j].key })
+
+	summaries.Lock()
+	summaries.leaves[index], summaries.builtAt[index] = leaves, time.Now()
+	summaries.Unlock()
+
+	return leaves, nil
+}
+
+// Gives the leaves whose key starts with the prefix
+func leavesInRange(leaves []summaryLeaf, prefix string) []summaryLeaf {
+	start := sort.Search(len(leaves), func(i int) bool { return leaves[i].key >= prefix })
+	end := start
+	for end < len(leaves) && strings.HasPrefix(leaves[end].key, prefix) {
+		end++
+	}
+
+	return leaves[start:end]
+}
+
+// Summarizes the leaves of a range
+func summarizeRange(leaves []summaryLeaf, prefix string) RangeSummary {
+	inRange := leavesInRange(leaves, prefix)
+	summary := RangeSummary{Prefix: prefix, Count: len(inRange)}
+	if len(inRange) == 0 {
+		return summary
+	}
+
+	hash := sha256.New()
+	for _, leaf := range inRange {
+		fmt.Fprintf(hash, "%s %s\n", leaf.id, leaf.version)
+	}
+	summary.Hash = hex.EncodeToString(hash.Sum(nil))
+
+	return summary
+}
+
+// Checks that a prefix names a range, no longer than the maximum
+func checkPrefix(prefix string, maxLength int) error {
+	if len(prefix) > maxLength || strings.Trim(prefix, summaryHexDigits) != "" {
+		return fmt.Errorf("invalid range prefix %q", prefix)
+	}
+
+	return nil
+}
+
+// Gives the summaries of the subranges of a range of a mirrored index
+func (n Node) SummarizeIndex(ctx context.Context, index, prefix string) ([]RangeSummary, error) {
+	// The deepest ranges aren't split anymore
+	if err := checkPrefix(prefix, summaryMaxDepth-1); err != nil {
+		return nil, err
+	}
+
+	leaves, err := n.summaryLeaves(ctx, index, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return summarizeSubranges(leaves, prefix), nil
+}
+
+// Summarizes the subranges of a range
+func summarizeSubranges(leaves []summaryLeaf, prefix string) []RangeSummary {
+	ranges := make([]RangeSummary, 0, summaryFanout)
+	for _, digit := range summaryHexDigits {
+		ranges = append(ranges, summarizeRange(leaves, prefix+string(digit)))
+	}
+
+	return ranges
+}
+
+// Gives the documents in a range of a mirrored index, as they're shared with the peers
+func (n Node) DocumentsInRange(ctx context.Context, index, prefix string) ([]map[string]interface{}, error) {
+	if err := checkPrefix(prefix, summaryMaxDepth); err != nil {
+		return nil, err
+	}
+
+	leaves, err := n.summaryLeaves(ctx, index, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []string
+	for _, leaf := range leavesInRange(leaves, prefix) {
+		ids = append(ids, leaf.id)
+	}
+
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	documents, _, err := n.FindDocuments(ctx, index, backlog.Ids(ids...), backlog.ListOptions{All: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the index %s: %v", index, err)
+	}
+
+	if scrub := mirroredIndices[index].scrub; scrub != nil {
+		for _, document := range documents {
+			scrub(document)
+		}
+	}
+
+	return documents, nil
+}
+
+// Compares the mirrored indices with the ones of a peer, range by range, and reconciles the documents
+// of the ranges that differ. Gives the number of documents stored
+func (n Node) antiEntropy(ctx context.Context, lease Lease, host string) (int, error) {
+	reconciled := 0
+	for index := range mirroredIndices {
+		leaves, err := n.summaryLeaves(ctx, index, true)
+		if err != nil {
+			return reconciled, err
+		}
+
+		exchanges, pending := 0, []string{""}
+		for len(pending) > 0 {
+			prefix := pending[0]
+			pending = pending[1:]
+
+			remote, err := peerTransport.SummarizeIndex(ctx, host, index, prefix)
+			if err != nil {
+				return reconciled, fmt.Errorf("failed to summarize the %s of %s: %v", index, host, err)
+			}
+			exchanges++
+
+			local := map[string]RangeSummary{}
+			for _, summary := range summarizeSubranges(leaves, prefix) {
+				local[summary.Prefix] = summary
+			}
+
+			for _, summary := range remote {
+				if summary.Count == 0 || summary.Hash == local[summary.Prefix].Hash {
+					continue
+				}
+
+				if summary.Count > mirrorPageSize && len(summary.Prefix) < summaryMaxDepth {
+					pending = append(pending, summary.Prefix)
+					continue
+				}
+
+				count, err := n.reconcileRange(ctx, lease, host, index, summary.Prefix)
+				if err != nil {
+					return reconciled, err
+				}
+
+				reconciled += count
+			}
+		}
+
+		Debugf(ctx, SubsystemSync, "compared the %s with %s in %d exchange(s)", index, host, exchanges)
+	}
+
+	return reconciled, nil
+}
+
+// Fetches the documents of a range from a peer and reconciles them. Gives the number of documents stored
+func (n Node) reconcileRange(ctx context.Context, lease Lease, host, index, prefix string) (int, error) {
+	documents, err := peerTransport.FetchDocumentRange(ctx, host, index, prefix)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch the %s in the range %s of %s: %v", index, prefix, host, err)
+	}
+
+	// The fetch may take long enough for another process to take the comparison over
+	if err := n.CheckLease(ctx, lease); err != nil {
+		return 0, err
+	}
+
+	reconciled := 0
+	for _, document := range documents {
+		stored, err := n.reconcile(ctx, index, document)
+		if err != nil {
+			return reconciled, err
+		}
+
+		if stored {
+			reconciled++
+		}
+	}
+
+	return reconciled, nil
+}
+
+// Schedules the job that compares the node against its mirror with the anti-entropy. Nodes without
+// a mirror don't schedule it
+func (n Node) StartAntiEntropy(schedule string) error {
+	if n.Mirror == "" || n.Mirror == "0.0.0.0" {
+		return nil
+	}
+
+	return ScheduleJob(Job{
+		Name:      "anti_entropy",
+		Schedule:  schedule,
+		Singleton: true,
+		Retries:   1,
+		Run: func(ctx context.Context, lease *Lease) error {
+			// A mirror of another chain or protocol is never reconciled (please, go to `handshake.go`)
+			if _, err := n.Handshake(ctx, n.Mirror); err != nil {
+				return err
+			}
+
+			count, err := n.antiEntropy(ctx, *lease, n.Mirror)
+			if err != nil {
+				return fmt.Errorf("failed to compare with the mirror %s: %v", n.Mirror, err)
+			}
+
+			if count > 0 {
+				Logf(ctx, "the anti-entropy reconciled %d document(s) with the mirror %s", count, n.Mirror)
+			}
+
+			return nil
+		},
+	})
+}
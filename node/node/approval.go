@@ -0,0 +1,252 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	backlog "node/backlog"
+	config "node/config"
+	timeutil "node/timeutil"
+
+	"github.com/google/uuid"
+)
+
+// The states of a request for an admin operation
+const (
+	RequestPending  string = "pending"  // Waits for the approval of a second admin
+	RequestApproved string = "approved" // Approved, while its operation runs
+	RequestExecuted string = "executed" // Approved and run successfully
+	RequestFailed   string = "failed"   // Approved, but the operation failed
+	RequestRejected string = "rejected" // Rejected by a second admin
+	RequestExpired  string = "expired"  // Nobody approved it in time
+)
+
+var (
+	ErrInvalidOperation = errors.New("invalid admin operation")
+	ErrUnknownRequest   = errors.New("unknown admin request")
+	ErrRequestDecided   = errors.New("the admin request isn't pending")
+	ErrSelfApproval     = errors.New("the admin request must be approved by another admin")
+)
+
+/*
+The destructive admin operations (please, go to `admin.go`) follow the two-person rule when they're
+requested through the admin API: an admin requests the operation, that waits in the `admin_requests`
+index until a second admin approves it within the ADMIN_APPROVAL_WINDOW, and only then it runs. The
+requests are stored with their whole trail (who requested, who decided, when and the outcome), and
+every step is recorded in the events journal as well.
+
+The approval is written with the version of the request, so when two admins decide at once, only one
+of them wins and the operation never runs twice.
+*/
+type AdminRequest struct {
+	Id          string   `json:"id"`
+	Operation   string   `json:"operation"`
+	Args        []string `json:"args"`
+	RequestedBy string   `json:"requested_by"`         // The name of the admin that requested the operation
+	RequestedAt int64    `json:"requested_at"`         // The timestamp when the operation was requested
+	ExpiresAt   int64    `json:"expires_at"`           // The timestamp after which the request can't be approved anymore
+	Status      string   `json:"status"`               // The state of the request (e.g. RequestPending)
+	DecidedBy   string   `json:"decided_by,omitempty"` // The name of the admin that approved or rejected the operation
+	DecidedAt   int64    `json:"decided_at,omitempty"` // The timestamp when the operation was approved or rejected
+	Summary     string   `json:"summary,omitempty"`    // The outcome of the operation, once it ran
+	Error       string   `json:"error,omitempty"`      // The failure of the operation, once it ran
+}
+
+// Requests an admin operation, that runs once another admin approves it
+func (n Node) RequestAdminOperation(ctx context.Context, admin, name string, args []string) (*AdminRequest, error) {
+	operation, ok := adminOperations[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown operation %s", ErrInvalidOperation, name)
+	}
+
+	if len(args) != operation.Args {
+		return nil, fmt.Errorf("%w: the %s operation takes %d argument(s), not %d", ErrInvalidOperation, name, operation.Args, len(args))
+	}
+
+	id, err := uuid.NewRandom()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate the request id: %v", err)
+	}
+
+	request := AdminRequest{
+		Id:          id.String(),
+		Operation:   name,
+		Args:        args,
+		RequestedBy: admin,
+		RequestedAt: timeutil.Now(),
+		ExpiresAt:   timeutil.After(config.ApprovalWindow()),
+		Status:      RequestPending,
+	}
+
+	document, err := toDocument(request)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := n.CreateDocument(ctx, "admin_requests", request.Id, document); err != nil {
+		return nil, fmt.Errorf("failed to store the admin request: %v", err)
+	}
+
+	n.auditRequest(ctx, "admin.requested", request)
+	return &request, nil
+}
+
+// Gives a stored admin request with its version
+func (n Node) adminRequest(ctx context.Context, id string) (*AdminRequest, backlog.Version, error) {
+	document, version, err := n.GetVersionedDocument(ctx, "admin_requests", id)
+	if errors.Is(err, backlog.ErrNotFound) {
+		return nil, version, fmt.Errorf("%w: %s", ErrUnknownRequest, id)
+	}
+
+	if err != nil {
+		return nil, version, fmt.Errorf("failed to get the admin request %s: %v", id, err)
+	}
+
+	request := AdminRequest{}
+	if err := fromDocument("admin_requests", id, document, &request, "operation", "requested_by", "status"); err != nil {
+		return nil, version, err
+	}
+
+	return &request, version, nil
+}
+
+// Moves a pending request to its decision, failing when another admin decided it in the meantime
+func (n Node) decideRequest(ctx context.Context, admin, id, status string) (*AdminRequest, error) {
+	request, version, err := n.adminRequest(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if request.Status != RequestPending {
+		return nil, fmt.Errorf("%w: the request %s is %s", ErrRequestDecided, id, request.Status)
+	}
+
+	if request.ExpiresAt <= timeutil.Now() {
+		request.Status = RequestExpired
+		if err := n.storeRequest(ctx, *request, version); err == nil {
+			n.auditRequest(ctx, "admin.expired", *request)
+		}
+
+		return nil, fmt.Errorf("%w: the request %s expired", ErrRequestDecided, id)
+	}
+
+	// The admin that requested the operation can only withdraw it
+	if request.RequestedBy == admin && status == RequestApproved {
+		return nil, ErrSelfApproval
+	}
+
+	request.Status, request.DecidedBy, request.DecidedAt = status, admin, timeutil.Now()
+	if err := n.storeRequest(ctx, *request, version); err != nil {
+		return nil, err
+	}
+
+	return request, nil
+}
+
+// Writes a request over its stored version
+func (n Node) storeRequest(ctx context.Context, request AdminRequest, version backlog.Version) error {
+	document, err := toDocument(request)
+	if err != nil {
+		return err
+	}
+
+	err = n.ReplaceDocumentIf(ctx, "admin_requests", request.Id, document, version)
+	if errors.Is(err, backlog.ErrConflict) {
+		return fmt.Errorf("%w: the request %s was decided by another admin", ErrRequestDecided, request.Id)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to store the admin request %s: %v", request.Id, err)
+	}
+
+	return nil
+}
+
+// Approves the request of another admin and runs its operation
+func (n Node) ApproveAdminRequest(ctx context.Context, admin, id string) (*AdminRequest, error) {
+	// The request is marked as approved before it runs, so it can't be approved twice
+	request, err := n.decideRequest(ctx, admin, id, RequestApproved)
+	if err != nil {
+		return nil, err
+	}
+	n.auditRequest(ctx, "admin.approved", *request)
+
+	report, err := n.RunAdminOperation(ctx, request.Operation, request.Args, false)
+	if err != nil {
+		request.Status, request.Error = RequestFailed, err.Error()
+	} else {
+		request.Status, request.Summary = RequestExecuted, report.Summary
+	}
+
+	// The outcome is written over the approval
+	_, version, err := n.adminRequest(ctx, id)
+	if err == nil {
+		err = n.storeRequest(ctx, *request, version)
+	}
+
+	if err != nil {
+		Logf(ctx, "failed to store the outcome of the admin request %s: %v", id, err)
+	}
+
+	n.auditRequest(ctx, "admin."+request.Status, *request)
+	return request, nil
+}
+
+// Rejects the request of another admin (or withdraws an own one), so its operation never runs
+func (n Node) RejectAdminRequest(ctx context.Context, admin, id string) (*AdminRequest, error) {
+	request, err := n.decideRequest(ctx, admin, id, RequestRejected)
+	if err != nil {
+		return nil, err
+	}
+
+	n.auditRequest(ctx, "admin.rejected", *request)
+	return request, nil
+}
+
+// Gives the admin requests in a state (all of them when the status is empty), the newest first
+func (n Node) AdminRequests(ctx context.Context, status string) ([]AdminRequest, error) {
+	query := backlog.Bool().Query()
+	if status != "" {
+		query = backlog.Term("status", status)
+	}
+
+	documents, _, err := n.FindDocuments(ctx, "admin_requests", query, backlog.ListOptions{All: true, Sort: []string{"requested_at:desc"}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the admin requests: %v", err)
+	}
+
+	requests := []AdminRequest{}
+	for _, document := range documents {
+		id, _ := document["id"].(string)
+
+		request := AdminRequest{}
+		if err := fromDocument("admin_requests", id, document, &request, "operation", "requested_by", "status"); err != nil {
+			return nil, err
+		}
+
+		requests = append(requests, request)
+	}
+
+	return requests, nil
+}
+
+// Records a step of an admin request in the events journal
+func (n Node) auditRequest(ctx context.Context, kind string, request AdminRequest) {
+	data := map[string]interface{}{
+		"request_id":   request.Id,
+		"operation":    request.Operation,
+		"args":         request.Args,
+		"requested_by": request.RequestedBy,
+		"status":       request.Status,
+	}
+
+	if request.DecidedBy != "" {
+		data["decided_by"] = request.DecidedBy
+	}
+
+	if request.Error != "" {
+		data["error"] = request.Error
+	}
+
+	n.Emit(ctx, kind, data)
+}
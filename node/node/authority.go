@@ -0,0 +1,74 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	client "node/client"
+)
+
+/*
+The blocks are produced under proof of authority: the node that mines a block records its id as the
+producer (covered by the hash) and signs the block with its key pair (please, go to `identity.go`).
+Before accepting a block from anyone else, the node verifies the signature against the public key that
+the producer registered in the `peers` index, so a block is never taken from an unknown node or
+altered on its way.
+
+The genesis is the only block without a producer, since every node of the network builds the same one
+(please, go to `genesis.go`).
+*/
+
+// Signs the block with the key of the node that produced it
+func (n Node) signBlock(block *Block) error {
+	if n.Key == nil {
+		return fmt.Errorf("the node has no key pair to sign the block %d", block.Height)
+	}
+
+	signature, err := n.Key.CreateSignature(block)
+	if err != nil {
+		return fmt.Errorf("failed to sign the block %d: %v", block.Height, err)
+	}
+
+	block.Signature = signature
+	return nil
+}
+
+// Gives the public key (identity) that a node registered in the peers
+func (n Node) producerKey(ctx context.Context, producer string) (string, error) {
+	if producer == n.Id {
+		return n.PublicKey, nil
+	}
+
+	document, err := n.GetDocument(ctx, "peers", producer)
+	if err != nil {
+		return "", fmt.Errorf("the producer %s isn't a known peer", producer)
+	}
+
+	publicKey, _ := document["public_key"].(string)
+	if publicKey == "" {
+		return "", fmt.Errorf("the peer %s has no known public key", producer)
+	}
+
+	return publicKey, nil
+}
+
+// Verifies that the block is signed by its producer
+func (n Node) verifyProducer(ctx context.Context, block *Block) error {
+	if block.Height == 0 {
+		return nil
+	}
+
+	if block.Producer == "" || block.Signature == "" {
+		return fmt.Errorf("the block %d isn't signed by its producer", block.Height)
+	}
+
+	publicKey, err := n.producerKey(ctx, block.Producer)
+	if err != nil {
+		return err
+	}
+
+	if err := client.VerifyWithIdentity(publicKey, block, block.Signature); err != nil {
+		return fmt.Errorf("the block %d isn't signed by the node %s", block.Height, block.Producer)
+	}
+
+	return nil
+}
@@ -0,0 +1,93 @@
+package node
+
+import (
+	"context"
+	backlog "node/backlog"
+	config "node/config"
+	timeutil "node/timeutil"
+	"sync"
+	"time"
+)
+
+/*
+When the backlog slows down, the node sheds its low-priority work to let the backlog recover: the
+gossip stops forwarding the blocks and the erasures (the peers fetch them when they notice the gap),
+the metrics aren't aggregated, and the write calls are capped together (please, go to `limits.go`
+in the server).
+
+The node enters the backpressure when the average latency of the backlog crosses the threshold
+(please, go to BACKPRESSURE_LATENCY in the config), and leaves it only when the latency falls under
+half of it, so the node doesn't flap around the threshold. The state is kept by process, since every
+process measures its own requests, and it's reported in the node stats.
+*/
+type Backpressure struct {
+	Active  bool          `json:"active"`  // Whether the node is shedding work
+	Latency time.Duration `json:"latency"` // The average latency of the backlog when it was last measured
+	Since   int64         `json:"since"`   // The timestamp when the backpressure started (zero when it's not active)
+}
+
+var (
+	backpressure      Backpressure
+	backpressureMutex sync.RWMutex
+)
+
+// Gives the backpressure state of the process
+func CurrentBackpressure() Backpressure {
+	backpressureMutex.RLock()
+	defer backpressureMutex.RUnlock()
+
+	return backpressure
+}
+
+// Checks if the process is shedding its low-priority work
+func UnderBackpressure() bool {
+	return CurrentBackpressure().Active
+}
+
+// Measures the latency of the backlog and enters (or leaves) the backpressure when it crosses the
+// thresholds
+func (n Node) CheckBackpressure(ctx context.Context) {
+	latency, threshold := backlog.Latency(), config.BackpressureLatency()
+
+	backpressureMutex.Lock()
+	backpressure.Latency = latency
+
+	var changed bool
+	switch {
+	case !backpressure.Active && latency > threshold:
+		backpressure.Active, backpressure.Since, changed = true, timeutil.Now(), true
+	case backpressure.Active && latency < threshold/2:
+		backpressure.Active, backpressure.Since, changed = false, 0, true
+	}
+	active := backpressure.Active
+	backpressureMutex.Unlock()
+
+	if !changed {
+		return
+	}
+
+	if active {
+		Warnf(ctx, "the backlog latency is %v (over %v): shedding the low-priority work", latency.Round(time.Millisecond), threshold)
+		n.Emit(ctx, "backpressure.started", map[string]interface{}{
+			"latency_ms":   latency.Milliseconds(),
+			"threshold_ms": threshold.Milliseconds(),
+		})
+	} else {
+		Logf(ctx, "the backlog latency recovered to %v: back to the normal operation", latency.Round(time.Millisecond))
+		n.Emit(ctx, "backpressure.ended", map[string]interface{}{
+			"latency_ms": latency.Milliseconds(),
+		})
+	}
+}
+
+// Schedules the job that watches the latency of the backlog, in every process
+func (n Node) StartBackpressureMonitor(schedule string) error {
+	return ScheduleJob(Job{
+		Name:     "backpressure_monitor",
+		Schedule: schedule,
+		Run: func(ctx context.Context, lease *Lease) error {
+			n.CheckBackpressure(ctx)
+			return nil
+		},
+	})
+}
@@ -0,0 +1,485 @@
+package node
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	backlog "node/backlog"
+	config "node/config"
+	timeutil "node/timeutil"
+	"strings"
+)
+
+// The blocks refused by the validation, whatever the chain or the backlog do later
+var ErrInvalidBlock = errors.New("invalid block")
+
+const (
+	blockDifficulty      int    = 5   // The number of zeros that a block hash must have at the left
+	maxBlockTransactions int    = 500 // The maximum number of transactions collapsed in a single block
+	genesisPreviousHash  string = "0000000000000000000000000000000000000000000000000000000000000000"
+)
+
+// The record of a transaction inside a block
+type BlockTransaction struct {
+	TransactionId string  `json:"transaction_id"`    // The universal id of the transaction
+	Sender        string  `json:"sender"`            // The client id of the sender
+	Recipient     string  `json:"recipient"`         // The client id of the recipient
+	Value         float64 `json:"value"`             // The value of the transaction
+	Fee           float64 `json:"fee,omitempty"`     // The fee paid to the producer of the block (please, go to `fees.go`)
+	Payload       []byte  `json:"payload,omitempty"` // The arbitrary data attached by the application (please, go to `payload.go`)
+	Timestamp     int64   `json:"timestamp"`         // The timestamp when the transaction was performed
+	Sequence      int64   `json:"sequence"`          // The position of the transaction in the history of the sender
+	Memo          string  `json:"memo,omitempty"`    // The memo sealed to the sender and the recipient
+	Tag           string  `json:"tag,omitempty"`     // The stealth tag, when the recipient is a stealth address
+	Signature     string  `json:"signature"`         // The signature made by the sender
+}
+
+/*
+A block collapses a set of signed transactions together with the hash of the previous block
+and a nonce. The block is valid when its hash has at least `blockDifficulty` zeros at the left,
+so producing a block requires to find a nonce that satisfies it (the mining).
+
+Since every block carries the hash of the previous one, changing any block invalidates all the
+blocks after it. The first block of the chain points to a hash made only by zeros.
+*/
+type Block struct {
+	Height       int64              `json:"height"`                  // The position of the block in the chain (starting from zero)
+	PreviousHash string             `json:"previous_hash"`           // The hash of the previous block in the chain
+	Timestamp    int64              `json:"timestamp"`               // The timestamp when the block was assembled
+	Nonce        int64              `json:"nonce"`                   // The number found by the mining to satisfy the difficulty
+	Transactions []BlockTransaction `json:"transactions"`            // The transactions included in the block
+	MerkleRoot   string             `json:"merkle_root,omitempty"`   // The root of the Merkle tree of the transactions (please, go to `merkle.go`)
+	Producer     string             `json:"producer,omitempty"`      // The id of the node that produced the block (empty for the genesis)
+	FeeRecipient string             `json:"fee_recipient,omitempty"` // The client id credited with the fees of the block (empty when they're burned)
+	Hash         string             `json:"hash"`                    // The hash of all the other fields (except the signature)
+	Signature    string             `json:"signature,omitempty"`     // The signature made by the key of the producer (please, go to `authority.go`)
+}
+
+// Converts the block header (except the hash itself) to a hashable byte array. The transactions are
+// committed by their Merkle root, so a header can be checked without them (please, go to `merkle.go`).
+// The fields are written in a fixed order (please, go to `canonical.go`)
+func (b Block) ToBytes() ([]byte, error) {
+	header := canonicalObject{
+		{"height", b.Height},
+		{"previous_hash", b.PreviousHash},
+		{"timestamp", b.Timestamp},
+		{"nonce", b.Nonce},
+		{"merkle_root", b.MerkleRoot},
+	}
+
+	if b.Producer != "" {
+		header = append(header, canonicalField{"producer", b.Producer})
+	}
+
+	if b.FeeRecipient != "" {
+		header = append(header, canonicalField{"fee_recipient", b.FeeRecipient})
+	}
+
+	headerBytes, err := canonicalJSON(header)
+	return headerBytes, err
+}
+
+// Computes the hash of the block
+func (b Block) ComputeHash() string {
+	// The header only holds integers and strings, so its encoding never fails (and the empty hash
+	// never matches a block)
+	headerBytes, err := b.ToBytes()
+	if err != nil {
+		return ""
+	}
+
+	hash := sha256.Sum256(headerBytes)
+	return hex.EncodeToString(hash[:])
+}
+
+// Checks if the block hash satisfies the difficulty
+func (b Block) Solved() bool {
+	return strings.HasPrefix(b.Hash, strings.Repeat("0", blockDifficulty))
+}
+
+// Finds the nonce that satisfies the difficulty and sets the block hash
+func (b *Block) Mine() {
+	for b.Nonce = 0; ; b.Nonce++ {
+		b.Hash = b.ComputeHash()
+
+		if b.Solved() {
+			return
+		}
+	}
+}
+
+/*
+The Blockchain manages the blocks stored in the `blockchain` index of the backlog. It assembles
+the pending transactions (signed, but not included in any block yet) into new blocks and appends
+blocks after validating their link with the last one.
+*/
+type Blockchain struct {
+	*backlog.Backlog
+}
+
+func NewBlockchain(b *backlog.Backlog) *Blockchain {
+	return &Blockchain{Backlog: b}
+}
+
+// Converts a block document into a block
+func blockFromDocument(document map[string]interface{}) (*Block, error) {
+	blockBytes, err := json.Marshal(document)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal the block document: %v", err)
+	}
+
+	var block Block
+	if err := json.Unmarshal(blockBytes, &block); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal the block document: %v", err)
+	}
+
+	// The large memos are stored compressed (please, go to `compression.go`)
+	for i, transaction := range block.Transactions {
+		if block.Transactions[i].Memo, err = decompressField(transaction.Memo); err != nil {
+			return nil, fmt.Errorf("failed to read the memo of the transaction %s: %v", transaction.TransactionId, err)
+		}
+	}
+
+	return &block, nil
+}
+
+// Gives the last block of the chain (nil when the chain is empty)
+func (bc Blockchain) LastBlock(ctx context.Context) (*Block, error) {
+	documents, err := bc.SearchDocuments(ctx, "blockchain", map[string]interface{}{
+		"size": 1,
+		"sort": []interface{}{map[string]interface{}{"height": "desc"}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the last block: %v", err)
+	}
+
+	if len(documents) == 0 {
+		return nil, nil
+	}
+
+	return blockFromDocument(documents[0])
+}
+
+// Gives the block at the height (nil when the chain is shorter)
+func (bc Blockchain) BlockAt(ctx context.Context, height int64) (*Block, error) {
+	documents, _, err := bc.FindDocuments(ctx, "blockchain", backlog.Term("height", height), backlog.ListOptions{Size: 1})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the block %d: %v", height, err)
+	}
+
+	if len(documents) == 0 {
+		return nil, nil
+	}
+
+	return blockFromDocument(documents[0])
+}
+
+// Matches the transactions that were signed but aren't included in any block yet
+var mempoolQuery = map[string]interface{}{
+	"bool": map[string]interface{}{
+		"must": map[string]interface{}{
+			"exists": map[string]interface{}{"field": "Signature"},
+		},
+		"must_not": map[string]interface{}{
+			"exists": map[string]interface{}{"field": "BlockHash"},
+		},
+	},
+}
+
+// Gives the pending transactions that fill the next block, the best fee per byte first among the
+// oldest ones (please, go to `fees.go`)
+func (bc Blockchain) PendingTransactions(ctx context.Context) ([]BlockTransaction, error) {
+	records, err := Node{Backlog: bc.Backlog}.Transactions().Search(ctx, map[string]interface{}{
+		"size": mempoolWindow,
+		"sort": []interface{}{
+			map[string]interface{}{"Timestamp": "asc"},
+			map[string]interface{}{"Sequence": "asc"},
+		},
+		"query": mempoolQuery,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the pending transactions: %v", err)
+	}
+
+	var transactions []BlockTransaction
+	for _, record := range records {
+		transactions = append(transactions, record.ChainTransaction())
+	}
+
+	return selectTransactions(transactions, maxBlockTransactions), nil
+}
+
+// Assembles the pending transactions into a new mined block linked to the last one, produced by the
+// node and paying the fees to the client of its producer
+func (bc Blockchain) Assemble(ctx context.Context, producer, feeRecipient string) (*Block, error) {
+	last, err := bc.LastBlock(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	transactions, err := bc.PendingTransactions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if transactions, err = bc.spendable(ctx, transactions); err != nil {
+		return nil, err
+	}
+
+	if len(transactions) == 0 {
+		return nil, fmt.Errorf("there are no pending transactions to include in a block")
+	}
+
+	// The chain starts from its genesis, created by `meander --init` or fetched from the peers
+	if last == nil {
+		return nil, fmt.Errorf("%w: please, initialize the chain or sync it with a peer", ErrNoGenesis)
+	}
+
+	block := Block{
+		Height:       last.Height + 1,
+		PreviousHash: last.Hash,
+		Timestamp:    timeutil.Now(),
+		Transactions: transactions,
+		Producer:     producer,
+		MerkleRoot:   MerkleRoot(transactions),
+	}
+
+	if block.Fees() > 0 {
+		block.FeeRecipient = feeRecipient
+	}
+
+	block.Mine()
+	return &block, nil
+}
+
+// Validates a block against the last one and stores it, marking its transactions as included
+func (bc Blockchain) Append(ctx context.Context, block *Block) error {
+	last, err := bc.LastBlock(ctx)
+	if err != nil {
+		return err
+	}
+
+	expectedHeight, expectedPrevious := int64(0), genesisPreviousHash
+	if last != nil {
+		expectedHeight, expectedPrevious = last.Height+1, last.Hash
+	}
+
+	if block.Height != expectedHeight || block.PreviousHash != expectedPrevious {
+		return fmt.Errorf("%w: the block %d doesn't follow the last block of the chain", ErrInvalidBlock, block.Height)
+	}
+
+	if block.ComputeHash() != block.Hash || !block.Solved() {
+		return fmt.Errorf("%w: the hash of the block %d is wrong or doesn't satisfy the difficulty", ErrInvalidBlock, block.Height)
+	}
+
+	if err := block.checkContents(); err != nil {
+		return err
+	}
+
+	if err := bc.checkRate(ctx, block); errors.Is(err, ErrRateLimited) {
+		return fmt.Errorf("%w: %v", ErrInvalidBlock, err)
+	} else if err != nil {
+		return err
+	}
+
+	// The transactions can't spend twice nor more than their senders have (please, go to `spending.go`)
+	if err := bc.checkSpending(ctx, block); err != nil {
+		return err
+	}
+
+	document, err := toDocument(block.compressed())
+	if err != nil {
+		return err
+	}
+
+	// The block and the confirmation of its transactions are committed together. The transactions
+	// from other nodes (e.g. in a block of a peer) are recorded as they're confirmed
+	commit := bc.Begin().Put("blockchain", block.Hash, document)
+	for _, transaction := range block.Transactions {
+		if _, err := bc.GetDocument(ctx, "transactions", transaction.TransactionId); err == nil {
+			commit.Update("transactions", transaction.TransactionId, map[string]interface{}{
+				"BlockHash": block.Hash,
+			})
+			continue
+		}
+
+		commit.Put("transactions", transaction.TransactionId, map[string]interface{}{
+			"TransactionId": transaction.TransactionId,
+			"Sender":        map[string]interface{}{"client_id": transaction.Sender},
+			"Recipient":     map[string]interface{}{"client_id": transaction.Recipient},
+			"Value":         transaction.Value,
+			"Fee":           transaction.Fee,
+			"Payload":       transaction.Payload,
+			"Timestamp":     transaction.Timestamp,
+			"Sequence":      transaction.Sequence,
+			"Signature":     transaction.Signature,
+			"BlockHash":     block.Hash,
+		})
+	}
+
+	// The block is journaled first, so a crash or a failure in the middle of the commit replays it
+	// on startup (please, go to `journal.go`)
+	if err := journalBlock(ctx, *block); err != nil {
+		return err
+	}
+
+	if err := commit.Apply(ctx); err != nil {
+		return fmt.Errorf("failed to store the block: %v", err)
+	}
+
+	if err := unjournalBlock(ctx, block.Hash); err != nil {
+		Warnf(ctx, "%v", err)
+	}
+
+	return nil
+}
+
+// Checks the contents of a block that don't depend on the chain: its Merkle root, the genesis and
+// the ids, fees, payloads and sequences of its transactions
+func (b *Block) checkContents() error {
+	if b.MerkleRoot != MerkleRoot(b.Transactions) {
+		return fmt.Errorf("%w: the Merkle root of the block %d doesn't match its transactions", ErrInvalidBlock, b.Height)
+	}
+
+	if b.Height == 0 {
+		if err := checkGenesis(b); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidBlock, err)
+		}
+	}
+
+	// The transactions of the same sender must keep the order of its sequence
+	sequences := map[string]int64{}
+	ids := map[string]bool{}
+	for _, transaction := range b.Transactions {
+		if ids[transaction.TransactionId] {
+			return fmt.Errorf("%w: the transaction %s is included twice", ErrInvalidBlock, transaction.TransactionId)
+		}
+		ids[transaction.TransactionId] = true
+
+		// The values and fees that aren't finite can't be encoded to check their signatures
+		if math.IsNaN(transaction.Value) || math.IsInf(transaction.Value, 0) {
+			return fmt.Errorf("%w: the value of the transaction %s isn't a finite number", ErrInvalidBlock, transaction.TransactionId)
+		}
+
+		if !(transaction.Fee >= 0) || math.IsInf(transaction.Fee, 0) {
+			return fmt.Errorf("%w: the transaction %s pays a negative or non-finite fee", ErrInvalidBlock, transaction.TransactionId)
+		}
+
+		if len(transaction.Payload) > MaxPayloadSize {
+			return fmt.Errorf("%w: the payload of the transaction %s is over %d bytes", ErrInvalidBlock, transaction.TransactionId, MaxPayloadSize)
+		}
+
+		if last, ok := sequences[transaction.Sender]; ok && transaction.Sequence <= last {
+			return fmt.Errorf("%w: the transaction %s breaks the sequence of its sender", ErrInvalidBlock, transaction.TransactionId)
+		}
+
+		sequences[transaction.Sender] = transaction.Sequence
+	}
+
+	return nil
+}
+
+// Assembles the pending transactions into a new block and appends it to the chain
+func (n Node) MineBlock(ctx context.Context) (*Block, error) {
+	blockchain := NewBlockchain(n.Backlog)
+
+	block, err := blockchain.Assemble(ctx, n.Id, config.FeeRecipient())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := n.signBlock(block); err != nil {
+		return nil, err
+	}
+
+	if err := blockchain.Append(ctx, block); err != nil {
+		return nil, err
+	}
+	n.claimStealth(ctx, *block)
+
+	runAfterCommit(ctx, *block)
+	n.Emit(ctx, "block.mined", map[string]interface{}{
+		"height":       block.Height,
+		"hash":         block.Hash,
+		"transactions": len(block.Transactions),
+	})
+	n.PropagateBlock(ctx, *block)
+
+	return block, nil
+}
+
+// Schedules the mining, a singleton job that assembles the pending transactions into a block. It isn't
+// scheduled unless the mining feature is enabled (please, go to `features.go` in the config)
+func (n Node) StartMining(schedule string) error {
+	if !config.FeatureEnabled(config.FeatureMining) {
+		return nil
+	}
+
+	return ScheduleJob(Job{
+		Name:      "mining",
+		Schedule:  schedule,
+		Singleton: true,
+		Run: func(ctx context.Context, lease *Lease) error {
+			pending, err := NewBlockchain(n.Backlog).PendingTransactions(ctx)
+			if err != nil || len(pending) == 0 {
+				return err
+			}
+
+			if err := n.CheckLease(ctx, *lease); err != nil {
+				return err
+			}
+
+			// A node without a chain waits for its genesis (please, go to `genesis.go`)
+			if _, err = n.MineBlock(ctx); errors.Is(err, ErrNoGenesis) {
+				Debugf(ctx, SubsystemSync, "the mining waits for the genesis: %v", err)
+				return nil
+			}
+
+			return err
+		},
+	})
+}
+
+// Appends a block produced somewhere else (e.g. by a peer) to the chain
+func (n Node) AppendBlock(ctx context.Context, block *Block) error {
+	// The signatures are checked before anything is stored, since the block may come from anyone
+	if err := n.verifySignatures(ctx, block); err != nil {
+		return err
+	}
+
+	if err := NewBlockchain(n.Backlog).Append(ctx, block); err != nil {
+		return err
+	}
+	n.claimStealth(ctx, *block)
+
+	runAfterCommit(ctx, *block)
+	n.Emit(ctx, "block.appended", map[string]interface{}{
+		"height": block.Height,
+		"hash":   block.Hash,
+	})
+
+	return nil
+}
+
+// Verifies the signature of the producer of a block and the signatures of its transactions
+func (n Node) verifySignatures(ctx context.Context, block *Block) error {
+	if err := n.verifyProducer(ctx, block); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidBlock, err)
+	}
+
+	for _, transaction := range block.Transactions {
+		if transaction.allocation(block.Height) {
+			continue
+		}
+
+		if err := transaction.VerifySignature(); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidBlock, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,89 @@
+package node
+
+import (
+	"runtime/debug"
+)
+
+/*
+The build info identifies the binary that's running on the node, so the operators and the peers can
+tell which code each node runs. The releases are built reproducibly and stamp it with the linker
+flags:
+
+	go build -trimpath -ldflags "-X node/node.buildVersion=v1.4.0 -X node/node.buildCommit=$(git rev-parse HEAD) -X node/node.buildTime=$(git log -1 --format=%cI)"
+
+The build time is taken from the commit (and not from the clock), so building the same commit twice
+gives the same binary. Without the flags, the info is read from the metadata that Go embeds in the
+binary (the revision and the time of the last commit, when it's built inside the repository).
+*/
+type BuildInfo struct {
+	Version   string `json:"version"`    // The released version (or "devel" when the binary isn't a release)
+	Commit    string `json:"commit"`     // The revision of the source code
+	BuiltAt   string `json:"built_at"`   // The time of the build, in RFC 3339
+	Modified  bool   `json:"modified"`   // Whether the source code had uncommitted changes
+	GoVersion string `json:"go_version"` // The version of the Go toolchain
+}
+
+// Stamped by the linker flags
+var (
+	buildVersion string
+	buildCommit  string
+	buildTime    string
+)
+
+// The version of the binaries that aren't releases
+const develVersion string = "devel"
+
+// Gives the build info of the running binary
+func CurrentBuild() BuildInfo {
+	build := BuildInfo{
+		Version: buildVersion,
+		Commit:  buildCommit,
+		BuiltAt: buildTime,
+	}
+
+	if info, ok := debug.ReadBuildInfo(); ok {
+		build.GoVersion = info.GoVersion
+
+		if build.Version == "" && info.Main.Version != "" && info.Main.Version != "(devel)" {
+			build.Version = info.Main.Version
+		}
+
+		for _, setting := range info.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				if build.Commit == "" {
+					build.Commit = setting.Value
+				}
+			case "vcs.time":
+				if build.BuiltAt == "" {
+					build.BuiltAt = setting.Value
+				}
+			case "vcs.modified":
+				build.Modified = setting.Value == "true"
+			}
+		}
+	}
+
+	if build.Version == "" {
+		build.Version = develVersion
+	}
+
+	return build
+}
+
+// Gives the identifier of the source code, e.g. "v1.4.0 (3f2a9c1d04be)"
+func (b BuildInfo) String() string {
+	commit := b.Commit
+	if len(commit) > 12 {
+		commit = commit[:12]
+	}
+
+	switch {
+	case commit == "":
+		return b.Version
+	case b.Modified:
+		return b.Version + " (" + commit + ", modified)"
+	default:
+		return b.Version + " (" + commit + ")"
+	}
+}
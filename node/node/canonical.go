@@ -0,0 +1,175 @@
+package node
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"unicode/utf8"
+)
+
+/*
+The bytes signed for the transactions and hashed for the blocks must be the same on every node,
+whatever its Go version, so they're never left to the reflection of `encoding/json`. The canonical
+encoding is a compact JSON with a closed set of values:
+
+  - The objects are either maps, written with their keys sorted, or an explicit list of fields,
+    written in the order of the list (the block header, whose order is fixed by the hashes of the
+    chain).
+  - The integers are written in base 10, and the floats in their shortest form that parses back to
+    the same number (with an exponent below 1e-6 and from 1e21 on). The negative zero is written as
+    zero, and the NaN and infinite floats can't be encoded.
+  - The strings escape the quote, the backslash, the control characters, `<`, `>`, `&`, U+2028 and
+    U+2029, and the invalid UTF-8 is replaced by U+FFFD. The byte arrays are written as the
+    standard base64 of their bytes.
+
+An encoding that fails (e.g. of a NaN value) is an error, never empty bytes, so the documents that
+can't be encoded are never signed nor hashed (please, go to `crypto.go` in the client package).
+*/
+type canonicalField struct {
+	name  string
+	value interface{}
+}
+
+// An object encoded with its fields in the given order
+type canonicalObject []canonicalField
+
+// Gives the canonical encoding of a value
+func canonicalJSON(value interface{}) ([]byte, error) {
+	var buffer bytes.Buffer
+	if err := writeCanonical(&buffer, value); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// Writes the canonical encoding of a value
+func writeCanonical(buffer *bytes.Buffer, value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		buffer.WriteString("null")
+	case bool:
+		buffer.WriteString(strconv.FormatBool(v))
+	case string:
+		writeCanonicalString(buffer, v)
+	case []byte:
+		writeCanonicalString(buffer, base64.StdEncoding.EncodeToString(v))
+	case int:
+		buffer.WriteString(strconv.FormatInt(int64(v), 10))
+	case int64:
+		buffer.WriteString(strconv.FormatInt(v, 10))
+	case float64:
+		return writeCanonicalFloat(buffer, v)
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		fields := make(canonicalObject, 0, len(keys))
+		for _, key := range keys {
+			fields = append(fields, canonicalField{key, v[key]})
+		}
+
+		return writeCanonical(buffer, fields)
+	case canonicalObject:
+		buffer.WriteByte('{')
+		for i, field := range v {
+			if i > 0 {
+				buffer.WriteByte(',')
+			}
+
+			writeCanonicalString(buffer, field.name)
+			buffer.WriteByte(':')
+			if err := writeCanonical(buffer, field.value); err != nil {
+				return fmt.Errorf("failed to encode the field %s: %v", field.name, err)
+			}
+		}
+		buffer.WriteByte('}')
+	default:
+		return fmt.Errorf("the type %T has no canonical encoding", value)
+	}
+
+	return nil
+}
+
+// Writes the shortest form of a float that parses back to it
+func writeCanonicalFloat(buffer *bytes.Buffer, value float64) error {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return fmt.Errorf("the float %v has no canonical encoding", value)
+	}
+
+	if value == 0 {
+		buffer.WriteByte('0')
+		return nil
+	}
+
+	format := byte('f')
+	if abs := math.Abs(value); abs < 1e-6 || abs >= 1e21 {
+		format = 'e'
+	}
+
+	encoded := strconv.AppendFloat(nil, value, format, -1, 64)
+
+	// The exponents are written without their leading zero (e.g. 1e-7 rather than 1e-07)
+	if n := len(encoded); format == 'e' && n >= 4 && encoded[n-4] == 'e' && encoded[n-3] == '-' && encoded[n-2] == '0' {
+		encoded[n-2] = encoded[n-1]
+		encoded = encoded[:n-1]
+	}
+
+	buffer.Write(encoded)
+	return nil
+}
+
+// Writes a quoted string, escaped
+func writeCanonicalString(buffer *bytes.Buffer, value string) {
+	const hexDigits = "0123456789abcdef"
+
+	buffer.WriteByte('"')
+	for i := 0; i < len(value); {
+		if c := value[i]; c < utf8.RuneSelf {
+			switch {
+			case c == '"' || c == '\\':
+				buffer.WriteByte('\\')
+				buffer.WriteByte(c)
+			case c == '\b':
+				buffer.WriteString(`\b`)
+			case c == '\f':
+				buffer.WriteString(`\f`)
+			case c == '\n':
+				buffer.WriteString(`\n`)
+			case c == '\r':
+				buffer.WriteString(`\r`)
+			case c == '\t':
+				buffer.WriteString(`\t`)
+			case c < 0x20 || c == '<' || c == '>' || c == '&':
+				buffer.WriteString(`\u00`)
+				buffer.WriteByte(hexDigits[c>>4])
+				buffer.WriteByte(hexDigits[c&0xf])
+			default:
+				buffer.WriteByte(c)
+			}
+
+			i++
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(value[i:])
+		switch {
+		case r == utf8.RuneError && size == 1:
+			buffer.WriteRune(utf8.RuneError)
+		case r == '\u2028' || r == '\u2029':
+			buffer.WriteString(`\u202`)
+			buffer.WriteByte(hexDigits[r&0xf])
+		default:
+			buffer.WriteString(value[i : i+size])
+		}
+
+		i += size
+	}
+	buffer.WriteByte('"')
+}
@@ -1,13 +1,14 @@
 package node
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/binary"
 	"encoding/json"
 	"fmt"
-	"log"
 	client "node/client"
-	"time"
+	config "node/config"
+	timeutil "node/timeutil"
 )
 
 /*
@@ -19,19 +20,44 @@ and the credentials are synced in local elastic
 type Client struct {
 	*client.CryptoResource `json:"-"`
 	*Node                  `json:"-"`
-	UID                    string `json:"uid"`        // A random hexadecimal id that's a internal reference along the node (no peers know the UID)
-	Alias                  string `json:"alias"`      // The nickname chosen to connect the client
-	AccountId              string `json:"account_id"` // A private random id composed by digits only
-	NodeAddress            string `json:"node"`       // The hash of the host address from the node where the client has been registered
-	Address                string `json:"address"`    // The hash of the host address from where the client was registered
-	ClientId               string `json:"client_id"`  // The identification generated by the public key (external reference known by all the peers)
-	PublicKey              string `json:"-"`          // RSA public key (result of ImpersonatePublicKey method)
-	PrivateKey             string `json:"-"`          // RSA private key used to assign the client transactions (result of ImpersonatePrivateKey method)
-	Secret                 string `json:"-"`          // The password that protects the private key in the node filesystem
-	Password               string `json:"password"`   // The hex hash from the password chosen together with the alias to connect the client
+	UID                    string            `json:"uid"`                // A random hexadecimal id that's a internal reference along the node (no peers know the UID)
+	Alias                  string            `json:"alias"`              // The nickname chosen to connect the client
+	AccountId              string            `json:"account_id"`         // A private random id composed by digits only
+	NodeAddress            string            `json:"node"`               // The hash of the host address from the node where the client has been registered
+	Address                string            `json:"address"`            // The hash of the host address from where the client was registered
+	ClientId               string            `json:"client_id"`          // The identification generated by the public key (external reference known by all the peers)
+	PublicKey              string            `json:"-"`                  // RSA public key (result of ImpersonatePublicKey method)
+	PrivateKey             string            `json:"-"`                  // RSA private key used to assign the client transactions (result of ImpersonatePrivateKey method)
+	Secret                 string            `json:"-"`                  // The password that protects the private key in the node filesystem
+	Password               string            `json:"password"`           // The hex hash from the password chosen together with the alias to connect the client
+	TermsVersion           string            `json:"terms_version"`      // The version of the terms accepted last by the client (please, go to `terms.go`)
+	TermsAcceptedAt        int64             `json:"terms_accepted_at"`  // The timestamp when the client accepted them
+	FailedAttempts         int               `json:"failed_attempts"`    // The connections refused for a wrong password since the last accepted one
+	Liquidated             bool              `json:"liquidated"`         // Whether the client was liquidated (please, go to `liquidation.go`)
+	LiquidatedAt           int64             `json:"liquidated_at"`      // The timestamp when the client was liquidated
+	Metadata               map[string]string `json:"metadata,omitempty"` // The metadata attached by the client, shared with the peers (please, go to `profile.go`)
 }
 
-// Gives a cache with new computed keys
+// Sets the identity and the PEM representations of the client key pair
+func (c *Client) impersonate() error {
+	identity, err := c.Identity()
+	if err != nil {
+		return err
+	}
+
+	publicKey, err := c.ImpersonatePublicKey()
+	if err != nil {
+		return err
+	}
+
+	c.ClientId = identity
+	c.PublicKey = string(publicKey)
+	c.PrivateKey = string(c.ImpersonatePrivateKey())
+
+	return nil
+}
+
+// Gives a cache with new computed keys (the key pair must be impersonated)
 func (c Client) CreateCache() client.Cache {
 	cka := client.GenerateComputedKeyA(c.AccountId)
 
@@ -43,17 +69,18 @@ func (c Client) CreateCache() client.Cache {
 	cache := client.Cache{
 		ComputedKeyA: cka,
 		ComputedKeyP: ckp,
-		Timestamp:    time.Now().Unix(),
+		Timestamp:    timeutil.Now(),
+		ExpiresAt:    timeutil.After(config.SessionWindow()),
 		Alias:        c.Alias,
 		Password:     c.Password,
-		PublicKey:    c.ImpersonatePublicKey(),
+		PublicKey:    []byte(c.PublicKey),
 	}
 
 	return cache
 }
 
 // (Over)Writes the client state and optionally the client cache in backlog using the current in-memory state
-func (c Client) SyncWithBacklog(ca ...client.Cache) error {
+func (c Client) SyncWithBacklog(ctx context.Context, ca ...client.Cache) error {
 	clientBytes, err := json.Marshal(c)
 	if err != nil {
 		return fmt.Errorf("failed to marshal the client: %v", err)
@@ -64,7 +91,7 @@ func (c Client) SyncWithBacklog(ca ...client.Cache) error {
 		return fmt.Errorf("failed to unmarshal the client into map: %v", err)
 	}
 
-	err = c.Backlog.IndexDocument("clients", c.UID, client)
+	err = c.Backlog.IndexDocument(ctx, "local_clients", c.UID, client)
 	if err != nil {
 		return fmt.Errorf("failed to overwrite the client document: %v", err)
 	}
@@ -80,7 +107,7 @@ func (c Client) SyncWithBacklog(ca ...client.Cache) error {
 			return fmt.Errorf("failed to unmarshal the cache into map: %v", err)
 		}
 
-		err = c.Backlog.IndexDocument("cache", c.UID, cache)
+		err = c.Backlog.IndexDocument(ctx, "cache", c.UID, cache)
 		if err != nil {
 			return fmt.Errorf("failed to overwrite the cache document: %v", err)
 		}
@@ -89,54 +116,97 @@ func (c Client) SyncWithBacklog(ca ...client.Cache) error {
 	return nil
 }
 
-// Retrieves the existing RSA key pair for the client and keep in-memory
-func (c *Client) RetrieveCrypto() {
-	private, err := client.DownloadPrivateKey(c.Secret, c.UID)
+// Writes the client, its cache, its foreign client and the consent to the terms in a single atomic
+// commit
+func (c Client) commitDocuments(ctx context.Context, cache client.Cache) error {
+	clientDocument, err := toDocument(c)
+	if err != nil {
+		return err
+	}
+
+	cacheDocument, err := toDocument(cache)
+	if err != nil {
+		return err
+	}
+
+	foreignDocument, err := toDocument(c.MakeForeign())
+	if err != nil {
+		return err
+	}
+
+	commit := c.Begin().
+		Put("local_clients", c.UID, clientDocument).
+		Put("cache", c.UID, cacheDocument).
+		Put("clients", c.ClientId, foreignDocument)
+
+	if c.TermsVersion != "" {
+		consentDocument, err := toDocument(Consent{UID: c.UID, ClientId: c.ClientId, Version: c.TermsVersion, AcceptedAt: c.TermsAcceptedAt})
+		if err != nil {
+			return err
+		}
 
+		commit = commit.Put("consents", c.UID+"@"+c.TermsVersion, consentDocument)
+	}
+
+	err = commit.Apply(ctx)
 	if err != nil {
-		log.Fatalf("failed to download private key: %v", err)
+		return fmt.Errorf("failed to commit the client documents: %v", err)
 	}
 
-	public, err := client.DownloadPublicKey(c.UID)
+	return nil
+}
 
+// Retrieves the existing RSA key pair for the client and keep in-memory
+func (c *Client) RetrieveCrypto(ctx context.Context) error {
+	keys, err := c.Keys.Get(ctx, c.UID)
 	if err != nil {
-		log.Fatalf("failed to download public key: %v", err)
+		return fmt.Errorf("failed to get the client keys: %v", err)
 	}
 
-	crypto := client.CryptoResource{
-		PrivateKey: private,
-		PublicKey:  public,
+	crypto, err := keys.Decode(c.Secret)
+	if err != nil {
+		return fmt.Errorf("failed to decode the client keys: %v", err)
 	}
 
-	c.CryptoResource = &crypto
+	c.CryptoResource = crypto
+	return nil
 }
 
-// Generates a new RSA key pair for the client and upload it
-func (c *Client) GenerateCrypto() {
+// Generates a new RSA key pair for the client and stores it
+func (c *Client) GenerateCrypto(ctx context.Context) error {
 	crypto, err := client.NewCryptoResource()
 
 	if err != nil {
-		log.Fatalf("failed to create a new crypto resource: %v", err)
+		return fmt.Errorf("failed to create a new crypto resource: %v", err)
 	}
 
+	return c.storeCrypto(ctx, crypto)
+}
+
+// Attaches a key pair to the client and stores it, sealed with the client secret
+func (c *Client) storeCrypto(ctx context.Context, crypto *client.CryptoResource) error {
 	c.CryptoResource = crypto
 
-	err = c.UploadPrivateKey(c.Secret, c.UID)
+	keys, err := client.EncodeKeyPair(*crypto, c.Secret)
 	if err != nil {
-		log.Fatalf("failed to upload private key: %v", err)
+		return fmt.Errorf("failed to encode the client keys: %v", err)
 	}
 
-	err = c.UploadPublicKey(c.UID)
-	if err != nil {
-		log.Fatalf("failed to upload public key: %v", err)
+	if err := c.Keys.Put(ctx, c.UID, *keys); err != nil {
+		return fmt.Errorf("failed to store the client keys: %v", err)
 	}
+
+	return nil
 }
 
 // Converts a Client to a Foreign Client
 func (c Client) MakeForeign() ForeignClient {
 	return ForeignClient{
+		Node:        c.Node,
 		ClientId:    c.ClientId,
 		NodeAddress: c.NodeAddress,
 		Address:     c.Address,
+		Metadata:    c.Metadata,
+		UpdatedAt:   timeutil.Now(),
 	}
 }
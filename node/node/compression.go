@@ -0,0 +1,108 @@
+package node
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+/*
+The busy nodes move and store a lot of chain data, so it's compressed with zstd wherever it's big:
+
+  - The batches of blocks and documents fetched by the sync are compressed on the wire (please, go
+    to `compression.go` in the server).
+  - The large sealed memos are stored compressed in the `transactions` and `blockchain` indices,
+    and decompressed when the documents are decoded, so the rest of the node only sees the memo it
+    signed. A memo that doesn't shrink is stored as it is.
+
+The memos stored with gzip by the earlier nodes are still read. The signatures always cover the memo
+as it was sealed, never its stored form.
+*/
+const (
+	compressionThreshold int    = 1024    // The sealed memos shorter than this size (in bytes) are stored as they are
+	compressedPrefix     string = "zstd:" // The prefix of a compressed field, followed by the base64 of its zstd frame
+	legacyPrefix         string = "gzip:" // The prefix of a field compressed by the earlier nodes, followed by the base64 of its gzip
+	maxDecompressedSize  uint64 = 1 << 24 // The largest field that is decompressed (in bytes), so a forged frame can't exhaust the memory
+)
+
+// The encoder and the decoder of the fields, safe for concurrent use through `EncodeAll` and `DecodeAll`
+var (
+	fieldEncoder, _ = zstd.NewWriter(nil)
+	fieldDecoder, _ = zstd.NewReader(nil, zstd.WithDecoderMaxMemory(maxDecompressedSize))
+)
+
+// Gives the stored form of a field: its zstd (as base64) when it's large and shrinks with it
+func compressField(value string) string {
+	if len(value) < compressionThreshold {
+		return value
+	}
+
+	compressed := compressedPrefix + base64.StdEncoding.EncodeToString(fieldEncoder.EncodeAll([]byte(value), nil))
+	if len(compressed) >= len(value) {
+		return value
+	}
+
+	return compressed
+}
+
+// Gives the original value of a field stored by `compressField`
+func decompressField(value string) (string, error) {
+	if encoded, ok := strings.CutPrefix(value, legacyPrefix); ok {
+		return gunzipField(encoded)
+	}
+
+	encoded, ok := strings.CutPrefix(value, compressedPrefix)
+	if !ok {
+		return value, nil
+	}
+
+	compressed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode the compressed field: %v", err)
+	}
+
+	decompressed, err := fieldDecoder.DecodeAll(compressed, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decompress the field: %v", err)
+	}
+
+	return string(decompressed), nil
+}
+
+// Gives the original value of a field compressed with gzip by the earlier nodes
+func gunzipField(encoded string) (string, error) {
+	compressed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode the compressed field: %v", err)
+	}
+
+	reader, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return "", fmt.Errorf("failed to decompress the field: %v", err)
+	}
+	defer reader.Close()
+
+	decompressed, err := io.ReadAll(io.LimitReader(reader, int64(maxDecompressedSize)))
+	if err != nil {
+		return "", fmt.Errorf("failed to decompress the field: %v", err)
+	}
+
+	return string(decompressed), nil
+}
+
+// Gives the block as it's stored, with the large memos of its transactions compressed
+func (b Block) compressed() Block {
+	stored := b
+	stored.Transactions = make([]BlockTransaction, len(b.Transactions))
+	for i, transaction := range b.Transactions {
+		transaction.Memo = compressField(transaction.Memo)
+		stored.Transactions[i] = transaction
+	}
+
+	return stored
+}
@@ -0,0 +1,109 @@
+package node
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	backlog "node/backlog"
+	timeutil "node/timeutil"
+)
+
+/*
+A client id is the identity of a key, so two clients can only share it when a node claims a client
+that isn't its own (or when the keys of two clients collide, which never happens in practice). The
+conflicts are detected whenever the registry is synced, through the gossip (please, go to
+`discovery.go`) or the mirror (please, go to `mirror.go`): a record whose home node differs from the
+home node of the copy that the node already has is a conflict.
+
+The first record seen wins: the node keeps its copy and records the rejected one in the
+`client_conflicts` index, with the evidence of where it came from (e.g. the signed announcement of
+the claiming node). Every conflict is reported to the operators once, as a warning and as a
+"client.conflict" event (so it reaches the webhooks), and it's listed by the doctor.
+*/
+type ClientConflict struct {
+	ClientId   string        `json:"client_id"`   // The client id claimed twice
+	Kept       ForeignClient `json:"kept"`        // The record that the node had (and kept)
+	Rejected   ForeignClient `json:"rejected"`    // The record that claimed the client id afterwards
+	Source     string        `json:"source"`      // Where the rejected record came from, e.g. "gossip from <node id>"
+	Evidence   string        `json:"evidence"`    // The signed proof of the claim, when there is one
+	DetectedAt int64         `json:"detected_at"` // The timestamp when the conflict was detected
+}
+
+// Checks if two records of a client id belong to different home nodes
+func conflicting(kept, claimed ForeignClient) bool {
+	return kept.NodeAddress != "" && claimed.NodeAddress != "" && kept.NodeAddress != claimed.NodeAddress
+}
+
+// Records a conflict and alerts the operators, unless the same claim was already recorded
+func (n Node) recordClientConflict(ctx context.Context, conflict ClientConflict) error {
+	conflict.DetectedAt = timeutil.Now()
+
+	document, err := toDocument(conflict)
+	if err != nil {
+		return err
+	}
+
+	// A claim is recorded once by the home node it claims for, however often it's announced
+	id := sha256.Sum256([]byte(conflict.ClientId + "@" + conflict.Rejected.NodeAddress))
+	err = n.CreateDocument(ctx, "client_conflicts", hex.EncodeToString(id[:]), document)
+	if errors.Is(err, backlog.ErrConflict) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to record the conflict of %s: %v", conflict.ClientId, err)
+	}
+
+	Warnf(ctx, "the client %s was claimed by another node (%s): the first record seen was kept", conflict.ClientId, conflict.Source)
+
+	n.Emit(ctx, "client.conflict", map[string]interface{}{
+		"client_id":     conflict.ClientId,
+		"kept_node":     conflict.Kept.NodeAddress,
+		"rejected_node": conflict.Rejected.NodeAddress,
+		"source":        conflict.Source,
+	})
+
+	return nil
+}
+
+// Gives the conflicts recorded by the node
+func (n Node) ClientConflicts(ctx context.Context) ([]ClientConflict, error) {
+	documents, err := n.ListDocuments(ctx, "client_conflicts", backlog.ListOptions{All: true, Sort: []string{"detected_at:desc"}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the client conflicts: %v", err)
+	}
+
+	var conflicts []ClientConflict
+	for _, document := range documents {
+		id, _ := document["_id"].(string)
+		delete(document, "_id")
+
+		var conflict ClientConflict
+		if err := fromDocument("client_conflicts", id, document, &conflict, "client_id"); err != nil {
+			return nil, err
+		}
+
+		conflicts = append(conflicts, conflict)
+	}
+
+	return conflicts, nil
+}
+
+// Records the conflict between the copy of a document and the copy of the mirror
+func (n Node) recordMirrorConflict(ctx context.Context, index, id string, local, remote map[string]interface{}) error {
+	var kept, rejected ForeignClient
+	if err := fromDocument(index, id, local, &kept); err != nil {
+		return err
+	}
+
+	if err := fromDocument(index, id, remote, &rejected); err != nil {
+		return err
+	}
+
+	return n.recordClientConflict(ctx, ClientConflict{
+		ClientId: id,
+		Kept:     kept,
+		Rejected: rejected,
+		Source:   "mirror " + n.Mirror,
+	})
+}
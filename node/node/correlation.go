@@ -0,0 +1,56 @@
+package node
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+)
+
+type correlationKey struct{}
+
+// Generates a new random correlation id
+func NewCorrelationId() string {
+	id := make([]byte, 16)
+	rand.Read(id)
+
+	return hex.EncodeToString(id)
+}
+
+// Gives a context that carries the correlation id of the request
+func WithCorrelationId(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationKey{}, id)
+}
+
+// Gives the correlation id carried by the context (empty if there is none)
+func CorrelationId(ctx context.Context) string {
+	id, _ := ctx.Value(correlationKey{}).(string)
+	return id
+}
+
+// Prints a log line prefixed with the correlation id carried by the context
+func Logf(ctx context.Context, format string, args ...interface{}) {
+	if logged(LevelInfo) {
+		printf(ctx, format, args...)
+	}
+}
+
+// Prints a log line whatever the log level is
+func printf(ctx context.Context, format string, args ...interface{}) {
+	if id := CorrelationId(ctx); id != "" {
+		format = "[" + id + "] " + format
+	}
+
+	fmt.Printf(format+"\n", args...)
+}
+
+// Gives a context for the work that outlives the request (e.g. notifications sent in background).
+// It keeps the correlation id (and the trace) of the request, but not its deadline or cancellation
+func Detach(ctx context.Context) context.Context {
+	detached := WithCorrelationId(context.Background(), CorrelationId(ctx))
+	if Traced(ctx) {
+		detached = context.WithValue(detached, traceKey{}, true)
+	}
+
+	return detached
+}
@@ -0,0 +1,149 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	backlog "node/backlog"
+	timeutil "node/timeutil"
+)
+
+// The stages of the client creation, in the order they're performed
+const (
+	stageAlias     string = "alias"     // The alias was reserved
+	stageKeys      string = "keys"      // The key pair was written to the key path
+	stageDocuments string = "documents" // The local client, its cache and its foreign client were committed to the backlog
+)
+
+/*
+The client creation touches the filesystem and several indices, and any step can fail. To not
+leave partial clients behind, the creation is staged: a pending record is written to the
+`pending_clients` index before the first step and every stage is recorded in it before being
+performed (except the alias, which may belong to another client until it's reserved). When a step
+fails, the compensator undoes the recorded stages in the reverse order and removes the pending
+record. The compensations tolerate missing artifacts, so undoing a stage that was only partially
+performed is safe.
+
+If the process dies in the middle of a creation, the pending record survives and the startup
+recovery compensates it.
+*/
+type clientCreation struct {
+	node     Node
+	UID      string   `json:"uid"`       // The internal reference of the client being created
+	Alias    string   `json:"alias"`     // The alias reserved for the client
+	ClientId string   `json:"client_id"` // The external reference of the client (known after the keys stage)
+	Stages   []string `json:"stages"`    // The stages performed (or being performed)
+	Started  int64    `json:"started"`   // The timestamp when the creation started
+}
+
+func (c *clientCreation) document() map[string]interface{} {
+	return map[string]interface{}{
+		"uid":       c.UID,
+		"alias":     c.Alias,
+		"client_id": c.ClientId,
+		"stages":    c.Stages,
+		"started":   c.Started,
+	}
+}
+
+// Writes the pending record of the creation
+func (c *clientCreation) begin(ctx context.Context) error {
+	c.Started = timeutil.Now()
+
+	if err := c.node.IndexDocument(ctx, "pending_clients", c.UID, c.document()); err != nil {
+		return fmt.Errorf("failed to write the pending client: %v", err)
+	}
+
+	return nil
+}
+
+// Records a stage in the pending record before it's performed
+func (c *clientCreation) advance(ctx context.Context, stage string) error {
+	c.Stages = append(c.Stages, stage)
+
+	if err := c.node.IndexDocument(ctx, "pending_clients", c.UID, c.document()); err != nil {
+		return fmt.Errorf("failed to update the pending client: %v", err)
+	}
+
+	return nil
+}
+
+// Removes the pending record, since the client is complete
+func (c *clientCreation) commit(ctx context.Context) error {
+	if err := c.node.DeleteDocument(ctx, "pending_clients", c.UID); err != nil {
+		return fmt.Errorf("failed to remove the pending client: %v", err)
+	}
+
+	return nil
+}
+
+// Undoes the recorded stages in the reverse order and removes the pending record
+func (c *clientCreation) rollback(ctx context.Context) error {
+	var failures []error
+
+	for i := len(c.Stages) - 1; i >= 0; i-- {
+		var err error
+
+		switch c.Stages[i] {
+		case stageDocuments:
+			err = c.node.Begin().
+				Delete("clients", c.ClientId).
+				Delete("cache", c.UID).
+				Delete("local_clients", c.UID).
+				Apply(ctx)
+		case stageKeys:
+			if err = c.node.Keys.Delete(ctx, c.UID); err == nil {
+				err = c.node.DeleteDocument(ctx, "keys", c.UID)
+			}
+		case stageAlias:
+			err = c.node.ReleaseAlias(ctx, c.Alias)
+		}
+
+		if err != nil {
+			failures = append(failures, fmt.Errorf("failed to undo the %s stage: %v", c.Stages[i], err))
+		}
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("failed to compensate the creation of %s: %v", c.UID, failures)
+	}
+
+	return c.commit(ctx)
+}
+
+// Compensates the client creations interrupted by an unclean shutdown
+func recoverPendingClients(ctx context.Context, n *Node, unclean bool) ([]string, error) {
+	documents, err := n.ListDocuments(ctx, "pending_clients", backlog.ListOptions{
+		All:   true,
+		Query: backlog.Range("started", nil, timeutil.After(-recoveryGrace)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the pending clients: %v", err)
+	}
+
+	var repairs []string
+	for _, document := range documents {
+		creation := clientCreation{node: *n}
+		creation.UID, _ = document["uid"].(string)
+		creation.Alias, _ = document["alias"].(string)
+		creation.ClientId, _ = document["client_id"].(string)
+
+		stages, _ := document["stages"].([]interface{})
+		for _, stage := range stages {
+			if name, ok := stage.(string); ok {
+				creation.Stages = append(creation.Stages, name)
+			}
+		}
+
+		if err := creation.rollback(ctx); err != nil {
+			return repairs, err
+		}
+
+		repairs = append(repairs, fmt.Sprintf("compensated the interrupted creation of the client %s", creation.UID))
+	}
+
+	return repairs, nil
+}
+
+func init() {
+	RegisterRecoveryStep(recoverPendingClients)
+}
@@ -0,0 +1,93 @@
+package node
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	timeutil "node/timeutil"
+	"unicode"
+)
+
+/*
+A client connects with its alias, its password and its secret. The password is checked against the
+hash kept in its document before the secret opens its keys, and every wrong password is counted in
+the document (`failed_attempts`), so the operators can spot a client under attack.
+
+The clients created before the passwords were hashed right have the hash of an empty password. Their
+first connection with the right secret takes the given password as theirs.
+*/
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+var ErrWeakPassword = errors.New("invalid password: password must have at least 10 chars with major and minor letters and numbers")
+
+// Checks that a password has at least 10 chars with major and minor letters and numbers
+func CheckPasswordPolicy(password string) error {
+	var hasMin, hasMaj, hasNum bool
+	length := 0
+
+	for _, char := range password {
+		switch {
+		case unicode.IsLower(char):
+			hasMin = true
+		case unicode.IsUpper(char):
+			hasMaj = true
+		case unicode.IsDigit(char):
+			hasNum = true
+		}
+
+		length++
+	}
+
+	if length < 10 || !hasMin || !hasMaj || !hasNum {
+		return ErrWeakPassword
+	}
+
+	return nil
+}
+
+// Gives the hex hash of a password, as it's kept in the client document
+func hashPassword(password string) string {
+	hash := sha256.Sum256([]byte(password))
+	return hex.EncodeToString(hash[:])
+}
+
+// The hash kept for the clients created before the passwords were hashed right
+var legacyPasswordHash = hashPassword("")
+
+// Checks the password of a client against its hash. Gives whether the client has no password yet
+// (please, go to `legacyPasswordHash`)
+func (n Node) checkPassword(ctx context.Context, client *Client, password string) (bool, error) {
+	if client.Password == legacyPasswordHash {
+		return true, nil
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hashPassword(password)), []byte(client.Password)) == 1 {
+		if client.FailedAttempts > 0 {
+			client.FailedAttempts = 0
+			if err := n.UpdateDocument(ctx, "local_clients", client.UID, map[string]interface{}{"failed_attempts": 0}); err != nil {
+				Logf(ctx, "failed to reset the failed attempts of %s: %v", client.UID, err)
+			}
+		}
+
+		return false, nil
+	}
+
+	client.FailedAttempts++
+	err := n.UpdateDocument(ctx, "local_clients", client.UID, map[string]interface{}{
+		"failed_attempts": client.FailedAttempts,
+		"last_failed_at":  timeutil.Now(),
+	})
+	if err != nil {
+		Logf(ctx, "failed to count the failed attempt of %s: %v", client.UID, err)
+	}
+
+	n.Emit(ctx, "client.connection_failed", map[string]interface{}{
+		"uid":             client.UID,
+		"failed_attempts": client.FailedAttempts,
+	})
+
+	return false, fmt.Errorf("%w: wrong password", ErrInvalidCredentials)
+}
@@ -0,0 +1,249 @@
+package node
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	client "node/client"
+	timeutil "node/timeutil"
+)
+
+// The layout version of the custody bundles
+const custodyVersion int = 1
+
+/*
+A custody bundle is a self-contained file that proves a transaction to an auditor, without access
+to any node: the transaction, the header of its block, the proof that the block includes it, the
+public keys of the parties and all the signatures (of the sender and, when the transaction was
+acknowledged, of the recipient).
+
+The proof of inclusion is the list of the block transactions, since the block hash commits to all
+of them. The signatures are binary, so they're kept as base64 in the file (the []byte fields).
+*/
+type CustodyBundle struct {
+	Version            int                    `json:"version"`                  // The layout version of the bundle
+	ExportedAt         int64                  `json:"exported_at"`              // The timestamp when the bundle was exported
+	TransactionId      string                 `json:"transaction_id"`           // The id of the proved transaction
+	Block              CustodyBlock           `json:"block"`                    // The header of the block that includes the transaction
+	Proof              []CustodyTransaction   `json:"proof"`                    // The transactions of the block, in order
+	SenderPublicKey    string                 `json:"sender_public_key"`        // The identity of the sender public key
+	RecipientPublicKey string                 `json:"recipient_public_key"`     // The identity of the recipient public key
+	Acknowledgment     *CustodyAcknowledgment `json:"acknowledgment,omitempty"` // The receipt signed by the recipient, when there's one
+}
+
+// The header of a block (every field but its transactions)
+type CustodyBlock struct {
+	Height       int64  `json:"height"`
+	PreviousHash string `json:"previous_hash"`
+	Timestamp    int64  `json:"timestamp"`
+	Nonce        int64  `json:"nonce"`
+	Producer     string `json:"producer,omitempty"`
+	FeeRecipient string `json:"fee_recipient,omitempty"`
+	MerkleRoot   string `json:"merkle_root,omitempty"`
+	Hash         string `json:"hash"`
+}
+
+// A block transaction with its binary signature
+type CustodyTransaction struct {
+	TransactionId string  `json:"transaction_id"`
+	Sender        string  `json:"sender"`
+	Recipient     string  `json:"recipient"`
+	Value         float64 `json:"value"`
+	Fee           float64 `json:"fee,omitempty"`
+	Payload       []byte  `json:"payload,omitempty"`
+	Timestamp     int64   `json:"timestamp"`
+	Sequence      int64   `json:"sequence"`
+	Memo          string  `json:"memo,omitempty"`
+	Tag           string  `json:"tag,omitempty"`
+	Signature     []byte  `json:"signature"`
+}
+
+// An acknowledgment with its binary signatures
+type CustodyAcknowledgment struct {
+	Recipient   string `json:"recipient"`
+	Transaction []byte `json:"transaction"`
+	SignedAt    int64  `json:"signed_at"`
+	Signature   []byte `json:"signature"`
+}
+
+// Exports the custody bundle of a confirmed transaction
+func (n Node) ExportCustody(ctx context.Context, transactionId string) (*CustodyBundle, error) {
+	transaction, err := n.signedTransaction(ctx, transactionId)
+	if err != nil {
+		return nil, err
+	}
+
+	if transaction.BlockHash == "" {
+		return nil, fmt.Errorf("the transaction %s isn't confirmed in a block yet", transactionId)
+	}
+
+	document, err := n.GetDocument(ctx, "blockchain", transaction.BlockHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the block of the transaction: %v", err)
+	}
+
+	block, err := blockFromDocument(document)
+	if err != nil {
+		return nil, err
+	}
+
+	bundle := CustodyBundle{
+		Version:       custodyVersion,
+		ExportedAt:    timeutil.Now(),
+		TransactionId: transactionId,
+		Block: CustodyBlock{
+			Height:       block.Height,
+			PreviousHash: block.PreviousHash,
+			Timestamp:    block.Timestamp,
+			Nonce:        block.Nonce,
+			Producer:     block.Producer,
+			FeeRecipient: block.FeeRecipient,
+			MerkleRoot:   block.MerkleRoot,
+			Hash:         block.Hash,
+		},
+		SenderPublicKey:    transaction.Sender,
+		RecipientPublicKey: transaction.Recipient,
+	}
+
+	for _, t := range block.Transactions {
+		bundle.Proof = append(bundle.Proof, CustodyTransaction{
+			TransactionId: t.TransactionId,
+			Sender:        t.Sender,
+			Recipient:     t.Recipient,
+			Value:         t.Value,
+			Fee:           t.Fee,
+			Payload:       t.Payload,
+			Timestamp:     t.Timestamp,
+			Sequence:      t.Sequence,
+			Memo:          t.Memo,
+			Tag:           t.Tag,
+			Signature:     []byte(t.Signature),
+		})
+	}
+
+	if a := transaction.Acknowledgment; a != nil {
+		bundle.Acknowledgment = &CustodyAcknowledgment{
+			Recipient:   a.Recipient,
+			Transaction: []byte(a.Transaction),
+			SignedAt:    a.SignedAt,
+			Signature:   []byte(a.Signature),
+		}
+	}
+
+	return &bundle, nil
+}
+
+// Reads a custody bundle from its file content
+func ParseCustody(content []byte) (*CustodyBundle, error) {
+	var bundle CustodyBundle
+	if err := json.Unmarshal(content, &bundle); err != nil {
+		return nil, fmt.Errorf("failed to read the custody bundle: %v", err)
+	}
+
+	if bundle.Version != custodyVersion {
+		return nil, fmt.Errorf("unsupported custody bundle version %d", bundle.Version)
+	}
+
+	return &bundle, nil
+}
+
+// Checks the custody bundle offline and gives the findings of each check
+func (b CustodyBundle) Verify() []Finding {
+	var findings []Finding
+	check := func(name string, err error, detail string) bool {
+		if err != nil {
+			findings = append(findings, Finding{Check: name, Ok: false, Detail: err.Error()})
+			return false
+		}
+
+		findings = append(findings, Finding{Check: name, Ok: true, Detail: detail})
+		return true
+	}
+
+	block := Block{
+		Height:       b.Block.Height,
+		PreviousHash: b.Block.PreviousHash,
+		Timestamp:    b.Block.Timestamp,
+		Nonce:        b.Block.Nonce,
+		Producer:     b.Block.Producer,
+		FeeRecipient: b.Block.FeeRecipient,
+		MerkleRoot:   b.Block.MerkleRoot,
+		Hash:         b.Block.Hash,
+	}
+
+	var transaction *BlockTransaction
+	for _, t := range b.Proof {
+		blockTransaction := BlockTransaction{
+			TransactionId: t.TransactionId,
+			Sender:        t.Sender,
+			Recipient:     t.Recipient,
+			Value:         t.Value,
+			Fee:           t.Fee,
+			Payload:       t.Payload,
+			Timestamp:     t.Timestamp,
+			Sequence:      t.Sequence,
+			Memo:          t.Memo,
+			Tag:           t.Tag,
+			Signature:     string(t.Signature),
+		}
+
+		block.Transactions = append(block.Transactions, blockTransaction)
+		if t.TransactionId == b.TransactionId {
+			transaction = &block.Transactions[len(block.Transactions)-1]
+		}
+	}
+
+	var err error
+	if block.ComputeHash() != block.Hash {
+		err = fmt.Errorf("the block hash doesn't match its content")
+	} else if MerkleRoot(block.Transactions) != block.MerkleRoot {
+		err = fmt.Errorf("the transactions of the block don't match its Merkle root")
+	} else if !block.Solved() {
+		err = fmt.Errorf("the block hash doesn't satisfy the difficulty")
+	}
+	check("block", err, fmt.Sprintf("the block %d (%s) is intact", block.Height, block.Hash))
+
+	if transaction == nil {
+		check("inclusion", fmt.Errorf("the block doesn't include the transaction %s", b.TransactionId), "")
+		return findings
+	}
+	check("inclusion", nil, fmt.Sprintf("the block includes the transaction %s", b.TransactionId))
+
+	err = nil
+	if transaction.Sender != b.SenderPublicKey {
+		err = fmt.Errorf("the sender of the transaction isn't the owner of the sender public key")
+	} else if key, parseErr := client.ParseIdentity(b.SenderPublicKey); parseErr != nil {
+		err = fmt.Errorf("the sender public key is invalid: %v", parseErr)
+	} else if client.VerifySignature(key, *transaction, transaction.Signature) != nil {
+		err = fmt.Errorf("the transaction isn't signed by its sender")
+	}
+	check("sender signature", err, "the transaction is signed by its sender")
+
+	if b.Acknowledgment == nil {
+		findings = append(findings, Finding{Check: "acknowledgment", Ok: true, Detail: "the transaction wasn't acknowledged by its recipient"})
+		return findings
+	}
+
+	err = nil
+	acknowledgment := Acknowledgment{
+		TransactionId: b.TransactionId,
+		Recipient:     b.Acknowledgment.Recipient,
+		Transaction:   string(b.Acknowledgment.Transaction),
+		SignedAt:      b.Acknowledgment.SignedAt,
+		Signature:     string(b.Acknowledgment.Signature),
+	}
+
+	if acknowledgment.Recipient != b.RecipientPublicKey {
+		err = fmt.Errorf("the acknowledgment isn't made by the owner of the recipient public key")
+	} else {
+		record := TransactionRecord{BlockTransaction: *transaction}
+		// The chain only has the stealth address of the recipient (please, go to `stealth.go`)
+		if record.Tag != "" {
+			record.Recipient = b.RecipientPublicKey
+		}
+		err = acknowledgment.Verify(record)
+	}
+	check("acknowledgment", err, "the transaction is acknowledged by its recipient")
+
+	return findings
+}
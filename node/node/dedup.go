@@ -0,0 +1,122 @@
+package node
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	backlog "node/backlog"
+	config "node/config"
+	timeutil "node/timeutil"
+)
+
+// The states of a deduplicated request
+const (
+	requestRunning string = "running" // The first call with the request id still runs
+	requestDone    string = "done"    // The call succeeded, and its response is replayed to the retries
+)
+
+var (
+	ErrRequestInFlight = errors.New("a call with the same request id is still running")
+	ErrRequestReused   = errors.New("the request id was already used for another request")
+)
+
+/*
+The mobile clients retry the calls whose response was lost on a flaky connection, so the mutating
+calls take a request id generated by the client (please, go to `dedup.go` in the server). The first
+call with an id reserves it in the `requests` index, and its response is stored once it succeeds: a
+retry within the REQUEST_DEDUP_WINDOW is given the stored response instead of running again, so it
+never creates a client or a transaction twice.
+
+A retry that arrives while the first call still runs is refused, and a failed call releases its id,
+so its retries run again. Every request is stored with the fingerprint of its payload, so an id
+reused for another request is refused instead of given a response that isn't its own.
+*/
+type deduplicatedRequest struct {
+	Fingerprint string `json:"fingerprint"`        // The hash of the request payload
+	Status      string `json:"status"`             // The state of the request (e.g. requestRunning)
+	CreatedAt   int64  `json:"created_at"`         // The timestamp when the first call arrived
+	Response    string `json:"response,omitempty"` // The stored response (as base64), once the call succeeded
+}
+
+// Reserves a request id for a call. Gives the response stored for a former call with the same id, or
+// nil when the call must run
+func (n Node) ReserveRequest(ctx context.Context, key, fingerprint string) ([]byte, error) {
+	reserved := deduplicatedRequest{Fingerprint: fingerprint, Status: requestRunning, CreatedAt: timeutil.Now()}
+	document, err := toDocument(reserved)
+	if err != nil {
+		return nil, err
+	}
+
+	err = n.CreateDocument(ctx, "requests", key, document)
+	if err == nil {
+		return nil, nil
+	}
+
+	if !errors.Is(err, backlog.ErrConflict) {
+		return nil, fmt.Errorf("failed to reserve the request: %v", err)
+	}
+
+	stored, version, err := n.GetVersionedDocument(ctx, "requests", key)
+	if errors.Is(err, backlog.ErrNotFound) {
+		// The first call failed and released the id in the meantime, so the retry is told to try again
+		return nil, ErrRequestInFlight
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the request: %v", err)
+	}
+
+	request := deduplicatedRequest{}
+	if err := fromDocument("requests", key, stored, &request, "fingerprint", "status"); err != nil {
+		return nil, err
+	}
+
+	// The id is free again once its window is over (the janitor deletes it eventually)
+	if request.CreatedAt <= timeutil.After(-config.DedupWindow()) {
+		if err := n.ReplaceDocumentIf(ctx, "requests", key, document, version); errors.Is(err, backlog.ErrConflict) {
+			return nil, ErrRequestInFlight
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to reserve the request: %v", err)
+		}
+
+		return nil, nil
+	}
+
+	if request.Fingerprint != fingerprint {
+		return nil, ErrRequestReused
+	}
+
+	if request.Status != requestDone {
+		return nil, ErrRequestInFlight
+	}
+
+	response, err := base64.StdEncoding.DecodeString(request.Response)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode the stored response: %v", err)
+	}
+
+	return response, nil
+}
+
+// Stores the response of a call that succeeded, so it's replayed to its retries
+func (n Node) CompleteRequest(ctx context.Context, key string, response []byte) error {
+	err := n.UpdateDocument(ctx, "requests", key, map[string]interface{}{
+		"status":   requestDone,
+		"response": base64.StdEncoding.EncodeToString(response),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store the response of the request: %v", err)
+	}
+
+	return nil
+}
+
+// Releases the request id of a call that failed, so its retries run again
+func (n Node) ReleaseRequest(ctx context.Context, key string) error {
+	if err := n.DeleteDocument(ctx, "requests", key); err != nil {
+		return fmt.Errorf("failed to release the request: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,134 @@
+package node
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	client "node/client"
+	timeutil "node/timeutil"
+	"sync"
+	"time"
+)
+
+// The limit to announce the departure to all the peers, so the node doesn't hang on exit
+const departureTimeout time.Duration = 5 * time.Second
+
+/*
+A departure is the signal that a node sends to its peers when it stops, so they mark it as
+hibernating and stop routing lookups to it right away, instead of finding it out by failing
+calls. Like the address changes, the departure is signed by the node key and only accepted
+by the peers when the signature matches the public key they know from the node.
+*/
+type Departure struct {
+	NodeId    string `json:"node_id"`    // The id of the node that is leaving
+	ResumesAt int64  `json:"resumes_at"` // The timestamp when the node expects to be back (zero when unknown)
+	LeftAt    int64  `json:"left_at"`    // The timestamp when the node left
+	Signature string `json:"signature"`  // The signature made by the node key
+}
+
+// Converts the departure (except the signature) to a signable byte array
+func (d Departure) ToBytes() ([]byte, error) {
+	departure := map[string]interface{}{
+		"node_id":    d.NodeId,
+		"resumes_at": d.ResumesAt,
+		"left_at":    d.LeftAt,
+	}
+
+	departureBytes, err := json.Marshal(departure)
+	return departureBytes, err
+}
+
+// Verifies the signature of the departure with the public key (identity) of the node
+func (d Departure) Verify(publicKey string) error {
+	if err := client.VerifyWithIdentity(publicKey, d, d.Signature); err != nil {
+		return fmt.Errorf("the departure isn't signed by the node %s", d.NodeId)
+	}
+
+	return nil
+}
+
+// Announces the departure of the node to the alive peers, in parallel and within a time limit
+func (n Node) announceDeparture(ctx context.Context) {
+	if peerTransport == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, departureTimeout)
+	defer cancel()
+
+	departure := Departure{
+		NodeId:    n.Id,
+		ResumesAt: n.ResumesAt,
+		LeftAt:    timeutil.Now(),
+	}
+
+	signature, err := n.Key.CreateSignature(departure)
+	if err != nil {
+		Logf(ctx, "failed to sign the departure: %v", err)
+		return
+	}
+	departure.Signature = signature
+
+	hosts, err := n.AlivePeers(ctx)
+	if err != nil {
+		Logf(ctx, "failed to announce the departure: %v", err)
+		return
+	}
+
+	var wg sync.WaitGroup
+	for _, host := range hosts {
+		wg.Add(1)
+
+		go func(host string) {
+			defer wg.Done()
+
+			if err := peerTransport.AnnounceDeparture(ctx, host, departure); err != nil {
+				Logf(ctx, "failed to announce the departure to %s: %v", host, err)
+			}
+		}(host)
+	}
+
+	wg.Wait()
+}
+
+// Marks a peer as hibernating after verifying that the peer signed its departure. A signed departure
+// can be announced again by anyone, so it's ignored unless it's newer than the last status change of
+// the peer: its last departure and the last time it was seen alive
+func (n Node) AcceptDeparture(ctx context.Context, departure Departure) error {
+	document, err := n.GetDocument(ctx, "peers", departure.NodeId)
+	if err != nil {
+		return fmt.Errorf("the node %s isn't a known peer", departure.NodeId)
+	}
+
+	publicKey, _ := document["public_key"].(string)
+	if publicKey == "" {
+		return fmt.Errorf("the peer %s has no known public key", departure.NodeId)
+	}
+
+	if err := departure.Verify(publicKey); err != nil {
+		return err
+	}
+
+	leftAt, _ := document["left_at"].(float64)
+	lastSeenAt, _ := document["last_seen_at"].(float64)
+	if departure.LeftAt <= int64(leftAt) || departure.LeftAt <= int64(lastSeenAt) {
+		Debugf(ctx, SubsystemGossip, "ignored the departure of %s older than its last status change", departure.NodeId)
+		return nil
+	}
+
+	err = n.UpdateDocument(ctx, "peers", departure.NodeId, map[string]interface{}{
+		"status":     NodeHibernating,
+		"resumes_at": departure.ResumesAt,
+		"left_at":    departure.LeftAt,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update the peer status: %v", err)
+	}
+
+	n.Emit(ctx, "peer.departed", map[string]interface{}{
+		"node_id":    departure.NodeId,
+		"resumes_at": departure.ResumesAt,
+	})
+
+	return nil
+}
@@ -0,0 +1,210 @@
+package node
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	backlog "node/backlog"
+	config "node/config"
+	timeutil "node/timeutil"
+	"time"
+)
+
+// The platforms of the push notification services supported by the node
+const (
+	PlatformFCM  string = "fcm"
+	PlatformAPNs string = "apns"
+)
+
+// A mobile device where a client wants to receive push notifications
+type Device struct {
+	UID          string `json:"uid"`           // The internal reference of the client that owns the device
+	ClientId     string `json:"client_id"`     // The external reference of the client, used to find the recipients of transactions
+	Platform     string `json:"platform"`      // The push notification service (fcm or apns)
+	Token        string `json:"token"`         // The token (or endpoint) given by the push notification service
+	RegisteredAt int64  `json:"registered_at"` // The timestamp when the device was registered
+}
+
+// A message delivered to the devices of a client
+type Notification struct {
+	Event string                 `json:"event"` // The kind of the notification
+	Title string                 `json:"title"` // A short human readable text
+	Data  map[string]interface{} `json:"data"`  // The details of the notification
+}
+
+/*
+A push provider delivers notifications to some push notification service. The providers are
+registered by platform, so deployments can plug the FCM/APNs SDKs without changing the node.
+
+When PUSH_RELAY_URL is set, a relay provider is used for the platforms without a provider: it posts the
+device and the notification as JSON to the relay, that must forward it to the right service.
+*/
+type PushProvider interface {
+	Send(device Device, notification Notification) error
+}
+
+var pushProviders = map[string]PushProvider{}
+
+// Registers the provider that delivers the notifications of some platform
+func RegisterPushProvider(platform string, provider PushProvider) {
+	pushProviders[platform] = provider
+}
+
+// A provider that forwards the notifications to an HTTP relay
+type RelayPushProvider struct {
+	URL string
+}
+
+func (r RelayPushProvider) Send(device Device, notification Notification) error {
+	payload, err := json.Marshal(map[string]interface{}{
+		"platform":     device.Platform,
+		"token":        device.Token,
+		"notification": notification,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal the notification: %v", err)
+	}
+
+	client := http.Client{Timeout: 5 * time.Second}
+	res, err := client.Post(r.URL, "application/json", bytes.NewBuffer(payload))
+	if err != nil {
+		return fmt.Errorf("failed to post the notification: %v", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 300 {
+		return fmt.Errorf("failed to post the notification: %s", res.Status)
+	}
+
+	return nil
+}
+
+// Gives the provider of some platform, falling back to the relay when it's configured
+func pushProviderFor(platform string) (PushProvider, bool) {
+	if provider, ok := pushProviders[platform]; ok {
+		return provider, true
+	}
+
+	if url := config.PushRelayURL(); url != "" {
+		return RelayPushProvider{URL: url}, true
+	}
+
+	return nil, false
+}
+
+// Gives the id of the device document, so a device registered twice is stored once
+func deviceId(platform, token string) string {
+	hash := sha256.Sum256([]byte(platform + ":" + token))
+	return hex.EncodeToString(hash[:])
+}
+
+// Registers a device of a client to receive push notifications
+func (n Node) RegisterDevice(ctx context.Context, uid, platform, token string) (*Device, error) {
+	if platform != PlatformFCM && platform != PlatformAPNs {
+		return nil, fmt.Errorf("unknown platform %s", platform)
+	}
+
+	clientData, err := n.GetDocument(ctx, "local_clients", uid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the client document: %v", err)
+	}
+
+	clientId, _ := clientData["client_id"].(string)
+	device := Device{
+		UID:          uid,
+		ClientId:     clientId,
+		Platform:     platform,
+		Token:        token,
+		RegisteredAt: timeutil.Now(),
+	}
+
+	document := map[string]interface{}{
+		"uid":           device.UID,
+		"client_id":     device.ClientId,
+		"platform":      device.Platform,
+		"token":         device.Token,
+		"registered_at": device.RegisteredAt,
+	}
+
+	if err := n.IndexDocument(ctx, "devices", deviceId(platform, token), document); err != nil {
+		return nil, fmt.Errorf("failed to store the device: %v", err)
+	}
+
+	return &device, nil
+}
+
+// Gives the devices registered by the client with the given client id
+func (n Node) ClientDevices(ctx context.Context, clientId string) ([]Device, error) {
+	documents, _, err := n.FindDocuments(ctx, "devices", backlog.Term("client_id", clientId), backlog.ListOptions{All: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search the devices: %v", err)
+	}
+
+	var devices []Device
+	for _, document := range documents {
+		device := Device{}
+		device.UID, _ = document["uid"].(string)
+		device.ClientId, _ = document["client_id"].(string)
+		device.Platform, _ = document["platform"].(string)
+		device.Token, _ = document["token"].(string)
+
+		if registeredAt, ok := document["registered_at"].(float64); ok {
+			device.RegisteredAt = int64(registeredAt)
+		}
+
+		devices = append(devices, device)
+	}
+
+	return devices, nil
+}
+
+// Delivers a notification to all the devices of a client. Devices without a provider are skipped
+func (n Node) Push(ctx context.Context, clientId string, notification Notification) error {
+	devices, err := n.ClientDevices(ctx, clientId)
+	if err != nil {
+		return err
+	}
+
+	for _, device := range devices {
+		provider, ok := pushProviderFor(device.Platform)
+		if !ok {
+			continue
+		}
+
+		if err := provider.Send(device, notification); err != nil {
+			Warnf(ctx, "failed to push the notification to a %s device: %v", device.Platform, err)
+		}
+	}
+
+	return nil
+}
+
+// Gives the notification of a received transaction
+func transactionNotification(transactionId, sender string, value float64, timestamp int64) Notification {
+	return Notification{
+		Event: "transaction.received",
+		Title: "You received a transaction",
+		Data: map[string]interface{}{
+			"transaction_id": transactionId,
+			"sender":         sender,
+			"value":          value,
+			"timestamp":      timestamp,
+		},
+	}
+}
+
+// Notifies the recipient of a transaction in its devices. When the recipient belongs to another
+// node, the transaction is also routed to that node (please, go to `routing.go` to see more about it)
+func (n Node) NotifyTransactionReceived(ctx context.Context, t Transaction) {
+	notification := transactionNotification(t.TransactionId, t.Sender.ClientId, t.Value, t.Timestamp)
+
+	if err := n.Push(ctx, t.Recipient.ClientId, notification); err != nil {
+		Warnf(ctx, "failed to notify the recipient of the transaction %s: %v", t.TransactionId, err)
+	}
+
+	n.routeTransaction(ctx, t)
+}
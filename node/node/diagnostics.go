@@ -0,0 +1,86 @@
+package node
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	backlog "node/backlog"
+	config "node/config"
+	"os"
+	"path/filepath"
+	"runtime/pprof"
+	"time"
+)
+
+/*
+A diagnostic is a section of the diagnostic bundle. It gives a JSON-encodable value that
+describes the state of some part of the node.
+
+The bundle is written under BASE_PATH/diagnostics and can be attached to bug reports. Besides
+the registered sections, the bundle always contains the goroutine stacks.
+*/
+type Diagnostic func(ctx context.Context, n Node) (interface{}, error)
+
+var diagnostics = map[string]Diagnostic{
+	"config": func(ctx context.Context, n Node) (interface{}, error) {
+		return config.Snapshot(), nil
+	},
+	"stats": func(ctx context.Context, n Node) (interface{}, error) {
+		return n.Stats()
+	},
+	"peers": func(ctx context.Context, n Node) (interface{}, error) {
+		return n.ListDocuments(ctx, "peers", backlog.ListOptions{All: true})
+	},
+	"client_conflicts": func(ctx context.Context, n Node) (interface{}, error) {
+		return n.ClientConflicts(ctx)
+	},
+	"mempool": func(ctx context.Context, n Node) (interface{}, error) {
+		pending, err := n.CountDocuments(ctx, "transactions", mempoolQuery)
+
+		return map[string]interface{}{"pending": pending}, err
+	},
+}
+
+// Registers a section in the diagnostic bundle
+func RegisterDiagnostic(name string, diagnostic Diagnostic) {
+	diagnostics[name] = diagnostic
+}
+
+// Writes the diagnostic bundle and gives the directory where it was written
+func (n Node) DumpDiagnostics(ctx context.Context) (string, error) {
+	dir := filepath.Join(config.BasePath(), "diagnostics", time.Now().UTC().Format("20060102T150405Z"))
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create path \"%s\": %v", dir, err)
+	}
+
+	stacks, err := os.Create(filepath.Join(dir, "goroutines.txt"))
+	if err != nil {
+		return dir, fmt.Errorf("failed to create the goroutines dump: %v", err)
+	}
+	defer stacks.Close()
+
+	if err := pprof.Lookup("goroutine").WriteTo(stacks, 2); err != nil {
+		return dir, fmt.Errorf("failed to dump the goroutines: %v", err)
+	}
+
+	for name, diagnostic := range diagnostics {
+		value, err := diagnostic(ctx, n)
+
+		// A failing section must not prevent the others from being written
+		section := map[string]interface{}{"data": value}
+		if err != nil {
+			section["error"] = err.Error()
+		}
+
+		content, err := json.MarshalIndent(section, "", "  ")
+		if err != nil {
+			return dir, fmt.Errorf("failed to marshal the %s diagnostic: %v", name, err)
+		}
+
+		if err := os.WriteFile(filepath.Join(dir, name+".json"), content, 0600); err != nil {
+			return dir, fmt.Errorf("failed to write the %s diagnostic: %v", name, err)
+		}
+	}
+
+	return dir, nil
+}
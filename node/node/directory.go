@@ -0,0 +1,142 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	backlog "node/backlog"
+	timeutil "node/timeutil"
+	"sort"
+	"time"
+)
+
+/*
+A listing is what the node tells the wallet apps about a peer, so they can pick an alternative
+node when their home node is hibernating. Besides the address, it carries the capabilities of the
+peer and the load hints that the peer recorded in its last metrics snapshot (please, go to
+`metrics.go` to see more about it).
+*/
+type NodeListing struct {
+	NodeId         string // The stable identity of the peer
+	Host           string // The host address where the peer serves the gRPC API
+	Version        string // Identifier of the source code that's running on the peer
+	SchemaVersion  int    // The layout of the documents written by the peer
+	AcceptsWrites  bool   // Whether the peer accepts writes (it's not in read-only mode)
+	MempoolSize    int64  // The number of transactions waiting for a block in the peer
+	RPCCalls       int64  // The number of RPC calls that the peer served in its last metrics interval
+	LoadMeasuredAt int64  // The timestamp of the load hints (zero when the peer never recorded them)
+	Latency        int64  // The round trip of the last exchange with the peer, in milliseconds (zero when never measured)
+	LastSeenAt     int64  // The timestamp of the last exchange with the peer (zero when it was never reached)
+}
+
+// The alternative nodes given to a client when it connects, and how recently they must have been
+// reached to be given
+const (
+	nearestNodes      int           = 3
+	nearestNodesFresh time.Duration = 5 * time.Minute
+)
+
+// Stores the load hints of the node in its peer record, so they are listed together with it
+func (n Node) publishLoad(ctx context.Context, metrics NodeMetrics) error {
+	err := n.UpdateDocument(ctx, "peers", n.Id, map[string]interface{}{
+		"load": map[string]interface{}{
+			"mempool_size": metrics.MempoolSize,
+			"rpc_calls":    metrics.RPCCalls,
+			"measured_at":  metrics.Timestamp,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to publish the node load: %v", err)
+	}
+
+	return nil
+}
+
+// Gives the known alive nodes (the current one included), the least loaded first
+func (n Node) ListNodes(ctx context.Context) ([]NodeListing, error) {
+	documents, err := n.ListDocuments(ctx, "peers", backlog.ListOptions{All: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the peers: %v", err)
+	}
+
+	var listings []NodeListing
+	for _, document := range documents {
+		status, _ := document["status"].(string)
+		host, _ := document["host"].(string)
+
+		if host == "" || NodeStatus(status) != NodeAlive {
+			continue
+		}
+
+		listing := NodeListing{Host: host}
+		listing.NodeId, _ = document["node_id"].(string)
+		listing.Version, _ = document["version"].(string)
+
+		readOnly, _ := document["read_only"].(bool)
+		listing.AcceptsWrites = !readOnly
+
+		if version, ok := document["schema_version"].(float64); ok {
+			listing.SchemaVersion = int(version)
+		}
+
+		latency, _ := document["latency"].(float64)
+		lastSeenAt, _ := document["last_seen_at"].(float64)
+		listing.Latency, listing.LastSeenAt = int64(latency), int64(lastSeenAt)
+
+		if load, ok := document["load"].(map[string]interface{}); ok {
+			value := func(key string) int64 {
+				number, _ := load[key].(float64)
+				return int64(number)
+			}
+
+			listing.MempoolSize = value("mempool_size")
+			listing.RPCCalls = value("rpc_calls")
+			listing.LoadMeasuredAt = value("measured_at")
+		}
+
+		listings = append(listings, listing)
+	}
+
+	sort.SliceStable(listings, func(i, j int) bool {
+		if listings[i].MempoolSize != listings[j].MempoolSize {
+			return listings[i].MempoolSize < listings[j].MempoolSize
+		}
+
+		return listings[i].RPCCalls < listings[j].RPCCalls
+	})
+
+	return listings, nil
+}
+
+// Gives the alive peers that a client can fail over to, the nearest first: the peers that accept
+// writes before the read-only ones, and then by the latency measured in the last exchange with them
+// (please, go to `peermanager.go`). The peers that weren't reached lately are left out, since their
+// status may be stale
+func (n Node) NearestNodes(ctx context.Context) ([]NodeListing, error) {
+	listings, err := n.ListNodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	fresh := timeutil.After(-nearestNodesFresh)
+
+	var nearest []NodeListing
+	for _, listing := range listings {
+		if listing.NodeId != n.Id && listing.Host != n.Host && listing.LastSeenAt >= fresh {
+			nearest = append(nearest, listing)
+		}
+	}
+
+	sort.SliceStable(nearest, func(i, j int) bool {
+		if nearest[i].AcceptsWrites != nearest[j].AcceptsWrites {
+			return nearest[i].AcceptsWrites
+		}
+
+		return nearest[i].Latency < nearest[j].Latency
+	})
+
+	if len(nearest) > nearestNodes {
+		nearest = nearest[:nearestNodes]
+	}
+
+	return nearest, nil
+}
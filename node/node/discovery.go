@@ -0,0 +1,256 @@
+package node
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	backlog "node/backlog"
+	client "node/client"
+	config "node/config"
+	hashing "node/hashing"
+	timeutil "node/timeutil"
+)
+
+/*
+A transaction can only be sent to a client that the node knows, so the nodes share the foreign
+client records of their own clients with the peers. A singleton job announces to every alive peer
+the records of the local clients changed since its last round (all of them, after a restart), and
+the peers store the records they don't have or that are newer than their copy, so the same record
+announced twice (or reached through the mirror as well) is stored once. A record that claims the
+client id of a client of another node is a conflict (please, go to `conflicts.go`).
+
+An announcement is signed by the node key and it's only accepted from the home node of the client,
+so a peer can't make up the clients of another node. The records of the erased clients are never
+revived: the erasure is final (please, go to `erasure.go`).
+*/
+type ClientAnnouncement struct {
+	NodeId      string        `json:"node_id"`      // The id of the home node of the client
+	Client      ForeignClient `json:"client"`       // The foreign client record
+	AnnouncedAt int64         `json:"announced_at"` // The timestamp when the record was announced
+	Signature   string        `json:"signature"`    // The signature made by the node key
+}
+
+// Converts the announcement (except the signature) to a signable byte array
+func (a ClientAnnouncement) ToBytes() ([]byte, error) {
+	announcement := map[string]interface{}{
+		"node_id":      a.NodeId,
+		"client":       a.Client,
+		"announced_at": a.AnnouncedAt,
+	}
+
+	announcementBytes, err := json.Marshal(announcement)
+	return announcementBytes, err
+}
+
+// Verifies the signature of the announcement with the public key (identity) of the node
+func (a ClientAnnouncement) Verify(publicKey string) error {
+	if err := client.VerifyWithIdentity(publicKey, a, a.Signature); err != nil {
+		return fmt.Errorf("the announcement of %s isn't signed by the node %s", a.Client.ClientId, a.NodeId)
+	}
+
+	return nil
+}
+
+// Gives whether a node address is the hash of the current host of the node or of a former one
+func (n Node) ownsAddress(nodeAddress string, formerHosts []string) bool {
+	if hashing.MatchesAddress(nodeAddress, n.Host) {
+		return true
+	}
+
+	for _, host := range formerHosts {
+		if hashing.MatchesAddress(nodeAddress, host) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Gives the hosts that the node had before the current one
+func (n Node) formerHosts(ctx context.Context) ([]string, error) {
+	changes, _, err := n.FindDocuments(ctx, "addresses", backlog.Term("node_id", n.Id), backlog.ListOptions{All: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the address changes: %v", err)
+	}
+
+	var hosts []string
+	for _, change := range changes {
+		if previous, _ := change["previous"].(string); previous != "" {
+			hosts = append(hosts, previous)
+		}
+	}
+
+	return hosts, nil
+}
+
+// Announces the records of the local clients changed since the watermark to the alive peers. Gives
+// the number of records announced
+func (n Node) announceClients(ctx context.Context, lease Lease, watermark *int64) (int, error) {
+	if peerTransport == nil || !config.FeatureEnabled(config.FeatureGossip) {
+		return 0, nil
+	}
+
+	hosts, err := n.AlivePeers(ctx)
+	if err != nil || len(hosts) == 0 {
+		return 0, err
+	}
+
+	formerHosts, err := n.formerHosts(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	announced := 0
+	since, afterId := *watermark, ""
+
+	for {
+		documents, err := n.DocumentsSince(ctx, "clients", since, afterId)
+		if err != nil {
+			return announced, err
+		}
+
+		// The announcements may take long enough for another process to take the gossip over
+		if err := n.CheckLease(ctx, lease); err != nil {
+			return announced, err
+		}
+
+		for _, document := range documents {
+			id, _ := document["_id"].(string)
+			since, afterId = documentTimestamp(document, "updated_at"), id
+			delete(document, "_id")
+
+			var foreign ForeignClient
+			if err := fromDocument("clients", id, document, &foreign, "client_id", "node"); err != nil {
+				Logf(ctx, "failed to announce the client %s: %v", id, err)
+				continue
+			}
+
+			if !n.ownsAddress(foreign.NodeAddress, formerHosts) {
+				continue
+			}
+
+			announcement := ClientAnnouncement{NodeId: n.Id, Client: foreign, AnnouncedAt: timeutil.Now()}
+			if announcement.Signature, err = n.Key.CreateSignature(announcement); err != nil {
+				return announced, fmt.Errorf("failed to sign the announcement of %s: %v", foreign.ClientId, err)
+			}
+
+			for _, host := range hosts {
+				host := host
+				gossipPool.Go(ctx, func(ctx context.Context) {
+					if err := peerTransport.AnnounceClient(ctx, host, announcement); err != nil {
+						Logf(ctx, "failed to announce the client %s to %s: %v", announcement.Client.ClientId, host, err)
+						return
+					}
+					Debugf(ctx, SubsystemGossip, "announced the client %s to %s", announcement.Client.ClientId, host)
+				})
+			}
+
+			announced++
+		}
+
+		*watermark = since
+		if len(documents) < mirrorPageSize {
+			return announced, nil
+		}
+	}
+}
+
+// Schedules the singleton job that announces the local clients to the peers, starting right away
+func (n Node) StartClientGossip(schedule string) error {
+	var watermark int64
+	return ScheduleJob(Job{
+		Name:       "client_gossip",
+		Schedule:   schedule,
+		Singleton:  true,
+		RunAtStart: true,
+		Retries:    3,
+		Run: func(ctx context.Context, lease *Lease) error {
+			count, err := n.announceClients(ctx, *lease, &watermark)
+			if err != nil {
+				return fmt.Errorf("failed to announce the clients: %v", err)
+			}
+
+			if count > 0 {
+				Debugf(ctx, SubsystemGossip, "announced %d client(s) to the peers", count)
+			}
+
+			return nil
+		},
+	})
+}
+
+// Stores the record of a foreign client after verifying that its home node signed the announcement.
+// Gives whether the record was stored (false when the node already had it, or a newer copy)
+func (n Node) AcceptClient(ctx context.Context, announcement ClientAnnouncement) (bool, error) {
+	document, err := n.GetDocument(ctx, "peers", announcement.NodeId)
+	if err != nil {
+		return false, fmt.Errorf("the node %s isn't a known peer", announcement.NodeId)
+	}
+
+	publicKey, _ := document["public_key"].(string)
+	if publicKey == "" {
+		return false, fmt.Errorf("the peer %s has no known public key", announcement.NodeId)
+	}
+
+	if err := announcement.Verify(publicKey); err != nil {
+		return false, err
+	}
+
+	foreign := announcement.Client
+	if _, err := client.ParseIdentity(foreign.ClientId); err != nil {
+		return false, fmt.Errorf("the client id %s isn't a valid identity: %v", foreign.ClientId, err)
+	}
+
+	// Only the home node of the client can announce it
+	peerHost, _ := document["host"].(string)
+	if host, err := n.homeNodeHost(ctx, foreign.NodeAddress); err != nil || host != peerHost {
+		return false, fmt.Errorf("the client %s doesn't belong to the node %s", foreign.ClientId, announcement.NodeId)
+	}
+
+	if foreign.Rotation != nil {
+		if err := foreign.Rotation.Verify(); err != nil || foreign.Rotation.ClientId != foreign.ClientId {
+			return false, fmt.Errorf("the rotation of the client %s is invalid", foreign.ClientId)
+		}
+	}
+
+	local, err := n.Clients().GetForeign(ctx, foreign.ClientId)
+	if err != nil && !errors.Is(err, ErrUnknownClient) {
+		return false, err
+	}
+
+	if local != nil && conflicting(*local, foreign) {
+		evidence, _ := json.Marshal(announcement)
+		return false, n.recordClientConflict(ctx, ClientConflict{
+			ClientId: foreign.ClientId,
+			Kept:     *local,
+			Rejected: foreign,
+			Source:   "gossip from " + announcement.NodeId,
+			Evidence: string(evidence),
+		})
+	}
+
+	if local != nil && (local.Erased || local.UpdatedAt >= foreign.UpdatedAt) {
+		return false, nil
+	}
+
+	record, err := toDocument(foreign)
+	if err != nil {
+		return false, err
+	}
+
+	commit := n.Begin().Put("clients", foreign.ClientId, record)
+
+	// The former record of a rotated client points to the new one, as in its home node
+	if foreign.Rotation != nil {
+		if previous, err := n.Clients().GetForeign(ctx, foreign.Rotation.PreviousId); err == nil && previous.RotatedTo == "" {
+			commit = commit.Update("clients", previous.ClientId, map[string]interface{}{"rotated_to": foreign.ClientId, "updated_at": foreign.Rotation.RotatedAt})
+		}
+	}
+
+	if err := commit.Apply(ctx); err != nil {
+		return false, fmt.Errorf("failed to store the client %s: %v", foreign.ClientId, err)
+	}
+
+	return true, nil
+}
@@ -0,0 +1,134 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	config "node/config"
+	"syscall"
+)
+
+// The space available in the disk that holds some path
+type DiskUsage struct {
+	Path  string `json:"path"`  // The path that's being monitored
+	Free  int64  `json:"free"`  // The available bytes for the node process
+	Total int64  `json:"total"` // The total bytes of the disk
+}
+
+// Reads the usage of the disk that holds the given path
+func ReadDiskUsage(path string) (DiskUsage, error) {
+	var stat syscall.Statfs_t
+
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return DiskUsage{}, fmt.Errorf("failed to read the disk usage of %s: %v", path, err)
+	}
+
+	usage := DiskUsage{
+		Path:  path,
+		Free:  int64(stat.Bavail) * int64(stat.Bsize),
+		Total: int64(stat.Blocks) * int64(stat.Bsize),
+	}
+
+	return usage, nil
+}
+
+// Reads the usage of every path where the node writes data: the base path, the key paths
+// and the backlog data path (when the backlog runs in the same host)
+func MonitoredDiskUsage() ([]DiskUsage, error) {
+	paths := append([]string{config.BasePath()}, config.KeyPaths()...)
+	if dataPath := config.BacklogDataPath(); dataPath != "" {
+		paths = append(paths, dataPath)
+	}
+
+	var usages []DiskUsage
+	seen := map[string]bool{}
+
+	for _, path := range paths {
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+
+		usage, err := ReadDiskUsage(path)
+		if err != nil {
+			return usages, err
+		}
+
+		usages = append(usages, usage)
+	}
+
+	return usages, nil
+}
+
+// Checks the free space of the monitored paths and switches the read-only mode on (or off)
+// when the space crosses the configured threshold
+func (n *Node) CheckDiskSpace(ctx context.Context) error {
+	usages, err := MonitoredDiskUsage()
+	if err != nil {
+		return err
+	}
+
+	minFree := config.MinFreeDisk()
+	var lowest *DiskUsage
+
+	for i, usage := range usages {
+		if usage.Free < minFree && (lowest == nil || usage.Free < lowest.Free) {
+			lowest = &usages[i]
+		}
+	}
+
+	switch {
+	case lowest != nil && !n.ReadOnly:
+		n.ReadOnly = true
+		Warnf(ctx, "low disk space in %s (%d bytes free): the node is now read-only", lowest.Path, lowest.Free)
+
+		n.Emit(ctx, "disk.low_space", map[string]interface{}{
+			"path":      lowest.Path,
+			"free":      lowest.Free,
+			"total":     lowest.Total,
+			"threshold": minFree,
+		})
+
+		return n.SyncWithBacklog(ctx, "node")
+	case lowest == nil && n.ReadOnly:
+		n.ReadOnly = false
+		fmt.Println("Disk space recovered: node is writable again")
+
+		n.Emit(ctx, "disk.recovered", map[string]interface{}{
+			"threshold": minFree,
+		})
+
+		return n.SyncWithBacklog(ctx, "node")
+	}
+
+	return nil
+}
+
+// Gives an error when the node can't accept writes
+func (n Node) CheckWritable() error {
+	if n.ReadOnly {
+		return ErrReadOnly
+	}
+
+	if n.Status == NodeMaintenance {
+		return fmt.Errorf("%w: the node is under maintenance", ErrReadOnly)
+	}
+
+	return nil
+}
+
+// Schedules the job that checks the disk space. Every process keeps its own read-only state, so
+// the job runs in all of them, starting right away
+func (n *Node) StartDiskMonitor(schedule string) error {
+	return ScheduleJob(Job{
+		Name:       "disk_monitor",
+		Schedule:   schedule,
+		RunAtStart: true,
+		Run: func(ctx context.Context, lease *Lease) error {
+			if err := n.CheckDiskSpace(ctx); err != nil {
+				return fmt.Errorf("failed to check the disk space: %v", err)
+			}
+
+			return nil
+		},
+	})
+}
@@ -0,0 +1,148 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"net"
+	backlog "node/backlog"
+	config "node/config"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// The maximum difference tolerated between the node clock and the backlog clock
+const maxClockSkew = 5 * time.Second
+
+// The result of a single check performed by the doctor
+type Finding struct {
+	Check  string // The name of the check
+	Ok     bool   // Whether the check passed
+	Detail string // What was found
+	Hint   string // What the operator can do to fix it (empty when the check passed)
+}
+
+/*
+The doctor verifies if the host is able to run a node: the configuration, the backlog
+connectivity and indices, the key directories permissions, the clock, and that the advertised
+address resolves and its port can be bound.
+
+Every check results in a Finding, so the operator gets all the problems at once instead
+of fixing them one by one.
+*/
+func Diagnose(ctx context.Context, port string) []Finding {
+	var findings []Finding
+
+	if err := config.Validate(); err != nil {
+		findings = append(findings, Finding{"configuration", false, err.Error(), "set --path (or BASE_PATH) to a writable directory"})
+	} else {
+		findings = append(findings, Finding{"configuration", true, fmt.Sprintf("base path %s", config.BasePath()), ""})
+	}
+
+	b, err := backlog.NewBacklog()
+	if err == nil {
+		var serverTime time.Time
+		if serverTime, err = b.ServerTime(ctx); err == nil {
+			findings = append(findings, Finding{"backlog", true, "ElasticSearch is reachable", ""})
+			findings = append(findings, diagnoseIndices(ctx, b)...)
+			findings = append(findings, diagnoseConflicts(ctx, b))
+			findings = append(findings, diagnoseClock(serverTime))
+		}
+	}
+
+	if err != nil {
+		findings = append(findings, Finding{"backlog", false, err.Error(), fmt.Sprintf("make sure the ElasticSearch is running at %s", config.BacklogAddress())})
+	}
+
+	findings = append(findings, diagnoseKeyPaths()...)
+	findings = append(findings, diagnoseAddress(port))
+
+	return findings
+}
+
+func diagnoseIndices(ctx context.Context, b *backlog.Backlog) []Finding {
+	var findings []Finding
+
+	for _, index := range backlog.Indices {
+		if err := b.IndexExists(ctx, index); err != nil {
+			findings = append(findings, Finding{"index " + index, false, "index is missing", "start the node once to initialize the backlog"})
+		} else {
+			findings = append(findings, Finding{"index " + index, true, "index exists", ""})
+		}
+	}
+
+	return findings
+}
+
+func diagnoseConflicts(ctx context.Context, b *backlog.Backlog) Finding {
+	count, err := b.CountDocuments(ctx, "client_conflicts")
+	if err != nil {
+		return Finding{"client conflicts", true, "the conflicts can't be counted yet", ""}
+	}
+
+	if count > 0 {
+		return Finding{"client conflicts", false, fmt.Sprintf("%d client id(s) were claimed by more than one node", count), "inspect the client_conflicts section of the diagnostic bundle and the peers that claimed them"}
+	}
+
+	return Finding{"client conflicts", true, "no client id was claimed by more than one node", ""}
+}
+
+func diagnoseClock(serverTime time.Time) Finding {
+	if serverTime.IsZero() {
+		return Finding{"clock", true, "the backlog doesn't report its time, skew not verified", ""}
+	}
+
+	skew := time.Since(serverTime)
+	if skew < 0 {
+		skew = -skew
+	}
+
+	if skew > maxClockSkew {
+		return Finding{"clock", false, fmt.Sprintf("clock is %v away from the backlog clock", skew.Round(time.Second)), "synchronize the host clock (e.g. with NTP)"}
+	}
+
+	return Finding{"clock", true, fmt.Sprintf("skew of %v", skew.Round(time.Millisecond)), ""}
+}
+
+func diagnoseKeyPaths() []Finding {
+	var findings []Finding
+
+	for _, path := range config.KeyPaths() {
+		probe := filepath.Join(path, ".doctor")
+
+		if err := os.WriteFile(probe, []byte{}, 0600); err != nil {
+			findings = append(findings, Finding{"keys " + path, false, err.Error(), "grant write permission on the directory to the node user"})
+			continue
+		}
+
+		os.Remove(probe)
+		findings = append(findings, Finding{"keys " + path, true, "directory is writable", ""})
+	}
+
+	return findings
+}
+
+func diagnoseAddress(port string) Finding {
+	host, err := getLocalAddress()
+	if err != nil {
+		return Finding{"address", false, err.Error(), "the host must be able to resolve its public address"}
+	}
+
+	address := net.JoinHostPort(host, port)
+
+	// The doctor runs before the node starts, so the port must be free to bind
+	listener, listenErr := net.Listen("tcp", net.JoinHostPort("", port))
+	if listenErr == nil {
+		listener.Close()
+		return Finding{"address", true, fmt.Sprintf("%s resolves and the port %s can be bound", address, port), ""}
+	}
+
+	// A port already bound may belong to the node itself, when it's checked while running
+	conn, err := net.DialTimeout("tcp", address, 3*time.Second)
+	if err != nil {
+		return Finding{"address", false, fmt.Sprintf("the port %s can't be bound (%v) and %s is unreachable", port, listenErr, address), "free the port or pick another one with --port"}
+	}
+	conn.Close()
+
+	return Finding{"address", true, fmt.Sprintf("%s is reachable (the port is in use, probably by the running node)", address), ""}
+}
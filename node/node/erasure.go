@@ -0,0 +1,219 @@
+package node
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	backlog "node/backlog"
+	client "node/client"
+	config "node/config"
+	timeutil "node/timeutil"
+)
+
+// The value that replaces the personal data of an erased client
+const erasedValue string = "erased"
+
+/*
+An erasure removes the personal data of a client at its request. The client id and the transactions
+stay, since the chain can't be verified without the hashes and the signatures, but nothing links
+them to the person anymore:
+
+- The local client, its cache, its devices and its key pair (in the backlog and in the key path) are
+deleted, so the client can't connect again
+- The alias stays reserved, without its text, so nobody else impersonates the client
+- The foreign client keeps only its id and the node where it was registered, and is marked as erased
+so no transaction reaches it anymore
+
+The erasure is recorded in the `erasures` index and in the events journal, and announced to the peers
+signed by the node key, so they anonymize their copies of the foreign client too.
+*/
+type Erasure struct {
+	NodeId    string `json:"node_id"`   // The id of the home node of the client
+	ClientId  string `json:"client_id"` // The id of the erased client
+	ErasedAt  int64  `json:"erased_at"` // The timestamp when the client was erased
+	Signature string `json:"signature"` // The signature made by the node key
+}
+
+// Converts the erasure (except the signature) to a signable byte array
+func (e Erasure) ToBytes() ([]byte, error) {
+	erasure := map[string]interface{}{
+		"node_id":   e.NodeId,
+		"client_id": e.ClientId,
+		"erased_at": e.ErasedAt,
+	}
+
+	erasureBytes, err := json.Marshal(erasure)
+	return erasureBytes, err
+}
+
+// Verifies the signature of the erasure with the public key (identity) of the node
+func (e Erasure) Verify(publicKey string) error {
+	if err := client.VerifyWithIdentity(publicKey, e, e.Signature); err != nil {
+		return fmt.Errorf("the erasure isn't signed by the node %s", e.NodeId)
+	}
+
+	return nil
+}
+
+// Erases the personal data of a local client and announces the erasure to the peers
+func (n Node) EraseClient(ctx context.Context, uid string) (*Erasure, error) {
+	owner, err := n.Clients().Get(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	erasure := Erasure{
+		NodeId:   n.Id,
+		ClientId: owner.ClientId,
+		ErasedAt: timeutil.Now(),
+	}
+
+	devices, err := n.ClientDevices(ctx, owner.ClientId)
+	if err != nil {
+		return nil, err
+	}
+
+	foreign := anonymizedClient(owner.ClientId, owner.NodeAddress, erasure.ErasedAt)
+
+	commit := n.Begin().
+		Put("erasures", uid, map[string]interface{}{
+			"client_id": erasure.ClientId,
+			"node_id":   erasure.NodeId,
+			"erased_at": erasure.ErasedAt,
+		}).
+		Delete("local_clients", uid).
+		Delete("cache", uid).
+		Delete("keys", uid).
+		Put("aliases", aliasId(owner.Alias), map[string]interface{}{
+			"alias":       erasedValue,
+			"reserved_at": erasure.ErasedAt,
+		}).
+		Put("clients", owner.ClientId, foreign)
+
+	for _, device := range devices {
+		commit = commit.Delete("devices", deviceId(device.Platform, device.Token))
+	}
+
+	if err := commit.Apply(ctx); err != nil {
+		return nil, fmt.Errorf("failed to erase the client documents: %v", err)
+	}
+
+	// The keys left in the key store are purged by the recovery when this fails (please, go to
+	// `recovery.go`)
+	if err := n.Keys.Delete(ctx, uid); err != nil {
+		Logf(ctx, "failed to purge the keys of the erased client %s: %v", owner.ClientId, err)
+	}
+
+	n.Emit(ctx, "client.erased", map[string]interface{}{
+		"client_id": erasure.ClientId,
+		"erased_at": erasure.ErasedAt,
+	})
+
+	signature, err := n.Key.CreateSignature(erasure)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign the erasure: %v", err)
+	}
+	erasure.Signature = signature
+
+	n.announceErasure(ctx, erasure)
+	return &erasure, nil
+}
+
+// Gives the document of a foreign client without its personal data
+func anonymizedClient(clientId, nodeAddress string, erasedAt int64) map[string]interface{} {
+	return map[string]interface{}{
+		"client_id":  clientId,
+		"node":       nodeAddress,
+		"address":    erasedValue,
+		"erased":     true,
+		"updated_at": erasedAt,
+	}
+}
+
+// Checks if a local client was erased
+func (n Node) ClientErased(ctx context.Context, uid string) (bool, error) {
+	_, err := n.GetDocument(ctx, "erasures", uid)
+	if errors.Is(err, backlog.ErrNotFound) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, fmt.Errorf("failed to get the erasure of %s: %v", uid, err)
+	}
+
+	return true, nil
+}
+
+// Announces the erasure of a client to the alive peers in background
+func (n Node) announceErasure(ctx context.Context, erasure Erasure) {
+	if peerTransport == nil || !config.FeatureEnabled(config.FeatureGossip) {
+		return
+	}
+
+	if UnderBackpressure() {
+		Debugf(ctx, SubsystemGossip, "the erasure of %s isn't announced under backpressure", erasure.ClientId)
+		return
+	}
+
+	hosts, err := n.AlivePeers(ctx)
+	if err != nil {
+		Logf(ctx, "failed to announce the erasure of %s: %v", erasure.ClientId, err)
+		return
+	}
+
+	for _, host := range hosts {
+		host := host
+		gossipPool.Go(ctx, func(ctx context.Context) {
+			if err := peerTransport.AnnounceErasure(ctx, host, erasure); err != nil {
+				Logf(ctx, "failed to announce the erasure of %s to %s: %v", erasure.ClientId, host, err)
+				return
+			}
+			Debugf(ctx, SubsystemGossip, "announced the erasure of %s to %s", erasure.ClientId, host)
+		})
+	}
+}
+
+// Anonymizes the copy of a foreign client after verifying that its home node signed the erasure
+func (n Node) AcceptErasure(ctx context.Context, erasure Erasure) error {
+	document, err := n.GetDocument(ctx, "peers", erasure.NodeId)
+	if err != nil {
+		return fmt.Errorf("the node %s isn't a known peer", erasure.NodeId)
+	}
+
+	publicKey, _ := document["public_key"].(string)
+	if publicKey == "" {
+		return fmt.Errorf("the peer %s has no known public key", erasure.NodeId)
+	}
+
+	if err := erasure.Verify(publicKey); err != nil {
+		return err
+	}
+
+	foreign, err := n.Clients().GetForeign(ctx, erasure.ClientId)
+	if errors.Is(err, ErrUnknownClient) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	// Only the home node of the client can erase it
+	peerHost, _ := document["host"].(string)
+	if host, err := n.homeNodeHost(ctx, foreign.NodeAddress); err != nil || host != peerHost {
+		return fmt.Errorf("the client %s doesn't belong to the node %s", erasure.ClientId, erasure.NodeId)
+	}
+
+	if err := n.IndexDocument(ctx, "clients", erasure.ClientId, anonymizedClient(foreign.ClientId, foreign.NodeAddress, erasure.ErasedAt)); err != nil {
+		return fmt.Errorf("failed to anonymize the client %s: %v", erasure.ClientId, err)
+	}
+
+	n.Emit(ctx, "client.erased", map[string]interface{}{
+		"client_id": erasure.ClientId,
+		"node_id":   erasure.NodeId,
+		"erased_at": erasure.ErasedAt,
+	})
+
+	return nil
+}
@@ -0,0 +1,123 @@
+package node
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	timeutil "node/timeutil"
+
+	"github.com/google/uuid"
+)
+
+/*
+The journal keeps every event emitted by the node in the `events` index, so the integrators can
+replay them to rebuild their downstream state after an outage.
+
+Emitting an event records it in the journal and posts it to the configured webhooks.
+*/
+type Event struct {
+	Id        string                 `json:"id"`        // A unique id that references the event
+	Kind      string                 `json:"kind"`      // The kind of the event (e.g. transaction.signed)
+	Timestamp int64                  `json:"timestamp"` // The timestamp (in milliseconds) when the event was emitted
+	Data      map[string]interface{} `json:"data"`      // The details of the event
+}
+
+// The number of events read from the journal at once during a replay
+const replayPageSize int = 500
+
+// Stores an event in the journal
+func (n Node) RecordEvent(ctx context.Context, kind string, data map[string]interface{}) (*Event, error) {
+	id, _ := uuid.NewUUID()
+	event := Event{
+		Id:        id.String(),
+		Kind:      kind,
+		Timestamp: timeutil.Now(),
+		Data:      data,
+	}
+
+	eventBytes, err := json.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal the event: %v", err)
+	}
+
+	var document map[string]interface{}
+	if err := json.Unmarshal(eventBytes, &document); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal the event into map: %v", err)
+	}
+
+	if err := n.IndexDocument(ctx, "events", event.Id, document); err != nil {
+		return nil, fmt.Errorf("failed to record the event: %v", err)
+	}
+
+	return &event, nil
+}
+
+// Records an event in the journal and posts it to the webhooks
+func (n Node) Emit(ctx context.Context, kind string, data map[string]interface{}) {
+	if _, err := n.RecordEvent(ctx, kind, data); err != nil {
+		Warnf(ctx, "failed to journal the %s event: %v", kind, err)
+	}
+
+	n.NotifyWebhooks(ctx, kind, data)
+}
+
+// Reads the journal between two timestamps (in milliseconds), the oldest first, and calls the
+// given function with every event of the given kinds (or all of them if no kind is given)
+func (n Node) ReplayEvents(ctx context.Context, from, to int64, kinds []string, fn func(Event) error) error {
+	filters := []interface{}{
+		map[string]interface{}{
+			"range": map[string]interface{}{
+				"timestamp": map[string]interface{}{"gte": from, "lte": to},
+			},
+		},
+	}
+
+	if len(kinds) > 0 {
+		filters = append(filters, map[string]interface{}{
+			"terms": map[string]interface{}{"kind.keyword": kinds},
+		})
+	}
+
+	var after []interface{}
+	for {
+		body := map[string]interface{}{
+			"size": replayPageSize,
+			"sort": []interface{}{
+				map[string]interface{}{"timestamp": "asc"},
+				map[string]interface{}{"id.keyword": "asc"},
+			},
+			"query": map[string]interface{}{
+				"bool": map[string]interface{}{"filter": filters},
+			},
+		}
+		if after != nil {
+			body["search_after"] = after
+		}
+
+		documents, err := n.SearchDocuments(ctx, "events", body)
+		if err != nil {
+			return fmt.Errorf("failed to read the journal: %v", err)
+		}
+
+		for _, document := range documents {
+			event := Event{}
+			event.Id, _ = document["id"].(string)
+			event.Kind, _ = document["kind"].(string)
+			event.Data, _ = document["data"].(map[string]interface{})
+
+			if timestamp, ok := document["timestamp"].(float64); ok {
+				event.Timestamp = int64(timestamp)
+			}
+
+			if err := fn(event); err != nil {
+				return err
+			}
+
+			after = []interface{}{event.Timestamp, event.Id}
+		}
+
+		if len(documents) < replayPageSize {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package node
+
+import (
+	"context"
+	timeutil "node/timeutil"
+	"time"
+)
+
+const (
+	feedPollInterval time.Duration = time.Second     // How often a watch reads the new events of the journal
+	feedLag          time.Duration = 5 * time.Second // How far back a watch reads again, for the events written late by other processes
+)
+
+// The kinds of the changes pushed to the watchers of the transactions
+const (
+	FeedReceived  string = "received"  // A new transaction for the client
+	FeedConfirmed string = "confirmed" // A transaction of the client was included in a block
+)
+
+/*
+A transaction feed pushes the changes of the transactions of a client while it's watching them: the
+new transactions it receives and the confirmation of the ones it sent or received.
+
+Any process of the node (or another node, for the routed transactions) may produce the change, so
+the feed tails the journal (please, go to `events.go`) instead of listening to the current process.
+The feed reads a few seconds back in every poll, since the processes may write their events late,
+and skips the events it already pushed.
+*/
+type FeedEvent struct {
+	Kind          string  // Either FeedReceived or FeedConfirmed
+	TransactionId string  // The universal id of the transaction
+	Sender        string  // The client id of the sender
+	Recipient     string  // The client id of the recipient
+	Value         float64 // The value of the transaction
+	Timestamp     int64   // The timestamp when the transaction was performed
+	BlockHash     string  // The hash of the block that confirmed the transaction (empty when received)
+	Height        int64   // The height of the block that confirmed the transaction
+}
+
+// The kinds of the journal events that can change the transactions of a client
+var feedKinds = []string{"transaction.signed", "transaction.routed", "block.mined", "block.appended"}
+
+// Gives the changes of the transactions of a client carried by a journal event
+func (n Node) feedEvents(ctx context.Context, clientId string, event Event) []FeedEvent {
+	text := func(key string) string {
+		value, _ := event.Data[key].(string)
+		return value
+	}
+
+	number := func(key string) float64 {
+		value, _ := event.Data[key].(float64)
+		return value
+	}
+
+	switch event.Kind {
+	case "transaction.signed", "transaction.routed":
+		if text("recipient") != clientId {
+			return nil
+		}
+
+		return []FeedEvent{{
+			Kind:          FeedReceived,
+			TransactionId: text("transaction_id"),
+			Sender:        text("sender"),
+			Recipient:     text("recipient"),
+			Value:         number("value"),
+			Timestamp:     int64(number("timestamp")),
+		}}
+	default:
+		document, err := n.GetDocument(ctx, "blockchain", text("hash"))
+		if err != nil {
+			Logf(ctx, "failed to read the block %s for the feed: %v", text("hash"), err)
+			return nil
+		}
+
+		block, err := blockFromDocument(document)
+		if err != nil {
+			return nil
+		}
+
+		var events []FeedEvent
+		for _, transaction := range block.Transactions {
+			if transaction.Sender != clientId && transaction.Recipient != clientId {
+				continue
+			}
+
+			events = append(events, FeedEvent{
+				Kind:          FeedConfirmed,
+				TransactionId: transaction.TransactionId,
+				Sender:        transaction.Sender,
+				Recipient:     transaction.Recipient,
+				Value:         transaction.Value,
+				Timestamp:     transaction.Timestamp,
+				BlockHash:     block.Hash,
+				Height:        block.Height,
+			})
+		}
+
+		return events
+	}
+}
+
+// Calls the given function with every change of the transactions of a client, from now until the
+// context is done or the function fails
+func (n Node) WatchTransactions(ctx context.Context, clientId string, fn func(FeedEvent) error) error {
+	ticker := time.NewTicker(feedPollInterval)
+	defer ticker.Stop()
+
+	start := timeutil.Now()
+	cursor := start
+	seen := map[string]int64{} // The ids of the events pushed inside the lag, by timestamp
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		from, to := cursor-feedLag.Milliseconds(), timeutil.Now()
+		err := n.ReplayEvents(ctx, from, to, feedKinds, func(event Event) error {
+			if _, ok := seen[event.Id]; ok {
+				return nil
+			}
+			seen[event.Id] = event.Timestamp
+
+			// The first polls read back before the watch started
+			if event.Timestamp < start {
+				return nil
+			}
+
+			for _, change := range n.feedEvents(ctx, clientId, event) {
+				if err := fn(change); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		cursor = to
+		for id, timestamp := range seen {
+			if timestamp < cursor-feedLag.Milliseconds() {
+				delete(seen, id)
+			}
+		}
+	}
+}
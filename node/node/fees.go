@@ -0,0 +1,122 @@
+package node
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	config "node/config"
+	"sort"
+)
+
+// How many of the oldest pending transactions are weighed to fill a block
+const mempoolWindow int = 10 * maxBlockTransactions
+
+/*
+A transaction may pay a fee to the node that includes it in a block. The fee is signed together
+with the transaction (it's left out of the signed bytes when it's zero, so the transactions signed
+before the fees still verify), and the sender pays it on top of the value. A node only accepts the
+transactions that pay at least its MIN_FEE, but it takes the blocks of its peers whatever their
+fees are.
+
+The blocks are filled with the pending transactions that pay the most for the room they take (the
+fee per byte of the transaction, as it's stored in the block), so the fee buys priority when the
+mempool is crowded. The transactions of a sender are still taken in the order of its sequence.
+
+The fees of a block are credited to the client of its producer (FEE_RECIPIENT), carried in the
+block header, so every node folds the same balances from the chain (please, go to `ledger.go`). The
+fees of a block without one are burned.
+*/
+var ErrFeeTooLow = errors.New("the transaction fee is too low")
+
+// Adds the fee to the signed fields of a transaction, when it pays one
+func withFee(transaction map[string]interface{}, fee float64) map[string]interface{} {
+	if fee != 0 {
+		transaction["fee"] = fee
+	}
+
+	return transaction
+}
+
+// Checks that a fee is valid and pays at least the minimum fee of the node
+func checkFee(fee float64) error {
+	if fee < 0 {
+		return fmt.Errorf("%w: the fee can't be negative", ErrFeeTooLow)
+	}
+
+	if minimum := config.MinFee(); fee < minimum {
+		return fmt.Errorf("%w: the node takes a fee of %v at least, but the transaction pays %v", ErrFeeTooLow, minimum, fee)
+	}
+
+	return nil
+}
+
+// Gives the size of the transaction, as it's stored in a block
+func (t BlockTransaction) Size() int {
+	transBytes, _ := json.Marshal(t)
+	return len(transBytes)
+}
+
+// Gives the fee that the transaction pays by byte of its size
+func (t BlockTransaction) FeeRate() float64 {
+	return t.Fee / float64(t.Size())
+}
+
+// Gives the sum of the fees of the block transactions
+func (b Block) Fees() float64 {
+	var fees float64
+	for _, transaction := range b.Transactions {
+		fees += transaction.Fee
+	}
+
+	return fees
+}
+
+// Selects the transactions that fill a block, the best fee per byte first. The pending transactions
+// of every sender are queued in the order of their sequence, and only the first of each queue
+// competes for the next place
+func selectTransactions(pending []BlockTransaction, limit int) []BlockTransaction {
+	queues := map[string][]BlockTransaction{}
+	var senders []string
+	for _, transaction := range pending {
+		if _, ok := queues[transaction.Sender]; !ok {
+			senders = append(senders, transaction.Sender)
+		}
+
+		queues[transaction.Sender] = append(queues[transaction.Sender], transaction)
+	}
+
+	for _, queue := range queues {
+		sort.SliceStable(queue, func(i, j int) bool { return queue[i].Sequence < queue[j].Sequence })
+	}
+
+	var selected []BlockTransaction
+	for len(selected) < limit {
+		best := ""
+		for _, sender := range senders {
+			queue := queues[sender]
+			if len(queue) == 0 {
+				continue
+			}
+
+			// The ties go to the oldest transaction, as before the fees
+			if best == "" {
+				best = sender
+				continue
+			}
+
+			head, bestHead := queue[0], queues[best][0]
+			if rate, bestRate := head.FeeRate(), bestHead.FeeRate(); rate > bestRate || (rate == bestRate && head.Timestamp < bestHead.Timestamp) {
+				best = sender
+			}
+		}
+
+		if best == "" {
+			break
+		}
+
+		selected = append(selected, queues[best][0])
+		queues[best] = queues[best][1:]
+	}
+
+	return selected
+}
@@ -0,0 +1,233 @@
+package node
+
+import (
+	"context"
+	"fmt"
+)
+
+// The deepest fork that the node reorganizes, so a peer can't rewrite the history of the chain
+const maxReorgDepth int64 = 1000
+
+/*
+Two peers may present conflicting chains, e.g. when both mined a block at the same height. The fork
+choice keeps the longest valid chain and, between chains of the same length, the one whose last block
+has the lowest hash, so every node picks the same chain whatever the order it heard of them.
+
+When the chain of a peer wins, the node reorganizes its own: the blocks after the common ancestor of
+both chains are orphaned (deleted, with their transactions returned to the mempool) and the blocks of
+the peer are appended in their place. The transactions of the orphaned blocks that the peer didn't
+include are mined again later. The balances fold the new blocks by themselves, since they notice that
+their last block is no longer in the chain (please, go to `ledger.go`).
+
+The branch is checked whole before anything is orphaned, including that its blocks don't confirm a
+transaction twice nor go back in the sequence of a sender between them. The checks that need the
+chain as it is before each block (e.g. the rate limits and the balances spent, please, go to
+`spending.go`) only run as the branch is appended, so when one of its blocks is refused, the branch
+is orphaned in turn and the blocks of the node get their place back.
+*/
+
+// Checks if a chain ending on the candidate block is preferred over the one ending on the current block
+func preferredChain(candidate, current Block) bool {
+	if candidate.Height != current.Height {
+		return candidate.Height > current.Height
+	}
+
+	return candidate.Hash < current.Hash
+}
+
+// Resolves the conflict between the chain of the node (ending on the last block) and the chain of a
+// peer, reorganizing the chain when the one of the peer is preferred. Gives the number of blocks appended
+func (n Node) resolveFork(ctx context.Context, host string, last *Block) (int, error) {
+	ancestor, err := n.commonAncestor(ctx, host, last.Height)
+	if err != nil {
+		return 0, err
+	}
+
+	branch, err := n.fetchBranch(ctx, host, ancestor.Height+1)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(branch) == 0 || !preferredChain(branch[len(branch)-1], *last) {
+		Debugf(ctx, SubsystemSync, "kept the chain over the fork of %s at the height %d", host, ancestor.Height+1)
+		return 0, nil
+	}
+
+	// The whole branch is validated before anything is orphaned, so an invalid chain never wins
+	previous := *ancestor
+	included, sequences := map[string]bool{}, map[string]int64{}
+	for i := range branch {
+		block := &branch[i]
+		if block.Height != previous.Height+1 || block.PreviousHash != previous.Hash {
+			return 0, fmt.Errorf("invalid fork: the block %d of %s doesn't follow its previous block", block.Height, host)
+		}
+
+		if block.ComputeHash() != block.Hash || !block.Solved() {
+			return 0, fmt.Errorf("invalid fork: the hash of the block %d of %s is wrong or doesn't satisfy the difficulty", block.Height, host)
+		}
+
+		if err := block.checkContents(); err != nil {
+			return 0, fmt.Errorf("invalid fork: %v", err)
+		}
+
+		if err := n.verifySignatures(ctx, block); err != nil {
+			return 0, fmt.Errorf("invalid fork: %v", err)
+		}
+
+		for _, transaction := range block.Transactions {
+			if included[transaction.TransactionId] {
+				return 0, fmt.Errorf("invalid fork: the transaction %s is included twice in the branch of %s", transaction.TransactionId, host)
+			}
+
+			if last, ok := sequences[transaction.Sender]; ok && transaction.Sequence <= last {
+				return 0, fmt.Errorf("invalid fork: the transaction %s replays the sequence of its sender in the branch of %s", transaction.TransactionId, host)
+			}
+
+			included[transaction.TransactionId] = true
+			sequences[transaction.Sender] = transaction.Sequence
+		}
+
+		previous = *block
+	}
+
+	return n.reorganize(ctx, host, *ancestor, branch)
+}
+
+// Gives the last block that the chain of the node shares with the chain of a peer, looking back from
+// the height
+func (n Node) commonAncestor(ctx context.Context, host string, height int64) (*Block, error) {
+	blockchain := NewBlockchain(n.Backlog)
+
+	for to := height; to >= 0 && height-to < maxReorgDepth; to -= fetchPageSize {
+		from := to - fetchPageSize + 1
+		if from < 0 {
+			from = 0
+		}
+
+		remote, err := peerTransport.FetchBlocks(ctx, host, from)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch the blocks from %s: %v", host, err)
+		}
+
+		local, err := blockchain.BlocksFrom(ctx, from)
+		if err != nil {
+			return nil, err
+		}
+
+		hashes := map[int64]string{}
+		for _, block := range local {
+			hashes[block.Height] = block.Hash
+		}
+
+		for i := len(remote) - 1; i >= 0; i-- {
+			if remote[i].Height <= to && hashes[remote[i].Height] == remote[i].Hash {
+				ancestor := remote[i]
+				return &ancestor, nil
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("the chain of %s doesn't share any of the last %d blocks of the node", host, maxReorgDepth)
+}
+
+// Fetches the blocks of a peer from some height up to its last one
+func (n Node) fetchBranch(ctx context.Context, host string, fromHeight int64) ([]Block, error) {
+	var branch []Block
+	for {
+		blocks, err := peerTransport.FetchBlocks(ctx, host, fromHeight+int64(len(branch)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch the blocks from %s: %v", host, err)
+		}
+
+		branch = append(branch, blocks...)
+		if int64(len(blocks)) < fetchPageSize {
+			return branch, nil
+		}
+	}
+}
+
+// Orphans the blocks after the ancestor, returning their transactions to the mempool, and appends the
+// branch of the peer in their place. Gives the number of blocks appended
+func (n Node) reorganize(ctx context.Context, host string, ancestor Block, branch []Block) (int, error) {
+	blockchain := NewBlockchain(n.Backlog)
+
+	var orphaned []Block
+	for {
+		blocks, err := blockchain.BlocksFrom(ctx, ancestor.Height+1+int64(len(orphaned)))
+		if err != nil {
+			return 0, err
+		}
+
+		orphaned = append(orphaned, blocks...)
+		if int64(len(blocks)) < fetchPageSize {
+			break
+		}
+	}
+
+	if err := n.orphanBlocks(ctx, orphaned); err != nil {
+		return 0, fmt.Errorf("failed to orphan the blocks after %d: %v", ancestor.Height, err)
+	}
+
+	Warnf(ctx, "reorganized the chain after the height %d onto the chain of %s: %d block(s) orphaned", ancestor.Height, host, len(orphaned))
+
+	// The checks that depend on the chain (e.g. the rate) only run as the branch is appended, so a
+	// branch refused halfway gives the orphaned blocks back their place
+	appended := 0
+	for i := range branch {
+		if err := n.AppendBlock(ctx, &branch[i]); err != nil {
+			err = fmt.Errorf("failed to append the block %d from %s: %v", branch[i].Height, host, err)
+			if restoreErr := n.restoreBlocks(ctx, branch[:appended], orphaned); restoreErr != nil {
+				return 0, fmt.Errorf("%v (%v)", err, restoreErr)
+			}
+
+			Warnf(ctx, "restored the %d orphaned block(s) after the height %d: %v", len(orphaned), ancestor.Height, err)
+			return 0, err
+		}
+
+		appended++
+	}
+
+	n.Emit(ctx, "chain.reorganized", map[string]interface{}{
+		"ancestor": ancestor.Height,
+		"orphaned": len(orphaned),
+		"appended": appended,
+		"host":     host,
+	})
+
+	return appended, nil
+}
+
+// Deletes the blocks from the chain. Their transactions without a block hash are pending again
+// (please, go to `blockchain.go`)
+func (n Node) orphanBlocks(ctx context.Context, blocks []Block) error {
+	commit := n.Begin()
+	for _, block := range blocks {
+		commit.Delete("blockchain", block.Hash)
+
+		for _, transaction := range block.Transactions {
+			if transaction.allocation(block.Height) {
+				continue
+			}
+
+			commit.Update("transactions", transaction.TransactionId, map[string]interface{}{"BlockHash": nil})
+		}
+	}
+
+	return commit.Apply(ctx)
+}
+
+// Orphans the blocks appended from a branch and appends the blocks it replaced back
+func (n Node) restoreBlocks(ctx context.Context, appended, orphaned []Block) error {
+	if err := n.orphanBlocks(ctx, appended); err != nil {
+		return fmt.Errorf("failed to orphan the blocks of the branch: %v", err)
+	}
+
+	blockchain := NewBlockchain(n.Backlog)
+	for i := range orphaned {
+		if err := blockchain.Append(ctx, &orphaned[i]); err != nil {
+			return fmt.Errorf("failed to restore the block %d: %v", orphaned[i].Height, err)
+		}
+	}
+
+	return nil
+}
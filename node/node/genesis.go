@@ -0,0 +1,111 @@
+package node
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	config "node/config"
+	"sort"
+)
+
+/*
+The genesis is the first block of the chain, created once by the node that bootstraps the network
+(`meander --init`) and fetched by every other node. It carries the initial allocation of the chain
+(please, go to GENESIS_ALLOCATION in the config): a transaction from the `genesisSender` to every
+client id allocated, which has no signature since nobody owns the genesis sender.
+
+The genesis is deterministic: the allocations are sorted by recipient, and the ids and the timestamp
+come from the allocation and the config, never from the clock, so the same settings always give the
+same block. Its hash anchors the chain: the validation starts from it, a chain without it isn't
+valid and no block is mined before it. The nodes that join a network pin its hash (please, go to
+GENESIS_HASH in the config), so they never take the genesis of another network from a peer.
+*/
+const genesisSender string = "genesis"
+
+var ErrNoGenesis = errors.New("the chain has no genesis block")
+
+// Builds the genesis block with an initial allocation (the value by client id) and a timestamp
+func GenesisBlock(allocation map[string]float64, timestamp int64) Block {
+	recipients := make([]string, 0, len(allocation))
+	for recipient := range allocation {
+		recipients = append(recipients, recipient)
+	}
+	sort.Strings(recipients)
+
+	transactions := []BlockTransaction{}
+	for i, recipient := range recipients {
+		id := sha256.Sum256([]byte(fmt.Sprintf("%s/%d/%s", genesisSender, i, recipient)))
+
+		transactions = append(transactions, BlockTransaction{
+			TransactionId: hex.EncodeToString(id[:]),
+			Sender:        genesisSender,
+			Recipient:     recipient,
+			Value:         allocation[recipient],
+			Timestamp:     timestamp,
+			Sequence:      int64(i),
+		})
+	}
+
+	block := Block{
+		Height:       0,
+		PreviousHash: genesisPreviousHash,
+		Timestamp:    timestamp,
+		Transactions: transactions,
+		MerkleRoot:   MerkleRoot(transactions),
+	}
+
+	block.Mine()
+	return block
+}
+
+// Checks if a transaction is an allocation of the genesis, which is only valid in the genesis block
+func (t BlockTransaction) allocation(height int64) bool {
+	return height == 0 && t.Sender == genesisSender
+}
+
+// Checks that a block may be the genesis of the chain: the first block, matching the pinned hash when
+// the node joins a network
+func checkGenesis(block *Block) error {
+	if pinned := config.GenesisHash(); pinned != "" && block.Hash != pinned {
+		return fmt.Errorf("the genesis %s isn't the genesis %s of the network", block.Hash, pinned)
+	}
+
+	return nil
+}
+
+// Gives the genesis block of the chain (nil when the chain is empty)
+func (bc Blockchain) Genesis(ctx context.Context) (*Block, error) {
+	return bc.BlockAt(ctx, 0)
+}
+
+// Creates the genesis block of the chain with the allocation and the timestamp of the config. A chain
+// that already has the same genesis is left as it is, and one with another genesis is refused
+func (n Node) InitChain(ctx context.Context) (*Block, error) {
+	allocation, err := config.GenesisAllocation()
+	if err != nil {
+		return nil, err
+	}
+
+	genesis := GenesisBlock(allocation, config.GenesisTimestamp())
+
+	existing, err := NewBlockchain(n.Backlog).Genesis(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if existing != nil {
+		if existing.Hash != genesis.Hash {
+			return nil, fmt.Errorf("the chain already has the genesis %s", existing.Hash)
+		}
+
+		return existing, nil
+	}
+
+	if err := n.AppendBlock(ctx, &genesis); err != nil {
+		return nil, fmt.Errorf("failed to create the genesis: %v", err)
+	}
+
+	return &genesis, nil
+}
@@ -0,0 +1,93 @@
+package node
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	backlog "node/backlog"
+	timeutil "node/timeutil"
+	"time"
+)
+
+// How often the former process checks if its successor is ready
+const handoverPollInterval time.Duration = 500 * time.Millisecond
+
+/*
+A handover restarts the node binary without dropping the client connections: the running process
+starts its successor passing the listening socket, so both accept connections on the same port
+for a moment, and stops once the successor records in the backlog (in the `handovers` index) that
+it's ready. The mempool and the pending work are already in the backlog, so the successor picks
+them up where the former process left them.
+
+The record is keyed by a random token that the former process passes to its successor (in the
+environment), so no other process can forge it by guessing a process id, and it's deleted once the
+former process saw it.
+
+The successor must not recover the backlog on startup, since the intents of the former process
+may still be in progress rather than left behind by a crash.
+*/
+type Handover struct {
+	NodeId  string `json:"node_id"`  // The id of the node that is restarting
+	Pid     int    `json:"pid"`      // The process id of the successor
+	ReadyAt int64  `json:"ready_at"` // The timestamp when the successor was ready to serve
+}
+
+// Generates the token of a new handover
+func NewHandoverToken() (string, error) {
+	token := make([]byte, 32)
+	if _, err := rand.Read(token); err != nil {
+		return "", fmt.Errorf("failed to generate the handover token: %v", err)
+	}
+
+	return hex.EncodeToString(token), nil
+}
+
+// Records, under the token given by the former process, that the current process took over the node
+// and is ready to serve
+func (n Node) CompleteHandover(ctx context.Context, token string, pid int) error {
+	handover := Handover{NodeId: n.Id, Pid: pid, ReadyAt: timeutil.Now()}
+
+	document, err := toDocument(handover)
+	if err != nil {
+		return err
+	}
+
+	if err := n.IndexDocument(ctx, "handovers", token, document); err != nil {
+		return fmt.Errorf("failed to record the handover: %v", err)
+	}
+
+	return nil
+}
+
+// Waits until the successor with the given process id records under the token that it's ready,
+// within a limit. The record is deleted once it's seen
+func (n Node) AwaitHandover(ctx context.Context, token string, pid int, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(handoverPollInterval)
+	defer ticker.Stop()
+
+	for {
+		_, err := n.GetDocument(ctx, "handovers", token)
+		if err == nil {
+			if err := n.DeleteDocument(ctx, "handovers", token); err != nil {
+				Warnf(ctx, "failed to delete the handover of the successor %d: %v", pid, err)
+			}
+
+			return nil
+		}
+
+		if !errors.Is(err, backlog.ErrNotFound) {
+			Logf(ctx, "failed to check the handover: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("the successor %d wasn't ready in %v", pid, timeout)
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package node
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	config "node/config"
+)
+
+/*
+Before syncing with its mirror or a peer, the node shakes hands with it: both sides tell their build,
+the version of the peer protocol, the height and the genesis of their chain, the parameters of the
+chain and the features enabled. Each side checks the other one, so a node never pulls the blocks or
+the documents of a node that follows another chain (a different genesis or parameters) or talks
+another protocol, which would only fail halfway through the sync.
+
+The build and the features are only informative: the nodes of different releases speak with each
+other as long as they share the protocol.
+*/
+type Handshake struct {
+	NodeId          string   `json:"node_id"`          // The id of the node
+	Version         string   `json:"version"`          // The build of the node (please, go to `build.go`)
+	ProtocolVersion int      `json:"protocol_version"` // The version of the peer protocol
+	Height          int64    `json:"height"`           // The height of the last block (-1 when the chain is empty)
+	GenesisHash     string   `json:"genesis_hash"`     // The hash of the first block (empty when the chain is empty)
+	ParamsHash      string   `json:"params_hash"`      // The digest of the parameters of the chain (please, go to `ratelimit.go`)
+	Features        []string `json:"features"`         // The features enabled in the node (please, go to `features.go` in the config)
+}
+
+// The version of the peer protocol. It changes whenever a peer RPC changes in a way that the older
+// nodes can't follow
+const protocolVersion int = 1
+
+var ErrIncompatiblePeer = errors.New("the peer is incompatible with the node")
+
+// Gives the digest of the parameters of the chain
+func (p ChainParams) Hash() string {
+	params, _ := json.Marshal(p)
+	sum := sha256.Sum256(params)
+	return hex.EncodeToString(sum[:])
+}
+
+// Gives the handshake of the node, as presented to its peers
+func (n Node) LocalHandshake(ctx context.Context) (*Handshake, error) {
+	handshake := Handshake{
+		NodeId:          n.Id,
+		Version:         n.Version,
+		ProtocolVersion: protocolVersion,
+		Height:          -1,
+		ParamsHash:      Params().Hash(),
+		Features:        config.EnabledFeatures(),
+	}
+
+	blockchain := NewBlockchain(n.Backlog)
+
+	last, err := blockchain.LastBlock(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	genesis, err := blockchain.BlockAt(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if last != nil {
+		handshake.Height = last.Height
+	}
+
+	// A node without a chain is only compatible with the genesis of its network, when it's pinned
+	if genesis != nil {
+		handshake.GenesisHash = genesis.Hash
+	} else {
+		handshake.GenesisHash = config.GenesisHash()
+	}
+
+	return &handshake, nil
+}
+
+// Checks that a peer follows the same chain and protocol as the node. A chain without blocks agrees
+// with any genesis (unless the node pinned the genesis of its network), since it's about to take the
+// genesis of the peer
+func (h Handshake) Compatible(peer Handshake) error {
+	switch {
+	case h.ProtocolVersion != peer.ProtocolVersion:
+		return fmt.Errorf("%w: the peer %s talks the protocol %d, not %d", ErrIncompatiblePeer, peer.NodeId, peer.ProtocolVersion, h.ProtocolVersion)
+	case h.ParamsHash != peer.ParamsHash:
+		return fmt.Errorf("%w: the peer %s follows other parameters of the chain", ErrIncompatiblePeer, peer.NodeId)
+	case h.GenesisHash != "" && peer.GenesisHash != "" && h.GenesisHash != peer.GenesisHash:
+		return fmt.Errorf("%w: the peer %s has the genesis %s, not %s", ErrIncompatiblePeer, peer.NodeId, peer.GenesisHash, h.GenesisHash)
+	}
+
+	return nil
+}
+
+// Checks the handshake of a peer that shook hands with the node. Gives the handshake of the node
+// when the peer is compatible
+func (n Node) AcceptHandshake(ctx context.Context, peer Handshake) (*Handshake, error) {
+	local, err := n.LocalHandshake(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := local.Compatible(peer); err != nil {
+		Warnf(ctx, "refused the handshake of %s (%s): %v", peer.NodeId, peer.Version, err)
+		return nil, err
+	}
+
+	return local, nil
+}
+
+// Shakes hands with a peer before syncing with it. Gives ErrIncompatiblePeer when either side refuses
+// the other one
+func (n Node) Handshake(ctx context.Context, host string) (*Handshake, error) {
+	if peerTransport == nil {
+		return nil, fmt.Errorf("there is no peer transport registered")
+	}
+
+	local, err := n.LocalHandshake(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	peer, err := peerTransport.Handshake(ctx, host, *local)
+	if errors.Is(err, ErrIncompatiblePeer) {
+		Warnf(ctx, "%s refused to sync with the node: %v", host, err)
+		return nil, err
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to shake hands with %s: %v", host, err)
+	}
+
+	if err := local.Compatible(*peer); err != nil {
+		Warnf(ctx, "refused to sync with %s (%s): %v", host, peer.Version, err)
+		return nil, err
+	}
+
+	Debugf(ctx, SubsystemSync, "shook hands with %s (%s, protocol %d, height %d)", host, peer.Version, peer.ProtocolVersion, peer.Height)
+	return peer, nil
+}
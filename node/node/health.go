@@ -0,0 +1,239 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	config "node/config"
+	timeutil "node/timeutil"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*
+The health of the node is broken down into checks of its dependencies, so the dashboard shows what's
+wrong and the orchestrator probes know if the node can serve. Every check has its status and the
+time it took, since a slow dependency is often the first sign of a failing one.
+
+The checks of the dependencies the node can't work without (the backlog, its mappings and the key
+store) make the node failing. The others (the mirror, the clock and the disk space) only make it
+degraded: the node still serves, maybe read-only.
+
+The health is checked by a job of every process (please, go to `StartHealthMonitor`), and the last
+report is kept, so the probes don't hit the dependencies on every poll.
+*/
+type HealthStatus string
+
+const (
+	HealthOk       HealthStatus = "ok"
+	HealthDegraded HealthStatus = "degraded"
+	HealthFailing  HealthStatus = "failing"
+)
+
+// The result of a check of the health
+type HealthCheck struct {
+	Name     string        `json:"name"`     // The name of the check, e.g. "backlog"
+	Status   HealthStatus  `json:"status"`   // Either "ok" or the status that the failure gives to the node
+	Detail   string        `json:"detail"`   // What was found
+	Latency  time.Duration `json:"latency"`  // How long the check took
+	Critical bool          `json:"critical"` // Whether the node fails when the check fails
+}
+
+// The health of the node, with the checks that it's made of
+type Health struct {
+	Status    HealthStatus  `json:"status"`     // The worst status of the checks
+	Checks    []HealthCheck `json:"checks"`     // The checks, in order
+	CheckedAt int64         `json:"checked_at"` // The timestamp when the health was checked
+}
+
+// The limit of every check, so a hanging dependency doesn't hang the report
+const healthCheckTimeout = 3 * time.Second
+
+// The age after which the last report is stale and the health is checked again
+const healthMaxAge = time.Minute
+
+// A check of some dependency, that gives what was found or why it failed
+type healthProbe struct {
+	name     string
+	critical bool
+	run      func(ctx context.Context, n *Node) (string, error)
+}
+
+var healthProbes = []healthProbe{
+	{"backlog", true, func(ctx context.Context, n *Node) (string, error) {
+		if _, err := n.ServerTime(ctx); err != nil {
+			return "", fmt.Errorf("the ElasticSearch is unreachable: %v", err)
+		}
+
+		return "the ElasticSearch is reachable", nil
+	}},
+	{"mappings", true, func(ctx context.Context, n *Node) (string, error) {
+		missing, err := n.MissingMappings(ctx)
+		if err != nil {
+			return "", err
+		}
+
+		if len(missing) > 0 {
+			return "", fmt.Errorf("missing %s", strings.Join(missing, ", "))
+		}
+
+		return "every index is mapped", nil
+	}},
+	{"keystore", true, func(ctx context.Context, n *Node) (string, error) {
+		if err := n.Keys.Probe(ctx); err != nil {
+			return "", err
+		}
+
+		return fmt.Sprintf("the %s key store is writable", config.KeyStore()), nil
+	}},
+	{"mirror", false, func(ctx context.Context, n *Node) (string, error) {
+		if n.Mirror == "" || n.Mirror == "0.0.0.0" || peerTransport == nil {
+			return "no mirror configured", nil
+		}
+
+		if err := peerTransport.Ping(ctx, n.Mirror); err != nil {
+			return "", fmt.Errorf("the mirror %s is unreachable: %v", n.Mirror, err)
+		}
+
+		return fmt.Sprintf("the mirror %s is reachable", n.Mirror), nil
+	}},
+	{"clock", false, func(ctx context.Context, n *Node) (string, error) {
+		serverTime, err := n.ServerTime(ctx)
+		if err != nil {
+			return "", fmt.Errorf("failed to read the backlog clock: %v", err)
+		}
+
+		finding := diagnoseClock(serverTime)
+		if !finding.Ok {
+			return "", fmt.Errorf("%s", finding.Detail)
+		}
+
+		return finding.Detail, nil
+	}},
+	{"disk", false, func(ctx context.Context, n *Node) (string, error) {
+		usages, err := MonitoredDiskUsage()
+		if err != nil {
+			return "", err
+		}
+
+		minFree := config.MinFreeDisk()
+		for _, usage := range usages {
+			if usage.Free < minFree {
+				return "", fmt.Errorf("%s has %d MB free, under the %d MB threshold", usage.Path, usage.Free/1024/1024, minFree/1024/1024)
+			}
+		}
+
+		return fmt.Sprintf("%d path(s) over the %d MB threshold", len(usages), minFree/1024/1024), nil
+	}},
+}
+
+// Checks every dependency of the node and gives its health
+func (n *Node) CheckHealth(ctx context.Context) Health {
+	health := Health{Status: HealthOk, Checks: make([]HealthCheck, len(healthProbes)), CheckedAt: timeutil.Now()}
+
+	var wg sync.WaitGroup
+	for i, probe := range healthProbes {
+		wg.Add(1)
+		go func(i int, probe healthProbe) {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+			defer cancel()
+
+			start := time.Now()
+			detail, err := probe.run(ctx, n)
+			check := HealthCheck{Name: probe.name, Status: HealthOk, Detail: detail, Latency: time.Since(start), Critical: probe.critical}
+
+			if err != nil {
+				check.Status, check.Detail = HealthDegraded, err.Error()
+				if probe.critical {
+					check.Status = HealthFailing
+				}
+			}
+
+			health.Checks[i] = check
+		}(i, probe)
+	}
+	wg.Wait()
+
+	for _, check := range health.Checks {
+		if check.Status == HealthFailing || (check.Status == HealthDegraded && health.Status == HealthOk) {
+			health.Status = check.Status
+		}
+	}
+
+	return health
+}
+
+var (
+	lastHealth      *Health
+	healthListeners []func(Health)
+	healthMutex     sync.Mutex
+)
+
+// Registers a function called with every report of the health (e.g. to update the probes)
+func RegisterHealthListener(listener func(Health)) {
+	healthMutex.Lock()
+	defer healthMutex.Unlock()
+
+	healthListeners = append(healthListeners, listener)
+}
+
+// Keeps a report of the health and gives it to the listeners
+func reportHealth(health Health) {
+	healthMutex.Lock()
+	lastHealth = &health
+	listeners := healthListeners
+	healthMutex.Unlock()
+
+	for _, listener := range listeners {
+		listener(health)
+	}
+}
+
+// Gives the last report of the health, and whether it's fresh (false when it's stale or missing)
+func LastHealth() (Health, bool) {
+	healthMutex.Lock()
+	defer healthMutex.Unlock()
+
+	if lastHealth == nil {
+		return Health{}, false
+	}
+
+	return *lastHealth, time.Since(timeutil.ToTime(lastHealth.CheckedAt)) < healthMaxAge
+}
+
+// Gives the last report of the health, checking the health again when the report is stale
+func (n *Node) Health(ctx context.Context) Health {
+	if health, fresh := LastHealth(); fresh {
+		return health
+	}
+
+	health := n.CheckHealth(ctx)
+	reportHealth(health)
+
+	return health
+}
+
+// Schedules the job that checks the health of the node, in every process
+func (n *Node) StartHealthMonitor(schedule string) error {
+	return ScheduleJob(Job{
+		Name:       "health_monitor",
+		Schedule:   schedule,
+		RunAtStart: true,
+		Run: func(ctx context.Context, lease *Lease) error {
+			health := n.CheckHealth(ctx)
+			reportHealth(health)
+
+			if health.Status != HealthOk {
+				for _, check := range health.Checks {
+					if check.Status != HealthOk {
+						Warnf(ctx, "the %s check is %s: %s", check.Name, check.Status, check.Detail)
+					}
+				}
+			}
+
+			return nil
+		},
+	})
+}
@@ -0,0 +1,138 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	backlog "node/backlog"
+)
+
+const (
+	defaultHistoryPage int = 20  // The number of transactions in a page of the history when it isn't set
+	maxHistoryPage     int = 100 // The maximum number of transactions in a page of the history
+)
+
+var ErrInvalidFilter = errors.New("invalid filter")
+
+// The filters of the transaction history of a client. The empty fields don't filter
+type TransactionFilter struct {
+	Since        int64  // The timestamp of the oldest transaction (inclusive)
+	Until        int64  // The timestamp of the newest transaction (inclusive)
+	Counterparty string // The client id of the other party
+	Status       string // Either "signed", "unsigned" or a status of the transactions (please, go to `TransactionStatus`)
+	Direction    string // Either "sent" or "received"
+}
+
+// Gives the query that matches the transactions where a client is a party (the "Sender" or the
+// "Recipient"). The client ids of the parties are mapped as keywords, so they're matched exactly
+func partyQuery(clientId string, parties ...string) backlog.Query {
+	query := backlog.Bool()
+	for _, party := range parties {
+		query.Should(backlog.Term(party+".client_id", clientId))
+	}
+
+	return query.Query()
+}
+
+// Gives the query of the filtered history of a client
+func (f TransactionFilter) query(clientId string) (backlog.Query, error) {
+	query := backlog.Bool()
+
+	switch f.Direction {
+	case "":
+		query.Must(partyQuery(clientId, "Sender", "Recipient"))
+	case "sent":
+		query.Must(partyQuery(clientId, "Sender"))
+	case "received":
+		query.Must(partyQuery(clientId, "Recipient"))
+	default:
+		return nil, fmt.Errorf("%w: unknown direction %q", ErrInvalidFilter, f.Direction)
+	}
+
+	if f.Counterparty != "" {
+		query.Must(partyQuery(f.Counterparty, "Sender", "Recipient"))
+	}
+
+	if f.Since != 0 || f.Until != 0 {
+		var since, until interface{}
+		if f.Since != 0 {
+			since = f.Since
+		}
+
+		if f.Until != 0 {
+			until = f.Until
+		}
+
+		query.Must(backlog.Range("Timestamp", since, until))
+	}
+
+	switch f.Status {
+	case "":
+	case "signed":
+		query.Must(backlog.Exists("Signature"))
+	case string(TransactionUnsigned):
+		query.MustNot(backlog.Exists("Signature"))
+	case string(TransactionPending):
+		query.Must(backlog.Exists("Signature")).MustNot(backlog.Exists("BlockHash"))
+	case string(TransactionConfirmed):
+		query.Must(backlog.Exists("BlockHash"))
+	default:
+		return nil, fmt.Errorf("%w: unknown status %q", ErrInvalidFilter, f.Status)
+	}
+
+	return query.Query(), nil
+}
+
+// Gives the status of a recorded transaction according to its signature and block
+func (r TransactionRecord) Status() TransactionStatus {
+	switch {
+	case r.Signature == "":
+		return TransactionUnsigned
+	case r.BlockHash == "":
+		return TransactionPending
+	default:
+		return TransactionConfirmed
+	}
+}
+
+// Gives a page of the transactions sent and received by a client, the newest first, with the
+// total of transactions that match the filter
+func (n Node) TransactionHistory(ctx context.Context, clientId string, filter TransactionFilter, offset, size int) ([]TransactionRecord, int64, error) {
+	query, err := filter.query(clientId)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if offset < 0 {
+		offset = 0
+	}
+
+	if size <= 0 {
+		size = defaultHistoryPage
+	} else if size > maxHistoryPage {
+		size = maxHistoryPage
+	}
+
+	documents, total, err := n.FindDocuments(ctx, "transactions", query, backlog.ListOptions{
+		From: offset,
+		Size: size,
+		Sort: []string{"Timestamp:desc", "Sequence:desc"},
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get the transaction history: %v", err)
+	}
+
+	var records []TransactionRecord
+	for _, document := range documents {
+		id, _ := document["_id"].(string)
+
+		record, err := decodeTransaction(id, document)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		records = append(records, *record)
+	}
+
+	return records, total, nil
+}
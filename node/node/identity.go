@@ -0,0 +1,106 @@
+package node
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	backlog "node/backlog"
+	client "node/client"
+	config "node/config"
+	hashing "node/hashing"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+/*
+The node is identified by a random id generated the first time it runs and kept in the `node_id`
+file under BASE_PATH. The id is the key of the node and peers documents and the identity of the node
+for its peers, while the host address is only metadata that may change (e.g. DHCP or cloud
+reassignments) without breaking the identity.
+
+Nodes created before the id existed were keyed by the hash of their host, which is kept as the
+legacy id to find their documents (please, go to `schema.go` to see how they're migrated).
+*/
+const nodeIdFile string = "node_id"
+
+// The file under BASE_PATH with the private key that the node signs its announcements with
+const nodeKeyFile string = "node.pem"
+
+// Gives the id of the node, generating and persisting it when the node runs for the first time
+func LoadNodeId() (string, error) {
+	path := filepath.Join(config.BasePath(), nodeIdFile)
+
+	content, err := os.ReadFile(path)
+	if err == nil {
+		if id := strings.TrimSpace(string(content)); id != "" {
+			return id, nil
+		}
+	} else if !os.IsNotExist(err) {
+		return "", fmt.Errorf("failed to read the node id: %v", err)
+	}
+
+	id, err := uuid.NewRandom()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate the node id: %v", err)
+	}
+
+	if err := os.WriteFile(path, []byte(id.String()+"\n"), 0644); err != nil {
+		return "", fmt.Errorf("failed to store the node id: %v", err)
+	}
+
+	return id.String(), nil
+}
+
+// Gives the key pair of the node, generating and persisting it when the node runs for the first time.
+// The public key is shared with the peers (as an identity) in the node document
+func LoadNodeKey() (*client.CryptoResource, error) {
+	path := filepath.Join(config.BasePath(), nodeKeyFile)
+
+	content, err := os.ReadFile(path)
+	if err == nil {
+		block, _ := pem.Decode(content)
+		if block == nil {
+			return nil, fmt.Errorf("failed to decode the node key")
+		}
+
+		privateKey, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the node key: %v", err)
+		}
+
+		return &client.CryptoResource{PrivateKey: privateKey, PublicKey: &privateKey.PublicKey}, nil
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read the node key: %v", err)
+	}
+
+	crypto, err := client.NewCryptoResource()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.WriteFile(path, crypto.ImpersonatePrivateKey(), 0600); err != nil {
+		return nil, fmt.Errorf("failed to store the node key: %v", err)
+	}
+
+	return crypto, nil
+}
+
+// Gives the id of the node documents before the node id existed (the hash of the host)
+func legacyNodeId(host string) string {
+	return hashing.LegacyHash(host)
+}
+
+// Gives the stored document of the node, falling back to the legacy id when it wasn't migrated yet
+func (n Node) storedDocument(ctx context.Context) (map[string]interface{}, error) {
+	document, err := n.GetDocument(ctx, "node", n.Id)
+	if errors.Is(err, backlog.ErrNotFound) {
+		return n.GetDocument(ctx, "node", legacyNodeId(n.Host))
+	}
+
+	return document, err
+}
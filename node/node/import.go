@@ -0,0 +1,245 @@
+package node
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	client "node/client"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+/*
+The operators migrating an existing user base onto a node import its clients in bulk from a manifest,
+through the admin API or the `import` command. Every entry of the manifest is a client with its alias,
+password and secret, and either the flag to generate a new key pair or the key pair that the client
+already has (its PEM private key, from which the public key follows). The node holds the keys of its
+clients, so an entry with only a public key can't be imported: the client couldn't sign anything.
+
+The manifest is a JSON array of entries or a CSV with a header row naming the columns (alias,
+password, secret, public_key, private_key, generate, terms_version). The entries are created in
+batches, each one through the same staged creation of a single client (please, go to `creation.go`),
+and the progress is reported after every batch. A failed entry doesn't stop the import.
+*/
+type ClientImport struct {
+	Alias        string `json:"alias"`         // The alias of the client
+	Password     string `json:"password"`      // The password of the client
+	Secret       string `json:"secret"`        // The secret that seals the client keys
+	PublicKey    string `json:"public_key"`    // The PEM public key of the client (checked against the private key)
+	PrivateKey   string `json:"private_key"`   // The PEM private key of the client, when it keeps its key pair
+	Generate     bool   `json:"generate"`      // Whether the node generates a new key pair for the client
+	TermsVersion string `json:"terms_version"` // The version of the terms accepted by the client (please, go to `terms.go`)
+}
+
+// The outcome of an entry of the manifest
+type ImportResult struct {
+	Alias    string `json:"alias"`     // The alias of the entry
+	UID      string `json:"uid"`       // The internal reference of the client created (empty when it failed)
+	ClientId string `json:"client_id"` // The external reference of the client created (empty when it failed)
+	Error    string `json:"error"`     // Why the entry failed (empty when it succeeded)
+}
+
+// The progress of an import, reported after every batch
+type ImportProgress struct {
+	Done    int            // The number of entries processed so far
+	Total   int            // The number of entries of the manifest
+	Failed  int            // The number of entries that failed so far
+	Results []ImportResult // The outcomes of the entries of the last batch
+}
+
+const (
+	importBatchSize   int = 50 // The number of entries created in each batch
+	importConcurrency int = 4  // The number of entries created at the same time (the key generation is expensive)
+)
+
+var ErrInvalidManifest = errors.New("invalid manifest")
+
+// Parses a manifest of clients, as a CSV when its name ends with `.csv` and as JSON otherwise
+func ParseClientManifest(name string, content []byte) ([]ClientImport, error) {
+	if strings.EqualFold(filepath.Ext(name), ".csv") {
+		return parseCSVManifest(content)
+	}
+
+	var entries []ClientImport
+	if err := json.Unmarshal(content, &entries); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidManifest, err)
+	}
+
+	return entries, nil
+}
+
+func parseCSVManifest(content []byte) ([]ClientImport, error) {
+	rows, err := csv.NewReader(bytes.NewReader(content)).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidManifest, err)
+	}
+
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	columns := map[string]int{}
+	for i, column := range rows[0] {
+		columns[strings.ToLower(strings.TrimSpace(column))] = i
+	}
+
+	if _, ok := columns["alias"]; !ok {
+		return nil, fmt.Errorf("%w: the header has no alias column", ErrInvalidManifest)
+	}
+
+	var entries []ClientImport
+	for line, row := range rows[1:] {
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(row) {
+				return strings.TrimSpace(row[i])
+			}
+
+			return ""
+		}
+
+		entry := ClientImport{
+			Alias:        field("alias"),
+			Password:     field("password"),
+			Secret:       field("secret"),
+			PublicKey:    field("public_key"),
+			PrivateKey:   field("private_key"),
+			TermsVersion: field("terms_version"),
+		}
+
+		if generate := field("generate"); generate != "" {
+			if entry.Generate, err = strconv.ParseBool(generate); err != nil {
+				return nil, fmt.Errorf("%w: invalid generate flag at the line %d", ErrInvalidManifest, line+2)
+			}
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// Gives the key pair of an entry (nil when the node must generate it)
+func (e ClientImport) crypto() (*client.CryptoResource, error) {
+	switch {
+	case e.Generate && e.PrivateKey != "":
+		return nil, fmt.Errorf("%w: the entry must either generate its keys or give its private key", ErrInvalidManifest)
+	case e.Generate:
+		return nil, nil
+	case e.PrivateKey == "" && e.PublicKey != "":
+		return nil, fmt.Errorf("%w: the node holds the keys of its clients, so the private key is required", ErrInvalidManifest)
+	case e.PrivateKey == "":
+		return nil, fmt.Errorf("%w: the entry must either generate its keys or give its private key", ErrInvalidManifest)
+	}
+
+	crypto, err := client.ParsePrivateKeyPEM(e.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidManifest, err)
+	}
+
+	if e.PublicKey != "" {
+		publicKey, err := client.ParsePublicKeyPEM(e.PublicKey)
+		if err != nil || !publicKey.Equal(crypto.PublicKey) {
+			return nil, fmt.Errorf("%w: the public key doesn't match the private key", ErrInvalidManifest)
+		}
+	}
+
+	return crypto, nil
+}
+
+// Creates the client of an entry of a manifest
+func (n Node) ImportClient(ctx context.Context, entry ClientImport) (*Client, error) {
+	if entry.Alias == "" || entry.Password == "" || entry.Secret == "" {
+		return nil, fmt.Errorf("%w: the entry requires: alias, password, secret", ErrInvalidManifest)
+	}
+
+	if err := CheckPasswordPolicy(entry.Password); err != nil {
+		return nil, err
+	}
+
+	crypto, err := entry.crypto()
+	if err != nil {
+		return nil, err
+	}
+
+	// A key pair that is already known would give two clients the same id
+	if crypto != nil {
+		identity, err := crypto.Identity()
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := n.GetDocument(ctx, "clients", identity); err == nil {
+			return nil, fmt.Errorf("%w: the key pair belongs to the client %s", ErrInvalidManifest, identity)
+		}
+	}
+
+	return n.newLocalClient(ctx, entry.Alias, "", entry.Secret, entry.Password, entry.TermsVersion, crypto)
+}
+
+// Creates the clients of a manifest in batches, reporting the progress after every batch. It stops
+// early only when the context is done
+func (n Node) ImportClients(ctx context.Context, entries []ClientImport, report func(ImportProgress) error) error {
+	if err := n.CheckWritable(); err != nil {
+		return err
+	}
+
+	progress := ImportProgress{Total: len(entries)}
+
+	for start := 0; start < len(entries); start += importBatchSize {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		end := start + importBatchSize
+		if end > len(entries) {
+			end = len(entries)
+		}
+
+		batch := entries[start:end]
+		results := make([]ImportResult, len(batch))
+		slots := make(chan struct{}, importConcurrency)
+		var wg sync.WaitGroup
+
+		for i, entry := range batch {
+			wg.Add(1)
+			slots <- struct{}{}
+
+			go func(i int, entry ClientImport) {
+				defer wg.Done()
+				defer func() { <-slots }()
+
+				result := ImportResult{Alias: entry.Alias}
+				if created, err := n.ImportClient(ctx, entry); err != nil {
+					result.Error = err.Error()
+				} else {
+					result.UID, result.ClientId = created.UID, created.ClientId
+				}
+
+				results[i] = result
+			}(i, entry)
+		}
+
+		wg.Wait()
+
+		for _, result := range results {
+			if result.Error != "" {
+				progress.Failed++
+			}
+		}
+
+		progress.Done += len(batch)
+		progress.Results = results
+		Logf(ctx, "imported %d of %d client(s) (%d failed)", progress.Done, progress.Total, progress.Failed)
+
+		if err := report(progress); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
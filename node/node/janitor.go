@@ -0,0 +1,130 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	backlog "node/backlog"
+	config "node/config"
+	timeutil "node/timeutil"
+	"time"
+)
+
+const (
+	leaseRetention    time.Duration = time.Hour          // How long an expired lease is kept before the janitor deletes it
+	handoverRetention time.Duration = 24 * time.Hour     // How long a completed handover is kept before the janitor deletes it
+	jobRunRetention   time.Duration = 7 * 24 * time.Hour // How long the runs of the jobs are kept in their history
+	jobStateRetention time.Duration = 24 * time.Hour     // How long the state of a job of a process that's gone is kept
+	cacheRetention    time.Duration = time.Hour          // How long the credentials of an expired session (or token) are kept, so the client is told why
+)
+
+// Schedules the janitor, a singleton job that deletes the records that outlived their use from the
+// backlog
+func (n Node) StartJanitor(schedule string) error {
+	return ScheduleJob(Job{
+		Name:      "janitor",
+		Schedule:  schedule,
+		Singleton: true,
+		Retries:   1,
+		Run: func(ctx context.Context, lease *Lease) error {
+			count, err := n.sweep(ctx, *lease)
+			if count > 0 {
+				Logf(ctx, "the janitor deleted %d stale document(s)", count)
+			}
+
+			return err
+		},
+	})
+}
+
+// Deletes the leases that expired long ago, the old handovers, the expired credentials, the old
+// history of the jobs and the requests out of their deduplication window. Gives how many were deleted
+func (n Node) sweep(ctx context.Context, lease Lease) (int, error) {
+	leases, _, err := n.FindDocuments(ctx, "leases", backlog.Range("expires_at", nil, timeutil.After(-leaseRetention)), backlog.ListOptions{All: true})
+	if err != nil {
+		return 0, fmt.Errorf("failed to find the expired leases: %v", err)
+	}
+
+	handovers, _, err := n.FindDocuments(ctx, "handovers", backlog.Range("ready_at", nil, timeutil.After(-handoverRetention)), backlog.ListOptions{All: true})
+	if err != nil {
+		return 0, fmt.Errorf("failed to find the old handovers: %v", err)
+	}
+
+	runs, _, err := n.FindDocuments(ctx, "job_runs", backlog.Range("started", nil, timeutil.After(-jobRunRetention)), backlog.ListOptions{All: true})
+	if err != nil {
+		return 0, fmt.Errorf("failed to find the old job runs: %v", err)
+	}
+
+	// The jobs that run in every process keep a state by process, that stops changing when it's gone
+	states, _, err := n.FindDocuments(ctx, "jobs", backlog.Bool().
+		Must(backlog.Exists("instance"), backlog.Range("updated_at", nil, timeutil.After(-jobStateRetention))).
+		MustNot(backlog.Term("instance", "")).
+		Query(), backlog.ListOptions{All: true})
+	if err != nil {
+		return 0, fmt.Errorf("failed to find the stale job states: %v", err)
+	}
+
+	// The credentials whose session (or token) expired can't authenticate anymore
+	caches, _, err := n.FindDocuments(ctx, "cache", backlog.Bool().Should(
+		backlog.Range("expires_at", 1, timeutil.After(-cacheRetention)),
+		backlog.Range("timestamp", nil, timeutil.After(-config.TokenTTL()-cacheRetention)),
+	).Query(), backlog.ListOptions{All: true})
+	if err != nil {
+		return 0, fmt.Errorf("failed to find the expired credentials: %v", err)
+	}
+
+	// The responses kept for the retries are useless once their window is over (please, go to `dedup.go`)
+	requests, _, err := n.FindDocuments(ctx, "requests", backlog.Range("created_at", nil, timeutil.After(-config.DedupWindow())), backlog.ListOptions{All: true})
+	if err != nil {
+		return 0, fmt.Errorf("failed to find the old requests: %v", err)
+	}
+
+	var stale [][2]string
+	for _, document := range leases {
+		duty, _ := document["duty"].(string)
+		stale = append(stale, [2]string{"leases", duty})
+	}
+
+	for _, document := range handovers {
+		id, _ := document["_id"].(string)
+		stale = append(stale, [2]string{"handovers", id})
+	}
+
+	for _, document := range runs {
+		id, _ := document["_id"].(string)
+		stale = append(stale, [2]string{"job_runs", id})
+	}
+
+	for _, document := range caches {
+		id, _ := document["_id"].(string)
+		stale = append(stale, [2]string{"cache", id})
+	}
+
+	for _, document := range states {
+		id, _ := document["_id"].(string)
+		stale = append(stale, [2]string{"jobs", id})
+	}
+
+	for _, document := range requests {
+		id, _ := document["_id"].(string)
+		stale = append(stale, [2]string{"requests", id})
+	}
+
+	if len(stale) == 0 {
+		return 0, nil
+	}
+
+	if err := n.CheckLease(ctx, lease); err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	for _, target := range stale {
+		if err := n.DeleteDocument(ctx, target[0], target[1]); err != nil {
+			return deleted, fmt.Errorf("failed to delete the document %s/%s: %v", target[0], target[1], err)
+		}
+
+		deleted++
+	}
+
+	return deleted, nil
+}
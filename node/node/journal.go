@@ -0,0 +1,175 @@
+package node
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	backlog "node/backlog"
+	config "node/config"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+/*
+The commit of a block writes the block and the confirmation of its transactions (the balances are
+folded from them, please, go to `ledger.go`). The atomic commit of the backlog rolls back the
+commits interrupted by a crash (please, go to `commit.go` in the backlog), but a block that was
+rolled back is lost until the sync fetches it again, and the blocks mined by the node may exist
+nowhere else.
+
+So the block is validated, written to a local journal (under BASE_PATH/journal, one file by
+block, synced to the disk) and only then committed. The entry is removed once the commit is done.
+An entry left in the journal means the commit failed (e.g. the backlog was unavailable) or the
+process died in the middle of it: on startup, after the interrupted commits are rolled back, the
+block is appended again, so the block is either fully committed or not at all. It's dropped when
+it's already in the chain or the validation refuses it (e.g. it no longer follows the chain), and
+kept for the next startup when the backlog fails again. Only the commit is replayed, not what
+follows it (e.g. the plugins or the events).
+*/
+const journalDir string = "journal"
+
+// Gives the path of the journal entry of a block
+func journalPath(hash string) string {
+	return filepath.Join(config.BasePath(), journalDir, hash+".json")
+}
+
+// Writes the block to the journal, synced to the disk, before it's committed
+func journalBlock(ctx context.Context, block Block) error {
+	path := journalPath(block.Hash)
+	if plan := backlog.DryRunOf(ctx); plan != nil {
+		plan.RecordFile(path)
+		return nil
+	}
+
+	content, err := json.Marshal(block)
+	if err != nil {
+		return fmt.Errorf("failed to marshal the block %d for the journal: %v", block.Height, err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create the journal: %v", err)
+	}
+
+	// The entry is renamed into place once it's on the disk, so a torn write is never replayed
+	temporary := path + ".tmp"
+	file, err := os.OpenFile(temporary, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to journal the block %d: %v", block.Height, err)
+	}
+
+	_, err = file.Write(content)
+	if err == nil {
+		err = file.Sync()
+	}
+
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		err = os.Rename(temporary, path)
+	}
+
+	if err != nil {
+		os.Remove(temporary)
+		return fmt.Errorf("failed to journal the block %d: %v", block.Height, err)
+	}
+
+	if dir, err := os.Open(filepath.Dir(path)); err == nil {
+		dir.Sync()
+		dir.Close()
+	}
+
+	return nil
+}
+
+// Removes the journal entry of a block, once it's committed or refused
+func unjournalBlock(ctx context.Context, hash string) error {
+	if backlog.DryRunOf(ctx) != nil {
+		return nil
+	}
+
+	if err := os.Remove(journalPath(hash)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove the block %s from the journal: %v", hash, err)
+	}
+
+	return nil
+}
+
+// Replays the block commits that failed or were interrupted by the crash, after their intents were
+// rolled back
+func recoverJournal(ctx context.Context, n *Node, unclean bool) ([]string, error) {
+	dir := filepath.Join(config.BasePath(), journalDir)
+
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read the journal: %v", err)
+	}
+
+	var repairs []string
+	blockchain := NewBlockchain(n.Backlog)
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+
+		// The younger entries may belong to a commit of another process that is still running
+		info, err := entry.Info()
+		if err != nil || entry.IsDir() || time.Since(info.ModTime()) < recoveryGrace {
+			continue
+		}
+
+		// The commit of a torn entry never started
+		if strings.HasSuffix(entry.Name(), ".tmp") {
+			if err := os.Remove(path); err != nil {
+				return repairs, fmt.Errorf("failed to remove the torn journal entry %s: %v", entry.Name(), err)
+			}
+
+			repairs = append(repairs, fmt.Sprintf("removed the torn journal entry %s", entry.Name()))
+			continue
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return repairs, fmt.Errorf("failed to read the journal entry %s: %v", entry.Name(), err)
+		}
+
+		block := Block{}
+		if err := json.Unmarshal(content, &block); err != nil || block.Hash == "" {
+			if err := os.Remove(path); err != nil {
+				return repairs, fmt.Errorf("failed to remove the journal entry %s: %v", entry.Name(), err)
+			}
+
+			repairs = append(repairs, fmt.Sprintf("removed the unreadable journal entry %s", entry.Name()))
+			continue
+		}
+
+		_, err = n.GetDocument(ctx, "blockchain", block.Hash)
+		switch {
+		case err == nil:
+			repairs = append(repairs, fmt.Sprintf("cleared the journal of the committed block %d", block.Height))
+		case !errors.Is(err, backlog.ErrNotFound):
+			return repairs, fmt.Errorf("failed to read the block %d: %v", block.Height, err)
+		default:
+			err := blockchain.Append(ctx, &block)
+			switch {
+			case errors.Is(err, ErrInvalidBlock):
+				repairs = append(repairs, fmt.Sprintf("dropped the journaled block %d: %v", block.Height, err))
+			case err != nil:
+				// The entry is kept, so the block is replayed once the backlog works again
+				return repairs, fmt.Errorf("failed to replay the journaled block %d: %v", block.Height, err)
+			default:
+				repairs = append(repairs, fmt.Sprintf("replayed the interrupted commit of the block %d", block.Height))
+			}
+		}
+
+		if err := unjournalBlock(ctx, block.Hash); err != nil {
+			return repairs, err
+		}
+	}
+
+	return repairs, nil
+}
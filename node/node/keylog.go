@@ -0,0 +1,292 @@
+package node
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	backlog "node/backlog"
+	timeutil "node/timeutil"
+	"strconv"
+)
+
+/*
+The key log is an append-only record of every key that the node registered for its clients, at
+their creation and at every rotation (please, go to `rotation.go`). Every entry carries the hash of
+the entry before it, so the log is a hash chain: its head (the size and the hash of the last entry)
+commits to the whole history, and a node can't change or drop an entry without changing every head
+after it.
+
+A caller that keeps the last head it saw can ask for the entries after it and check that they chain
+from that head to the current one (the consistency proof). If the node ever served a different key
+for the same identity, the caller either finds both keys in the log or can't chain the heads, and
+so it can prove that the node forked its own history.
+
+The entries are created with the sequence as their id, so two processes appending at once can't
+both take the same position: the one that loses reads the head again and retries.
+*/
+type KeyLogEntry struct {
+	Sequence     int64  `json:"sequence"`              // The position of the entry in the log (the first is 1)
+	Kind         string `json:"kind"`                  // Either "registration" or "rotation"
+	ClientId     string `json:"client_id"`             // The identity of the key
+	PreviousId   string `json:"previous_id,omitempty"` // The identity replaced by the key (rotations only)
+	NodeId       string `json:"node_id"`               // The node that registered the key
+	LoggedAt     int64  `json:"logged_at"`             // The timestamp when the entry was appended
+	PreviousHash string `json:"previous_hash"`         // The hash of the entry before it (empty for the first)
+	Hash         string `json:"hash"`                  // The hash of all the other fields
+}
+
+const (
+	KeyRegistered string = "registration"
+	KeyRotated    string = "rotation"
+)
+
+var ErrOutOfLog = errors.New("the size is out of the key log")
+
+// The maximum number of entries given at once
+const keyLogPageSize int64 = 1000
+
+// How many times an append is retried when another process took its position
+const keyLogRetries int = 5
+
+// Converts the entry (except the hash itself) to a hashable byte array
+func (e KeyLogEntry) ToBytes() ([]byte, error) {
+	entry := map[string]interface{}{
+		"sequence":      e.Sequence,
+		"kind":          e.Kind,
+		"client_id":     e.ClientId,
+		"previous_id":   e.PreviousId,
+		"node_id":       e.NodeId,
+		"logged_at":     e.LoggedAt,
+		"previous_hash": e.PreviousHash,
+	}
+
+	entryBytes, err := json.Marshal(entry)
+	return entryBytes, err
+}
+
+// Computes the hash of the entry
+func (e KeyLogEntry) ComputeHash() string {
+	// The entry only holds integers and strings, so its encoding never fails (and the empty hash
+	// never matches an entry)
+	entryBytes, err := e.ToBytes()
+	if err != nil {
+		return ""
+	}
+
+	hash := sha256.Sum256(entryBytes)
+	return hex.EncodeToString(hash[:])
+}
+
+// The head of the key log: the number of entries and the hash of the last one (empty when empty)
+type KeyLogHead struct {
+	Size int64  `json:"size"`
+	Hash string `json:"hash"`
+}
+
+// The entries that lead from a head of the log to a later one
+type KeyLogProof struct {
+	From    KeyLogHead    `json:"from"`
+	To      KeyLogHead    `json:"to"`
+	Entries []KeyLogEntry `json:"entries"`
+}
+
+// Checks that the entries chain from the first head to the second one, so the later log extends
+// the former one
+func (p KeyLogProof) Verify() error {
+	if int64(len(p.Entries)) != p.To.Size-p.From.Size {
+		return fmt.Errorf("the proof has %d entries from the size %d to %d", len(p.Entries), p.From.Size, p.To.Size)
+	}
+
+	previous := p.From.Hash
+	for i, entry := range p.Entries {
+		if entry.Sequence != p.From.Size+int64(i)+1 {
+			return fmt.Errorf("the entry %d is out of place", entry.Sequence)
+		}
+
+		if entry.PreviousHash != previous || entry.ComputeHash() != entry.Hash {
+			return fmt.Errorf("the entry %d doesn't chain from the one before it", entry.Sequence)
+		}
+
+		previous = entry.Hash
+	}
+
+	if previous != p.To.Hash {
+		return fmt.Errorf("the entries don't lead to the head %d", p.To.Size)
+	}
+
+	return nil
+}
+
+func keyLogEntryFromDocument(document map[string]interface{}) (*KeyLogEntry, error) {
+	var entry KeyLogEntry
+	id, _ := document["_id"].(string)
+
+	if err := fromDocument("key_log", id, document, &entry, "sequence", "client_id", "hash"); err != nil {
+		return nil, err
+	}
+
+	return &entry, nil
+}
+
+// Gives the current head of the key log
+func (n Node) KeyLogHead(ctx context.Context) (KeyLogHead, error) {
+	documents, err := n.SearchDocuments(ctx, "key_log", map[string]interface{}{
+		"size": 1,
+		"sort": []interface{}{map[string]interface{}{"sequence": "desc"}},
+	})
+	if err != nil {
+		return KeyLogHead{}, fmt.Errorf("failed to get the head of the key log: %v", err)
+	}
+
+	if len(documents) == 0 {
+		return KeyLogHead{}, nil
+	}
+
+	entry, err := keyLogEntryFromDocument(documents[0])
+	if err != nil {
+		return KeyLogHead{}, err
+	}
+
+	return KeyLogHead{Size: entry.Sequence, Hash: entry.Hash}, nil
+}
+
+// Appends the key of a client to the log
+func (n Node) appendKeyLog(ctx context.Context, kind, clientId, previousId string) (*KeyLogEntry, error) {
+	for attempt := 0; attempt < keyLogRetries; attempt++ {
+		head, err := n.KeyLogHead(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		entry := KeyLogEntry{
+			Sequence:     head.Size + 1,
+			Kind:         kind,
+			ClientId:     clientId,
+			PreviousId:   previousId,
+			NodeId:       n.Id,
+			LoggedAt:     timeutil.Now(),
+			PreviousHash: head.Hash,
+		}
+		entry.Hash = entry.ComputeHash()
+
+		document, err := toDocument(entry)
+		if err != nil {
+			return nil, err
+		}
+
+		err = n.CreateDocument(backlog.WithPriority(ctx, backlog.PriorityChain), "key_log", strconv.FormatInt(entry.Sequence, 10), document)
+		if errors.Is(err, backlog.ErrConflict) {
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to append the key of %s to the log: %v", clientId, err)
+		}
+
+		return &entry, nil
+	}
+
+	return nil, fmt.Errorf("failed to append the key of %s to the log: the log is too busy", clientId)
+}
+
+// Appends the key of a client to the log, only logging the failure, since the key was already
+// committed (the recovery appends the keys missing from the log)
+func (n Node) logKey(ctx context.Context, kind, clientId, previousId string) {
+	if _, err := n.appendKeyLog(ctx, kind, clientId, previousId); err != nil {
+		Logf(ctx, "%v", err)
+	}
+}
+
+// Gives the entries of the log after the size `from` up to the size `to` (the current head when
+// zero), limited to a page, with the heads they lead from and to
+func (n Node) KeyLogProof(ctx context.Context, from, to int64) (*KeyLogProof, error) {
+	head, err := n.KeyLogHead(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if to <= 0 || to > head.Size {
+		to = head.Size
+	}
+
+	if from < 0 || from > to {
+		return nil, fmt.Errorf("%w: the size %d is after the size %d", ErrOutOfLog, from, to)
+	}
+
+	if to-from > keyLogPageSize {
+		to = from + keyLogPageSize
+	}
+
+	// The entry at `from` gives the hash of the first head
+	first := from
+	if first == 0 {
+		first = 1
+	}
+
+	documents, err := n.SearchDocuments(ctx, "key_log", map[string]interface{}{
+		"size":  to - first + 1,
+		"sort":  []interface{}{map[string]interface{}{"sequence": "asc"}},
+		"query": backlog.Range("sequence", first, to),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the key log: %v", err)
+	}
+
+	proof := KeyLogProof{From: KeyLogHead{Size: from}, To: KeyLogHead{Size: to}}
+	for _, document := range documents {
+		entry, err := keyLogEntryFromDocument(document)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case entry.Sequence == from:
+			proof.From.Hash = entry.Hash
+		case entry.Sequence > from:
+			proof.Entries = append(proof.Entries, *entry)
+		}
+
+		if entry.Sequence == to {
+			proof.To.Hash = entry.Hash
+		}
+	}
+
+	return &proof, nil
+}
+
+// Appends to the key log the keys of the local clients that are missing from it, e.g. when the node
+// stopped right after a client was committed
+func recoverKeyLog(ctx context.Context, n *Node, unclean bool) ([]string, error) {
+	logged := map[string]bool{}
+	err := n.ScrollDocuments(ctx, "key_log", backlog.ListOptions{}, func(document map[string]interface{}) error {
+		clientId, _ := document["client_id"].(string)
+		logged[clientId] = true
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the key log: %v", err)
+	}
+
+	var missing []string
+	err = n.ScrollDocuments(ctx, "local_clients", backlog.ListOptions{}, func(document map[string]interface{}) error {
+		if clientId, _ := document["client_id"].(string); clientId != "" && !logged[clientId] {
+			missing = append(missing, clientId)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the local clients: %v", err)
+	}
+
+	var repairs []string
+	for _, clientId := range missing {
+		if _, err := n.appendKeyLog(ctx, KeyRegistered, clientId, ""); err != nil {
+			return repairs, err
+		}
+
+		repairs = append(repairs, fmt.Sprintf("appended the key of %s to the key log", clientId))
+	}
+
+	return repairs, nil
+}
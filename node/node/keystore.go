@@ -0,0 +1,150 @@
+package node
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	backlog "node/backlog"
+	client "node/client"
+	config "node/config"
+	timeutil "node/timeutil"
+)
+
+/*
+The BacklogKeyStore keeps the key pairs of the clients in the `key_store` index, so the clients can
+be hosted on nodes without persistent disks, and every process of the node reads the same keys. The
+key pairs are sealed to the node key before they're stored: the private keys are already encrypted
+by the client secrets, and the index alone doesn't even tell which public key belongs to which uid.
+*/
+type BacklogKeyStore struct {
+	backlog *backlog.Backlog
+	key     *client.CryptoResource
+}
+
+// Creates a key store over the backlog, sealing the keys with the node key
+func NewBacklogKeyStore(b *backlog.Backlog, key *client.CryptoResource) *BacklogKeyStore {
+	return &BacklogKeyStore{backlog: b, key: key}
+}
+
+func (s BacklogKeyStore) Put(ctx context.Context, uid string, keys client.KeyPair) error {
+	keysBytes, err := json.Marshal(keys)
+	if err != nil {
+		return fmt.Errorf("failed to marshal the keys of %s: %v", uid, err)
+	}
+
+	sealed, err := client.Seal(keysBytes, s.key.PublicKey)
+	if err != nil {
+		return fmt.Errorf("failed to seal the keys of %s: %v", uid, err)
+	}
+
+	err = s.backlog.IndexDocument(ctx, "key_store", uid, map[string]interface{}{
+		"sealed":    sealed,
+		"stored_at": timeutil.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store the keys of %s: %v", uid, err)
+	}
+
+	return nil
+}
+
+func (s BacklogKeyStore) Get(ctx context.Context, uid string) (*client.KeyPair, error) {
+	document, err := s.backlog.GetDocument(ctx, "key_store", uid)
+	if errors.Is(err, backlog.ErrNotFound) {
+		return nil, client.ErrNoKeys
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get the keys of %s: %v", uid, err)
+	}
+
+	sealed, _ := document["sealed"].(string)
+	keysBytes, err := s.key.Open(sealed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the keys of %s: %v", uid, err)
+	}
+
+	keys := client.KeyPair{}
+	if err := json.Unmarshal(keysBytes, &keys); err != nil {
+		return nil, fmt.Errorf("%w: the stored keys of %s are unreadable", ErrSchemaDrift, uid)
+	}
+
+	return &keys, nil
+}
+
+func (s BacklogKeyStore) Delete(ctx context.Context, uid string) error {
+	if err := s.backlog.DeleteDocument(ctx, "key_store", uid); err != nil && !errors.Is(err, backlog.ErrNotFound) {
+		return fmt.Errorf("failed to delete the keys of %s: %v", uid, err)
+	}
+
+	return nil
+}
+
+func (s BacklogKeyStore) List(ctx context.Context) ([]string, error) {
+	documents, err := s.backlog.ListDocuments(ctx, "key_store", backlog.ListOptions{All: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the stored keys: %v", err)
+	}
+
+	var uids []string
+	for _, document := range documents {
+		if id, _ := document["_id"].(string); id != "" {
+			uids = append(uids, id)
+		}
+	}
+
+	return uids, nil
+}
+
+func (s BacklogKeyStore) Probe(ctx context.Context) error {
+	if err := s.backlog.IndexExists(ctx, "key_store"); err != nil {
+		return fmt.Errorf("the key_store index is unavailable: %v", err)
+	}
+
+	return nil
+}
+
+// The file store of the node, that only records the files it would touch in a dry run (please, go to
+// `dryrun.go` in the backlog)
+type plannedFileKeyStore struct {
+	*client.FileKeyStore
+}
+
+func (s plannedFileKeyStore) Put(ctx context.Context, uid string, keys client.KeyPair) error {
+	if plan := backlog.DryRunOf(ctx); plan != nil {
+		plan.RecordFile(s.Dir(uid))
+		return nil
+	}
+
+	return s.FileKeyStore.Put(ctx, uid, keys)
+}
+
+func (s plannedFileKeyStore) Delete(ctx context.Context, uid string) error {
+	if plan := backlog.DryRunOf(ctx); plan != nil {
+		plan.RecordFile(s.Dir(uid))
+		return nil
+	}
+
+	return s.FileKeyStore.Delete(ctx, uid)
+}
+
+// The memory store is shared by all the nodes loaded by the process, so it lives as long as it
+var memoryKeys = client.NewMemoryKeyStore()
+
+// Opens the key store of the given kind (please, go to `KEY_STORE` in the config)
+func openKeyStore(kind string, roots []string, b *backlog.Backlog, key *client.CryptoResource) (client.KeyStore, error) {
+	switch kind {
+	case config.FileKeyStore:
+		store, err := client.NewFileKeyStore(roots...)
+		if err != nil {
+			return nil, err
+		}
+
+		return plannedFileKeyStore{store}, nil
+	case config.BacklogKeyStore:
+		return NewBacklogKeyStore(b, key), nil
+	case config.MemoryKeyStore:
+		return memoryKeys, nil
+	default:
+		return nil, fmt.Errorf("unknown key store %q", kind)
+	}
+}
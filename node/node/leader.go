@@ -0,0 +1,167 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	backlog "node/backlog"
+	timeutil "node/timeutil"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+/*
+Several processes may serve the same node behind a load balancer, sharing its backlog. The requests
+can reach any of them, but the background jobs of the node (e.g. the mirror sync or the janitor)
+must run exactly once, so they're guarded by leases: a lease is a document of the `leases` index, by
+duty, that tells which process holds the duty until when. The holder renews the lease in every run of
+the job (please, go to `scheduler.go`), and another process takes it over once it expires.
+
+The leases are written only if they didn't change since they were read, so two processes never take
+the same lease at once. Every time a lease changes hands its fencing token grows, and a holder checks
+its token before writing (`CheckLease`), so a process that lost the lease while paused (e.g. by a long
+garbage collection) can't write over its successor.
+
+ElasticSearch has no expiration of documents, so the janitor job deletes the leases that expired long
+ago (please, go to `janitor.go`).
+*/
+type Lease struct {
+	Duty      string `json:"duty"`       // The name of the duty
+	Holder    string `json:"holder"`     // The id of the process that holds the duty
+	Token     int64  `json:"token"`      // The fencing token, that grows every time the lease changes hands
+	ExpiresAt int64  `json:"expires_at"` // The timestamp when the lease expires, unless it's renewed
+}
+
+// Given when a process acts on a lease that was taken over by another process
+var ErrLeaseLost = errors.New("the lease was taken over by another process")
+
+// The id of the current process among the processes that serve the node
+var instanceId = uuid.NewString()
+
+// Gives the id of the current process among the processes that serve the node
+func InstanceId() string {
+	return instanceId
+}
+
+// Gives the stored lease of a duty with its version (nil when the duty was never leased)
+func (n Node) storedLease(ctx context.Context, duty string) (*Lease, backlog.Version, error) {
+	document, version, err := n.GetVersionedDocument(ctx, "leases", duty)
+	if errors.Is(err, backlog.ErrNotFound) {
+		return nil, version, nil
+	}
+
+	if err != nil {
+		return nil, version, fmt.Errorf("failed to get the lease of %s: %v", duty, err)
+	}
+
+	lease := Lease{}
+	if err := fromDocument("leases", duty, document, &lease, "holder"); err != nil {
+		return nil, version, err
+	}
+
+	return &lease, version, nil
+}
+
+// Takes (or renews) the lease of a duty for the current process. Gives nil when another process
+// holds the duty
+func (n Node) AcquireLease(ctx context.Context, duty string, ttl time.Duration) (*Lease, error) {
+	stored, version, err := n.storedLease(ctx, duty)
+	if err != nil {
+		return nil, err
+	}
+
+	lease := Lease{Duty: duty, Holder: instanceId, Token: 1, ExpiresAt: timeutil.After(ttl)}
+	if stored != nil {
+		if stored.Holder != instanceId && stored.ExpiresAt > timeutil.Now() {
+			return nil, nil
+		}
+
+		lease.Token = stored.Token
+		if stored.Holder != instanceId {
+			lease.Token++
+		}
+	}
+
+	document, err := toDocument(lease)
+	if err != nil {
+		return nil, err
+	}
+
+	if stored == nil {
+		err = n.CreateDocument(ctx, "leases", duty, document)
+	} else {
+		err = n.ReplaceDocumentIf(ctx, "leases", duty, document, version)
+	}
+
+	// Another process took the lease in the meantime
+	if errors.Is(err, backlog.ErrConflict) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to write the lease of %s: %v", duty, err)
+	}
+
+	return &lease, nil
+}
+
+// Checks that the lease is still held with the same fencing token and didn't expire, before acting on it
+func (n Node) CheckLease(ctx context.Context, lease Lease) error {
+	// The lease of a dry run is only recorded in its plan, and nothing a dry run does needs fencing
+	if backlog.DryRunOf(ctx) != nil {
+		return nil
+	}
+
+	stored, _, err := n.storedLease(ctx, lease.Duty)
+	if err != nil {
+		return err
+	}
+
+	if stored == nil || stored.Holder != lease.Holder || stored.Token != lease.Token || stored.ExpiresAt <= timeutil.Now() {
+		return fmt.Errorf("%w: %s", ErrLeaseLost, lease.Duty)
+	}
+
+	return nil
+}
+
+// Gives up the leases held by the current process, so the other processes take the duties right away
+func (n Node) ReleaseLeases(ctx context.Context) error {
+	documents, _, err := n.FindDocuments(ctx, "leases", backlog.Term("holder", instanceId), backlog.ListOptions{All: true})
+	if err != nil {
+		return fmt.Errorf("failed to find the leases: %v", err)
+	}
+
+	for _, document := range documents {
+		duty, _ := document["duty"].(string)
+
+		stored, version, err := n.storedLease(ctx, duty)
+		if err != nil || stored == nil || stored.Holder != instanceId {
+			continue
+		}
+
+		// The lease stays (with its token), but expired, so the token keeps growing
+		stored.ExpiresAt = timeutil.Now()
+		released, err := toDocument(stored)
+		if err != nil {
+			return err
+		}
+
+		if err := n.ReplaceDocumentIf(ctx, "leases", duty, released, version); err != nil && !errors.Is(err, backlog.ErrConflict) {
+			return fmt.Errorf("failed to release the lease of %s: %v", duty, err)
+		}
+	}
+
+	return nil
+}
+
+// Takes (or renews) the lease of a duty for the next run of its job, which comes after the interval.
+// Gives nil when the current process doesn't hold the duty. Failures are taken as not holding it
+func (n Node) leads(ctx context.Context, duty string, interval time.Duration) *Lease {
+	lease, err := n.AcquireLease(ctx, duty, 3*interval)
+	if err != nil {
+		Logf(ctx, "failed to acquire the lease of %s: %v", duty, err)
+	}
+
+	return lease
+}
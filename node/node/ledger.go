@@ -0,0 +1,248 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	backlog "node/backlog"
+	hashing "node/hashing"
+	timeutil "node/timeutil"
+)
+
+/*
+The ledger keeps the balance of every client, computed from the confirmed blocks: the values the
+client received (and the fees of the blocks credited to it) minus the values and the fees it sent.
+Walking the whole chain in every transaction is too slow, so the balances are cached in the
+`balances` index, by the hash of the client id, together with the height and the hash of the last
+block folded into them. The next computation only folds the blocks after it, and starts over when that block is
+no longer in the chain (e.g. after the chain was replaced).
+
+A client can't spend what it doesn't have, so the new transactions are refused when their value is
+greater than the available balance of the sender: its confirmed balance minus the values of its
+pending transactions (and their fees), that will be taken from it once they're confirmed.
+*/
+type Balance struct {
+	ClientId  string  `json:"client_id"`  // The client id of the owner
+	Confirmed float64 `json:"confirmed"`  // The balance after the confirmed blocks
+	Height    int64   `json:"height"`     // The height of the last block folded into the balance (-1 when none)
+	BlockHash string  `json:"block_hash"` // The hash of the last block folded into the balance
+	UpdatedAt int64   `json:"updated_at"` // The timestamp when the balance was computed
+}
+
+var ErrInsufficientFunds = errors.New("insufficient funds")
+
+// Gives the id of the cached balance of a client (the client ids are longer than a document id can be)
+func balanceId(clientId string) string {
+	return hashing.HashClientId(clientId)
+}
+
+// Gives the cached balance of a client (nil when it was never computed or the cache is corrupted)
+func (n Node) cachedBalance(ctx context.Context, clientId string) *Balance {
+	document, err := n.GetDocument(ctx, "balances", balanceId(clientId))
+	if err != nil {
+		return nil
+	}
+
+	balance := Balance{}
+	if err := fromDocument("balances", clientId, document, &balance, "client_id", "height"); err != nil {
+		Logf(ctx, "recomputing the balance of %s: %v", clientId, err)
+		return nil
+	}
+
+	// The last folded block was replaced, so the balance may count blocks that aren't in the chain
+	if balance.Height >= 0 {
+		block, err := NewBlockchain(n.Backlog).BlockAt(ctx, balance.Height)
+		if err != nil || block == nil || block.Hash != balance.BlockHash {
+			return nil
+		}
+	}
+
+	return &balance
+}
+
+// Gives the balance of a client after the confirmed blocks, folding the blocks confirmed since it
+// was cached and caching it again
+func (n Node) ConfirmedBalance(ctx context.Context, clientId string) (*Balance, error) {
+	balance := n.cachedBalance(ctx, clientId)
+	if balance == nil {
+		balance = &Balance{ClientId: clientId, Height: -1}
+	}
+
+	// The stealth payments claimed for the client are credited to it too (please, go to `stealth.go`)
+	addresses, err := n.stealthAddresses(ctx, clientId)
+	if err != nil {
+		return nil, err
+	}
+
+	// The ids the client had before rotating its keys are still its own (please, go to `rotation.go`)
+	owned, err := n.ownedIds(ctx, clientId)
+	if err != nil {
+		return nil, err
+	}
+
+	blocks, _, err := n.FindDocuments(ctx, "blockchain", backlog.Range("height", balance.Height+1, nil), backlog.ListOptions{
+		All:  true,
+		Sort: []string{"height"},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the blocks: %v", err)
+	}
+
+	for _, document := range blocks {
+		block, err := blockFromDocument(document)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, transaction := range block.Transactions {
+			if owned[transaction.Sender] {
+				balance.Confirmed -= transaction.Value + transaction.Fee
+			}
+
+			if owned[transaction.Recipient] || addresses[transaction.Recipient] {
+				balance.Confirmed += transaction.Value
+			}
+		}
+
+		// The fees are paid to the client of the producer (please, go to `fees.go`)
+		if owned[block.FeeRecipient] {
+			balance.Confirmed += block.Fees()
+		}
+
+		balance.Height, balance.BlockHash = block.Height, block.Hash
+	}
+
+	balance.UpdatedAt = timeutil.Now()
+	document, err := toDocument(balance)
+	if err != nil {
+		return nil, err
+	}
+
+	// The cache is only an optimization, so a failure to write it doesn't fail the computation
+	if err := n.IndexDocument(ctx, "balances", balanceId(clientId), document); err != nil {
+		Logf(ctx, "failed to cache the balance of %s: %v", clientId, err)
+	}
+
+	return balance, nil
+}
+
+// Gives the sum of the values of the pending transactions (signed, but not confirmed yet) where the
+// client is the party (the "Sender" or the "Recipient"). The sender pays the fees too
+func (n Node) pendingValue(ctx context.Context, party, clientId string) (float64, error) {
+	query := backlog.Bool().
+		Must(backlog.Exists("Signature"), partyQuery(clientId, party)).
+		MustNot(backlog.Exists("BlockHash")).
+		Query()
+
+	records, err := n.Transactions().Find(ctx, query, backlog.ListOptions{All: true})
+	if err != nil {
+		return 0, err
+	}
+
+	var value float64
+	for _, record := range records {
+		value += record.Value
+		if party == "Sender" {
+			value += record.Fee
+		}
+	}
+
+	return value, nil
+}
+
+// Gives the sum of the values of the pending transactions where the client, or any of its former ids,
+// is the party
+func (n Node) ownedPendingValue(ctx context.Context, party, clientId string) (float64, error) {
+	owned, err := n.ownedIds(ctx, clientId)
+	if err != nil {
+		return 0, err
+	}
+
+	var value float64
+	for id := range owned {
+		idValue, err := n.pendingValue(ctx, party, id)
+		if err != nil {
+			return 0, err
+		}
+
+		value += idValue
+	}
+
+	return value, nil
+}
+
+// Gives the sum of the values of the pending transactions of a sender
+func (n Node) PendingDebits(ctx context.Context, clientId string) (float64, error) {
+	return n.ownedPendingValue(ctx, "Sender", clientId)
+}
+
+// Gives the sum of the values of the pending transactions of a recipient
+func (n Node) PendingCredits(ctx context.Context, clientId string) (float64, error) {
+	return n.ownedPendingValue(ctx, "Recipient", clientId)
+}
+
+// Gives the balance that a client can spend: the confirmed one minus its pending transactions
+func (n Node) AvailableBalance(ctx context.Context, clientId string) (float64, error) {
+	balance, err := n.ConfirmedBalance(ctx, clientId)
+	if err != nil {
+		return 0, err
+	}
+
+	debits, err := n.PendingDebits(ctx, clientId)
+	if err != nil {
+		return 0, err
+	}
+
+	return balance.Confirmed - debits, nil
+}
+
+// Checks that a sender can spend the value
+func (n Node) CheckFunds(ctx context.Context, clientId string, value float64) error {
+	available, err := n.AvailableBalance(ctx, clientId)
+	if err != nil {
+		return fmt.Errorf("failed to compute the balance of %s: %v", clientId, err)
+	}
+
+	if value > available {
+		return fmt.Errorf("%w: %s can spend %v but the transaction takes %v", ErrInsufficientFunds, clientId, available, value)
+	}
+
+	return nil
+}
+
+// The balance of a client as shown to it
+type BalanceSummary struct {
+	ClientId  string  // The client id of the owner
+	Confirmed float64 // The balance after the confirmed blocks
+	Pending   float64 // The net value of the pending transactions (received minus sent)
+	Available float64 // The balance that the client can spend (the pending values received don't count)
+	Height    int64   // The height of the last block in the balance (-1 when none)
+}
+
+// Gives the confirmed, pending and available balances of a client
+func (n Node) SummarizeBalance(ctx context.Context, clientId string) (*BalanceSummary, error) {
+	balance, err := n.ConfirmedBalance(ctx, clientId)
+	if err != nil {
+		return nil, err
+	}
+
+	debits, err := n.PendingDebits(ctx, clientId)
+	if err != nil {
+		return nil, err
+	}
+
+	credits, err := n.PendingCredits(ctx, clientId)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := BalanceSummary{
+		ClientId:  clientId,
+		Confirmed: balance.Confirmed,
+		Pending:   credits - debits,
+		Available: balance.Confirmed - debits,
+		Height:    balance.Height,
+	}
+
+	return &summary, nil
+}
@@ -0,0 +1,67 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	timeutil "node/timeutil"
+)
+
+/*
+A liquidation closes a client at its request, proved by its password on top of its token. Unlike an
+erasure (please, go to `erasure.go`), the client keeps its data: it's only closed for good.
+
+- The local client and its foreign client are marked as liquidated, so the client can't connect
+again and no transaction reaches it anymore
+- Its cache is deleted, so the tokens issued before stop working right away
+- Its key pair is deleted from the backlog and shredded from the key store, so nobody can sign for
+the client anymore
+
+The alias stays reserved by the liquidated client.
+*/
+var ErrClientLiquidated = errors.New("the client was liquidated")
+
+// Liquidates a local client, once its password is checked
+func (n Node) LiquidateClient(ctx context.Context, uid, password string) error {
+	owner, err := n.Clients().Get(ctx, uid)
+	if err != nil {
+		return err
+	}
+
+	if owner.Liquidated {
+		return ErrClientLiquidated
+	}
+
+	if _, err := n.checkPassword(ctx, owner, password); err != nil {
+		return err
+	}
+
+	return n.liquidate(ctx, owner)
+}
+
+// Liquidates a local client without checking its password (please, go to `admin.go`)
+func (n Node) liquidate(ctx context.Context, owner *Client) error {
+	liquidatedAt := timeutil.Now()
+	err := n.Begin().
+		Update("local_clients", owner.UID, map[string]interface{}{"liquidated": true, "liquidated_at": liquidatedAt}).
+		Update("clients", owner.ClientId, map[string]interface{}{"liquidated": true, "updated_at": liquidatedAt}).
+		Delete("cache", owner.UID).
+		Delete("keys", owner.UID).
+		Apply(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to liquidate the client documents: %v", err)
+	}
+
+	// The keys left in the key store are purged by the recovery when this fails (please, go to
+	// `recovery.go`)
+	if err := n.Keys.Delete(ctx, owner.UID); err != nil {
+		Logf(ctx, "failed to shred the keys of the liquidated client %s: %v", owner.ClientId, err)
+	}
+
+	n.Emit(ctx, "client.liquidated", map[string]interface{}{
+		"client_id":     owner.ClientId,
+		"liquidated_at": liquidatedAt,
+	})
+
+	return nil
+}
@@ -0,0 +1,196 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	backlog "node/backlog"
+	"sort"
+	"sync"
+)
+
+/*
+The logging of the node can be tuned at runtime (please, go to `SetLogging` in the admin API), so a
+node under investigation doesn't need a restart, which would lose the state being investigated:
+
+  - The log level hides the lines below it. Logf logs at the info level and Warnf at the warn one.
+  - The debug logging can be enabled for a single subsystem (sync, gossip or backlog), since the
+    debug lines of all of them together are too many for a busy node.
+  - The trace sampling enables the debug logging of all the subsystems for a fraction of the
+    requests, e.g. 0.01 traces one request in a hundred. The work detached from a traced request
+    keeps being traced.
+
+The settings are kept in memory only: a restarted node logs at the info level again.
+*/
+type LogLevel int
+
+const (
+	LevelDebug LogLevel = iota
+	LevelInfo
+	LevelWarn
+)
+
+var logLevelNames = map[LogLevel]string{
+	LevelDebug: "debug",
+	LevelInfo:  "info",
+	LevelWarn:  "warn",
+}
+
+// Gives the name of the level, e.g. "info"
+func (l LogLevel) String() string {
+	return logLevelNames[l]
+}
+
+// Parses the name of a log level
+func ParseLogLevel(name string) (LogLevel, error) {
+	for level, levelName := range logLevelNames {
+		if levelName == name {
+			return level, nil
+		}
+	}
+
+	return LevelInfo, fmt.Errorf("unknown log level %q", name)
+}
+
+// The subsystems whose debug logging can be enabled by itself
+const (
+	SubsystemSync    string = "sync"
+	SubsystemGossip  string = "gossip"
+	SubsystemBacklog string = "backlog"
+)
+
+var loggedSubsystems = []string{SubsystemSync, SubsystemGossip, SubsystemBacklog}
+
+// The logging settings in use
+type LoggingState struct {
+	Level         LogLevel
+	Debug         []string // The subsystems with the debug logging enabled, sorted
+	TraceSampling float64  // The fraction of the requests that are traced
+}
+
+var logging = struct {
+	sync.RWMutex
+	level    LogLevel
+	debug    map[string]bool
+	sampling float64
+}{level: LevelInfo, debug: map[string]bool{}}
+
+func init() {
+	backlog.RegisterTracer(func(ctx context.Context, format string, args ...interface{}) {
+		Debugf(ctx, SubsystemBacklog, format, args...)
+	})
+}
+
+// Gives the logging settings in use
+func Logging() LoggingState {
+	logging.RLock()
+	defer logging.RUnlock()
+
+	state := LoggingState{Level: logging.level, TraceSampling: logging.sampling}
+	for subsystem, enabled := range logging.debug {
+		if enabled {
+			state.Debug = append(state.Debug, subsystem)
+		}
+	}
+	sort.Strings(state.Debug)
+
+	return state
+}
+
+// Changes the level below which the log lines are hidden
+func SetLogLevel(level LogLevel) {
+	logging.Lock()
+	defer logging.Unlock()
+
+	logging.level = level
+}
+
+// Checks if the debug logging of a subsystem can be enabled by itself
+func IsSubsystem(name string) bool {
+	for _, subsystem := range loggedSubsystems {
+		if subsystem == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Enables (or disables) the debug logging of a subsystem, whatever the log level is
+func SetSubsystemDebug(subsystem string, enabled bool) error {
+	if !IsSubsystem(subsystem) {
+		return fmt.Errorf("unknown subsystem %q: the subsystems are %v", subsystem, loggedSubsystems)
+	}
+
+	logging.Lock()
+	defer logging.Unlock()
+
+	logging.debug[subsystem] = enabled
+	return nil
+}
+
+// Changes the fraction of the requests that are traced (between 0 and 1)
+func SetTraceSampling(rate float64) error {
+	if rate < 0 || rate > 1 {
+		return fmt.Errorf("the trace sampling must be between 0 and 1, not %v", rate)
+	}
+
+	logging.Lock()
+	defer logging.Unlock()
+
+	logging.sampling = rate
+	return nil
+}
+
+type traceKey struct{}
+
+// Gives a context that is traced, according to the trace sampling in use. It's called once by
+// request, when the request starts
+func SampleTrace(ctx context.Context) context.Context {
+	logging.RLock()
+	sampling := logging.sampling
+	logging.RUnlock()
+
+	if sampling == 0 || rand.Float64() >= sampling {
+		return ctx
+	}
+
+	return context.WithValue(ctx, traceKey{}, true)
+}
+
+// Checks if the context belongs to a traced request
+func Traced(ctx context.Context) bool {
+	traced, _ := ctx.Value(traceKey{}).(bool)
+	return traced
+}
+
+// Checks if the lines of some level are logged
+func logged(level LogLevel) bool {
+	logging.RLock()
+	defer logging.RUnlock()
+
+	return level >= logging.level
+}
+
+// Checks if the debug lines of a subsystem are logged in the context
+func debugged(ctx context.Context, subsystem string) bool {
+	logging.RLock()
+	defer logging.RUnlock()
+
+	return logging.level == LevelDebug || logging.debug[subsystem] || Traced(ctx)
+}
+
+// Prints a debug log line of a subsystem, when the debug logging of the subsystem is enabled or the
+// request is traced
+func Debugf(ctx context.Context, subsystem string, format string, args ...interface{}) {
+	if debugged(ctx, subsystem) {
+		printf(ctx, "debug "+subsystem+": "+format, args...)
+	}
+}
+
+// Prints a warning, for the failures that need the attention of the operators
+func Warnf(ctx context.Context, format string, args ...interface{}) {
+	if logged(LevelWarn) {
+		printf(ctx, "warning: "+format, args...)
+	}
+}
@@ -1,14 +1,16 @@
 package node
 
 import (
-	"crypto/sha256"
-	"encoding/hex"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
 	backlog "node/backlog"
 	client "node/client"
-	"os"
+	config "node/config"
+	hashing "node/hashing"
+	timeutil "node/timeutil"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -19,6 +21,8 @@ const (
 	NodeAlive       NodeStatus = "alive"       // When the program starts
 	NodeHibernating NodeStatus = "hibernating" // When te program ends
 	NodeLiquidated  NodeStatus = "liquidated"  // When the node is destroyed
+	NodeUnreachable NodeStatus = "unreachable" // When a peer stopped answering without a departure (please, go to `peermanager.go`)
+	NodeMaintenance NodeStatus = "maintenance" // While a scheduled maintenance window lasts (please, go to `maintenance.go`)
 )
 
 /*
@@ -30,78 +34,134 @@ A backlog is directly associated within a node. Please go to `backlog.go` to see
 The node also has several clients that registered through it. The actions that can be performed
 must have a client as their owner, as like the client must have a node as its owner.
 
-The node data are registered in the backlog in a document identified by the node address hash.
-Therefore, a node can be created from the host public address (for the first time) or can be retrieved
-from the backlog by the same address hash.
+The node data are registered in the backlog in a document identified by the node id (please, go to
+`identity.go` to see more about it). Therefore, a node can be created from the host public address
+(for the first time) or can be retrieved from the backlog by the same id.
 
 With this, the node should be understand as a abstraction of the Backlog, since it just creates a
 handling layer to connect the clients and the other server resources around the Elastic Search database.
 */
 type Node struct {
 	*backlog.Backlog `json:"-"`
-	Mirror           string     `json:"syncer"`  // The host address from some peer that serves as mirror
-	Host             string     `json:"host"`    // The host address from the current node server
-	Version          string     `json:"version"` // Identifier of the source code that's running on the current node server
-	Status           NodeStatus `json:"status"`  // The status of the meander
+	Id               string                 `json:"node_id"`        // The stable identity of the node (the host may change)
+	Key              *client.CryptoResource `json:"-"`              // The key pair that the node signs its announcements with
+	Keys             client.KeyStore        `json:"-"`              // The store of the client key pairs
+	PublicKey        string                 `json:"public_key"`     // The identity of the node public key, used by the peers to verify its announcements
+	Mirror           string                 `json:"syncer"`         // The host address from some peer that serves as mirror
+	Host             string                 `json:"host"`           // The host address from the current node server
+	Version          string                 `json:"version"`        // Identifier of the source code that's running on the current node server
+	Status           NodeStatus             `json:"status"`         // The status of the meander
+	ReadOnly         bool                   `json:"read_only"`      // Whether the node is refusing writes (e.g. when the disk is almost full)
+	SchemaVersion    int                    `json:"schema_version"` // The layout of the documents written by the current node server
+	ResumesAt        int64                  `json:"resumes_at"`     // The timestamp when a hibernating node expects to be back (zero when unknown)
+	Maintenance      *MaintenanceWindow     `json:"maintenance"`    // The scheduled maintenance window (nil when there's none)
+	Signature        string                 `json:"signature"`      // The signature of the status made by the node key (please, go to `signing.go`)
 }
 
-const nodeVersion string = "2023-12-26"
+var ErrReadOnly = errors.New("the node is in read-only mode")
 
-var BasePath = os.Getenv("BASE_PATH")
-
-// Creates a new node struct since the local host
-func NewLocalNode(syncer string) *Node {
+// Creates a new node struct since the local host, with the given settings. The identity of the node
+// is shared with the other processes that serve it (please, go to `shared.go` to see more about it)
+func NewLocalNode(ctx context.Context, cfg *config.Config) (*Node, error) {
 	host, err := getLocalAddress()
 
 	if err != nil {
-		log.Fatalf("Failed to find the host: %v", err)
+		return nil, fmt.Errorf("failed to find the host: %v", err)
+	}
+
+	backlog, err := backlog.NewBacklog(cfg.BacklogAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := shareIdentity(ctx, backlog); err != nil {
+		return nil, err
+	}
+
+	id, err := LoadNodeId()
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := LoadNodeKey()
+	if err != nil {
+		return nil, err
+	}
+
+	publicKey, err := key.Identity()
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := openKeyStore(cfg.KeyStore, cfg.KeyRoots(), backlog, key)
+	if err != nil {
+		return nil, err
 	}
 
-	backlog := backlog.NewBacklog()
 	node := Node{
-		Backlog: backlog,
-		Mirror:  syncer,
-		Host:    host,
-		Version: nodeVersion,
-		Status:  NodeAlive,
+		Backlog:       backlog,
+		Id:            id,
+		Key:           key,
+		Keys:          keys,
+		PublicKey:     publicKey,
+		Mirror:        cfg.Mirror,
+		Host:          host,
+		Version:       CurrentBuild().String(),
+		Status:        NodeAlive,
+		SchemaVersion: schemaVersion,
 	}
 
-	return &node
+	return &node, nil
 }
 
 // Creates a new node struct since the node stored in local elastic
-func GetLocalNode() *Node {
-	host, err := getLocalAddress()
+func GetLocalNode(ctx context.Context) (*Node, error) {
+	id, err := LoadNodeId()
 	if err != nil {
-		log.Fatalf("Failed to find the host: %v", err)
+		return nil, err
 	}
 
-	hasher := sha256.New()
-	hasher.Write([]byte(host))
-	hash := hex.EncodeToString(hasher.Sum(nil))
+	backlog, err := backlog.NewBacklog()
+	if err != nil {
+		return nil, err
+	}
 
-	backlog := backlog.NewBacklog()
-	nodeData, err := backlog.GetDocument("node", hash)
+	key, err := LoadNodeKey()
 	if err != nil {
-		log.Fatalf("Failed to get the node elastic document: %v", err)
+		return nil, err
 	}
 
-	node := Node{
-		Backlog: backlog,
-		Mirror:  nodeData["syncer"].(string),
-		Host:    nodeData["host"].(string),
-		Status:  NodeStatus(nodeData["status"].(string)),
-		Version: nodeData["version"].(string),
+	publicKey, err := key.Identity()
+	if err != nil {
+		return nil, err
+	}
+
+	// The key store was exported to the environment by the process that serves the node
+	keys, err := openKeyStore(config.KeyStore(), config.KeyPaths(), backlog, key)
+	if err != nil {
+		return nil, err
+	}
+
+	node, err := Node{Backlog: backlog}.Nodes().Get(ctx, "node", id)
+	if err != nil {
+		return nil, err
 	}
 
-	return &node
+	node.Backlog = backlog
+	node.Id = id
+	node.Key = key
+	node.Keys = keys
+	node.PublicKey = publicKey
+	node.Version = CurrentBuild().String()
+
+	return node, nil
 }
 
 // (Over)Writes the node state in local elastic using the current in-memory node state
-func (n Node) SyncWithBacklog(nodeIndex string) error {
-	hasher := sha256.New()
-	hasher.Write([]byte(n.Host))
-	hash := hex.EncodeToString(hasher.Sum(nil))
+func (n Node) SyncWithBacklog(ctx context.Context, nodeIndex string) error {
+	if err := n.signStatus(); err != nil {
+		return err
+	}
 
 	nodeBytes, err := json.Marshal(n)
 	if err != nil {
@@ -112,7 +172,7 @@ func (n Node) SyncWithBacklog(nodeIndex string) error {
 		return fmt.Errorf("failed to unmarshal the current node into map: %v", err)
 	}
 
-	err = n.Backlog.IndexDocument(nodeIndex, hash, node)
+	err = n.Backlog.IndexDocument(ctx, nodeIndex, n.Id, node)
 	if err != nil {
 		return fmt.Errorf("failed to overwrite the node document: %v", err)
 	}
@@ -121,39 +181,63 @@ func (n Node) SyncWithBacklog(nodeIndex string) error {
 }
 
 // Sends node start signal to local elastic
-func (n *Node) Attach() {
+func (n *Node) Attach(ctx context.Context) {
 	n.Status = NodeAlive
-	n.SyncWithBacklog("peers")
-	n.SyncWithBacklog("node")
+	n.ResumesAt = 0
+	n.SyncWithBacklog(ctx, "peers")
+	n.SyncWithBacklog(ctx, "node")
 }
 
-// Sends node end signal to local elastic
-func (n *Node) Dettach() {
+// Sends node end signal to local elastic and to the alive peers, so they stop routing to the node
+// right away. The downtime (zero when unknown) tells the peers when the node expects to be back
+func (n *Node) Dettach(ctx context.Context, downtime time.Duration) {
 	n.Status = NodeHibernating
-	n.SyncWithBacklog("peers")
-	n.SyncWithBacklog("node")
+	if downtime > 0 {
+		n.ResumesAt = timeutil.After(downtime)
+	}
+
+	n.SyncWithBacklog(ctx, "peers")
+	n.SyncWithBacklog(ctx, "node")
+	n.announceDeparture(ctx)
 }
 
-// Sends node destroying signal to local elastic
-func (n *Node) Liquidate() {
+// Sends node destroying signal to local elastic. Both records change together, so a node is
+// never liquidated in one of them only
+func (n *Node) Liquidate(ctx context.Context) error {
+	status := n.Status
 	n.Status = NodeLiquidated
-	n.SyncWithBacklog("peers")
-	n.SyncWithBacklog("node")
-}
 
-// Creates a new client in the node
-func (n Node) NewLocalClient(alias, address, secret, password string) *Client {
-	nodeHasher := sha256.New()
-	nodeHasher.Write([]byte(n.Host))
-	nodeHash := hex.EncodeToString(nodeHasher.Sum(nil))
+	if err := n.signStatus(); err != nil {
+		n.Status = status
+		return err
+	}
 
-	addrHasher := sha256.New()
-	addrHasher.Write([]byte(address))
-	addrHash := hex.EncodeToString(addrHasher.Sum(nil))
+	node, err := toDocument(n)
+	if err != nil {
+		n.Status = status
+		return err
+	}
 
-	pwdHasher := sha256.New()
-	addrHasher.Write([]byte(password))
-	pwdHash := hex.EncodeToString(pwdHasher.Sum(nil))
+	if err := n.Begin().Put("peers", n.Id, node).Put("node", n.Id, node).Apply(ctx); err != nil {
+		n.Status = status
+		return fmt.Errorf("failed to liquidate the node: %v", err)
+	}
+
+	return nil
+}
+
+// Creates a new client in the node. The creation is staged, so a failure in any step undoes
+// the previous ones (please, go to `creation.go` to see more about it)
+func (n Node) NewLocalClient(ctx context.Context, alias, address, secret, password, terms string) (*Client, error) {
+	return n.newLocalClient(ctx, alias, address, secret, password, terms, nil)
+}
+
+// Creates a new client in the node with a key pair, or with a new one when it's nil (please, go to
+// `import.go`)
+func (n Node) newLocalClient(ctx context.Context, alias, address, secret, password, terms string, crypto *client.CryptoResource) (*Client, error) {
+	if err := checkTermsVersion(terms); err != nil {
+		return nil, err
+	}
 
 	uuid, _ := uuid.NewUUID()
 	accountId := generateAccountId()
@@ -163,82 +247,155 @@ func (n Node) NewLocalClient(alias, address, secret, password string) *Client {
 		UID:         uuid.String(),
 		AccountId:   accountId,
 		Alias:       alias,
-		NodeAddress: nodeHash,
-		Address:     addrHash,
+		NodeAddress: hashing.HashAddress(n.Host),
+		Address:     hashing.HashAddress(address),
 		Secret:      secret,
-		Password:    pwdHash,
+		Password:    hashPassword(password),
 	}
 
-	if _, err := os.Stat(fmt.Sprintf("%s/%s", os.Getenv("BASE_PATH"), uuid.String())); os.IsNotExist(err) {
-		os.Mkdir(fmt.Sprintf("%s/%s", os.Getenv("BASE_PATH"), uuid.String()), 0755)
+	if terms != "" {
+		client.TermsVersion, client.TermsAcceptedAt = terms, timeutil.Now()
 	}
 
-	client.GenerateCrypto()
-	client.ClientId = client.Identity()
-	client.PublicKey = string(client.ImpersonatePublicKey())
-	client.PrivateKey = string(client.ImpersonatePrivateKey())
-	cache := client.CreateCache()
+	creation := clientCreation{node: n, UID: client.UID, Alias: alias}
+	if err := creation.begin(ctx); err != nil {
+		return nil, err
+	}
 
-	err := client.SyncWithBacklog(cache)
-	if err != nil {
-		log.Fatalf("failed to sync with backlog: %v", err)
+	fail := func(err error) (*Client, error) {
+		if rollbackErr := creation.rollback(ctx); rollbackErr != nil {
+			return nil, fmt.Errorf("%v (%v)", err, rollbackErr)
+		}
+
+		return nil, err
 	}
 
-	foreign := client.MakeForeign()
-	err = foreign.SyncWithBacklog()
-	if err != nil {
-		log.Fatalf("failed to sync foreign client with backlog: %v", err)
+	// The alias may belong to another client, so it's only recorded once reserved
+	if err := n.ReserveAlias(ctx, alias); err != nil {
+		return fail(err)
 	}
 
-	return &client
-}
+	if err := creation.advance(ctx, stageAlias); err != nil {
+		return fail(err)
+	}
 
-// Manually builds a client in the node with existing informations
-func (n Node) RetrieveClient(uid, secret string) (*Client, client.Cache) {
-	document, err := n.GetDocument("local_clients", uid)
+	if err := creation.advance(ctx, stageKeys); err != nil {
+		return fail(err)
+	}
 
-	if err != nil {
-		log.Fatalf("failed to retrieve the client document: %v", err)
+	if crypto == nil {
+		if err := client.GenerateCrypto(ctx); err != nil {
+			return fail(err)
+		}
+	} else if err := client.storeCrypto(ctx, crypto); err != nil {
+		return fail(err)
 	}
 
-	client := Client{
-		Node:        &n,
-		UID:         uid,
-		AccountId:   document["account_id"].(string),
-		Alias:       document["alias"].(string),
-		NodeAddress: document["node"].(string),
-		Address:     document["address"].(string),
-		Secret:      secret,
-		Password:    document["password"].(string),
+	if err := n.shareClientKeys(ctx, client.UID); err != nil {
+		return fail(err)
+	}
+
+	if err := client.impersonate(); err != nil {
+		return fail(err)
 	}
 
-	client.RetrieveCrypto()
-	client.ClientId = client.Identity()
-	client.PublicKey = string(client.ImpersonatePublicKey())
-	client.PrivateKey = string(client.ImpersonatePrivateKey())
 	cache := client.CreateCache()
 
-	err = client.SyncWithBacklog(cache)
+	creation.ClientId = client.ClientId
+	if err := creation.advance(ctx, stageDocuments); err != nil {
+		return fail(err)
+	}
+
+	if err := client.commitDocuments(ctx, cache); err != nil {
+		return fail(err)
+	}
+
+	if err := creation.commit(ctx); err != nil {
+		return fail(err)
+	}
+
+	n.logKey(ctx, KeyRegistered, client.ClientId, "")
+
+	n.Emit(ctx, "client.created", map[string]interface{}{
+		"uid":       client.UID,
+		"client_id": client.ClientId,
+		"alias":     client.Alias,
+	})
+
+	return &client, nil
+}
+
+// Loads a client of the node with its key pair, without touching its cache
+func (n Node) LoadClient(ctx context.Context, uid, secret string) (*Client, error) {
+	client, err := n.Clients().Get(ctx, uid)
 	if err != nil {
-		log.Fatalf("failed to sync client with backlog: %v", err)
+		return nil, err
+	}
+
+	if client.Liquidated {
+		return nil, ErrClientLiquidated
+	}
+
+	client.Secret = secret
+
+	if err := n.RestoreClientKeys(ctx, uid); err != nil {
+		return nil, err
 	}
 
-	return &client, cache
+	if err := client.RetrieveCrypto(ctx); err != nil {
+		return nil, err
+	}
+
+	if err := client.impersonate(); err != nil {
+		return nil, err
+	}
+
+	return client, nil
 }
 
-// Manually builds a foreign client in the node with existing informations
-func (n Node) RetrieveForeignClient(clientId string) (*ForeignClient, error) {
-	document, err := n.FindDocument("clients", "client_id", clientId)
+// Manually builds a client in the node with existing informations, once its password is checked.
+// Gives ErrInvalidCredentials when the password is wrong
+func (n Node) RetrieveClient(ctx context.Context, uid, secret, password string) (*Client, *client.Cache, error) {
+	stored, err := n.Clients().Get(ctx, uid)
 	if err != nil {
-		return nil, fmt.Errorf("failed to find the foreign client document: %v", err)
+		return nil, nil, err
 	}
 
-	client := ForeignClient{
-		Node:        &n,
-		ClientId:    document["client_id"].(string),
-		NodeAddress: document["node"].(string),
-		Address:     document["address"].(string),
+	if stored.Liquidated {
+		return nil, nil, ErrClientLiquidated
 	}
 
-	return &client, nil
+	legacy, err := n.checkPassword(ctx, stored, password)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	client, err := n.LoadClient(ctx, uid, secret)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// The password is taken only once the secret proved the owner, and it's stored by the sync
+	if legacy {
+		client.Password = hashPassword(password)
+	}
+
+	cache := client.CreateCache()
+
+	err = client.SyncWithBacklog(ctx, cache)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to sync client with backlog: %v", err)
+	}
+
+	n.Emit(ctx, "client.connected", map[string]interface{}{
+		"uid":       client.UID,
+		"client_id": client.ClientId,
+	})
+
+	return client, &cache, nil
+}
+
+// Manually builds a foreign client in the node with existing informations
+func (n Node) RetrieveForeignClient(ctx context.Context, clientId string) (*ForeignClient, error) {
+	return n.Clients().GetForeign(ctx, clientId)
 }
@@ -0,0 +1,174 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	backlog "node/backlog"
+	timeutil "node/timeutil"
+)
+
+var ErrInvalidWindow = errors.New("invalid maintenance window")
+
+/*
+The operators schedule the maintenance of a node ahead, through the admin API. The window is kept in
+the `maintenance` index, so every process of the node follows it, and it's announced:
+
+- To the peers, in the signed status of the node (please, go to `peermanager.go`), so they stop
+routing to the node while it lasts
+- To the operators, in the node stats
+- To the clients, with a push notification to their devices and the `node.maintenance_scheduled` event
+
+Once the window starts, the node enters the maintenance mode: it's reported with the maintenance status
+and refuses the writes. When the window ends, the node is alive again and the window is cleared.
+*/
+type MaintenanceWindow struct {
+	StartsAt int64  `json:"starts_at"` // The timestamp when the maintenance starts
+	EndsAt   int64  `json:"ends_at"`   // The timestamp when the node is expected to be back
+	Reason   string `json:"reason"`    // Why the node is under maintenance, told to the peers and clients
+}
+
+// Gives the maintenance window scheduled for the node (nil when there's none)
+func (n Node) storedMaintenance(ctx context.Context) (*MaintenanceWindow, error) {
+	document, err := n.GetDocument(ctx, "maintenance", n.Id)
+	if errors.Is(err, backlog.ErrNotFound) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the maintenance window: %v", err)
+	}
+
+	window := MaintenanceWindow{}
+	if err := fromDocument("maintenance", n.Id, document, &window, "starts_at", "ends_at"); err != nil {
+		return nil, err
+	}
+
+	return &window, nil
+}
+
+// Schedules a maintenance window, replacing the one scheduled before, and announces it to the clients
+func (n Node) ScheduleMaintenance(ctx context.Context, window MaintenanceWindow) error {
+	if window.EndsAt <= window.StartsAt || window.EndsAt <= timeutil.Now() {
+		return fmt.Errorf("%w: the window must end after it starts, in the future", ErrInvalidWindow)
+	}
+
+	document, err := toDocument(window)
+	if err != nil {
+		return err
+	}
+	document["scheduled_at"] = timeutil.Now()
+
+	if err := n.IndexDocument(ctx, "maintenance", n.Id, document); err != nil {
+		return fmt.Errorf("failed to store the maintenance window: %v", err)
+	}
+
+	n.Emit(ctx, "node.maintenance_scheduled", map[string]interface{}{
+		"starts_at": window.StartsAt,
+		"ends_at":   window.EndsAt,
+		"reason":    window.Reason,
+	})
+
+	n.notifyMaintenance(ctx, window)
+	return nil
+}
+
+// Cancels the scheduled maintenance window. The node leaves the maintenance mode on the next check
+func (n Node) CancelMaintenance(ctx context.Context) error {
+	if err := n.DeleteDocument(ctx, "maintenance", n.Id); err != nil && !errors.Is(err, backlog.ErrNotFound) {
+		return fmt.Errorf("failed to cancel the maintenance window: %v", err)
+	}
+
+	n.Emit(ctx, "node.maintenance_cancelled", map[string]interface{}{})
+	return nil
+}
+
+// Pushes the maintenance window to the devices of all the clients, through the outbox workers
+func (n Node) notifyMaintenance(ctx context.Context, window MaintenanceWindow) {
+	devices, _, err := n.FindDocuments(ctx, "devices", backlog.Exists("token"), backlog.ListOptions{All: true})
+	if err != nil {
+		Logf(ctx, "failed to notify the maintenance window: %v", err)
+		return
+	}
+
+	notification := Notification{
+		Event: "node.maintenance",
+		Title: "The node will be under maintenance",
+		Data: map[string]interface{}{
+			"starts_at": window.StartsAt,
+			"ends_at":   window.EndsAt,
+			"reason":    window.Reason,
+		},
+	}
+
+	for _, document := range devices {
+		device := Device{}
+		if err := fromDocument("devices", "", document, &device, "platform", "token"); err != nil {
+			continue
+		}
+
+		provider, ok := pushProviderFor(device.Platform)
+		if !ok {
+			continue
+		}
+
+		outboxPool.Go(ctx, func(ctx context.Context) {
+			if err := provider.Send(device, notification); err != nil {
+				Logf(ctx, "failed to push the maintenance window to a %s device: %v", device.Platform, err)
+			}
+		})
+	}
+}
+
+// Follows the scheduled maintenance window: enters the maintenance mode once it starts and gets back
+// to alive when it ends, announcing the changes to the peers
+func (n *Node) followMaintenance(ctx context.Context) error {
+	window, err := n.storedMaintenance(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := timeutil.Now()
+	if window != nil && window.EndsAt <= now {
+		if err := n.DeleteDocument(ctx, "maintenance", n.Id); err != nil && !errors.Is(err, backlog.ErrNotFound) {
+			return fmt.Errorf("failed to clear the maintenance window: %v", err)
+		}
+		window = nil
+	}
+
+	changed := (window == nil) != (n.Maintenance == nil) || (window != nil && *window != *n.Maintenance)
+	n.Maintenance = window
+
+	switch {
+	case window != nil && window.StartsAt <= now && n.Status == NodeAlive:
+		n.Status = NodeMaintenance
+		Warnf(ctx, "the node is under maintenance until %s: %s", timeutil.Format(window.EndsAt), window.Reason)
+		n.Emit(ctx, "node.maintenance_started", map[string]interface{}{"ends_at": window.EndsAt, "reason": window.Reason})
+	case (window == nil || window.StartsAt > now) && n.Status == NodeMaintenance:
+		n.Status = NodeAlive
+		Logf(ctx, "the maintenance ended: the node is alive again")
+		n.Emit(ctx, "node.maintenance_ended", map[string]interface{}{})
+	case !changed:
+		return nil
+	}
+
+	// The peers learn the new status on their next exchange with the node
+	return n.SyncWithBacklog(ctx, "node")
+}
+
+// Schedules the job that follows the maintenance window. Every process keeps its own status, so the
+// job runs in all of them, starting right away
+func (n *Node) StartMaintenanceMonitor(schedule string) error {
+	return ScheduleJob(Job{
+		Name:       "maintenance_monitor",
+		Schedule:   schedule,
+		RunAtStart: true,
+		Run: func(ctx context.Context, lease *Lease) error {
+			if err := n.followMaintenance(ctx); err != nil {
+				return fmt.Errorf("failed to follow the maintenance window: %v", err)
+			}
+
+			return nil
+		},
+	})
+}
@@ -0,0 +1,69 @@
+package node
+
+import (
+	"errors"
+	"fmt"
+	client "node/client"
+)
+
+/*
+A transaction can carry a memo (e.g. an invoice reference or the metadata of the value) that's
+sealed to its sender and its recipient before the signature, so the nodes store and relay it without
+reading it. Please, go to `memo.go` in the client module to see how it's sealed.
+
+The sealed memo is included in the signed bytes of the transaction only when there's one, so the
+signatures of the transactions without a memo don't change.
+*/
+var ErrInvalidMemo = errors.New("invalid memo")
+
+// Seals a memo to the sender and the recipient of the unsigned transaction
+func (t *Transaction) SealMemo(memo string) error {
+	if t.Signature != nil {
+		return fmt.Errorf("%w: the transaction %s is already signed", ErrInvalidMemo, t.TransactionId)
+	}
+
+	if len(memo) > client.MaxMemoSize {
+		return fmt.Errorf("%w: the memo has %d bytes but the limit is %d", ErrInvalidMemo, len(memo), client.MaxMemoSize)
+	}
+
+	recipientKey, err := client.ParseIdentity(t.Recipient.ClientId)
+	if err != nil {
+		return fmt.Errorf("the recipient isn't a valid client id: %v", err)
+	}
+
+	sealed, err := client.SealMemo([]byte(memo), t.Sender.CryptoResource.PublicKey, recipientKey)
+	if err != nil {
+		return err
+	}
+
+	t.Memo = sealed
+	return nil
+}
+
+// Adds the sealed memo and the stealth tag (when there are ones) to the signable fields of a
+// transaction
+func withSealed(transaction map[string]interface{}, memo, tag string) map[string]interface{} {
+	if memo != "" {
+		transaction["memo"] = memo
+	}
+
+	if tag != "" {
+		transaction["tag"] = tag
+	}
+
+	return transaction
+}
+
+// Gives the memo of a transaction of the client (empty when it has none or the client isn't a party)
+func (c Client) ReadMemo(record TransactionRecord) string {
+	if record.Memo == "" || c.CryptoResource == nil {
+		return ""
+	}
+
+	memo, err := c.Open(record.Memo)
+	if err != nil {
+		return ""
+	}
+
+	return string(memo)
+}
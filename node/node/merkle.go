@@ -0,0 +1,193 @@
+package node
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// The prefixes that tell the leaves from the inner nodes of the tree, so a pair of hashes can't pass
+// for a transaction
+const (
+	merkleLeafPrefix byte = 0x00
+	merkleNodePrefix byte = 0x01
+)
+
+var ErrInvalidProof = errors.New("invalid Merkle proof")
+
+/*
+The transactions of a block are the leaves of a Merkle tree, whose root is stored in the block header
+(and so covered by the block hash). A light client that trusts the header can check that a block
+includes a transaction with the proof given by `Block.Prove`: the hashes of the siblings on the path
+from the leaf of the transaction to the root, without downloading the whole block.
+
+Every leaf is the hash of the whole transaction, signature included. A node without a sibling is
+carried to the level above as it is, so no leaf is ever duplicated.
+*/
+type MerkleProof struct {
+	TransactionId string       `json:"transaction_id"` // The id of the proved transaction
+	Index         int          `json:"index"`          // The position of the transaction in the block
+	Path          []MerkleStep `json:"path"`           // The siblings from the leaf up to the root
+}
+
+// A sibling on the path of a Merkle proof
+type MerkleStep struct {
+	Hash string `json:"hash"` // The hash of the sibling
+	Left bool   `json:"left"` // Whether the sibling is on the left
+}
+
+// Gives the hash of a leaf of the tree
+func merkleLeaf(transaction BlockTransaction) []byte {
+	transactionBytes, _ := json.Marshal(transaction)
+	hash := sha256.Sum256(append([]byte{merkleLeafPrefix}, transactionBytes...))
+	return hash[:]
+}
+
+// Gives the hash of an inner node of the tree
+func merkleNode(left, right []byte) []byte {
+	hash := sha256.Sum256(append(append([]byte{merkleNodePrefix}, left...), right...))
+	return hash[:]
+}
+
+// Gives the level of the tree above some level
+func merkleLevel(level [][]byte) [][]byte {
+	next := make([][]byte, 0, (len(level)+1)/2)
+	for i := 0; i < len(level); i += 2 {
+		if i+1 == len(level) {
+			next = append(next, level[i])
+			continue
+		}
+
+		next = append(next, merkleNode(level[i], level[i+1]))
+	}
+
+	return next
+}
+
+// Computes the Merkle root of some transactions (the hash of nothing when there's none)
+func MerkleRoot(transactions []BlockTransaction) string {
+	if len(transactions) == 0 {
+		hash := sha256.Sum256(nil)
+		return hex.EncodeToString(hash[:])
+	}
+
+	level := make([][]byte, 0, len(transactions))
+	for _, transaction := range transactions {
+		level = append(level, merkleLeaf(transaction))
+	}
+
+	for len(level) > 1 {
+		level = merkleLevel(level)
+	}
+
+	return hex.EncodeToString(level[0])
+}
+
+// Gives the proof that the block includes a transaction
+func (b Block) Prove(transactionId string) (*MerkleProof, error) {
+	index := -1
+	level := make([][]byte, 0, len(b.Transactions))
+	for i, transaction := range b.Transactions {
+		if transaction.TransactionId == transactionId {
+			index = i
+		}
+
+		level = append(level, merkleLeaf(transaction))
+	}
+
+	if index < 0 {
+		return nil, fmt.Errorf("%w: the block %d doesn't include the transaction %s", ErrUnknownTransaction, b.Height, transactionId)
+	}
+
+	proof := MerkleProof{TransactionId: transactionId, Index: index}
+	for position := index; len(level) > 1; position /= 2 {
+		sibling := position ^ 1
+		if sibling < len(level) {
+			proof.Path = append(proof.Path, MerkleStep{Hash: hex.EncodeToString(level[sibling]), Left: sibling < position})
+		}
+
+		level = merkleLevel(level)
+	}
+
+	return &proof, nil
+}
+
+// Verifies that a transaction is included in the block whose header carries the Merkle root
+func VerifyProof(transaction BlockTransaction, proof MerkleProof, root string) error {
+	if transaction.TransactionId != proof.TransactionId {
+		return fmt.Errorf("%w: the proof is for the transaction %s", ErrInvalidProof, proof.TransactionId)
+	}
+
+	hash := merkleLeaf(transaction)
+	for _, step := range proof.Path {
+		sibling, err := hex.DecodeString(step.Hash)
+		if err != nil || len(sibling) != sha256.Size {
+			return fmt.Errorf("%w: the path has a malformed hash", ErrInvalidProof)
+		}
+
+		if step.Left {
+			hash = merkleNode(sibling, hash)
+		} else {
+			hash = merkleNode(hash, sibling)
+		}
+	}
+
+	if hex.EncodeToString(hash) != root {
+		return fmt.Errorf("%w: the transaction %s doesn't lead to the root %s", ErrInvalidProof, transaction.TransactionId, root)
+	}
+
+	return nil
+}
+
+// A transaction with its block header and the proof that the block includes it, so a light client can
+// check a payment against a node it doesn't fully trust
+type TransactionProof struct {
+	Transaction BlockTransaction `json:"transaction"` // The proved transaction, as included in the block
+	Header      Block            `json:"header"`      // The block without its transactions
+	Proof       MerkleProof      `json:"proof"`       // The path from the transaction to the Merkle root of the header
+}
+
+// Gives the proof that a confirmed transaction is included in its block
+func (n Node) TransactionProof(ctx context.Context, transactionId string) (*TransactionProof, error) {
+	transaction, err := n.signedTransaction(ctx, transactionId)
+	if err != nil {
+		return nil, err
+	}
+
+	if transaction.BlockHash == "" {
+		return nil, fmt.Errorf("the transaction %s isn't confirmed in a block yet", transactionId)
+	}
+
+	document, err := n.GetDocument(ctx, "blockchain", transaction.BlockHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the block of the transaction: %v", err)
+	}
+
+	block, err := blockFromDocument(document)
+	if err != nil {
+		return nil, err
+	}
+
+	merkleProof, err := block.Prove(transactionId)
+	if err != nil {
+		return nil, err
+	}
+
+	proof := TransactionProof{Transaction: block.Transactions[merkleProof.Index], Header: *block, Proof: *merkleProof}
+	proof.Header.Transactions = nil
+
+	return &proof, nil
+}
+
+// Verifies the header (its hash and difficulty) and the inclusion of the transaction. The producer
+// of the header is checked by the caller, against the public key of the node it trusts
+func (p TransactionProof) Verify() error {
+	if p.Header.ComputeHash() != p.Header.Hash || !p.Header.Solved() {
+		return fmt.Errorf("%w: the hash of the header is wrong or doesn't satisfy the difficulty", ErrInvalidProof)
+	}
+
+	return VerifyProof(p.Transaction, p.Proof, p.Header.MerkleRoot)
+}
@@ -0,0 +1,166 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	timeutil "node/timeutil"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// A snapshot of the node key metrics, persisted in the `node_metrics` index
+type NodeMetrics struct {
+	Timestamp   int64 `json:"timestamp"`    // The timestamp when the snapshot was taken
+	Peers       int64 `json:"peers"`        // The number of known peers
+	ChainHeight int64 `json:"chain_height"` // The number of blocks in the chain
+	MempoolSize int64 `json:"mempool_size"` // The number of transactions waiting to be included in a block
+	RPCCalls    int64 `json:"rpc_calls"`    // The number of RPC calls since the last snapshot
+	RPCErrors   int64 `json:"rpc_errors"`   // The number of failed RPC calls since the last snapshot
+	RPCQueued   int64 `json:"rpc_queued"`   // The number of RPC calls that waited for a slot since the last snapshot
+	RPCRejected int64 `json:"rpc_rejected"` // The number of RPC calls rejected at the cap since the last snapshot
+	RPCWaitMs   int64 `json:"rpc_wait_ms"`  // The longest wait for a slot since the last snapshot, in milliseconds
+}
+
+var rpcCalls, rpcErrors, rpcQueued, rpcRejected, rpcWait atomic.Int64
+
+// Accounts an RPC call (and its failure) to the next metrics snapshot
+func RecordRPC(err error) {
+	rpcCalls.Add(1)
+	if err != nil {
+		rpcErrors.Add(1)
+	}
+}
+
+// Accounts an RPC call that waited for a slot of its RPC (and whether it was rejected) to the next
+// metrics snapshot
+func RecordRPCQueue(wait time.Duration, rejected bool) {
+	rpcQueued.Add(1)
+	if rejected {
+		rpcRejected.Add(1)
+	}
+
+	for waitMs := wait.Milliseconds(); ; {
+		longest := rpcWait.Load()
+		if waitMs <= longest || rpcWait.CompareAndSwap(longest, waitMs) {
+			break
+		}
+	}
+}
+
+// Takes a snapshot of the node metrics. The RPC counters restart after every snapshot
+func (n Node) CollectMetrics(ctx context.Context) (NodeMetrics, error) {
+	metrics := NodeMetrics{Timestamp: timeutil.Now()}
+
+	peers, err := n.CountDocuments(ctx, "peers")
+	if err != nil {
+		return metrics, fmt.Errorf("failed to count the peers: %v", err)
+	}
+
+	height, err := n.CountDocuments(ctx, "blockchain")
+	if err != nil {
+		return metrics, fmt.Errorf("failed to count the blocks: %v", err)
+	}
+
+	mempool, err := n.CountDocuments(ctx, "transactions", mempoolQuery)
+	if err != nil {
+		return metrics, fmt.Errorf("failed to count the pending transactions: %v", err)
+	}
+
+	metrics.Peers = peers
+	metrics.ChainHeight = height
+	metrics.MempoolSize = mempool
+	metrics.RPCCalls = rpcCalls.Swap(0)
+	metrics.RPCErrors = rpcErrors.Swap(0)
+	metrics.RPCQueued = rpcQueued.Swap(0)
+	metrics.RPCRejected = rpcRejected.Swap(0)
+	metrics.RPCWaitMs = rpcWait.Swap(0)
+
+	return metrics, nil
+}
+
+// Takes a snapshot of the node metrics and stores it in the backlog, publishing the load hints
+func (n Node) PersistMetrics(ctx context.Context) error {
+	metrics, err := n.CollectMetrics(ctx)
+	if err != nil {
+		return err
+	}
+
+	document := map[string]interface{}{
+		"timestamp":    metrics.Timestamp,
+		"peers":        metrics.Peers,
+		"chain_height": metrics.ChainHeight,
+		"mempool_size": metrics.MempoolSize,
+		"rpc_calls":    metrics.RPCCalls,
+		"rpc_errors":   metrics.RPCErrors,
+		"rpc_queued":   metrics.RPCQueued,
+		"rpc_rejected": metrics.RPCRejected,
+		"rpc_wait_ms":  metrics.RPCWaitMs,
+	}
+
+	id := fmt.Sprintf("%s-%s", n.Host, strconv.FormatInt(metrics.Timestamp, 10))
+	if err := n.IndexDocument(ctx, "node_metrics", id, document); err != nil {
+		return fmt.Errorf("failed to store the metrics: %v", err)
+	}
+
+	return n.publishLoad(ctx, metrics)
+}
+
+// Gives the metrics snapshots taken between two timestamps, the oldest first
+func (n Node) MetricsHistory(ctx context.Context, from, to int64) ([]NodeMetrics, error) {
+	documents, err := n.SearchDocuments(ctx, "node_metrics", map[string]interface{}{
+		"size": 1000,
+		"sort": []interface{}{map[string]interface{}{"timestamp": "asc"}},
+		"query": map[string]interface{}{
+			"range": map[string]interface{}{
+				"timestamp": map[string]interface{}{"gte": from, "lte": to},
+			},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search the metrics: %v", err)
+	}
+
+	var history []NodeMetrics
+	for _, document := range documents {
+		value := func(key string) int64 {
+			number, _ := document[key].(float64)
+			return int64(number)
+		}
+
+		history = append(history, NodeMetrics{
+			Timestamp:   value("timestamp"),
+			Peers:       value("peers"),
+			ChainHeight: value("chain_height"),
+			MempoolSize: value("mempool_size"),
+			RPCCalls:    value("rpc_calls"),
+			RPCErrors:   value("rpc_errors"),
+			RPCQueued:   value("rpc_queued"),
+			RPCRejected: value("rpc_rejected"),
+			RPCWaitMs:   value("rpc_wait_ms"),
+		})
+	}
+
+	return history, nil
+}
+
+// Schedules the job that persists the node metrics. The metrics are counted by process, so the job
+// runs in all of them
+func (n Node) StartMetricsRecorder(schedule string) error {
+	return ScheduleJob(Job{
+		Name:     "metrics_recorder",
+		Schedule: schedule,
+		Run: func(ctx context.Context, lease *Lease) error {
+			// The counters keep adding up until the backlog recovers
+			if UnderBackpressure() {
+				return nil
+			}
+
+			if err := n.PersistMetrics(ctx); err != nil {
+				return fmt.Errorf("failed to persist the metrics: %v", err)
+			}
+
+			return nil
+		},
+	})
+}
@@ -0,0 +1,228 @@
+package node
+
+import (
+	"context"
+	"fmt"
+)
+
+// The maximum number of documents fetched from the mirror at once
+const mirrorPageSize int = 100
+
+// An index reconciled against the mirror, with the fields used to page and compare its documents
+type mirroredIndex struct {
+	timestamp string                                          // The field with the timestamp of the last change
+	id        string                                          // The keyword field with the document id (the tiebreaker of the paging)
+	scrub     func(map[string]interface{})                    // Removes the fields that must not leave the node
+	prefer    func(local, remote map[string]interface{}) bool // Decides the conflicts with the same timestamp
+	conflict  func(local, remote map[string]interface{}) bool // Detects the copies that are different documents, never reconciled (nil when none is)
+	progress  func(map[string]interface{}) int                // Gives how far along a document is, when it changes without its timestamp (nil when it doesn't)
+}
+
+/*
+A node with a mirror keeps its copies of the shared indices (the foreign clients, the transactions
+and the chain) reconciled against it. The documents changed in the mirror since the last round are
+fetched and compared with the local ones: the copy with the latest timestamp wins. The blocks are
+fetched in order, the same way they're pulled from the peers.
+*/
+var mirroredIndices = map[string]mirroredIndex{
+	"clients": {
+		timestamp: "updated_at",
+		id:        "client_id",
+		conflict: func(local, remote map[string]interface{}) bool {
+			// Two clients of different nodes with the same id (please, go to `conflicts.go`)
+			localNode, _ := local["node"].(string)
+			remoteNode, _ := remote["node"].(string)
+			return localNode != "" && remoteNode != "" && localNode != remoteNode
+		},
+	},
+	"transactions": {
+		timestamp: "Timestamp",
+		id:        "TransactionId.keyword",
+		scrub: func(document map[string]interface{}) {
+			// The sender is a local client, so only its public reference is shared
+			if sender, ok := document["Sender"].(map[string]interface{}); ok {
+				document["Sender"] = map[string]interface{}{"client_id": sender["client_id"]}
+			}
+		},
+		prefer: func(local, remote map[string]interface{}) bool {
+			// A transaction only moves forward (signed, confirmed, acknowledged), so the copy further along wins
+			return transactionProgress(remote) > transactionProgress(local)
+		},
+		progress: transactionProgress,
+	},
+}
+
+func transactionProgress(document map[string]interface{}) int {
+	progress := 0
+	for _, field := range []string{"Signature", "BlockHash", "Acknowledgment"} {
+		if document[field] != nil {
+			progress++
+		}
+	}
+
+	return progress
+}
+
+func documentTimestamp(document map[string]interface{}, field string) int64 {
+	timestamp, _ := document[field].(float64)
+	return int64(timestamp)
+}
+
+// Gives the documents of a mirrored index changed since some timestamp, in order, limited to a
+// page. The `afterId` continues a page that ended at the `since` timestamp
+func (n Node) DocumentsSince(ctx context.Context, index string, since int64, afterId string) ([]map[string]interface{}, error) {
+	mirrored, ok := mirroredIndices[index]
+	if !ok {
+		return nil, fmt.Errorf("the index %s isn't mirrored", index)
+	}
+
+	body := map[string]interface{}{
+		"size": mirrorPageSize,
+		"sort": []interface{}{
+			map[string]interface{}{mirrored.timestamp: "asc"},
+			map[string]interface{}{mirrored.id: "asc"},
+		},
+		"query": map[string]interface{}{
+			"range": map[string]interface{}{mirrored.timestamp: map[string]interface{}{"gte": since}},
+		},
+	}
+	if afterId != "" {
+		body["search_after"] = []interface{}{since, afterId}
+	}
+
+	documents, err := n.SearchDocuments(ctx, index, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the index %s: %v", index, err)
+	}
+
+	for _, document := range documents {
+		if mirrored.scrub != nil {
+			mirrored.scrub(document)
+		}
+	}
+
+	return documents, nil
+}
+
+// Stores a document of the mirror when it's newer than the local copy. Gives whether it was stored
+func (n Node) reconcile(ctx context.Context, index string, document map[string]interface{}) (bool, error) {
+	mirrored := mirroredIndices[index]
+
+	id, _ := document["_id"].(string)
+	delete(document, "_id")
+
+	local, err := n.GetDocument(ctx, index, id)
+	if err == nil && mirrored.conflict != nil && mirrored.conflict(local, document) {
+		return false, n.recordMirrorConflict(ctx, index, id, local, document)
+	}
+
+	if err == nil {
+		localTimestamp := documentTimestamp(local, mirrored.timestamp)
+		remoteTimestamp := documentTimestamp(document, mirrored.timestamp)
+
+		switch {
+		case remoteTimestamp < localTimestamp:
+			return false, nil
+		case remoteTimestamp == localTimestamp && (mirrored.prefer == nil || !mirrored.prefer(local, document)):
+			return false, nil
+		}
+	}
+
+	if err := n.ReplaceDocument(ctx, index, id, document); err != nil {
+		return false, fmt.Errorf("failed to store the document %s/%s: %v", index, id, err)
+	}
+
+	return true, nil
+}
+
+// Reconciles the mirrored indices and the chain against the mirror, once. The watermarks keep the
+// timestamp reached in each index, so the next round only fetches the newer changes
+func (n Node) syncMirror(ctx context.Context, lease Lease, watermarks map[string]int64) (int, error) {
+	// A mirror of another chain or protocol is never reconciled (please, go to `handshake.go`)
+	if _, err := n.Handshake(ctx, n.Mirror); err != nil {
+		return 0, err
+	}
+
+	reconciled, err := n.reconcileMirror(ctx, lease, watermarks)
+	if err != nil {
+		return reconciled, err
+	}
+
+	blocks, err := n.fetchBlocks(ctx, n.Mirror)
+	return reconciled + blocks, err
+}
+
+// Reconciles the mirrored indices against the mirror, from the watermarks. Gives the number of
+// documents stored
+func (n Node) reconcileMirror(ctx context.Context, lease Lease, watermarks map[string]int64) (int, error) {
+	reconciled := 0
+	for index, mirrored := range mirroredIndices {
+		since, afterId := watermarks[index], ""
+
+		for {
+			documents, err := peerTransport.FetchDocuments(ctx, n.Mirror, index, since, afterId)
+			if err != nil {
+				return reconciled, fmt.Errorf("failed to fetch the %s from the mirror: %v", index, err)
+			}
+
+			Debugf(ctx, SubsystemSync, "fetched %d document(s) of %s from the mirror since %d", len(documents), index, since)
+
+			// The fetch may take long enough for another process to take the sync over
+			if err := n.CheckLease(ctx, lease); err != nil {
+				return reconciled, err
+			}
+
+			for _, document := range documents {
+				timestamp := documentTimestamp(document, mirrored.timestamp)
+				id, _ := document["_id"].(string)
+
+				stored, err := n.reconcile(ctx, index, document)
+				if err != nil {
+					return reconciled, err
+				}
+
+				if stored {
+					reconciled++
+				}
+
+				since, afterId = timestamp, id
+			}
+
+			watermarks[index] = since
+			if len(documents) < mirrorPageSize {
+				break
+			}
+		}
+	}
+
+	return reconciled, nil
+}
+
+// Schedules the job that reconciles the node against its mirror, starting right away. Nodes without
+// a mirror don't schedule it
+func (n Node) StartMirrorSync(schedule string) error {
+	if n.Mirror == "" || n.Mirror == "0.0.0.0" {
+		return nil
+	}
+
+	watermarks := map[string]int64{}
+	return ScheduleJob(Job{
+		Name:       "mirror_sync",
+		Schedule:   schedule,
+		Singleton:  true,
+		RunAtStart: true,
+		Retries:    3,
+		Run: func(ctx context.Context, lease *Lease) error {
+			count, err := n.syncMirror(ctx, *lease, watermarks)
+			if err != nil {
+				return fmt.Errorf("failed to sync with the mirror %s: %v", n.Mirror, err)
+			}
+
+			if count > 0 {
+				Logf(ctx, "reconciled %d document(s) with the mirror %s", count, n.Mirror)
+			}
+
+			return nil
+		},
+	})
+}
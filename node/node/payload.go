@@ -0,0 +1,42 @@
+package node
+
+import (
+	"errors"
+	"fmt"
+)
+
+// The maximum size (in bytes) of the payload of a transaction
+const MaxPayloadSize int = 4096
+
+/*
+Besides its value, a transaction can carry a payload: arbitrary bytes that the applications attach
+to the transfer (e.g. a structured order or a plain reference). Unlike the memo (please, go to
+`memo.go`), the payload isn't sealed, so every node and the chain keep it readable.
+
+The payload is signed with the transaction, as the base64 of its bytes, only when there's one, so
+the signatures of the transactions without a payload don't change.
+*/
+var ErrInvalidPayload = errors.New("invalid payload")
+
+// Attaches a payload to the unsigned transaction
+func (t *Transaction) AttachPayload(payload []byte) error {
+	if t.Signature != nil {
+		return fmt.Errorf("%w: the transaction %s is already signed", ErrInvalidPayload, t.TransactionId)
+	}
+
+	if len(payload) > MaxPayloadSize {
+		return fmt.Errorf("%w: the payload has %d bytes but the limit is %d", ErrInvalidPayload, len(payload), MaxPayloadSize)
+	}
+
+	t.Payload = payload
+	return nil
+}
+
+// Adds the payload (when there's one) to the signable fields of a transaction
+func withPayload(transaction map[string]interface{}, payload []byte) map[string]interface{} {
+	if len(payload) > 0 {
+		transaction["payload"] = payload
+	}
+
+	return transaction
+}
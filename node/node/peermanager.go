@@ -0,0 +1,290 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	backlog "node/backlog"
+	client "node/client"
+	config "node/config"
+	timeutil "node/timeutil"
+	"sync"
+	"time"
+)
+
+// The exchanges in a row that a peer can fail before it's taken as unreachable
+const peerFailureThreshold int = 3
+
+/*
+The peer manager keeps the `peers` index up to date. On every round it exchanges the peers with
+every known peer (and with the mirror and the seeds in SEED_PEERS, until they are known): the node
+sends its signed status (please, go to `signing.go`) and the peers it knows, and it gets back the
+same from the peer.
+
+  - The status that a peer gives of itself is its current status, so it replaces the known one. A
+    peer must keep its key: a host that answers with another key for the same node is refused.
+  - The peers learned from another peer are only stored when they are unknown and their status is
+    signed by their own key, since an old status could be replayed. They are reached directly in
+    the next round anyway.
+  - A peer that fails `peerFailureThreshold` exchanges in a row is taken as unreachable, unless it
+    announced its departure, so the propagation and the sync skip it (please, go to `AlivePeers`).
+  - The round trip of every exchange is stored as the latency of the peer, so the clients are
+    pointed to the nearest alternatives (please, go to `NearestNodes`).
+*/
+type PeerManager struct {
+	node     Node
+	mutex    sync.Mutex
+	failures map[string]int // The exchanges failed in a row, by node id
+}
+
+// Creates the peer manager of a node
+func NewPeerManager(n Node) *PeerManager {
+	return &PeerManager{node: n, failures: map[string]int{}}
+}
+
+// Gives the status of the node signed by its key, as it's given to the peers
+func (n Node) SignedStatus() (*Node, error) {
+	status := n
+	if err := status.signStatus(); err != nil {
+		return nil, err
+	}
+
+	return &status, nil
+}
+
+// Gives the status of a peer from its document
+func peerFromDocument(document map[string]interface{}) (*Node, error) {
+	id, _ := document["_id"].(string)
+	delete(document, "_id")
+
+	var peer Node
+	if err := fromDocument("peers", id, document, &peer, "node_id"); err != nil {
+		return nil, err
+	}
+
+	return &peer, nil
+}
+
+// Gives the known peers whose stored status is still the one they signed, so they can be shared
+func (n Node) KnownPeers(ctx context.Context) ([]Node, error) {
+	documents, err := n.ListDocuments(ctx, "peers", backlog.ListOptions{All: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the peers: %v", err)
+	}
+
+	var peers []Node
+	for _, document := range documents {
+		peer, err := peerFromDocument(document)
+		if err != nil || peer.Id == n.Id || peer.VerifyStatus() != nil {
+			continue
+		}
+
+		peers = append(peers, *peer)
+	}
+
+	return peers, nil
+}
+
+// Gives the fields of a peer document that come from the status of the peer
+func peerStatusFields(status Node) map[string]interface{} {
+	return map[string]interface{}{
+		"node_id":        status.Id,
+		"public_key":     status.PublicKey,
+		"host":           status.Host,
+		"version":        status.Version,
+		"status":         status.Status,
+		"read_only":      status.ReadOnly,
+		"schema_version": status.SchemaVersion,
+		"resumes_at":     status.ResumesAt,
+		"maintenance":    status.Maintenance,
+		"signature":      status.Signature,
+	}
+}
+
+// Stores a peer learned from another node, when it's unknown and its status is signed by its key.
+// Gives whether the peer was stored
+func (n Node) LearnPeer(ctx context.Context, status Node) (bool, error) {
+	if status.Id == "" || status.Id == n.Id || status.Host == "" {
+		return false, nil
+	}
+
+	if err := status.VerifyStatus(); err != nil {
+		return false, err
+	}
+
+	if _, err := client.ParseIdentity(status.PublicKey); err != nil {
+		return false, fmt.Errorf("the node %s has an invalid public key: %v", status.Id, err)
+	}
+
+	if _, err := n.GetDocument(ctx, "peers", status.Id); err == nil {
+		return false, nil
+	}
+
+	document := peerStatusFields(status)
+	document["learned_at"] = timeutil.Now()
+
+	if err := n.CreateDocument(ctx, "peers", status.Id, document); err != nil {
+		return false, fmt.Errorf("failed to store the peer %s: %v", status.Id, err)
+	}
+
+	n.Emit(ctx, "peer.discovered", map[string]interface{}{
+		"node_id": status.Id,
+		"host":    status.Host,
+	})
+
+	return true, nil
+}
+
+// Stores the status that a peer gave of itself, after checking it's signed by the key known of it,
+// with the round trip of the exchange
+func (n Node) recordPeerStatus(ctx context.Context, status Node, known *Node, latency time.Duration) error {
+	if err := status.VerifyStatus(); err != nil {
+		return err
+	}
+
+	if known != nil && known.PublicKey != "" && known.PublicKey != status.PublicKey {
+		return fmt.Errorf("the node %s answered with another key than the one known of it", status.Id)
+	}
+
+	document := peerStatusFields(status)
+	document["last_seen_at"] = timeutil.Now()
+	document["latency"] = latency.Milliseconds()
+
+	if known == nil {
+		if err := n.CreateDocument(ctx, "peers", status.Id, document); err != nil {
+			return fmt.Errorf("failed to store the peer %s: %v", status.Id, err)
+		}
+
+		return nil
+	}
+
+	if err := n.UpdateDocument(ctx, "peers", status.Id, document); err != nil {
+		return fmt.Errorf("failed to update the peer %s: %v", status.Id, err)
+	}
+
+	return nil
+}
+
+// Counts a failed exchange with a known peer, taking it as unreachable after too many in a row
+func (m *PeerManager) fail(ctx context.Context, peer Node, err error) {
+	m.mutex.Lock()
+	m.failures[peer.Id]++
+	failures := m.failures[peer.Id]
+	m.mutex.Unlock()
+
+	Debugf(ctx, SubsystemGossip, "failed to exchange the peers with %s (%d in a row): %v", peer.Host, failures, err)
+
+	if failures < peerFailureThreshold || peer.Status != NodeAlive {
+		return
+	}
+
+	if err := m.node.UpdateDocument(ctx, "peers", peer.Id, map[string]interface{}{"status": NodeUnreachable}); err != nil {
+		Logf(ctx, "failed to mark the peer %s as unreachable: %v", peer.Id, err)
+		return
+	}
+
+	Warnf(ctx, "the peer %s (%s) is unreachable after %d failed exchanges", peer.Id, peer.Host, failures)
+	m.node.Emit(ctx, "peer.unreachable", map[string]interface{}{
+		"node_id": peer.Id,
+		"host":    peer.Host,
+	})
+}
+
+// Exchanges the peers with a host, that serves the known peer (nil for the mirror and the seeds)
+func (m *PeerManager) exchange(ctx context.Context, host string, known *Node, self Node, shared []Node) error {
+	start := time.Now()
+	status, learned, err := peerTransport.ExchangePeers(ctx, host, self, shared)
+	latency := time.Since(start)
+	if err == nil && known != nil && status.Id != known.Id {
+		err = fmt.Errorf("the host now serves the node %s", status.Id)
+	}
+
+	if err == nil {
+		err = m.node.recordPeerStatus(ctx, *status, known, latency)
+	}
+
+	if err != nil {
+		if known != nil {
+			m.fail(ctx, *known, err)
+		}
+
+		return err
+	}
+
+	if known != nil {
+		m.mutex.Lock()
+		delete(m.failures, known.Id)
+		m.mutex.Unlock()
+	}
+
+	for _, peer := range learned {
+		if _, err := m.node.LearnPeer(ctx, peer); err != nil {
+			Debugf(ctx, SubsystemGossip, "refused the peer %s learned from %s: %v", peer.Id, host, err)
+		}
+	}
+
+	return nil
+}
+
+// Exchanges the peers with every known peer, the mirror and the seeds, once
+func (m *PeerManager) Round(ctx context.Context) error {
+	if peerTransport == nil {
+		return nil
+	}
+
+	self, err := m.node.SignedStatus()
+	if err != nil {
+		return err
+	}
+
+	documents, err := m.node.ListDocuments(ctx, "peers", backlog.ListOptions{All: true})
+	if err != nil {
+		return fmt.Errorf("failed to list the peers: %v", err)
+	}
+
+	var known []Node
+	hosts := map[string]bool{m.node.Host: true}
+	for _, document := range documents {
+		peer, err := peerFromDocument(document)
+		if err != nil || peer.Id == m.node.Id || peer.Host == "" || peer.Status == NodeLiquidated {
+			continue
+		}
+
+		known = append(known, *peer)
+		hosts[peer.Host] = true
+	}
+
+	shared, err := m.node.KnownPeers(ctx)
+	if err != nil {
+		return err
+	}
+
+	for i := range known {
+		m.exchange(ctx, known[i].Host, &known[i], *self, shared)
+	}
+
+	for _, host := range append([]string{m.node.Mirror}, config.SeedPeers()...) {
+		if host == "" || host == "0.0.0.0" || hosts[host] {
+			continue
+		}
+
+		if err := m.exchange(ctx, host, nil, *self, shared); err != nil {
+			Logf(ctx, "failed to exchange the peers with the seed %s: %v", host, err)
+		}
+	}
+
+	return nil
+}
+
+// Schedules the singleton job that exchanges the peers and checks their health, starting right away
+func (n Node) StartPeerManager(schedule string) error {
+	manager := NewPeerManager(n)
+	return ScheduleJob(Job{
+		Name:       "peer_manager",
+		Schedule:   schedule,
+		Singleton:  true,
+		RunAtStart: true,
+		Run: func(ctx context.Context, lease *Lease) error {
+			return manager.Round(ctx)
+		},
+	})
+}
@@ -0,0 +1,202 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	backlog "node/backlog"
+	config "node/config"
+)
+
+// The maximum number of blocks fetched from a peer at once
+const fetchPageSize int64 = 100
+
+/*
+The nodes share their blocks with the peers: every mined block is announced to the alive peers
+and, on startup, the blocks that the node missed while it was down are fetched from them.
+
+The peers are reached through the gRPC server, but the server depends on the node package, so the
+transport is registered by the program with `RegisterPeerTransport`. Without a transport, the node
+keeps its blocks to itself.
+*/
+type PeerTransport interface {
+	AnnounceBlock(ctx context.Context, host string, block Block) error                                                     // Pushes a block to the peer
+	FetchBlocks(ctx context.Context, host string, fromHeight int64) ([]Block, error)                                       // Pulls the blocks of the peer from some height, in order
+	AnnounceAddress(ctx context.Context, host string, change AddressChange) error                                          // Pushes an address change of the node to the peer
+	AnnounceDeparture(ctx context.Context, host string, departure Departure) error                                         // Tells the peer that the node is hibernating (please, go to `departure.go`)
+	AnnounceErasure(ctx context.Context, host string, erasure Erasure) error                                               // Tells the peer that a client of the node was erased (please, go to `erasure.go`)
+	AnnounceClient(ctx context.Context, host string, announcement ClientAnnouncement) error                                // Shares the record of a client of the node with the peer (please, go to `discovery.go`)
+	RouteTransaction(ctx context.Context, host string, transaction RoutedTransaction) error                                // Delivers a signed transaction to the home node of its recipient (please, go to `routing.go`)
+	RouteAcknowledgment(ctx context.Context, host string, acknowledgment Acknowledgment) error                             // Delivers the acknowledgment of a transaction to the home node of its sender (please, go to `acknowledgment.go`)
+	FetchDocuments(ctx context.Context, host, index string, since int64, afterId string) ([]map[string]interface{}, error) // Pulls the documents of a mirrored index changed since some timestamp (please, go to `mirror.go`)
+	SummarizeIndex(ctx context.Context, host, index, prefix string) ([]RangeSummary, error)                                // Gives the summaries of the subranges of a range of a mirrored index (please, go to `antientropy.go`)
+	FetchDocumentRange(ctx context.Context, host, index, prefix string) ([]map[string]interface{}, error)                  // Pulls the documents in a range of a mirrored index
+	ExchangePeers(ctx context.Context, host string, self Node, peers []Node) (*Node, []Node, error)                        // Gives the signed status of the peer and the peers it knows (please, go to `peermanager.go`)
+	Handshake(ctx context.Context, host string, self Handshake) (*Handshake, error)                                        // Exchanges the handshakes with the peer before a sync (please, go to `handshake.go`)
+	Ping(ctx context.Context, host string) error                                                                           // Checks if the peer is reachable (please, go to `health.go`)
+}
+
+var peerTransport PeerTransport
+
+// Registers the transport used to talk to the peers
+func RegisterPeerTransport(transport PeerTransport) {
+	peerTransport = transport
+}
+
+// Gives the hosts of the alive peers (except the node itself, even under a former host), as kept by
+// the peer manager (please, go to `peermanager.go`)
+func (n Node) AlivePeers(ctx context.Context) ([]string, error) {
+	documents, err := n.ListDocuments(ctx, "peers", backlog.ListOptions{All: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the peers: %v", err)
+	}
+
+	var hosts []string
+	for _, document := range documents {
+		id, _ := document["node_id"].(string)
+		host, _ := document["host"].(string)
+		status, _ := document["status"].(string)
+
+		if host != "" && id != n.Id && host != n.Host && NodeStatus(status) == NodeAlive {
+			hosts = append(hosts, host)
+		}
+	}
+
+	return hosts, nil
+}
+
+// Announces a block to all the alive peers in background, through the gossip workers. The failures
+// don't stop the propagation, since the peers that missed the block fetch it when they notice the gap
+func (n Node) PropagateBlock(ctx context.Context, block Block) {
+	if peerTransport == nil || !config.FeatureEnabled(config.FeatureGossip) {
+		return
+	}
+
+	if UnderBackpressure() {
+		Debugf(ctx, SubsystemGossip, "the block %d isn't announced under backpressure", block.Height)
+		return
+	}
+
+	hosts, err := n.AlivePeers(ctx)
+	if err != nil {
+		Logf(ctx, "failed to propagate the block %d: %v", block.Height, err)
+		return
+	}
+
+	for _, host := range hosts {
+		host := host
+		gossipPool.Go(ctx, func(ctx context.Context) {
+			if err := peerTransport.AnnounceBlock(ctx, host, block); err != nil {
+				Logf(ctx, "failed to announce the block %d to %s: %v", block.Height, host, err)
+				return
+			}
+			Debugf(ctx, SubsystemGossip, "announced the block %d to %s", block.Height, host)
+		})
+	}
+}
+
+// Fetches the blocks after the last one of the chain from a peer and appends them, once the peer
+// shook hands with the node (please, go to `handshake.go`). Gives the number of blocks appended
+func (n Node) SyncBlocks(ctx context.Context, host string) (int, error) {
+	if _, err := n.Handshake(ctx, host); err != nil {
+		return 0, err
+	}
+
+	return n.fetchBlocks(ctx, host)
+}
+
+// Fetches the blocks after the last one of the chain from a peer that already shook hands with the
+// node, and appends them. When the chains of both conflict, the fork choice picks one of them
+// (please, go to `forkchoice.go`)
+func (n Node) fetchBlocks(ctx context.Context, host string) (int, error) {
+	blockchain := NewBlockchain(n.Backlog)
+	appended := 0
+
+	for {
+		last, err := blockchain.LastBlock(ctx)
+		if err != nil {
+			return appended, err
+		}
+
+		// The last block is fetched again, to notice when the peer has another one at its height
+		fromHeight := int64(0)
+		if last != nil {
+			fromHeight = last.Height
+		}
+
+		fetched, err := peerTransport.FetchBlocks(ctx, host, fromHeight)
+		if err != nil {
+			return appended, fmt.Errorf("failed to fetch the blocks from %s: %v", host, err)
+		}
+		Debugf(ctx, SubsystemSync, "fetched %d block(s) from %s since the height %d", len(fetched), host, fromHeight)
+
+		blocks := fetched
+		if last != nil && len(blocks) > 0 {
+			if blocks[0].Hash != last.Hash {
+				count, err := n.resolveFork(ctx, host, last)
+				return appended + count, err
+			}
+
+			blocks = blocks[1:]
+		}
+
+		for i := range blocks {
+			if err := n.AppendBlock(ctx, &blocks[i]); err != nil {
+				return appended, fmt.Errorf("failed to append the block %d from %s: %v", blocks[i].Height, host, err)
+			}
+
+			appended++
+		}
+
+		if int64(len(fetched)) < fetchPageSize {
+			return appended, nil
+		}
+	}
+}
+
+// Fetches the missing blocks from all the alive peers. Gives the number of blocks appended
+func (n Node) PullBlocks(ctx context.Context) (int, error) {
+	if peerTransport == nil {
+		return 0, nil
+	}
+
+	hosts, err := n.AlivePeers(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	appended := 0
+	for _, host := range hosts {
+		count, err := n.SyncBlocks(ctx, host)
+		appended += count
+
+		if err != nil {
+			Logf(ctx, "failed to pull the blocks from %s: %v", host, err)
+		}
+	}
+
+	return appended, nil
+}
+
+// Gives the blocks of the chain from some height, in order, limited to a page
+func (bc Blockchain) BlocksFrom(ctx context.Context, fromHeight int64) ([]Block, error) {
+	documents, err := bc.SearchDocuments(ctx, "blockchain", map[string]interface{}{
+		"size":  fetchPageSize,
+		"sort":  []interface{}{map[string]interface{}{"height": "asc"}},
+		"query": map[string]interface{}{"range": map[string]interface{}{"height": map[string]interface{}{"gte": fromHeight}}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the chain: %v", err)
+	}
+
+	var blocks []Block
+	for _, document := range documents {
+		block, err := blockFromDocument(document)
+		if err != nil {
+			return nil, err
+		}
+
+		blocks = append(blocks, *block)
+	}
+
+	return blocks, nil
+}
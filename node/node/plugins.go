@@ -0,0 +1,88 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+/*
+A plugin adds custom logic (e.g. compliance checks or business rules) to the lifecycle of the
+transactions, without changing the node. The plugins are compiled into the program and registered
+with `RegisterPlugin` before the node starts, and each one implements the hooks it needs:
+
+- `BeforeAcceptHook` runs before the node accepts a transaction of a local client, and can reject it
+- `AfterSignHook` runs after a transaction was signed and stored
+- `AfterCommitHook` runs after a block was committed to the chain (mined or received)
+
+The hooks run in the registration order, in the request that triggered them. A rejection stops the
+transaction, while the failures of the other hooks are only logged, since the transaction (or the
+block) is already stored.
+*/
+type Plugin interface {
+	Name() string // The name of the plugin, used in the logs and in the rejections
+}
+
+type BeforeAcceptHook interface {
+	BeforeAccept(ctx context.Context, t Transaction) error // Gives an error to reject the transaction
+}
+
+type AfterSignHook interface {
+	AfterSign(ctx context.Context, t Transaction) error
+}
+
+type AfterCommitHook interface {
+	AfterCommit(ctx context.Context, block Block) error
+}
+
+var ErrRejected = errors.New("rejected by a plugin")
+
+var plugins []Plugin
+
+// Registers a plugin, whose hooks run after the ones of the plugins registered before it
+func RegisterPlugin(plugin Plugin) {
+	plugins = append(plugins, plugin)
+}
+
+// Runs the validation scripts and the hooks before a transaction is accepted. Gives the first
+// rejection
+func runBeforeAccept(ctx context.Context, t Transaction) error {
+	if err := runScripts(ctx, t); err != nil {
+		return err
+	}
+
+	for _, plugin := range plugins {
+		hook, ok := plugin.(BeforeAcceptHook)
+		if !ok {
+			continue
+		}
+
+		if err := hook.BeforeAccept(ctx, t); err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrRejected, plugin.Name(), err)
+		}
+	}
+
+	return nil
+}
+
+// Runs the hooks after a transaction was signed
+func runAfterSign(ctx context.Context, t Transaction) {
+	for _, plugin := range plugins {
+		if hook, ok := plugin.(AfterSignHook); ok {
+			if err := hook.AfterSign(ctx, t); err != nil {
+				Logf(ctx, "the plugin %s failed after the transaction %s was signed: %v", plugin.Name(), t.TransactionId, err)
+			}
+		}
+	}
+}
+
+// Runs the hooks after a block was committed
+func runAfterCommit(ctx context.Context, block Block) {
+	for _, plugin := range plugins {
+		if hook, ok := plugin.(AfterCommitHook); ok {
+			if err := hook.AfterCommit(ctx, block); err != nil {
+				Logf(ctx, "the plugin %s failed after the block %d was committed: %v", plugin.Name(), block.Height, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	backlog "node/backlog"
+	timeutil "node/timeutil"
+)
+
+/*
+A client can change its alias and attach metadata to itself (e.g. a display name or a contact),
+so the peers can show something friendlier than the client id.
+
+The alias stays in the node, since it's what the client connects with: a new alias is reserved as
+at the creation (please, go to `alias.go`) and the former one is released once the change is
+committed. The metadata is copied to the foreign client, so it reaches the mirrored peers with the
+other changes of the `clients` index (please, go to `mirror.go`), and it's dropped when the client
+is erased.
+
+The metadata is merged into the current one: an empty value removes its key.
+*/
+const (
+	maxMetadataEntries int = 16  // The keys that a client can attach at most
+	maxMetadataKey     int = 64  // The length of a key at most
+	maxMetadataValue   int = 256 // The length of a value at most
+)
+
+var ErrInvalidMetadata = errors.New("the metadata of the client is invalid")
+
+// Merges the changes into the metadata, checking the limits of the result
+func mergeMetadata(current, changes map[string]string) (map[string]string, error) {
+	merged := map[string]string{}
+	for key, value := range current {
+		merged[key] = value
+	}
+
+	for key, value := range changes {
+		switch {
+		case key == "" || len(key) > maxMetadataKey:
+			return nil, fmt.Errorf("%w: the keys must have from 1 to %d chars", ErrInvalidMetadata, maxMetadataKey)
+		case len(value) > maxMetadataValue:
+			return nil, fmt.Errorf("%w: the value of %s has more than %d chars", ErrInvalidMetadata, key, maxMetadataValue)
+		case value == "":
+			delete(merged, key)
+		default:
+			merged[key] = value
+		}
+	}
+
+	if len(merged) > maxMetadataEntries {
+		return nil, fmt.Errorf("%w: a client has %d keys at most", ErrInvalidMetadata, maxMetadataEntries)
+	}
+
+	return merged, nil
+}
+
+// Gives the commit that replaces the documents of a client with the new alias and metadata. The
+// documents are replaced as a whole, since a partial update would merge the metadata, keeping the
+// keys removed
+func (n Node) profileCommit(ctx context.Context, owner *Client, alias string, metadata map[string]string) (*backlog.Commit, error) {
+	local, err := n.GetDocument(ctx, "local_clients", owner.UID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the client document: %v", err)
+	}
+
+	foreign, err := n.GetDocument(ctx, "clients", owner.ClientId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the foreign client document: %v", err)
+	}
+
+	local["alias"], local["metadata"] = alias, metadata
+	foreign["metadata"], foreign["updated_at"] = metadata, timeutil.Now()
+
+	return n.Begin().Put("local_clients", owner.UID, local).Put("clients", owner.ClientId, foreign), nil
+}
+
+// Changes the alias (when given and different) and merges the metadata of a local client. Gives the
+// updated client, without its key pair
+func (n Node) UpdateClient(ctx context.Context, uid, alias string, metadata map[string]string) (*Client, error) {
+	owner, err := n.Clients().Get(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	if owner.Liquidated {
+		return nil, ErrClientLiquidated
+	}
+
+	merged, err := mergeMetadata(owner.Metadata, metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	previous := owner.Alias
+	if alias == "" {
+		alias = previous
+	}
+
+	// An alias that only differs by case keeps its reservation
+	renamed := NormalizeAlias(alias) != NormalizeAlias(previous)
+	if renamed {
+		if err := n.ReserveAlias(ctx, alias); err != nil {
+			return nil, err
+		}
+	}
+
+	fail := func(err error) (*Client, error) {
+		if !renamed {
+			return nil, err
+		}
+
+		if releaseErr := n.ReleaseAlias(ctx, alias); releaseErr != nil {
+			return nil, fmt.Errorf("%v (%v)", err, releaseErr)
+		}
+
+		return nil, err
+	}
+
+	commit, err := n.profileCommit(ctx, owner, alias, merged)
+	if err != nil {
+		return fail(err)
+	}
+
+	if renamed {
+		commit = commit.Delete("aliases", aliasId(previous))
+	}
+
+	if err := commit.Apply(ctx); err != nil {
+		return fail(fmt.Errorf("failed to update the client: %v", err))
+	}
+
+	owner.Alias, owner.Metadata = alias, merged
+
+	n.Emit(ctx, "client.updated", map[string]interface{}{
+		"uid":       uid,
+		"client_id": owner.ClientId,
+		"renamed":   renamed,
+	})
+
+	return owner, nil
+}
@@ -0,0 +1,66 @@
+package node
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	client "node/client"
+)
+
+/*
+The public key of a client can be derived from its client id, but the peers and the verifiers also
+need to know whether the key is still the one in use (it may have been rotated, erased or
+liquidated). The key directory gives the key of a client in PEM together with that state, and an
+etag that changes whenever the state does, so the callers can cache the keys and only fetch them
+again when they changed.
+*/
+type PublicKeyRecord struct {
+	ClientId    string `json:"client_id"`  // The identity of the key
+	PEM         string `json:"pem"`        // The key in PEM
+	Algorithm   string `json:"algorithm"`  // The algorithm of the key, e.g. "RSA"
+	Bits        int    `json:"bits"`       // The size of the key
+	NodeAddress string `json:"node"`       // The hash of the host address from the node of the client
+	RotatedTo   string `json:"rotated_to"` // The client id of the key that replaced it (empty when none did)
+	Revoked     bool   `json:"revoked"`    // Whether the client was erased or liquidated, so the key can't sign anymore
+	Version     int64  `json:"version"`    // The timestamp of the last change of the client
+}
+
+// Gives the etag of the key record, which changes whenever any of its fields does
+func (r PublicKeyRecord) ETag() string {
+	recordBytes, _ := json.Marshal(r)
+	hash := sha256.Sum256(recordBytes)
+	return hex.EncodeToString(hash[:16])
+}
+
+// Gives the key record of a client known by the node. Gives ErrUnknownClient when it's unknown
+func (n Node) PublicKeyOf(ctx context.Context, clientId string) (*PublicKeyRecord, error) {
+	foreign, err := n.Clients().GetForeign(ctx, clientId)
+	if err != nil {
+		return nil, err
+	}
+
+	publicKey, err := client.ParseIdentity(clientId)
+	if err != nil {
+		return nil, fmt.Errorf("the client %s has an invalid identity: %v", clientId, err)
+	}
+
+	pem, err := client.CryptoResource{PublicKey: publicKey}.ImpersonatePublicKey()
+	if err != nil {
+		return nil, err
+	}
+
+	record := PublicKeyRecord{
+		ClientId:    clientId,
+		PEM:         string(pem),
+		Algorithm:   "RSA",
+		Bits:        publicKey.N.BitLen(),
+		NodeAddress: foreign.NodeAddress,
+		RotatedTo:   foreign.RotatedTo,
+		Revoked:     foreign.Erased || foreign.Liquidated,
+		Version:     foreign.UpdatedAt,
+	}
+
+	return &record, nil
+}
@@ -0,0 +1,180 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	backlog "node/backlog"
+	timeutil "node/timeutil"
+	"sort"
+	"time"
+)
+
+/*
+The fees are optional (the MIN_FEE of a node is zero by default, and the blocks of the peers are
+taken whatever their fees are, please, go to `fees.go`), so they don't stop a spammer from flooding
+the chain. The protocol also caps the transactions of every sender with a token bucket: the bucket
+holds `RateBurst` transactions and gets one back every `RateRefill`.
+
+The limit is a parameter of the chain, not of the node config: a block with a transaction over the
+limit is invalid in every node, so a node can't let its clients spam the others by relaxing it. The
+bucket is replayed over the timestamps of the sender transactions (never over the clock of the node,
+so every node agrees), starting full `RateBurst` refills before the transaction checked, since any
+bucket is full again after that.
+
+The limit is checked when the transaction is signed, so the client knows right away, and again when
+a block is appended, since the block may come from a peer. The blocks assembled before the limit was
+activated are accepted as they are, so the nodes can still sync the older chain.
+*/
+type ChainParams struct {
+	RateBurst       int           `json:"rate_burst"`        // The transactions a sender can make at once
+	RateRefill      time.Duration `json:"rate_refill"`       // The time to get back one transaction
+	RateActivatedAt int64         `json:"rate_activated_at"` // The timestamp of the first block whose transactions are limited
+}
+
+// The parameters of the chain. Changing them forks the chain from the nodes that didn't change them
+var chainParams = ChainParams{
+	RateBurst:       20,
+	RateRefill:      3 * time.Second,
+	RateActivatedAt: 1793491200000, // 2026-11-01T00:00:00Z
+}
+
+// Gives the parameters of the chain
+func Params() ChainParams {
+	return chainParams
+}
+
+var ErrRateLimited = errors.New("the sender is over the transaction rate of the protocol")
+
+// Gives the time (in milliseconds) after which an idle bucket is full again
+func (p ChainParams) lookback() int64 {
+	return int64(p.RateBurst) * p.RateRefill.Milliseconds()
+}
+
+// Replays the bucket of a sender over the timestamps of its past transactions and the next ones.
+// Gives the position of the first next timestamp over the limit (-1 when none is)
+func (p ChainParams) overLimit(past, next []int64) int {
+	type entry struct {
+		timestamp int64
+		position  int // The position among the next timestamps (-1 for the past ones)
+	}
+
+	var entries []entry
+	for _, timestamp := range past {
+		entries = append(entries, entry{timestamp, -1})
+	}
+	for i, timestamp := range next {
+		entries = append(entries, entry{timestamp, i})
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].timestamp < entries[j].timestamp })
+
+	tokens, refill := float64(p.RateBurst), float64(p.RateRefill.Milliseconds())
+	for i, e := range entries {
+		if i > 0 {
+			tokens += float64(e.timestamp-entries[i-1].timestamp) / refill
+			if tokens > float64(p.RateBurst) {
+				tokens = float64(p.RateBurst)
+			}
+		}
+
+		if tokens < 1 && e.position >= 0 {
+			return e.position
+		}
+
+		// A past transaction over the limit (e.g. from before the limit) doesn't owe tokens
+		if tokens = tokens - 1; tokens < 0 {
+			tokens = 0
+		}
+	}
+
+	return -1
+}
+
+// Checks that a transaction about to be signed keeps its sender within the rate of the protocol,
+// counting the transactions it signed before (confirmed or pending)
+func (n Node) CheckRate(ctx context.Context, clientId string, timestamp int64) error {
+	params := Params()
+	if timestamp < params.RateActivatedAt {
+		return nil
+	}
+
+	query := backlog.Bool().
+		Must(backlog.Exists("Signature"), partyQuery(clientId, "Sender"), backlog.Range("Timestamp", timestamp-params.lookback(), nil)).
+		Query()
+
+	records, err := n.Transactions().Find(ctx, query, backlog.ListOptions{All: true})
+	if err != nil {
+		return err
+	}
+
+	var past []int64
+	for _, record := range records {
+		// The match is loose on the analyzed field, so the sender is checked again
+		if record.Sender == clientId {
+			past = append(past, timeutil.Normalize(record.Timestamp))
+		}
+	}
+
+	if params.overLimit(past, []int64{timestamp}) >= 0 {
+		return fmt.Errorf("%w: %d transactions at most, one more every %v", ErrRateLimited, params.RateBurst, params.RateRefill)
+	}
+
+	return nil
+}
+
+// Checks that the transactions of a block keep their senders within the rate of the protocol,
+// counting the transactions of the blocks before it
+func (bc Blockchain) checkRate(ctx context.Context, block *Block) error {
+	// The allocations of the genesis aren't limited (please, go to `genesis.go`)
+	params := Params()
+	if block.Height == 0 || timeutil.Normalize(block.Timestamp) < params.RateActivatedAt {
+		return nil
+	}
+
+	bySender := map[string][]int64{}
+	earliest := int64(-1)
+	for _, transaction := range block.Transactions {
+		timestamp := timeutil.Normalize(transaction.Timestamp)
+		bySender[transaction.Sender] = append(bySender[transaction.Sender], timestamp)
+
+		if earliest < 0 || timestamp < earliest {
+			earliest = timestamp
+		}
+	}
+
+	if len(bySender) == 0 {
+		return nil
+	}
+
+	// A block is assembled after its transactions, so the blocks before the lookback can't hold any
+	// transaction that counts
+	from := earliest - params.lookback()
+	past := map[string][]int64{}
+	err := bc.ScrollDocuments(ctx, "blockchain", backlog.ListOptions{Query: backlog.Range("timestamp", from, nil)}, func(document map[string]interface{}) error {
+		previous, err := blockFromDocument(document)
+		if err != nil {
+			return err
+		}
+
+		for _, transaction := range previous.Transactions {
+			timestamp := timeutil.Normalize(transaction.Timestamp)
+			if _, ok := bySender[transaction.Sender]; ok && timestamp >= from {
+				past[transaction.Sender] = append(past[transaction.Sender], timestamp)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read the blocks before the block %d: %v", block.Height, err)
+	}
+
+	for sender, timestamps := range bySender {
+		if params.overLimit(past[sender], timestamps) >= 0 {
+			return fmt.Errorf("%w: the block %d has too many transactions of %s", ErrRateLimited, block.Height, sender)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,191 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	backlog "node/backlog"
+	client "node/client"
+	config "node/config"
+	timeutil "node/timeutil"
+	"path/filepath"
+	"time"
+)
+
+/*
+A recovery step inspects the backlog and the node filesystem looking for artifacts left by an
+unclean shutdown and repairs them. It gives a description of every repair it made.
+
+The steps run in the registration order by `Recover`, before the node accepts any traffic.
+Subsystems that keep intermediate state (e.g. pending writes) can register their own steps.
+*/
+type RecoveryStep func(ctx context.Context, n *Node, unclean bool) ([]string, error)
+
+// The age of the intermediate records that are taken as left behind. The younger ones may belong to
+// another process of the node that is still running, so they wait for a later startup (please, go
+// to `shared.go`)
+const recoveryGrace time.Duration = time.Minute
+
+var recoverySteps = []RecoveryStep{
+	recoverIntents,
+	recoverJournal,
+	recoverStaleStatus,
+	recoverOrphanKeys,
+	recoverKeyLog,
+}
+
+// Registers a recovery step to run on startup
+func RegisterRecoveryStep(step RecoveryStep) {
+	recoverySteps = append(recoverySteps, step)
+}
+
+// Detects an unclean shutdown and runs the recovery steps. Gives the list of the repairs made
+func (n *Node) Recover(ctx context.Context) ([]string, error) {
+	// A node that was still alive in the backlog has never sent the end signal
+	unclean := false
+	if document, err := n.storedDocument(ctx); err == nil {
+		status, _ := document["status"].(string)
+		unclean = NodeStatus(status) == NodeAlive
+	}
+
+	var repairs []string
+	for _, step := range recoverySteps {
+		stepRepairs, err := step(ctx, n, unclean)
+		repairs = append(repairs, stepRepairs...)
+
+		if err != nil {
+			return repairs, fmt.Errorf("failed to recover the node: %v", err)
+		}
+	}
+
+	return repairs, nil
+}
+
+// Rolls back the atomic commits interrupted by the crash, before any other step reads the backlog
+func recoverIntents(ctx context.Context, n *Node, unclean bool) ([]string, error) {
+	commits, err := n.RecoverIntents(ctx, timeutil.After(-recoveryGrace))
+
+	var repairs []string
+	for _, commit := range commits {
+		repairs = append(repairs, fmt.Sprintf("rolled back the interrupted commit %s", commit))
+	}
+
+	return repairs, err
+}
+
+// Marks the node as hibernating in the peers index when the last run didn't detach it
+func recoverStaleStatus(ctx context.Context, n *Node, unclean bool) ([]string, error) {
+	if !unclean {
+		return nil, nil
+	}
+
+	status := n.Status
+	n.Status = NodeHibernating
+	defer func() { n.Status = status }()
+
+	if err := n.SyncWithBacklog(ctx, "peers"); err != nil {
+		return nil, err
+	}
+
+	if err := n.SyncWithBacklog(ctx, "node"); err != nil {
+		return nil, err
+	}
+
+	return []string{"reset the stale alive status left by an unclean shutdown"}, nil
+}
+
+// Moves away the key pairs from clients whose creation never reached the backlog
+func recoverOrphanKeys(ctx context.Context, n *Node, unclean bool) ([]string, error) {
+	var repairs []string
+	recoveredPath := filepath.Join(config.BasePath(), "recovered")
+
+	uids, err := n.Keys.List(ctx)
+	if err != nil {
+		return repairs, err
+	}
+
+	for _, uid := range uids {
+		if uid == "recovered" {
+			continue
+		}
+
+		// Only the documents known to be absent make the keys orphan: a backlog that can't be read
+		// (e.g. still starting) aborts the recovery instead of moving the keys of live clients
+		if _, err := n.GetDocument(ctx, "cache", uid); err == nil {
+			continue
+		} else if !errors.Is(err, backlog.ErrNotFound) {
+			return repairs, fmt.Errorf("failed to get the client %s: %v", uid, err)
+		}
+
+		document, err := n.GetDocument(ctx, "local_clients", uid)
+		if err != nil && !errors.Is(err, backlog.ErrNotFound) {
+			return repairs, fmt.Errorf("failed to get the local client %s: %v", uid, err)
+		}
+
+		if err == nil {
+			// The keys of a liquidated client must not survive anywhere (please, go to `liquidation.go`)
+			if liquidated, _ := document["liquidated"].(bool); liquidated {
+				if err := n.Keys.Delete(ctx, uid); err != nil {
+					return repairs, fmt.Errorf("failed to purge the keys of the liquidated client %s: %v", uid, err)
+				}
+
+				repairs = append(repairs, fmt.Sprintf("purged the keys of the liquidated client %s", uid))
+			}
+
+			continue
+		}
+
+		// Interrupted creations are compensated by their own recovery step
+		if _, err := n.GetDocument(ctx, "pending_clients", uid); err == nil {
+			continue
+		} else if !errors.Is(err, backlog.ErrNotFound) {
+			return repairs, fmt.Errorf("failed to get the pending client %s: %v", uid, err)
+		}
+
+		// The keys of an erased client must not survive anywhere
+		erased, err := n.ClientErased(ctx, uid)
+		if err != nil {
+			return repairs, err
+		}
+
+		if erased {
+			if err := n.Keys.Delete(ctx, uid); err != nil {
+				return repairs, fmt.Errorf("failed to purge the keys of the erased client %s: %v", uid, err)
+			}
+
+			repairs = append(repairs, fmt.Sprintf("purged the keys of the erased client %s", uid))
+			continue
+		}
+
+		// The orphan keys are kept in the filesystem, whatever the store, so they can be inspected
+		recovered, err := client.NewFileKeyStore(recoveredPath)
+		if err != nil {
+			return repairs, err
+		}
+
+		keys, err := n.Keys.Get(ctx, uid)
+		if errors.Is(err, client.ErrNoKeys) {
+			// Half of a key pair can't be used by anyone
+			if err := n.Keys.Delete(ctx, uid); err != nil {
+				return repairs, fmt.Errorf("failed to remove the incomplete keys of %s: %v", uid, err)
+			}
+
+			repairs = append(repairs, fmt.Sprintf("removed the incomplete keys of %s", uid))
+			continue
+		} else if err != nil {
+			return repairs, fmt.Errorf("failed to read the orphan keys of %s: %v", uid, err)
+		}
+
+		if err := recovered.Put(ctx, uid, *keys); err != nil {
+			return repairs, fmt.Errorf("failed to move the orphan keys of %s: %v", uid, err)
+		}
+
+		if err := n.Keys.Delete(ctx, uid); err != nil {
+			return repairs, fmt.Errorf("failed to move the orphan keys of %s: %v", uid, err)
+		}
+
+		repairs = append(repairs, fmt.Sprintf("moved the orphan keys of %s to %s", uid, recoveredPath))
+	}
+
+	return repairs, nil
+}
@@ -0,0 +1,261 @@
+package node
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	backlog "node/backlog"
+)
+
+/*
+The repositories read the backlog documents into the concrete types of the node, instead of
+asserting the types of the document fields one by one. A document that doesn't fit its type (e.g.
+a field is missing or changed its type between versions) gives an error wrapping ErrSchemaDrift
+that tells the index, the document and the field, so malformed data never panics the node.
+
+The repositories are reached from the node: `n.Clients()`, `n.Nodes()` and `n.Transactions()`.
+*/
+var ErrSchemaDrift = errors.New("the document doesn't match its schema")
+
+// Decodes a backlog document into a struct, following its json tags. The required fields must be
+// present and not empty
+func fromDocument(index, id string, document map[string]interface{}, v interface{}, required ...string) error {
+	for _, field := range required {
+		if value, ok := document[field]; !ok || value == nil || value == "" {
+			return fmt.Errorf("%w: the %s document %s has no %s", ErrSchemaDrift, index, id, field)
+		}
+	}
+
+	documentBytes, err := json.Marshal(document)
+	if err != nil {
+		return fmt.Errorf("failed to marshal the %s document %s: %v", index, id, err)
+	}
+
+	if err := json.Unmarshal(documentBytes, v); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return fmt.Errorf("%w: the field %s of the %s document %s is a %s instead of a %s", ErrSchemaDrift, typeErr.Field, index, id, typeErr.Value, typeErr.Type)
+		}
+
+		return fmt.Errorf("%w: the %s document %s: %v", ErrSchemaDrift, index, id, err)
+	}
+
+	return nil
+}
+
+// The repository of the local clients (`local_clients`) and the foreign clients (`clients`)
+type ClientRepo struct {
+	node Node
+}
+
+// Gives the repository of the clients
+func (n Node) Clients() ClientRepo {
+	return ClientRepo{node: n}
+}
+
+// Gives the local client with the given uid, without its key pair
+func (r ClientRepo) Get(ctx context.Context, uid string) (*Client, error) {
+	document, err := r.node.GetDocument(ctx, "local_clients", uid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve the client document: %v", err)
+	}
+
+	client := Client{}
+	if err := fromDocument("local_clients", uid, document, &client, "alias", "account_id", "node", "address", "password"); err != nil {
+		return nil, err
+	}
+
+	client.Node = &r.node
+	client.UID = uid
+
+	return &client, nil
+}
+
+// Gives the foreign client with the given client id
+func (r ClientRepo) GetForeign(ctx context.Context, clientId string) (*ForeignClient, error) {
+	document, err := r.node.FindDocument(ctx, "clients", "client_id", clientId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find the foreign client document: %v", err)
+	}
+
+	if document == nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownClient, clientId)
+	}
+
+	client := ForeignClient{}
+	if err := fromDocument("clients", clientId, document, &client, "client_id", "node", "address"); err != nil {
+		return nil, err
+	}
+
+	client.Node = &r.node
+
+	return &client, nil
+}
+
+// The repository of the node records (`node`) and the peer records (`peers`)
+type NodeRepo struct {
+	node Node
+}
+
+// Gives the repository of the nodes
+func (n Node) Nodes() NodeRepo {
+	return NodeRepo{node: n}
+}
+
+// Gives the node stored in the index (node or peers) with the given id, without its backlog and key
+func (r NodeRepo) Get(ctx context.Context, index, id string) (*Node, error) {
+	document, err := r.node.GetDocument(ctx, index, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the node elastic document: %v", err)
+	}
+
+	node := Node{}
+	if err := fromDocument(index, id, document, &node, "host"); err != nil {
+		return nil, err
+	}
+
+	if node.Id == "" {
+		node.Id = id
+	}
+
+	return &node, nil
+}
+
+// A transaction as it's stored in the backlog, with the client ids of its parties
+type TransactionRecord struct {
+	BlockTransaction
+	BlockHash      string          // The hash of the block that includes the transaction (empty while pending)
+	Acknowledgment *Acknowledgment // The receipt signed by the recipient (nil when it wasn't acknowledged)
+	Stealth        *StealthAddress // The stealth address that replaces the recipient in the chain (nil when none)
+}
+
+// The layout of the transactions documents, written from the Transaction struct
+type transactionDocument struct {
+	TransactionId string
+	Sender        struct {
+		ClientId string `json:"client_id"`
+	}
+	Recipient struct {
+		ClientId string `json:"client_id"`
+	}
+	Value          float64
+	Fee            float64
+	Payload        []byte
+	Timestamp      int64
+	Sequence       int64
+	Memo           string
+	Stealth        *StealthAddress
+	Signature      *string
+	BlockHash      *string
+	Acknowledgment *Acknowledgment
+}
+
+// The repository of the transactions (`transactions`)
+type TransactionRepo struct {
+	node Node
+}
+
+// Gives the repository of the transactions
+func (n Node) Transactions() TransactionRepo {
+	return TransactionRepo{node: n}
+}
+
+// Decodes a transactions document into a record
+func decodeTransaction(id string, document map[string]interface{}) (*TransactionRecord, error) {
+	stored := transactionDocument{}
+	if err := fromDocument("transactions", id, document, &stored, "TransactionId", "Sender", "Recipient"); err != nil {
+		return nil, err
+	}
+
+	if stored.Sender.ClientId == "" || stored.Recipient.ClientId == "" {
+		return nil, fmt.Errorf("%w: the transactions document %s has no client id for its parties", ErrSchemaDrift, id)
+	}
+
+	// The large memos are stored compressed (please, go to `compression.go`)
+	memo, err := decompressField(stored.Memo)
+	if err != nil {
+		return nil, fmt.Errorf("%w: the memo of the transactions document %s is corrupted: %v", ErrSchemaDrift, id, err)
+	}
+
+	record := TransactionRecord{
+		BlockTransaction: BlockTransaction{
+			TransactionId: stored.TransactionId,
+			Sender:        stored.Sender.ClientId,
+			Recipient:     stored.Recipient.ClientId,
+			Value:         stored.Value,
+			Fee:           stored.Fee,
+			Payload:       stored.Payload,
+			Timestamp:     stored.Timestamp,
+			Sequence:      stored.Sequence,
+			Memo:          memo,
+		},
+	}
+
+	if stored.Signature != nil {
+		record.Signature = *stored.Signature
+	}
+
+	if stored.BlockHash != nil {
+		record.BlockHash = *stored.BlockHash
+	}
+
+	record.Acknowledgment = stored.Acknowledgment
+	record.Stealth = stored.Stealth
+
+	return &record, nil
+}
+
+// Gives the transaction with the given id
+func (r TransactionRepo) Get(ctx context.Context, transactionId string) (*TransactionRecord, error) {
+	document, err := r.node.GetDocument(ctx, "transactions", transactionId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the transaction document: %v", err)
+	}
+
+	return decodeTransaction(transactionId, document)
+}
+
+// Gives the transactions that match a raw search body
+func (r TransactionRepo) Search(ctx context.Context, body map[string]interface{}) ([]TransactionRecord, error) {
+	documents, err := r.node.SearchDocuments(ctx, "transactions", body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search the transactions: %v", err)
+	}
+
+	var records []TransactionRecord
+	for _, document := range documents {
+		id, _ := document["_id"].(string)
+
+		record, err := decodeTransaction(id, document)
+		if err != nil {
+			return nil, err
+		}
+
+		records = append(records, *record)
+	}
+
+	return records, nil
+}
+
+// Gives the transactions that match a query
+func (r TransactionRepo) Find(ctx context.Context, query backlog.Query, opts backlog.ListOptions) ([]TransactionRecord, error) {
+	documents, _, err := r.node.FindDocuments(ctx, "transactions", query, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find the transactions: %v", err)
+	}
+
+	var records []TransactionRecord
+	for _, document := range documents {
+		id, _ := document["_id"].(string)
+
+		record, err := decodeTransaction(id, document)
+		if err != nil {
+			return nil, err
+		}
+
+		records = append(records, *record)
+	}
+
+	return records, nil
+}
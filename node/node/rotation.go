@@ -0,0 +1,169 @@
+package node
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	backlog "node/backlog"
+	client "node/client"
+	timeutil "node/timeutil"
+)
+
+/*
+A client can rotate its key pair (e.g. when it suspects that its private key leaked). The client id
+is the identity of the public key, so the rotation gives the client a new id.
+
+The former id isn't forgotten: its foreign client stays in the `clients` index, pointing to the new
+one (`rotated_to`), so the signatures of its past transactions are still verified with its key (the
+former id is the public key itself), and the ledger keeps crediting the values sent to it. The
+rotation record, kept in the foreign client of the new id, is signed by both keys, so anybody can
+check that the same owner holds both ids.
+
+The memos sealed to the former key (please, go to `memo.go`) can't be read after the rotation.
+*/
+type KeyRotation struct {
+	PreviousId    string `json:"previous_id"`    // The client id before the rotation
+	ClientId      string `json:"client_id"`      // The client id after the rotation
+	RotatedAt     int64  `json:"rotated_at"`     // The timestamp when the keys were rotated
+	Signature     string `json:"signature"`      // The signature made by the former key
+	NextSignature string `json:"next_signature"` // The signature made by the new key
+}
+
+// Converts the rotation (except the signatures) to a signable byte array
+func (r KeyRotation) ToBytes() ([]byte, error) {
+	rotationBytes, err := json.Marshal(map[string]interface{}{
+		"previous_id": r.PreviousId,
+		"client_id":   r.ClientId,
+		"rotated_at":  r.RotatedAt,
+	})
+
+	return rotationBytes, err
+}
+
+// Verifies that the rotation was signed by the keys of both ids
+func (r KeyRotation) Verify() error {
+	for _, signer := range []struct{ id, signature string }{{r.PreviousId, r.Signature}, {r.ClientId, r.NextSignature}} {
+		if err := client.VerifyWithIdentity(signer.id, r, signer.signature); err != nil {
+			return fmt.Errorf("the rotation of %s isn't signed by the key of %s", r.PreviousId, signer.id)
+		}
+	}
+
+	return nil
+}
+
+// Replaces the key pair of the client by a new one, protected by the secret (the same or a new one),
+// and publishes the rotation. The client must be loaded with its key pair
+func (c *Client) RotateCrypto(ctx context.Context, secret string) (*KeyRotation, error) {
+	if c.CryptoResource == nil {
+		return nil, fmt.Errorf("the keys of the client must be loaded to be rotated")
+	}
+
+	crypto, err := client.NewCryptoResource()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create a new crypto resource: %v", err)
+	}
+
+	keys, err := client.EncodeKeyPair(*crypto, secret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode the client keys: %v", err)
+	}
+
+	rotated := *c
+	rotated.CryptoResource, rotated.Secret = crypto, secret
+	if err := rotated.impersonate(); err != nil {
+		return nil, err
+	}
+
+	rotation := KeyRotation{PreviousId: c.ClientId, ClientId: rotated.ClientId, RotatedAt: timeutil.Now()}
+	if rotation.Signature, err = c.CreateSignature(rotation); err != nil {
+		return nil, err
+	}
+	if rotation.NextSignature, err = rotated.CreateSignature(rotation); err != nil {
+		return nil, err
+	}
+
+	foreign := rotated.MakeForeign()
+	foreign.Rotation = &rotation
+
+	foreignDocument, err := toDocument(foreign)
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.Begin().
+		Update("local_clients", c.UID, map[string]interface{}{"client_id": rotated.ClientId}).
+		Put("clients", rotated.ClientId, foreignDocument).
+		Update("clients", c.ClientId, map[string]interface{}{"rotated_to": rotated.ClientId, "updated_at": rotation.RotatedAt}).
+		Apply(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to publish the rotation: %v", err)
+	}
+
+	// The documents already point to the new id, so they're turned back when the keys can't be kept
+	if err := c.Keys.Put(ctx, c.UID, *keys); err != nil {
+		revertErr := c.Begin().
+			Update("local_clients", c.UID, map[string]interface{}{"client_id": c.ClientId}).
+			Delete("clients", rotated.ClientId).
+			Update("clients", c.ClientId, map[string]interface{}{"rotated_to": "", "updated_at": timeutil.Now()}).
+			Apply(ctx)
+		if revertErr != nil {
+			return nil, fmt.Errorf("failed to store the client keys: %v (and to revert the rotation: %v)", err, revertErr)
+		}
+
+		return nil, fmt.Errorf("failed to store the client keys: %v", err)
+	}
+
+	if err := c.shareClientKeys(ctx, c.UID); err != nil {
+		Logf(ctx, "failed to share the rotated keys of %s: %v", c.UID, err)
+	}
+
+	c.logKey(ctx, KeyRotated, rotated.ClientId, c.ClientId)
+
+	*c = rotated
+	c.Emit(ctx, "client.rotated", map[string]interface{}{
+		"uid":         c.UID,
+		"previous_id": rotation.PreviousId,
+		"client_id":   rotation.ClientId,
+	})
+
+	return &rotation, nil
+}
+
+// Gives the former ids of a client, following its rotations back
+func (n Node) formerIds(ctx context.Context, clientId string) ([]string, error) {
+	var ids []string
+	seen := map[string]bool{clientId: true}
+
+	for next := []string{clientId}; len(next) > 0; {
+		documents, _, err := n.FindDocuments(ctx, "clients", backlog.Term("rotated_to", next[0]), backlog.ListOptions{All: true})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the former ids of %s: %v", clientId, err)
+		}
+		next = next[1:]
+
+		for _, document := range documents {
+			if id, _ := document["client_id"].(string); id != "" && !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+				next = append(next, id)
+			}
+		}
+	}
+
+	return ids, nil
+}
+
+// Gives the set of the ids owned by a client: its id and its former ones
+func (n Node) ownedIds(ctx context.Context, clientId string) (map[string]bool, error) {
+	formerIds, err := n.formerIds(ctx, clientId)
+	if err != nil {
+		return nil, err
+	}
+
+	owned := map[string]bool{clientId: true}
+	for _, id := range formerIds {
+		owned[id] = true
+	}
+
+	return owned, nil
+}
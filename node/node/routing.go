@@ -0,0 +1,158 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	backlog "node/backlog"
+	client "node/client"
+	hashing "node/hashing"
+)
+
+/*
+The devices of a client are registered in its home node, so the node of the sender can't push the
+transactions to them. When the recipient belongs to another node, the signed transaction is routed
+straight to that node (besides reaching it in a block), which verifies the signature of the sender
+and notifies the devices of the recipient in near real time.
+
+The home node is found from the foreign client record, that keeps the hash of the node host (the
+former hosts of the peers are looked up in the address history, since the record isn't updated
+when the node address changes).
+*/
+type RoutedTransaction struct {
+	TransactionId string  `json:"transaction_id"`    // The id of the transaction
+	Sender        string  `json:"sender"`            // The client id of the sender
+	Recipient     string  `json:"recipient"`         // The client id of the recipient
+	Value         float64 `json:"value"`             // The value of the transaction
+	Fee           float64 `json:"fee,omitempty"`     // The fee paid to the producer of the block
+	Payload       []byte  `json:"payload,omitempty"` // The arbitrary data attached by the application
+	Timestamp     int64   `json:"timestamp"`         // The timestamp when the transaction was performed
+	Sequence      int64   `json:"sequence"`          // The position of the transaction in the history of the sender
+	Memo          string  `json:"memo,omitempty"`    // The memo sealed to the sender and the recipient
+	Tag           string  `json:"tag,omitempty"`     // The stealth tag, when the recipient is a stealth address
+	Signature     string  `json:"signature"`         // The signature made by the sender
+}
+
+// Converts the routed transaction to the same byte array signed by the sender
+func (r RoutedTransaction) ToBytes() ([]byte, error) {
+	transaction := map[string]interface{}{
+		"sender":    r.Sender,
+		"recipient": r.Recipient,
+		"value":     r.Value,
+		"timestamp": r.Timestamp,
+		"sequence":  r.Sequence,
+	}
+
+	transBytes, err := canonicalJSON(withSealed(withPayload(withFee(transaction, r.Fee), r.Payload), r.Memo, r.Tag))
+	return transBytes, err
+}
+
+// Gives the host of the alive peer whose host (or some former host) hashes to the node address
+func (n Node) homeNodeHost(ctx context.Context, nodeAddress string) (string, error) {
+	peers, err := n.ListDocuments(ctx, "peers", backlog.ListOptions{All: true})
+	if err != nil {
+		return "", fmt.Errorf("failed to list the peers: %v", err)
+	}
+
+	hosts := map[string]string{}
+	for _, peer := range peers {
+		id, _ := peer["node_id"].(string)
+		host, _ := peer["host"].(string)
+		status, _ := peer["status"].(string)
+
+		if host == "" || NodeStatus(status) != NodeAlive {
+			continue
+		}
+
+		if hashing.MatchesAddress(nodeAddress, host) {
+			return host, nil
+		}
+
+		hosts[id] = host
+	}
+
+	changes, err := n.ListDocuments(ctx, "addresses", backlog.ListOptions{All: true})
+	if err != nil {
+		return "", fmt.Errorf("failed to list the address changes: %v", err)
+	}
+
+	for _, change := range changes {
+		id, _ := change["node_id"].(string)
+		previous, _ := change["previous"].(string)
+
+		if host, ok := hosts[id]; ok && hashing.MatchesAddress(nodeAddress, previous) {
+			return host, nil
+		}
+	}
+
+	return "", fmt.Errorf("the node of the client isn't an alive peer")
+}
+
+// Routes a signed transaction to the home node of the recipient, when it isn't the current node
+func (n Node) routeTransaction(ctx context.Context, t Transaction) {
+	if peerTransport == nil || t.Signature == nil || hashing.MatchesAddress(t.Recipient.NodeAddress, n.Host) {
+		return
+	}
+
+	host, err := n.homeNodeHost(ctx, t.Recipient.NodeAddress)
+	if err != nil {
+		Logf(ctx, "failed to route the transaction %s: %v", t.TransactionId, err)
+		return
+	}
+
+	routed := RoutedTransaction{
+		TransactionId: t.TransactionId,
+		Sender:        t.Sender.ClientId,
+		Recipient:     t.Recipient.ClientId,
+		Value:         t.Value,
+		Fee:           t.Fee,
+		Payload:       t.Payload,
+		Timestamp:     t.Timestamp,
+		Sequence:      t.Sequence,
+		Memo:          t.Memo,
+		Signature:     *t.Signature,
+	}
+
+	if t.Stealth != nil {
+		routed.Recipient, routed.Tag = t.Stealth.Id, t.Stealth.Tag
+	}
+
+	if err := peerTransport.RouteTransaction(ctx, host, routed); err != nil {
+		Logf(ctx, "failed to route the transaction %s to %s: %v", t.TransactionId, host, err)
+	}
+}
+
+// Notifies the recipient of a transaction routed by the node of the sender, once the signature
+// of the sender (whose client id is the identity of its public key) is verified
+func (n Node) AcceptRoutedTransaction(ctx context.Context, routed RoutedTransaction) error {
+	if _, err := client.ParseIdentity(routed.Sender); err != nil {
+		return fmt.Errorf("the sender isn't a valid client id: %v", err)
+	}
+
+	if err := client.VerifyWithIdentity(routed.Sender, routed, routed.Signature); err != nil {
+		return fmt.Errorf("the transaction %s isn't signed by its sender", routed.TransactionId)
+	}
+
+	recipient := routed.Recipient
+	if routed.Tag != "" {
+		clientId, ok := n.openStealth(StealthAddress{Id: routed.Recipient, Tag: routed.Tag})
+		if !ok {
+			return fmt.Errorf("the stealth address of the transaction %s doesn't belong to the node", routed.TransactionId)
+		}
+		recipient = clientId
+	}
+
+	n.Emit(ctx, "transaction.routed", map[string]interface{}{
+		"transaction_id": routed.TransactionId,
+		"sender":         routed.Sender,
+		"recipient":      recipient,
+		"value":          routed.Value,
+		"timestamp":      routed.Timestamp,
+	})
+
+	notification := transactionNotification(routed.TransactionId, routed.Sender, routed.Value, routed.Timestamp)
+	if err := n.Push(ctx, recipient, notification); err != nil {
+		Logf(ctx, "failed to notify the recipient of the transaction %s: %v", routed.TransactionId, err)
+	}
+
+	return nil
+}
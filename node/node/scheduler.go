@@ -0,0 +1,293 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	backlog "node/backlog"
+	timeutil "node/timeutil"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	schedulerTick time.Duration = time.Second      // How often the scheduler looks for the jobs that are due
+	retryDelay    time.Duration = 10 * time.Second // The delay before the first retry of a failed run (doubled in every retry)
+)
+
+/*
+The background tasks of the node (e.g. the janitor or the mirror sync) are jobs run by the scheduler.
+Every job has a schedule (please, go to `cron.go` in the timeutil package), and the scheduler tracks
+its next run in the `jobs` index, so a restart doesn't run the jobs earlier (or later) than planned.
+
+A singleton job runs only in the process that holds its duty (please, go to `leader.go`), and its
+state is shared by all the processes of the node. The other jobs run in every process (e.g. the disk
+monitor, that changes the state of the process itself), and their state is kept by process.
+
+A failed run is retried after a growing delay, up to the retries of the job, and then the job waits
+for its next scheduled run. Every run is recorded in the `job_runs` index, so the history of the jobs
+can be inspected.
+*/
+type Job struct {
+	Name       string                                        // The unique name of the job (the duty of singleton jobs)
+	Schedule   string                                        // A cron expression or an interval, e.g. "*/5 * * * *" or "@every 1m"
+	Singleton  bool                                          // Whether the job runs in a single process of the node
+	RunAtStart bool                                          // Whether the job runs right away when it was never run
+	Retries    int                                           // How many times a failed run is retried before waiting for the next one
+	Run        func(ctx context.Context, lease *Lease) error // The work, that receives the lease of singleton jobs (nil for the others)
+}
+
+// The state of a job, as tracked in the backlog
+type JobState struct {
+	Name       string `json:"name"`        // The name of the job
+	Instance   string `json:"instance"`    // The process that runs the job (empty for singleton jobs)
+	Schedule   string `json:"schedule"`    // The schedule of the job
+	NextRun    int64  `json:"next_run"`    // The timestamp of the next run (or retry)
+	LastRun    int64  `json:"last_run"`    // The timestamp when the last run started (zero when never)
+	LastStatus string `json:"last_status"` // The result of the last run
+	LastError  string `json:"last_error"`  // The failure of the last run (empty when it succeeded)
+	Attempt    int    `json:"attempt"`     // The number of failed attempts of the current run
+	UpdatedAt  int64  `json:"updated_at"`  // The timestamp when the state changed
+}
+
+// A run of a job, as recorded in the history
+type JobRun struct {
+	Job      string `json:"job"`      // The name of the job
+	Instance string `json:"instance"` // The process that ran the job
+	Attempt  int    `json:"attempt"`  // The attempt of the run (zero for the first)
+	Token    int64  `json:"token"`    // The fencing token of the lease (zero for jobs that aren't singleton)
+	Started  int64  `json:"started"`  // The timestamp when the run started
+	Finished int64  `json:"finished"` // The timestamp when the run finished
+	Status   string `json:"status"`   // Either "succeeded" or "failed"
+	Error    string `json:"error"`    // The failure of the run (empty when it succeeded)
+}
+
+// A registered job with its parsed schedule and the state known by the current process
+type scheduledJob struct {
+	Job
+	schedule timeutil.Schedule
+	next     int64 // The timestamp when the job is due (zero until it's loaded)
+	running  bool
+}
+
+var (
+	jobs      = map[string]*scheduledJob{}
+	jobsMutex sync.Mutex
+)
+
+// Registers a job in the scheduler. A job with the same name replaces the previous one
+func ScheduleJob(job Job) error {
+	schedule, err := timeutil.ParseSchedule(job.Schedule)
+	if err != nil {
+		return fmt.Errorf("failed to schedule the job %s: %v", job.Name, err)
+	}
+
+	if schedule.Next(time.Now()).IsZero() {
+		return fmt.Errorf("failed to schedule the job %s: the schedule %q never fires", job.Name, job.Schedule)
+	}
+
+	jobsMutex.Lock()
+	defer jobsMutex.Unlock()
+
+	jobs[job.Name] = &scheduledJob{Job: job, schedule: schedule}
+	return nil
+}
+
+// Gives the id of the job state document
+func (j *scheduledJob) stateId() string {
+	if j.Singleton {
+		return j.Name
+	}
+
+	return j.Name + "@" + instanceId
+}
+
+// Gives the time between two runs of the job, which the lease of a singleton job must outlive
+func (j *scheduledJob) period() time.Duration {
+	next := j.schedule.Next(time.Now())
+	return j.schedule.Next(next).Sub(next)
+}
+
+// Starts the scheduler, that runs the registered jobs when they're due, until the context is done
+func (n Node) StartScheduler(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(schedulerTick)
+		defer ticker.Stop()
+
+		for {
+			now := timeutil.Now()
+
+			jobsMutex.Lock()
+			for _, job := range jobs {
+				if job.running || job.next > now {
+					continue
+				}
+
+				job.running = true
+				go n.runJob(ctx, job)
+			}
+			jobsMutex.Unlock()
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Gives the stored state of a job (nil when it was never stored)
+func (n Node) jobState(ctx context.Context, id string) (*JobState, error) {
+	document, err := n.GetDocument(ctx, "jobs", id)
+	if errors.Is(err, backlog.ErrNotFound) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the state of the job %s: %v", id, err)
+	}
+
+	state := JobState{}
+	if err := fromDocument("jobs", id, document, &state, "name", "next_run"); err != nil {
+		return nil, err
+	}
+
+	return &state, nil
+}
+
+// Runs a due job, if the current process must run it, and plans its next run
+func (n Node) runJob(ctx context.Context, job *scheduledJob) {
+	next := job.schedule.Next(time.Now())
+
+	defer func() {
+		jobsMutex.Lock()
+		job.next, job.running = timeutil.FromTime(next), false
+		jobsMutex.Unlock()
+	}()
+
+	var lease *Lease
+	if job.Singleton {
+		// The lease outlives a few runs, so a slow run doesn't let another process take over
+		if lease = n.leads(ctx, job.Name, job.period()); lease == nil {
+			return
+		}
+	}
+
+	state, err := n.jobState(ctx, job.stateId())
+	if err != nil {
+		Logf(ctx, "failed to load the job %s: %v", job.Name, err)
+		return
+	}
+
+	stored := state != nil
+	if !stored {
+		state = &JobState{Name: job.Name, Schedule: job.Schedule, NextRun: timeutil.FromTime(next)}
+		if !job.Singleton {
+			state.Instance = instanceId
+		}
+
+		if job.RunAtStart {
+			state.NextRun = timeutil.Now()
+		}
+	}
+
+	// Another process (or an earlier start) already planned the next run
+	if state.NextRun > timeutil.Now() {
+		next = timeutil.ToTime(state.NextRun)
+		if !stored {
+			n.saveJobState(ctx, job, lease, state)
+		}
+
+		return
+	}
+
+	run := JobRun{Job: job.Name, Instance: instanceId, Attempt: state.Attempt, Started: timeutil.Now()}
+	if lease != nil {
+		run.Token = lease.Token
+	}
+
+	err = job.Run(ctx, lease)
+	run.Finished, run.Status = timeutil.Now(), "succeeded"
+
+	state.LastRun, state.LastStatus, state.LastError = run.Started, run.Status, ""
+	state.Attempt = 0
+
+	if err != nil {
+		run.Status, run.Error = "failed", err.Error()
+		state.LastStatus, state.LastError = run.Status, run.Error
+		Logf(ctx, "the job %s failed (attempt %d): %v", job.Name, run.Attempt+1, err)
+
+		// The retry waits longer in every attempt, but never beyond the next scheduled run
+		if run.Attempt < job.Retries {
+			state.Attempt = run.Attempt + 1
+			if retry := time.Now().Add(retryDelay << run.Attempt); retry.Before(next) {
+				next = retry
+			}
+		}
+	}
+
+	state.Schedule = job.Schedule
+	state.NextRun = timeutil.FromTime(next)
+	n.recordJobRun(ctx, run)
+	n.saveJobState(ctx, job, lease, state)
+}
+
+// Writes the state of a job. A singleton job only writes it while it holds its lease
+func (n Node) saveJobState(ctx context.Context, job *scheduledJob, lease *Lease, state *JobState) {
+	if lease != nil {
+		if err := n.CheckLease(ctx, *lease); err != nil {
+			Logf(ctx, "the state of the job %s wasn't saved: %v", job.Name, err)
+			return
+		}
+	}
+
+	state.UpdatedAt = timeutil.Now()
+	document, err := toDocument(state)
+	if err == nil {
+		err = n.IndexDocument(ctx, "jobs", job.stateId(), document)
+	}
+
+	if err != nil {
+		Logf(ctx, "failed to save the state of the job %s: %v", job.Name, err)
+	}
+}
+
+// Adds a run to the history of the jobs
+func (n Node) recordJobRun(ctx context.Context, run JobRun) {
+	document, err := toDocument(run)
+	if err == nil {
+		err = n.IndexDocument(ctx, "job_runs", uuid.NewString(), document)
+	}
+
+	if err != nil {
+		Logf(ctx, "failed to record the run of the job %s: %v", run.Job, err)
+	}
+}
+
+// Gives the latest runs of a job, from the newest
+func (n Node) JobHistory(ctx context.Context, name string, size int) ([]JobRun, error) {
+	documents, _, err := n.FindDocuments(ctx, "job_runs", backlog.Term("job", name), backlog.ListOptions{
+		Size: size,
+		Sort: []string{"started:desc"},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the history of the job %s: %v", name, err)
+	}
+
+	var runs []JobRun
+	for _, document := range documents {
+		id, _ := document["_id"].(string)
+
+		run := JobRun{}
+		if err := fromDocument("job_runs", id, document, &run, "job", "started"); err != nil {
+			return nil, err
+		}
+
+		runs = append(runs, run)
+	}
+
+	return runs, nil
+}
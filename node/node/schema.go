@@ -0,0 +1,264 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	backlog "node/backlog"
+	client "node/client"
+	hashing "node/hashing"
+	timeutil "node/timeutil"
+)
+
+/*
+The schema version identifies the layout of the documents that the node writes in the backlog.
+It's recorded in the node document whenever the node attaches, together with the binary version.
+
+Whenever the documents layout changes, the schema version must be increased and a migration
+from the previous version must be registered in `migrations`. A node refuses to start over
+documents written by a newer schema, since it could corrupt them.
+*/
+const schemaVersion int = 9
+
+// The migrations indexed by the schema version they migrate from
+var migrations = map[int]func(ctx context.Context, n *Node) error{
+	// Documents written before the schema tracking have the same layout of the version 1
+	0: func(ctx context.Context, n *Node) error { return nil },
+	// Timestamps were stored in seconds until the version 2, that stores them in milliseconds
+	1: func(ctx context.Context, n *Node) error {
+		for index, properties := range backlog.Mappings {
+			var fields []string
+			for field := range properties {
+				fields = append(fields, field)
+			}
+
+			if err := n.IndexExists(ctx, index); err != nil {
+				continue
+			}
+
+			if err := n.UpdateByQuery(ctx, index, timeutil.MigrationScript(fields...)); err != nil {
+				return fmt.Errorf("failed to migrate the timestamps of %s: %v", index, err)
+			}
+		}
+
+		return nil
+	},
+	// The node and peers documents were keyed by the hash of the host until the version 3, that
+	// keys them by the node id
+	2: func(ctx context.Context, n *Node) error {
+		legacy := legacyNodeId(n.Host)
+
+		for _, index := range []string{"node", "peers"} {
+			document, err := n.GetDocument(ctx, index, legacy)
+			if err != nil {
+				continue
+			}
+
+			document["node_id"] = n.Id
+			err = n.Begin().Put(index, n.Id, document).Delete(index, legacy).Apply(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to rekey the %s document: %v", index, err)
+			}
+		}
+
+		return nil
+	},
+	// The signatures were the raw bytes cast to a string until the version 4, that encodes them in
+	// base64 (please, go to `crypto.go` in the client package). The transactions already in a block
+	// keep their legacy signatures, since the block hashes them
+	3: func(ctx context.Context, n *Node) error {
+		if err := n.IndexExists(ctx, "transactions"); err != nil {
+			return nil
+		}
+
+		query := backlog.Bool().Must(backlog.Exists("Signature")).MustNot(backlog.Exists("BlockHash")).Query()
+
+		return n.ScrollDocuments(ctx, "transactions", backlog.ListOptions{Query: query, All: true}, func(document map[string]interface{}) error {
+			signature, _ := document["Signature"].(string)
+			if !client.IsLegacySignature(signature) {
+				return nil
+			}
+
+			id, _ := document["_id"].(string)
+			err := n.UpdateDocument(ctx, "transactions", id, map[string]interface{}{
+				"Signature": client.EncodeSignature([]byte(signature)),
+			})
+			if err != nil {
+				return fmt.Errorf("failed to encode the signature of the transaction %s: %v", id, err)
+			}
+
+			return nil
+		})
+	},
+	// The aliases documents were keyed by the plain hash of the alias until the version 5, that keys
+	// them by its domain-separated hash (please, go to the hashing package). The aliases of the erased
+	// clients can't be read, so they keep the legacy id
+	4: func(ctx context.Context, n *Node) error {
+		if err := n.IndexExists(ctx, "aliases"); err != nil {
+			return nil
+		}
+
+		return n.ScrollDocuments(ctx, "aliases", backlog.ListOptions{All: true}, func(document map[string]interface{}) error {
+			id, _ := document["_id"].(string)
+			alias, _ := document["alias"].(string)
+			if alias == "" || id != hashing.LegacyHash(alias) {
+				return nil
+			}
+
+			delete(document, "_id")
+			if err := n.Begin().Put("aliases", aliasId(alias), document).Delete("aliases", id).Apply(ctx); err != nil {
+				return fmt.Errorf("failed to rekey the alias document %s: %v", id, err)
+			}
+
+			return nil
+		})
+	},
+	// The devices were found by the keyword subfield of the dynamic `client_id` until the version 6,
+	// that maps it as a keyword: the client ids are longer than the subfield indexes, so they were
+	// never found. A mapping can't change, so the index is copied aside and created again
+	5: func(ctx context.Context, n *Node) error {
+		return remapIndex(ctx, n, "devices", "client_id")
+	},
+	// The parties of the transactions were matched on their analyzed `client_id` until the version
+	// 7, that maps them as keywords: the analyzer splits the long client ids, so they weren't
+	// matched as a whole. Both parties are mapped by the same copy of the index
+	6: func(ctx context.Context, n *Node) error {
+		return remapIndex(ctx, n, "transactions", "Sender.client_id")
+	},
+	// The sequences documents were keyed by the client id until the version 8, that keys them by its
+	// hash (the longer ids couldn't be stored) and keeps the id in the document
+	7: func(ctx context.Context, n *Node) error {
+		if err := n.IndexExists(ctx, "sequences"); err != nil {
+			return nil
+		}
+
+		return n.ScrollDocuments(ctx, "sequences", backlog.ListOptions{All: true}, func(document map[string]interface{}) error {
+			id, _ := document["_id"].(string)
+			if _, ok := document["client_id"]; ok {
+				return nil
+			}
+
+			delete(document, "_id")
+			document["client_id"] = id
+			if err := n.Begin().Put("sequences", sequenceId(id), document).Delete("sequences", id).Apply(ctx); err != nil {
+				return fmt.Errorf("failed to rekey the sequence document %s: %v", id, err)
+			}
+
+			return nil
+		})
+	},
+	// The balances were cached by the client id until the version 9, that caches them by its hash.
+	// They're computed again from the chain, so the former documents are only deleted
+	8: func(ctx context.Context, n *Node) error {
+		if err := n.IndexExists(ctx, "balances"); err != nil {
+			return nil
+		}
+
+		return n.ScrollDocuments(ctx, "balances", backlog.ListOptions{All: true}, func(document map[string]interface{}) error {
+			id, _ := document["_id"].(string)
+			if clientId, _ := document["client_id"].(string); id == balanceId(clientId) {
+				return nil
+			}
+
+			if err := n.DeleteDocument(ctx, "balances", id); err != nil {
+				return fmt.Errorf("failed to delete the balance document %s: %v", id, err)
+			}
+
+			return nil
+		})
+	},
+}
+
+// Creates the index again with its current mappings, keeping its documents, when the field isn't
+// mapped as a keyword yet. The documents go through a copy of the index, so the migration can run
+// again after an interruption: the index created by the node on startup is filled from the copy
+func remapIndex(ctx context.Context, n *Node, index, field string) error {
+	copied := index + "_remap"
+
+	if n.IndexExists(ctx, index) != nil {
+		return nil
+	}
+
+	fieldType, err := n.FieldType(ctx, index, field)
+	if err != nil {
+		return err
+	}
+
+	if fieldType == "keyword" {
+		// The index was deleted by an interrupted migration, so its documents are in the copy
+		if n.IndexExists(ctx, copied) == nil {
+			if err := n.Reindex(ctx, copied, index); err != nil {
+				return err
+			}
+
+			return n.DeleteIndex(ctx, copied)
+		}
+
+		return nil
+	}
+
+	// A copy left by a migration interrupted before deleting the index may be incomplete
+	if err := n.DeleteIndex(ctx, copied); err != nil {
+		return err
+	}
+
+	if err := n.CreateIndex(ctx, copied, backlog.Mappings[index]); err != nil {
+		return err
+	}
+
+	if err := n.Reindex(ctx, index, copied); err != nil {
+		return err
+	}
+
+	if err := n.DeleteIndex(ctx, index); err != nil {
+		return err
+	}
+
+	if err := n.CreateIndex(ctx, index, backlog.Mappings[index]); err != nil {
+		return err
+	}
+
+	if err := n.Reindex(ctx, copied, index); err != nil {
+		return err
+	}
+
+	return n.DeleteIndex(ctx, copied)
+}
+
+// Compares the schema version stored in the backlog with the binary one, migrating the
+// documents when they're older and refusing to start when they're newer
+func (n *Node) CheckSchema(ctx context.Context) error {
+	document, err := n.storedDocument(ctx)
+	if err != nil {
+		// There is no stored node yet, so there is nothing to migrate
+		return nil
+	}
+
+	stored := 0
+	if version, ok := document["schema_version"].(float64); ok {
+		stored = int(version)
+	}
+
+	storedBinary, _ := document["version"].(string)
+
+	if stored > schemaVersion {
+		return fmt.Errorf(
+			"the backlog was written by the version %s (schema %d) and this binary (version %s) only supports the schema %d: please upgrade the node",
+			storedBinary, stored, n.Version, schemaVersion,
+		)
+	}
+
+	for version := stored; version < schemaVersion; version++ {
+		migrate, ok := migrations[version]
+		if !ok {
+			return fmt.Errorf("there is no migration from the schema %d: please migrate the backlog manually", version)
+		}
+
+		if err := migrate(ctx, n); err != nil {
+			return fmt.Errorf("failed to migrate the schema %d to %d: %v", version, version+1, err)
+		}
+
+		fmt.Printf("Backlog migrated from the schema %d to %d\n", version, version+1)
+	}
+
+	return nil
+}
@@ -0,0 +1,312 @@
+package node
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	backlog "node/backlog"
+	config "node/config"
+	timeutil "node/timeutil"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*
+A validation script is a small WebAssembly module that evaluates the transactions of the local
+clients against custom rules (e.g. limits or sanctions lists), so the policies of a node change
+without recompiling it. The scripts are published in the `scripts` index, where every publication of
+a name is a new version, and each node enforces the scripts listed in its VALIDATION_SCRIPTS (the
+latest version of each one, unless it's pinned).
+
+A module exports its `memory`, an `alloc(size i32) i32` function that reserves the input and a
+`validate(pointer i32, size i32) i32` function that receives the transaction as JSON. It returns zero
+to accept the transaction, or the size of a reason written at the start of its memory to reject it.
+The modules run sandboxed by a script runtime, which bounds their memory, the calls they make and the
+time they take (please, go to the config package). The runtime is compiled into the program and
+registered with `RegisterScriptRuntime`, like the plugins: the node registers its own on startup
+(please, go to `wasm.go`).
+
+The scripts run before the plugins accept a transaction. A script that fails (e.g. it runs out of
+fuel) rejects the transaction, since the node can't tell whether it complies.
+*/
+type ScriptRuntime interface {
+	Evaluate(ctx context.Context, module []byte, input []byte, limits ScriptLimits) (reason string, err error)
+}
+
+// The resources that a script can use to evaluate a transaction
+type ScriptLimits struct {
+	Memory  int64         // The memory (in bytes)
+	Fuel    uint64        // The number of function calls
+	Timeout time.Duration // The time
+}
+
+// A version of a validation script, as stored in the backlog
+type ScriptVersion struct {
+	Name        string `json:"name"`         // The name of the script
+	Version     int64  `json:"version"`      // The version of the script (starting from one)
+	Digest      string `json:"digest"`       // The SHA-256 of the module
+	Module      string `json:"module"`       // The module encoded in base64
+	PublishedAt int64  `json:"published_at"` // The timestamp when the version was published
+}
+
+var (
+	ErrNoScriptRuntime = errors.New("no script runtime was registered")
+	ErrInvalidScript   = errors.New("the script isn't a WebAssembly module")
+)
+
+// The magic number and the version that open every WebAssembly module
+var wasmHeader = []byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00}
+
+// The scripts enforced by the node, with their modules decoded
+type loadedScript struct {
+	ScriptVersion
+	module []byte
+}
+
+var (
+	scriptRuntime ScriptRuntime
+	scripts       []loadedScript
+	scriptsMutex  sync.RWMutex
+)
+
+// Registers the runtime that runs the validation scripts
+func RegisterScriptRuntime(runtime ScriptRuntime) {
+	scriptsMutex.Lock()
+	defer scriptsMutex.Unlock()
+
+	scriptRuntime = runtime
+}
+
+// Gives the id of the document of a script version
+func scriptId(name string, version int64) string {
+	return name + "@" + strconv.FormatInt(version, 10)
+}
+
+// Stores a module as the next version of a script
+func (n Node) PublishScript(ctx context.Context, name string, module []byte) (*ScriptVersion, error) {
+	if name == "" || strings.ContainsAny(name, "@,") {
+		return nil, fmt.Errorf("invalid script name %q", name)
+	}
+
+	if !bytes.HasPrefix(module, wasmHeader) {
+		return nil, ErrInvalidScript
+	}
+
+	digest := sha256.Sum256(module)
+
+	// Two publications of the same script race for the same version, and the loser takes the next one
+	for {
+		latest, err := n.latestScript(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+
+		script := ScriptVersion{
+			Name:        name,
+			Version:     1,
+			Digest:      hex.EncodeToString(digest[:]),
+			Module:      base64.StdEncoding.EncodeToString(module),
+			PublishedAt: timeutil.Now(),
+		}
+
+		if latest != nil {
+			script.Version = latest.Version + 1
+		}
+
+		document, err := toDocument(script)
+		if err != nil {
+			return nil, err
+		}
+
+		err = n.CreateDocument(ctx, "scripts", scriptId(name, script.Version), document)
+		if errors.Is(err, backlog.ErrConflict) {
+			continue
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to publish the script %s: %v", name, err)
+		}
+
+		n.Emit(ctx, "script.published", map[string]interface{}{
+			"name":    script.Name,
+			"version": script.Version,
+			"digest":  script.Digest,
+		})
+
+		return &script, nil
+	}
+}
+
+// Gives the latest version of a script (nil when it was never published)
+func (n Node) latestScript(ctx context.Context, name string) (*ScriptVersion, error) {
+	documents, _, err := n.FindDocuments(ctx, "scripts", backlog.Term("name", name), backlog.ListOptions{
+		Size: 1,
+		Sort: []string{"version:desc"},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find the script %s: %v", name, err)
+	}
+
+	if len(documents) == 0 {
+		return nil, nil
+	}
+
+	id, _ := documents[0]["_id"].(string)
+	return scriptFromDocument(id, documents[0])
+}
+
+// Gives a version of a script
+func (n Node) scriptVersion(ctx context.Context, name string, version int64) (*ScriptVersion, error) {
+	id := scriptId(name, version)
+
+	document, err := n.GetDocument(ctx, "scripts", id)
+	if errors.Is(err, backlog.ErrNotFound) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the script %s: %v", id, err)
+	}
+
+	return scriptFromDocument(id, document)
+}
+
+func scriptFromDocument(id string, document map[string]interface{}) (*ScriptVersion, error) {
+	script := ScriptVersion{}
+	if err := fromDocument("scripts", id, document, &script, "name", "version", "module"); err != nil {
+		return nil, err
+	}
+
+	return &script, nil
+}
+
+// Loads the versions of the scripts enforced by the node, replacing the ones loaded before. The
+// scripts aren't replaced when any of them can't be loaded
+func (n Node) LoadScripts(ctx context.Context) error {
+	names := config.ValidationScripts()
+
+	scriptsMutex.RLock()
+	runtime := scriptRuntime
+	scriptsMutex.RUnlock()
+
+	if len(names) > 0 && runtime == nil {
+		return ErrNoScriptRuntime
+	}
+
+	var loaded []loadedScript
+	for _, name := range names {
+		var script *ScriptVersion
+		var err error
+
+		if base, pin, ok := strings.Cut(name, "@"); ok {
+			version, parseErr := strconv.ParseInt(pin, 10, 64)
+			if parseErr != nil {
+				return fmt.Errorf("invalid script version %q", name)
+			}
+
+			name = base
+			script, err = n.scriptVersion(ctx, name, version)
+		} else {
+			script, err = n.latestScript(ctx, name)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if script == nil {
+			return fmt.Errorf("the script %s was never published", name)
+		}
+
+		module, err := base64.StdEncoding.DecodeString(script.Module)
+		if err != nil {
+			return fmt.Errorf("failed to decode the script %s: %v", scriptId(script.Name, script.Version), err)
+		}
+
+		digest := sha256.Sum256(module)
+		if hex.EncodeToString(digest[:]) != script.Digest {
+			return fmt.Errorf("the module of the script %s doesn't match its digest", scriptId(script.Name, script.Version))
+		}
+
+		loaded = append(loaded, loadedScript{ScriptVersion: *script, module: module})
+	}
+
+	scriptsMutex.Lock()
+	scripts = loaded
+	scriptsMutex.Unlock()
+
+	return nil
+}
+
+// Loads the validation scripts, failing when they can't be loaded, and schedules a job that picks up
+// their new versions
+func (n Node) StartScriptLoader(ctx context.Context, schedule string) error {
+	if err := n.LoadScripts(ctx); err != nil {
+		return fmt.Errorf("failed to load the validation scripts: %v", err)
+	}
+
+	// A failed reload keeps the scripts loaded before, so the node never runs unchecked
+	return ScheduleJob(Job{
+		Name:     "scripts",
+		Schedule: schedule,
+		Run: func(ctx context.Context, lease *Lease) error {
+			return n.LoadScripts(ctx)
+		},
+	})
+}
+
+// Runs the validation scripts on a transaction. Gives the first rejection
+func runScripts(ctx context.Context, t Transaction) error {
+	scriptsMutex.RLock()
+	runtime, enforced := scriptRuntime, scripts
+	scriptsMutex.RUnlock()
+
+	if len(enforced) == 0 {
+		return nil
+	}
+
+	input, err := json.Marshal(map[string]interface{}{
+		"transaction_id": t.TransactionId,
+		"sender":         t.Sender.ClientId,
+		"recipient":      t.Recipient.ClientId,
+		"value":          t.Value,
+		"fee":            t.Fee,
+		"payload":        t.Payload,
+		"timestamp":      t.Timestamp,
+		"sequence":       t.Sequence,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal the transaction: %v", err)
+	}
+
+	limits := ScriptLimits{
+		Memory:  config.ScriptMemory(),
+		Fuel:    config.ScriptFuel(),
+		Timeout: config.ScriptTimeout(),
+	}
+
+	for _, script := range enforced {
+		id := scriptId(script.Name, script.Version)
+
+		evaluation, cancel := context.WithTimeout(ctx, limits.Timeout)
+		reason, err := runtime.Evaluate(evaluation, script.module, input, limits)
+		cancel()
+
+		if err != nil {
+			return fmt.Errorf("%w: the script %s failed: %v", ErrRejected, id, err)
+		}
+
+		if reason != "" {
+			return fmt.Errorf("%w: %s: %s", ErrRejected, id, reason)
+		}
+	}
+
+	return nil
+}
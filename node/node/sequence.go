@@ -0,0 +1,92 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	backlog "node/backlog"
+	hashing "node/hashing"
+)
+
+/*
+Every sender has a sequence of transactions maintained by its node. Each transaction receives
+the next number of the sequence of its sender, that's included in the signed bytes, so the
+recipient and the auditors can detect gaps or reordering in the history of a sender.
+
+The last accepted number of each sender is stored in the `sequences` index, by the hash of the
+client id (the ids are longer than a document id can be) and with the client id itself. Several
+processes may serve the node, so a number is only accepted if the document didn't change since the
+last one was read (please, go to `versioned.go` in the backlog package).
+*/
+
+var ErrInvalidSequence = errors.New("invalid sequence")
+
+// Gives the id of the sequence document of a sender
+func sequenceId(clientId string) string {
+	return hashing.HashClientId(clientId)
+}
+
+// Gives the last accepted sequence number of a sender (zero when it has no transactions)
+func (n Node) LastSequence(ctx context.Context, clientId string) (int64, error) {
+	document, err := n.GetDocument(ctx, "sequences", sequenceId(clientId))
+	if err != nil {
+		// A sender without a sequence document has never transacted
+		return 0, nil
+	}
+
+	last, ok := document["last"].(float64)
+	if !ok {
+		return 0, fmt.Errorf("the sequence document of %s is corrupted", clientId)
+	}
+
+	return int64(last), nil
+}
+
+// Gives the sequence number that the next transaction of a sender must have
+func (n Node) NextSequence(ctx context.Context, clientId string) (int64, error) {
+	last, err := n.LastSequence(ctx, clientId)
+	if err != nil {
+		return 0, err
+	}
+
+	return last + 1, nil
+}
+
+// Validates that a sequence number follows the last accepted one of the sender and accepts it
+func (n Node) AcceptSequence(ctx context.Context, clientId string, sequence int64) error {
+	document, version, err := n.GetVersionedDocument(ctx, "sequences", sequenceId(clientId))
+	if err != nil && !errors.Is(err, backlog.ErrNotFound) {
+		return fmt.Errorf("failed to get the sequence: %v", err)
+	}
+
+	var last int64
+	if document != nil {
+		number, ok := document["last"].(float64)
+		if !ok {
+			return fmt.Errorf("the sequence document of %s is corrupted", clientId)
+		}
+		last = int64(number)
+	}
+
+	if sequence != last+1 {
+		return fmt.Errorf("%w: expected %d for the sender but got %d", ErrInvalidSequence, last+1, sequence)
+	}
+
+	next := map[string]interface{}{"client_id": clientId, "last": sequence}
+	if document == nil {
+		err = n.CreateDocument(ctx, "sequences", sequenceId(clientId), next)
+	} else {
+		err = n.ReplaceDocumentIf(ctx, "sequences", sequenceId(clientId), next, version)
+	}
+
+	// Another process accepted a transaction of the sender in the meantime
+	if errors.Is(err, backlog.ErrConflict) {
+		return fmt.Errorf("%w: the sequence %d was taken concurrently", ErrInvalidSequence, sequence)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to store the sequence: %v", err)
+	}
+
+	return nil
+}
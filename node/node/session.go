@@ -0,0 +1,66 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	config "node/config"
+	timeutil "node/timeutil"
+)
+
+// Checks if the session stored in a cache document has expired. Caches written before the
+// sessions expiry have no expiration and never expire
+func SessionExpired(cache map[string]interface{}) bool {
+	expiresAt, ok := cache["expires_at"].(float64)
+	if !ok || expiresAt == 0 {
+		return false
+	}
+
+	return timeutil.Now() > timeutil.Normalize(int64(expiresAt))
+}
+
+// Checks if the token of a cache document is older than the configured age. The sliding session
+// doesn't extend it, so a stolen token stops working even while it's being used
+func TokenExpired(cache map[string]interface{}) bool {
+	timestamp, ok := cache["timestamp"].(float64)
+	if !ok {
+		return true
+	}
+
+	return timeutil.Now() > timeutil.Normalize(int64(timestamp))+config.TokenTTL().Milliseconds()
+}
+
+// Replaces the credentials of a client with new ones, so the tokens issued before stop working.
+// Gives the new token
+func (n Node) RefreshToken(ctx context.Context, uid, secret string) (string, error) {
+	client, err := n.LoadClient(ctx, uid, secret)
+	if err != nil {
+		return "", err
+	}
+
+	cache := client.CreateCache()
+	if err := client.SyncWithBacklog(ctx, cache); err != nil {
+		return "", fmt.Errorf("failed to store the new credentials: %v", err)
+	}
+
+	token, err := cache.Token()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate the token: %v", err)
+	}
+
+	return token, nil
+}
+
+// Extends the session of a client by the configured window since now (sliding window).
+// Gives the new expiration timestamp
+func (n Node) TouchSession(ctx context.Context, uid string) (int64, error) {
+	expiresAt := timeutil.After(config.SessionWindow())
+
+	err := n.UpdateDocument(ctx, "cache", uid, map[string]interface{}{
+		"expires_at": expiresAt,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to extend the session: %v", err)
+	}
+
+	return expiresAt, nil
+}
@@ -0,0 +1,148 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	backlog "node/backlog"
+	client "node/client"
+	config "node/config"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+/*
+Several processes may serve the same node, each one with its own BASE_PATH, as long as they share
+the backlog. So whatever a process keeps in its filesystem is also kept in the backlog, and a
+process that misses it restores it from there:
+
+  - the identity of the node (its id and key pair), in the `identity` index;
+  - the key pairs of the clients (the private keys stay encrypted by the client secrets), in the
+    `keys` index, by uid.
+
+The backlog already holds every record of the node, so it's trusted with the node key as well.
+*/
+const identityDocument string = "local"
+
+// Makes the identity files of the process match the identity shared in the backlog. The first
+// process of the node shares its own identity
+func shareIdentity(ctx context.Context, b *backlog.Backlog) error {
+	idPath := filepath.Join(config.BasePath(), nodeIdFile)
+	keyPath := filepath.Join(config.BasePath(), nodeKeyFile)
+
+	document, err := b.GetDocument(ctx, "identity", identityDocument)
+	if errors.Is(err, backlog.ErrNotFound) {
+		_, statErr := os.Stat(idPath)
+		fresh := os.IsNotExist(statErr)
+
+		id, err := LoadNodeId()
+		if err != nil {
+			return err
+		}
+
+		key, err := LoadNodeKey()
+		if err != nil {
+			return err
+		}
+
+		err = b.CreateDocument(ctx, "identity", identityDocument, map[string]interface{}{
+			"node_id": id,
+			"key":     string(key.ImpersonatePrivateKey()),
+		})
+
+		// Another process shared its identity first. An identity made right now is dropped for it
+		if errors.Is(err, backlog.ErrConflict) && fresh {
+			os.Remove(idPath)
+			os.Remove(keyPath)
+			return shareIdentity(ctx, b)
+		}
+
+		if err != nil && !errors.Is(err, backlog.ErrConflict) {
+			return fmt.Errorf("failed to share the node identity: %v", err)
+		}
+
+		if err == nil {
+			return nil
+		}
+
+		document, err = b.GetDocument(ctx, "identity", identityDocument)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to get the shared node identity: %v", err)
+	}
+
+	id, _ := document["node_id"].(string)
+	key, _ := document["key"].(string)
+	if id == "" || key == "" {
+		return fmt.Errorf("%w: the shared node identity is incomplete", ErrSchemaDrift)
+	}
+
+	if content, err := os.ReadFile(idPath); err == nil {
+		if local := strings.TrimSpace(string(content)); local != id {
+			return fmt.Errorf("the backlog belongs to the node %s, but the process is the node %s", id, local)
+		}
+	} else if err := os.WriteFile(idPath, []byte(id+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to store the node id: %v", err)
+	}
+
+	if _, err := os.Stat(keyPath); os.IsNotExist(err) {
+		if err := os.WriteFile(keyPath, []byte(key), 0600); err != nil {
+			return fmt.Errorf("failed to store the node key: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// Shares the key pair of a client, so every process of the node can load the client. The keys kept
+// in the backlog are already shared
+func (n Node) shareClientKeys(ctx context.Context, uid string) error {
+	if _, shared := n.Keys.(*BacklogKeyStore); shared {
+		return nil
+	}
+
+	keys, err := n.Keys.Get(ctx, uid)
+	if err != nil {
+		return fmt.Errorf("failed to get the client keys: %v", err)
+	}
+
+	err = n.IndexDocument(ctx, "keys", uid, map[string]interface{}{
+		"private": string(keys.Private),
+		"public":  string(keys.Public),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to share the client keys: %v", err)
+	}
+
+	return nil
+}
+
+// Restores the key pair of a client from the backlog when the key store of the process doesn't
+// have it
+func (n Node) RestoreClientKeys(ctx context.Context, uid string) error {
+	_, err := n.Keys.Get(ctx, uid)
+	if err == nil {
+		return nil
+	} else if !errors.Is(err, client.ErrNoKeys) {
+		return err
+	}
+
+	document, err := n.GetDocument(ctx, "keys", uid)
+	if err != nil {
+		return fmt.Errorf("failed to get the shared client keys: %v", err)
+	}
+
+	private, _ := document["private"].(string)
+	public, _ := document["public"].(string)
+	if private == "" || public == "" {
+		return fmt.Errorf("%w: the shared keys of %s are incomplete", ErrSchemaDrift, uid)
+	}
+
+	if err := n.Keys.Put(ctx, uid, client.KeyPair{Private: []byte(private), Public: []byte(public)}); err != nil {
+		return fmt.Errorf("failed to restore the client keys: %v", err)
+	}
+
+	return nil
+}
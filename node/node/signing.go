@@ -0,0 +1,92 @@
+package node
+
+import (
+	"encoding/json"
+	"fmt"
+	client "node/client"
+)
+
+/*
+Every document that is signed (or hashed) is Signable, so the signatures are made and checked the
+same way whatever the document is: the key signs the canonical encoding of the document (its
+`ToBytes`), and the signature is checked against the identity of the signer with
+`client.VerifyWithIdentity`.
+
+  - The transactions, the routed transactions and the acknowledgments are signed by the clients.
+  - The node status, the departures, the address changes, the erasures and the client
+    announcements are signed by the node.
+  - The key rotations are signed by both keys of the client.
+  - The blocks are hashed over their header (please, go to `blockchain.go`), so a block signature
+    covers the same bytes as its hash.
+
+The encodings leave the signatures out, and they never change once released, since the documents
+signed before would stop matching them. The transactions and the block headers, checked by every
+peer, are encoded canonically (please, go to `canonical.go`), so their bytes don't depend on the Go
+version of the node.
+*/
+var (
+	_ client.Signable = Transaction{}
+	_ client.Signable = BlockTransaction{}
+	_ client.Signable = RoutedTransaction{}
+	_ client.Signable = Acknowledgment{}
+	_ client.Signable = Node{}
+	_ client.Signable = Departure{}
+	_ client.Signable = AddressChange{}
+	_ client.Signable = Erasure{}
+	_ client.Signable = ClientAnnouncement{}
+	_ client.Signable = KeyRotation{}
+	_ client.Signable = KeyLogEntry{}
+	_ client.Signable = Block{}
+)
+
+// Converts the status of the node (except the signature) to a signable byte array. Only the fields
+// that the node announces are signed, never the local ones (e.g. the mirror)
+func (n Node) ToBytes() ([]byte, error) {
+	status := map[string]interface{}{
+		"node_id":        n.Id,
+		"public_key":     n.PublicKey,
+		"host":           n.Host,
+		"version":        n.Version,
+		"status":         n.Status,
+		"read_only":      n.ReadOnly,
+		"schema_version": n.SchemaVersion,
+		"resumes_at":     n.ResumesAt,
+	}
+
+	// The window is only signed when there's one, so the statuses of the nodes that never scheduled
+	// one are the same for the peers that don't know the windows yet
+	if n.Maintenance != nil {
+		status["maintenance"] = n.Maintenance
+	}
+
+	statusBytes, err := json.Marshal(status)
+	return statusBytes, err
+}
+
+// Signs the status of the node with the node key. A node without its key keeps the status unsigned
+func (n *Node) signStatus() error {
+	if n.Key == nil {
+		return nil
+	}
+
+	signature, err := n.Key.CreateSignature(n)
+	if err != nil {
+		return fmt.Errorf("failed to sign the node status: %v", err)
+	}
+
+	n.Signature = signature
+	return nil
+}
+
+// Verifies the signature of the node status with the public key (identity) that it announces
+func (n Node) VerifyStatus() error {
+	if n.Signature == "" {
+		return fmt.Errorf("the status of the node %s isn't signed", n.Id)
+	}
+
+	if err := client.VerifyWithIdentity(n.PublicKey, n, n.Signature); err != nil {
+		return fmt.Errorf("the status isn't signed by the node %s", n.Id)
+	}
+
+	return nil
+}
@@ -0,0 +1,148 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	backlog "node/backlog"
+)
+
+/*
+A block is refused when it spends what the chain doesn't allow, whoever signed its transactions:
+
+  - A transaction already confirmed by a block of the chain (or twice in the same block).
+  - A transaction whose sequence isn't after the last one of its sender confirmed by the chain.
+  - A sender whose balance, after the values and the fees it sends in the block, is negative.
+
+The chain is taken as it is before the block, so the transactions are checked in the order of the
+block: a value received earlier in the block can be spent later in it. The checks run whenever a
+block is appended (mined, fetched from a peer or appended by the fork choice), and the mining skips
+the pending transactions that would fail them, so a single one doesn't stop the node from mining.
+*/
+type spending struct {
+	node      Node
+	confirmed map[string]bool    // The transactions confirmed so far
+	sequences map[string]int64   // The last sequence of every sender seen so far
+	balances  map[string]float64 // The balance of every sender after the transactions seen so far
+	credits   map[string]float64 // The values received in the block by the clients not seen as senders yet
+}
+
+// Starts the checks of the transactions of a block over the chain as it is
+func (bc Blockchain) newSpending() *spending {
+	return &spending{
+		node:      Node{Backlog: bc.Backlog},
+		confirmed: map[string]bool{},
+		sequences: map[string]int64{},
+		balances:  map[string]float64{},
+		credits:   map[string]float64{},
+	}
+}
+
+// Checks the next transaction of the block and, when it's valid, takes it into account for the next
+// ones. The refused transactions give an ErrInvalidBlock error
+func (s *spending) spend(ctx context.Context, transaction BlockTransaction) error {
+	if s.confirmed[transaction.TransactionId] {
+		return fmt.Errorf("%w: the transaction %s is included twice", ErrInvalidBlock, transaction.TransactionId)
+	}
+
+	document, err := s.node.GetDocument(ctx, "transactions", transaction.TransactionId)
+	if err != nil && !errors.Is(err, backlog.ErrNotFound) {
+		return fmt.Errorf("failed to get the transaction %s: %v", transaction.TransactionId, err)
+	}
+
+	if blockHash, _ := document["BlockHash"].(string); blockHash != "" {
+		return fmt.Errorf("%w: the transaction %s is already confirmed", ErrInvalidBlock, transaction.TransactionId)
+	}
+
+	last, ok := s.sequences[transaction.Sender]
+	if !ok {
+		if last, err = s.confirmedSequence(ctx, transaction.Sender); err != nil {
+			return err
+		}
+	}
+
+	if transaction.Sequence <= last {
+		return fmt.Errorf("%w: the transaction %s replays the sequence %d of its sender", ErrInvalidBlock, transaction.TransactionId, transaction.Sequence)
+	}
+
+	balance, ok := s.balances[transaction.Sender]
+	if !ok {
+		confirmed, err := s.node.ConfirmedBalance(ctx, transaction.Sender)
+		if err != nil {
+			return fmt.Errorf("failed to compute the balance of %s: %v", transaction.Sender, err)
+		}
+
+		balance = confirmed.Confirmed + s.credits[transaction.Sender]
+	}
+
+	balance -= transaction.Value + transaction.Fee
+	if balance < 0 {
+		return fmt.Errorf("%w: the transaction %s spends more than the balance of its sender", ErrInvalidBlock, transaction.TransactionId)
+	}
+
+	s.confirmed[transaction.TransactionId] = true
+	s.sequences[transaction.Sender] = transaction.Sequence
+	s.balances[transaction.Sender] = balance
+
+	if _, ok := s.balances[transaction.Recipient]; ok {
+		s.balances[transaction.Recipient] += transaction.Value
+	} else {
+		s.credits[transaction.Recipient] += transaction.Value
+	}
+
+	return nil
+}
+
+// Gives the last sequence of a sender confirmed by the chain (zero when none is)
+func (s *spending) confirmedSequence(ctx context.Context, sender string) (int64, error) {
+	query := backlog.Bool().
+		Must(partyQuery(sender, "Sender"), backlog.Exists("BlockHash")).
+		Query()
+
+	records, err := s.node.Transactions().Find(ctx, query, backlog.ListOptions{Size: 1, Sort: []string{"Sequence:desc"}})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get the confirmed sequence of %s: %v", sender, err)
+	}
+
+	if len(records) == 0 {
+		return 0, nil
+	}
+
+	return records[0].Sequence, nil
+}
+
+// Checks that the transactions of a block only spend what the chain allows
+func (bc Blockchain) checkSpending(ctx context.Context, block *Block) error {
+	spending := bc.newSpending()
+	for _, transaction := range block.Transactions {
+		if transaction.allocation(block.Height) {
+			continue
+		}
+
+		if err := spending.spend(ctx, transaction); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Gives the pending transactions, in their order, that the chain allows to confirm together
+func (bc Blockchain) spendable(ctx context.Context, transactions []BlockTransaction) ([]BlockTransaction, error) {
+	spending := bc.newSpending()
+
+	var allowed []BlockTransaction
+	for _, transaction := range transactions {
+		err := spending.spend(ctx, transaction)
+		if errors.Is(err, ErrInvalidBlock) {
+			Debugf(ctx, SubsystemSync, "left the transaction %s out of the block: %v", transaction.TransactionId, err)
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		allowed = append(allowed, transaction)
+	}
+
+	return allowed, nil
+}
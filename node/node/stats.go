@@ -0,0 +1,37 @@
+package node
+
+import (
+	config "node/config"
+)
+
+// A snapshot of the node state, used by the operators to inspect the node
+type NodeStats struct {
+	Host         string             `json:"host"`         // The host address from the node
+	Version      string             `json:"version"`      // Identifier of the source code that's running on the node
+	Status       NodeStatus         `json:"status"`       // The status of the node
+	ReadOnly     bool               `json:"read_only"`    // Whether the node is refusing writes
+	Maintenance  *MaintenanceWindow `json:"maintenance"`  // The scheduled maintenance window (nil when there's none)
+	Build        BuildInfo          `json:"build"`        // The build of the binary that's running on the node
+	Features     []string           `json:"features"`     // The features enabled in the node (please, go to `features.go` in the config)
+	Backpressure Backpressure       `json:"backpressure"` // Whether the node is shedding work because of the backlog latency
+	Disk         []DiskUsage        `json:"disk"`         // The usage of the disks where the node writes data
+}
+
+// Collects the current node stats
+func (n Node) Stats() (NodeStats, error) {
+	disk, err := MonitoredDiskUsage()
+
+	stats := NodeStats{
+		Host:         n.Host,
+		Version:      n.Version,
+		Status:       n.Status,
+		ReadOnly:     n.ReadOnly,
+		Maintenance:  n.Maintenance,
+		Build:        CurrentBuild(),
+		Features:     config.EnabledFeatures(),
+		Backpressure: CurrentBackpressure(),
+		Disk:         disk,
+	}
+
+	return stats, err
+}
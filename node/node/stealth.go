@@ -0,0 +1,186 @@
+package node
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	backlog "node/backlog"
+	client "node/client"
+	hashing "node/hashing"
+	timeutil "node/timeutil"
+)
+
+/*
+The chain is public, so every payment to the same client id can be linked to the others. A sender
+can pay to a stealth address instead: a one-time id derived from a random seed and the client id of
+the recipient, that replaces the client id in the chain (and in the signature).
+
+The seed and the client id travel in the tag of the transaction, sealed to the key of the home node
+of the recipient. Only that node can open the tag, so it recognizes the payments to its clients and
+claims them in the `stealth` index, that the ledger reads to credit the recipient. For everybody
+else, two stealth payments to the same client look unrelated.
+*/
+type StealthAddress struct {
+	Id  string `json:"id"`  // The one-time id that replaces the client id of the recipient in the chain
+	Tag string `json:"tag"` // The seed and the client id of the recipient, sealed to its home node
+}
+
+// The content of the tag of a stealth address
+type stealthTag struct {
+	ClientId string `json:"client_id"`
+	Seed     []byte `json:"seed"`
+}
+
+// Derives the one-time id of a stealth address
+func stealthId(seed []byte, clientId string) string {
+	hash := sha256.Sum256(append(append([]byte{}, seed...), clientId...))
+	return hex.EncodeToString(hash[:])
+}
+
+// Gives the public key (identity) of the node where a client was registered
+func (n Node) homeNodeKey(ctx context.Context, nodeAddress string) (string, error) {
+	if hashing.MatchesAddress(nodeAddress, n.Host) {
+		return n.PublicKey, nil
+	}
+
+	host, err := n.homeNodeHost(ctx, nodeAddress)
+	if err != nil {
+		return "", err
+	}
+
+	document, err := n.FindDocument(ctx, "peers", "host", host)
+	if err != nil {
+		return "", fmt.Errorf("failed to get the peer %s: %v", host, err)
+	}
+
+	publicKey, _ := document["public_key"].(string)
+	if publicKey == "" {
+		return "", fmt.Errorf("the peer %s has no known public key", host)
+	}
+
+	return publicKey, nil
+}
+
+// Pays the unsigned transaction to a new stealth address of its recipient
+func (t *Transaction) UseStealthAddress(ctx context.Context) error {
+	if t.Signature != nil {
+		return fmt.Errorf("the transaction %s is already signed", t.TransactionId)
+	}
+
+	identity, err := t.Sender.homeNodeKey(ctx, t.Recipient.NodeAddress)
+	if err != nil {
+		return fmt.Errorf("failed to find the node of the recipient: %v", err)
+	}
+
+	nodeKey, err := client.ParseIdentity(identity)
+	if err != nil {
+		return err
+	}
+
+	tag := stealthTag{ClientId: t.Recipient.ClientId, Seed: make([]byte, 32)}
+	if _, err := rand.Read(tag.Seed); err != nil {
+		return fmt.Errorf("failed to generate the stealth seed: %v", err)
+	}
+
+	tagBytes, err := json.Marshal(tag)
+	if err != nil {
+		return fmt.Errorf("failed to marshal the stealth tag: %v", err)
+	}
+
+	sealed, err := client.Seal(tagBytes, nodeKey)
+	if err != nil {
+		return err
+	}
+
+	t.Stealth = &StealthAddress{Id: stealthId(tag.Seed, tag.ClientId), Tag: sealed}
+	return nil
+}
+
+// Gives the client id behind a stealth address, when it belongs to a client of the node
+func (n Node) openStealth(address StealthAddress) (string, bool) {
+	if n.Key == nil {
+		return "", false
+	}
+
+	tagBytes, err := n.Key.Open(address.Tag)
+	if err != nil {
+		return "", false
+	}
+
+	tag := stealthTag{}
+	if err := json.Unmarshal(tagBytes, &tag); err != nil {
+		return "", false
+	}
+
+	// A tag copied from another transaction doesn't derive the same id
+	if stealthId(tag.Seed, tag.ClientId) != address.Id {
+		return "", false
+	}
+
+	return tag.ClientId, true
+}
+
+// Claims the stealth payments of a block that belong to the clients of the node, recording their
+// client ids in the `stealth` index and in the transactions, and dropping their cached balances
+func (n Node) claimStealth(ctx context.Context, block Block) {
+	for _, transaction := range block.Transactions {
+		if transaction.Tag == "" {
+			continue
+		}
+
+		address := StealthAddress{Id: transaction.Recipient, Tag: transaction.Tag}
+		clientId, ok := n.openStealth(address)
+		if !ok {
+			continue
+		}
+
+		err := n.Begin().
+			Put("stealth", address.Id, map[string]interface{}{
+				"client_id":      clientId,
+				"transaction_id": transaction.TransactionId,
+				"claimed_at":     timeutil.Now(),
+			}).
+			Update("transactions", transaction.TransactionId, map[string]interface{}{
+				"Recipient": map[string]interface{}{"client_id": clientId},
+				"Stealth":   address,
+			}).
+			// The cached balance already folded the block without the payment, so it's computed again
+			Delete("balances", balanceId(clientId)).
+			Apply(ctx)
+		if err != nil {
+			Logf(ctx, "failed to claim the stealth payment %s: %v", transaction.TransactionId, err)
+		}
+	}
+}
+
+// Gives the stealth addresses claimed for a client
+func (n Node) stealthAddresses(ctx context.Context, clientId string) (map[string]bool, error) {
+	documents, _, err := n.FindDocuments(ctx, "stealth", backlog.Term("client_id", clientId), backlog.ListOptions{All: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the stealth addresses of %s: %v", clientId, err)
+	}
+
+	addresses := map[string]bool{}
+	for _, document := range documents {
+		if id, _ := document["_id"].(string); id != "" {
+			addresses[id] = true
+		}
+	}
+
+	return addresses, nil
+}
+
+// Gives the signable form of the transaction as it's recorded in the chain, where a stealth address
+// replaces the client id of the recipient
+func (r TransactionRecord) ChainTransaction() BlockTransaction {
+	transaction := r.BlockTransaction
+	if r.Stealth != nil {
+		transaction.Recipient = r.Stealth.Id
+		transaction.Tag = r.Stealth.Tag
+	}
+
+	return transaction
+}
@@ -0,0 +1,89 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	config "node/config"
+	timeutil "node/timeutil"
+)
+
+/*
+The operators in regulated contexts publish terms (or a policy) that the clients must accept. The
+current version is set in TERMS_VERSION, and every client records the version it accepted, at its
+creation and whenever it accepts a new one. Every acceptance is kept in the `consents` index, so
+the operators can prove what each client agreed to and when.
+
+When the terms change, the clients that didn't accept the new version keep working, unless
+TERMS_ENFORCED is set: then they can't transact until they accept it.
+*/
+type Consent struct {
+	UID        string `json:"uid"`         // The internal reference of the client
+	ClientId   string `json:"client_id"`   // The external reference of the client
+	Version    string `json:"version"`     // The version of the terms accepted
+	AcceptedAt int64  `json:"accepted_at"` // The timestamp when the terms were accepted
+}
+
+var ErrTermsNotAccepted = errors.New("the client didn't accept the current terms")
+
+// Checks if a version is the current version of the terms (any version is, when there are no terms)
+func checkTermsVersion(version string) error {
+	current := config.TermsVersion()
+	if current != "" && version != current {
+		return fmt.Errorf("%w: the current terms are the version %s", ErrTermsNotAccepted, current)
+	}
+
+	return nil
+}
+
+// Checks if the client can transact under the current terms
+func (c Client) CheckTerms() error {
+	if !config.TermsEnforced() {
+		return nil
+	}
+
+	return checkTermsVersion(c.TermsVersion)
+}
+
+// Records that a local client accepted the current version of the terms
+func (n Node) AcceptTerms(ctx context.Context, uid, version string) (*Consent, error) {
+	if err := checkTermsVersion(version); err != nil {
+		return nil, err
+	}
+
+	owner, err := n.Clients().Get(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	consent := Consent{
+		UID:        uid,
+		ClientId:   owner.ClientId,
+		Version:    version,
+		AcceptedAt: timeutil.Now(),
+	}
+
+	document, err := toDocument(consent)
+	if err != nil {
+		return nil, err
+	}
+
+	err = n.Begin().
+		Put("consents", uid+"@"+version, document).
+		Update("local_clients", uid, map[string]interface{}{
+			"terms_version":     consent.Version,
+			"terms_accepted_at": consent.AcceptedAt,
+		}).
+		Apply(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record the consent: %v", err)
+	}
+
+	n.Emit(ctx, "terms.accepted", map[string]interface{}{
+		"uid":       uid,
+		"client_id": consent.ClientId,
+		"version":   consent.Version,
+	})
+
+	return &consent, nil
+}
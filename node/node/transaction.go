@@ -1,9 +1,11 @@
 package node
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"time"
+	timeutil "node/timeutil"
 
 	"github.com/google/uuid"
 )
@@ -21,14 +23,24 @@ accessible for all the nodes in the network.
 A Client can be easily converted to a ForeignClient with the method `MakeForeign`
 */
 type ForeignClient struct {
-	*Node
-	ClientId    string `json:"client_id"`
-	NodeAddress string `json:"node"`
-	Address     string `json:"address"`
+	*Node       `json:"-"`
+	ClientId    string            `json:"client_id"`
+	NodeAddress string            `json:"node"`
+	Address     string            `json:"address"`
+	UpdatedAt   int64             `json:"updated_at"`           // The timestamp of the last change, used to reconcile the copies across the nodes
+	Erased      bool              `json:"erased"`               // Whether the client was erased (please, go to `erasure.go`)
+	Liquidated  bool              `json:"liquidated,omitempty"` // Whether the client was liquidated (please, go to `liquidation.go`)
+	RotatedTo   string            `json:"rotated_to,omitempty"` // The client id after a rotation of the keys (please, go to `rotation.go`)
+	Rotation    *KeyRotation      `json:"rotation,omitempty"`   // The rotation that gave the client id, signed by both keys
+	Metadata    map[string]string `json:"metadata,omitempty"`   // The metadata attached by the client (please, go to `profile.go`)
 }
 
+var ErrUnknownClient = errors.New("the client is unknown to the node")
+
 // (Over)Writes the foreign client state in backlog using the current in-memory state
-func (c ForeignClient) SyncWithBacklog() error {
+func (c ForeignClient) SyncWithBacklog(ctx context.Context) error {
+	c.UpdatedAt = timeutil.Now()
+
 	clientBytes, err := json.Marshal(c)
 	if err != nil {
 		return fmt.Errorf("failed to marshal the client: %v", err)
@@ -39,7 +51,7 @@ func (c ForeignClient) SyncWithBacklog() error {
 		return fmt.Errorf("failed to unmarshal the client into map: %v", err)
 	}
 
-	err = c.IndexDocument("clients", c.ClientId, client)
+	err = c.IndexDocument(ctx, "clients", c.ClientId, client)
 	if err != nil {
 		return fmt.Errorf("failed to overwrite the client document: %v", err)
 	}
@@ -56,25 +68,52 @@ To sign a transaction, you must to do it in the node of the sender/signer, once
 is signed by the private key and the key pair is only available at the node that owns the client
 credentials.
 
-Every transaction has a value, a timestamp and a signature. The transaction is
-signed only when it's included in a valid block. Please, go to `blockchain` to see
-more about blocks.
+Every transaction has a value, a timestamp and a signature. A signed transaction stays pending
+until it's included in a valid block, when it receives the hash of that block. Please, go to
+`blockchain.go` to see more about blocks.
 
 The transaction can be converted into a byte array, a marshalling of the following information:
-sender client id, recipient client id, value and timestamp. The signature is not included
-in the marshalling process.
+sender client id, recipient client id (or its stealth address), value, timestamp, sequence and the
+fee, payload, sealed memo and stealth tag (when there are ones). The signature is not included in
+the marshalling process.
 */
 type Transaction struct {
-	TransactionId string         // A unique and universal id that references the transaction anywhere
-	Sender        *Client        // The client who performed the transaction
-	Recipient     *ForeignClient // The target client of the transaction (it belongs to the local node or to an external node)
-	Value         float64        // The value is the current content of the transaction. It could be changed to a message or another content type
-	Timestamp     int64          // The timestamp that records when the transaction was performed
-	Signature     *string        // A pointer to the signature made by the sender client when the transaction have been accepted
+	TransactionId string          // A unique and universal id that references the transaction anywhere
+	Sender        *Client         // The client who performed the transaction
+	Recipient     *ForeignClient  // The target client of the transaction (it belongs to the local node or to an external node)
+	Value         float64         // The value transferred by the transaction (any other content goes in the payload)
+	Payload       []byte          // The arbitrary data attached by the application, included in the signature (please, go to `payload.go`)
+	Fee           float64         // The fee paid to the producer of the block, on top of the value (please, go to `fees.go`)
+	Timestamp     int64           // The timestamp that records when the transaction was performed
+	Sequence      int64           // The position of the transaction in the history of the sender (starting from one)
+	Memo          string          // The memo sealed to the sender and the recipient, included in the signature (please, go to `memo.go`)
+	Stealth       *StealthAddress // The one-time address that replaces the recipient in the chain (please, go to `stealth.go`)
+	Signature     *string         // A pointer to the signature made by the sender client when the transaction have been accepted
+	BlockHash     *string         // A pointer to the hash of the block that includes the transaction (nil while pending)
+}
+
+type TransactionStatus string
+
+const (
+	TransactionUnsigned  TransactionStatus = "unsigned"  // When the transaction was created but not signed yet
+	TransactionPending   TransactionStatus = "pending"   // When the transaction is signed and waits for a block
+	TransactionConfirmed TransactionStatus = "confirmed" // When the transaction is included in a block
+)
+
+// Gives the status of the transaction according to its signature and block
+func (t Transaction) Status() TransactionStatus {
+	switch {
+	case t.Signature == nil:
+		return TransactionUnsigned
+	case t.BlockHash == nil:
+		return TransactionPending
+	default:
+		return TransactionConfirmed
+	}
 }
 
 // (Over)Writes the transaction state in backlog using the current in-memory state
-func (t Transaction) SyncWithBacklog() error {
+func (t Transaction) SyncWithBacklog(ctx context.Context) error {
 	transBytes, err := json.Marshal(t)
 	if err != nil {
 		return fmt.Errorf("failed to marshal the client: %v", err)
@@ -85,7 +124,12 @@ func (t Transaction) SyncWithBacklog() error {
 		return fmt.Errorf("failed to unmarshal the client into map: %v", err)
 	}
 
-	err = t.Sender.IndexDocument("transactions", t.TransactionId, transaction)
+	// The large memos are stored compressed (please, go to `compression.go`)
+	if t.Memo != "" {
+		transaction["Memo"] = compressField(t.Memo)
+	}
+
+	err = t.Sender.IndexDocument(ctx, "transactions", t.TransactionId, transaction)
 	if err != nil {
 		return fmt.Errorf("failed to overwrite the client document: %v", err)
 	}
@@ -94,40 +138,108 @@ func (t Transaction) SyncWithBacklog() error {
 }
 
 // Converts the transaction  information to a encryptable byte array
-func (t Transaction) ToBytes() []byte {
+func (t Transaction) ToBytes() ([]byte, error) {
+	recipient, tag := t.Recipient.ClientId, ""
+	if t.Stealth != nil {
+		recipient, tag = t.Stealth.Id, t.Stealth.Tag
+	}
+
 	transaction := map[string]interface{}{
 		"sender":    t.Sender.ClientId,
-		"recipient": t.Recipient.ClientId,
+		"recipient": recipient,
 		"value":     t.Value,
 		"timestamp": t.Timestamp,
+		"sequence":  t.Sequence,
 	}
 
-	transBytes, _ := json.Marshal(transaction)
-	return transBytes
+	transBytes, err := canonicalJSON(withSealed(withPayload(withFee(transaction, t.Fee), t.Payload), t.Memo, tag))
+	return transBytes, err
 }
 
-// Signs the transaction and updates the transaction record in backlog with the new signature
-func (t *Transaction) SignTransaction() error {
-	signature := t.Sender.CreateSignature(t)
+// Signs the transaction and updates the transaction record in backlog with the new signature.
+// The transaction is only accepted when its sequence follows the last one of the sender, the sender
+// is within the rate of the protocol (please, go to `ratelimit.go`) and the validation scripts and
+// the plugins don't reject it (please, go to `scripts.go` and `plugins.go`)
+func (t *Transaction) SignTransaction(ctx context.Context) error {
+	if err := runBeforeAccept(ctx, *t); err != nil {
+		return err
+	}
+
+	if err := t.Sender.CheckRate(ctx, t.Sender.ClientId, t.Timestamp); err != nil {
+		return err
+	}
+
+	if err := t.Sender.AcceptSequence(ctx, t.Sender.ClientId, t.Sequence); err != nil {
+		return err
+	}
+
+	signature, err := t.Sender.CreateSignature(t)
+	if err != nil {
+		return err
+	}
 	t.Signature = &signature
 
-	err := t.SyncWithBacklog()
+	err = t.SyncWithBacklog(ctx)
 	if err != nil {
 		return err
 	}
 
+	runAfterSign(ctx, *t)
+	t.Sender.Emit(ctx, "transaction.signed", map[string]interface{}{
+		"transaction_id": t.TransactionId,
+		"sender":         t.Sender.ClientId,
+		"recipient":      t.Recipient.ClientId,
+		"value":          t.Value,
+		"fee":            t.Fee,
+		"timestamp":      t.Timestamp,
+		"sequence":       t.Sequence,
+	})
+	signed := *t
+	outboxPool.Go(ctx, func(ctx context.Context) {
+		signed.Sender.NotifyTransactionReceived(ctx, signed)
+	})
+
 	return nil
 }
 
-// Creates a new transaction from the client as its sender
-func (c Client) NewTransaction(rcp string, value float64) *Transaction {
+// Creates a new transaction from the client as its sender, paying the fee. The fee must reach the
+// minimum of the node, and the sender must have the funds for the value and the fee (please, go to
+// `ledger.go` to see more about it)
+func (c Client) NewTransaction(ctx context.Context, rcp string, value, fee float64) (*Transaction, error) {
+	if err := c.CheckTerms(); err != nil {
+		return nil, err
+	}
+
+	if err := checkFee(fee); err != nil {
+		return nil, err
+	}
+
 	transactionId, _ := uuid.NewUUID()
 	sender := &c
-	recipient, err := c.Node.RetrieveForeignClient(rcp)
-	timestamp := time.Now().Unix()
+	recipient, err := c.Node.RetrieveForeignClient(ctx, rcp)
+	timestamp := timeutil.Now()
 
 	if err != nil {
-		return nil
+		return nil, fmt.Errorf("%w: %v", ErrUnknownClient, err)
+	}
+
+	if recipient.Erased {
+		return nil, fmt.Errorf("%w: the client %s was erased", ErrUnknownClient, rcp)
+	}
+
+	if recipient.Liquidated {
+		return nil, fmt.Errorf("%w: the client %s was liquidated", ErrUnknownClient, rcp)
+	}
+
+	sequence, err := c.Node.NextSequence(ctx, c.ClientId)
+	if err != nil {
+		return nil, err
+	}
+
+	// The funds are checked after the sequence is read, so a transaction signed concurrently either
+	// is counted as pending or takes the sequence away from this one
+	if err := c.Node.CheckFunds(ctx, c.ClientId, value+fee); err != nil {
+		return nil, err
 	}
 
 	transaction := Transaction{
@@ -135,9 +247,11 @@ func (c Client) NewTransaction(rcp string, value float64) *Transaction {
 		Sender:        sender,
 		Recipient:     recipient,
 		Value:         value,
+		Fee:           fee,
 		Timestamp:     timestamp,
+		Sequence:      sequence,
 		Signature:     nil,
 	}
 
-	return &transaction
+	return &transaction, nil
 }
@@ -1,6 +1,8 @@
 package node
 
 import (
+	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"math"
 	"math/rand"
@@ -62,3 +64,18 @@ func generateAccountId() string {
 
 	return accountID
 }
+
+// Converts a struct into a backlog document, following its json tags
+func toDocument(v interface{}) (map[string]interface{}, error) {
+	documentBytes, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal the document: %v", err)
+	}
+
+	var document map[string]interface{}
+	if err := json.Unmarshal(documentBytes, &document); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal the document into map: %v", err)
+	}
+
+	return document, nil
+}
@@ -0,0 +1,198 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	client "node/client"
+	"sync"
+)
+
+// The number of blocks read from the backlog at once while validating the chain
+const validationPageSize int = 100
+
+// The result of a chain validation. When the chain is corrupted, it points the first bad block
+type ChainReport struct {
+	Valid           bool   `json:"valid"`            // Whether all the blocks are valid
+	Blocks          int64  `json:"blocks"`           // The number of blocks checked
+	CorruptedHeight int64  `json:"corrupted_height"` // The height of the first corrupted block
+	CorruptedHash   string `json:"corrupted_hash"`   // The stored hash of the first corrupted block
+	Reason          string `json:"reason"`           // What's wrong with the first corrupted block
+}
+
+// Converts the transaction information to the same byte array signed by the sender (please, go
+// to the `ToBytes` method of the Transaction)
+func (t BlockTransaction) ToBytes() ([]byte, error) {
+	transaction := map[string]interface{}{
+		"sender":    t.Sender,
+		"recipient": t.Recipient,
+		"value":     t.Value,
+		"timestamp": t.Timestamp,
+		"sequence":  t.Sequence,
+	}
+
+	transBytes, err := canonicalJSON(withSealed(withPayload(withFee(transaction, t.Fee), t.Payload), t.Memo, t.Tag))
+	return transBytes, err
+}
+
+// Gives why a block is corrupted (empty when it's valid), given the block expected before it
+func (bc Blockchain) inspect(ctx context.Context, block *Block, height int64, previousHash string) string {
+	switch {
+	case block.Height != height:
+		return fmt.Sprintf("expected the height %d but got %d", height, block.Height)
+	case block.PreviousHash != previousHash:
+		return "the previous hash doesn't match the hash of the previous block"
+	case block.ComputeHash() != block.Hash:
+		return "the stored hash doesn't match the recomputed hash"
+	case !block.Solved():
+		return "the hash doesn't satisfy the difficulty"
+	case block.MerkleRoot != MerkleRoot(block.Transactions):
+		return "the Merkle root doesn't match the transactions"
+	}
+
+	if height == 0 {
+		if err := checkGenesis(block); err != nil {
+			return err.Error()
+		}
+	}
+
+	// The signatures are verified in parallel by the verification workers. Without them (e.g. in the
+	// tools that don't start the node), every check runs right away
+	reasons := make([]string, len(block.Transactions))
+	var wg sync.WaitGroup
+
+	for i, transaction := range block.Transactions {
+		i, transaction := i, transaction
+		if transaction.allocation(block.Height) {
+			continue
+		}
+
+		check := func(ctx context.Context) {
+			defer wg.Done()
+			reasons[i] = bc.inspectTransaction(ctx, transaction)
+		}
+
+		wg.Add(1)
+		if err := verificationPool.Submit(ctx, check); err != nil {
+			check(ctx)
+		}
+	}
+
+	wg.Wait()
+
+	for _, reason := range reasons {
+		if reason != "" {
+			return reason
+		}
+	}
+
+	return ""
+}
+
+// Gives why a transaction of a block is invalid (empty when it's valid)
+func (bc Blockchain) inspectTransaction(ctx context.Context, transaction BlockTransaction) string {
+	// The client id is the public key of the sender, the same one stored in the `clients` index
+	if _, err := bc.FindDocument(ctx, "clients", "client_id", transaction.Sender); err != nil {
+		return fmt.Sprintf("the sender of the transaction %s is unknown", transaction.TransactionId)
+	}
+
+	if err := transaction.VerifySignature(); err != nil {
+		return err.Error()
+	}
+
+	return ""
+}
+
+// Verifies the signature of the transaction with the public key of its sender (whose client id is
+// the identity of the key)
+func (t BlockTransaction) VerifySignature() error {
+	if _, err := client.ParseIdentity(t.Sender); err != nil {
+		return fmt.Errorf("the sender of the transaction %s has an invalid public key", t.TransactionId)
+	}
+
+	if err := client.VerifyWithIdentity(t.Sender, t, t.Signature); err != nil {
+		return fmt.Errorf("the signature of the transaction %s is invalid", t.TransactionId)
+	}
+
+	return nil
+}
+
+// Walks the whole chain from its genesis recomputing the block hashes and verifying the transaction
+// signatures. It stops at the first corrupted block, and a chain without its genesis isn't valid
+func (bc Blockchain) Validate(ctx context.Context) (*ChainReport, error) {
+	report := ChainReport{Valid: true}
+	previousHash := genesisPreviousHash
+
+	for {
+		documents, err := bc.SearchDocuments(ctx, "blockchain", map[string]interface{}{
+			"size":  validationPageSize,
+			"sort":  []interface{}{map[string]interface{}{"height": "asc"}},
+			"query": map[string]interface{}{"range": map[string]interface{}{"height": map[string]interface{}{"gte": report.Blocks}}},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the chain: %v", err)
+		}
+
+		for _, document := range documents {
+			block, err := blockFromDocument(document)
+			if err != nil {
+				return nil, err
+			}
+
+			if reason := bc.inspect(ctx, block, report.Blocks, previousHash); reason != "" {
+				report.Valid = false
+				report.CorruptedHeight = block.Height
+				report.CorruptedHash = block.Hash
+				report.Reason = reason
+
+				return &report, nil
+			}
+
+			report.Blocks++
+			previousHash = block.Hash
+		}
+
+		if report.Blocks == 0 && report.Valid {
+			report.Valid = false
+			report.Reason = ErrNoGenesis.Error()
+		}
+
+		if len(documents) < validationPageSize {
+			return &report, nil
+		}
+	}
+}
+
+// Validates the chain of the node and checks it against the anchored checkpoints (please, go to
+// `anchors.go`), recording an event when it's corrupted
+func (n Node) VerifyChain(ctx context.Context) (*ChainReport, error) {
+	report, err := NewBlockchain(n.Backlog).Validate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// A chain that is consistent by itself may still have been rewritten since it was anchored
+	if report.Valid {
+		record, err := n.verifyAnchors(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		if record != nil {
+			report.Valid = false
+			report.CorruptedHeight = record.Height
+			report.CorruptedHash = record.BlockHash
+			report.Reason = fmt.Sprintf("the block differs from the checkpoint anchored to %s", record.Anchor)
+		}
+	}
+
+	// A chain without its genesis isn't corrupted, it's only waiting to be synced
+	if !report.Valid && report.CorruptedHash != "" {
+		n.Emit(ctx, "chain.corrupted", map[string]interface{}{
+			"height": report.CorruptedHeight,
+			"hash":   report.CorruptedHash,
+			"reason": report.Reason,
+		})
+	}
+
+	return report, nil
+}
@@ -0,0 +1,163 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync/atomic"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/experimental"
+)
+
+const (
+	wasmPageSize int64 = 65536 // The size of a page of the WebAssembly memory (in bytes)
+	maxWasmPages int64 = 65536 // The most pages that a WebAssembly memory can have (4 GiB)
+)
+
+/*
+The script runtime of the node runs the modules with wazero, a WebAssembly runtime written in Go, so
+the scripts need no native library. Every evaluation runs in a new instance of the module, without
+any import (a module that imports something, e.g. WASI, can't be instantiated), and within the
+limits of the node:
+
+  - The memory is capped to the pages that fit in the limit, so a module that asks for more fails.
+  - The fuel is spent by every call of a function of the module, and the evaluation is stopped once
+    it runs out. The loops inside a function are bounded by the time.
+  - The evaluation is stopped when its context is done (e.g. its time is over), in the next call or
+    iteration of a loop.
+
+The modules are compiled once and kept in a cache shared by the evaluations.
+*/
+type wasmRuntime struct {
+	cache wazero.CompilationCache
+}
+
+var ErrOutOfFuel = errors.New("the script ran out of fuel")
+
+// Gives the WebAssembly runtime of the validation scripts
+func NewWasmRuntime() ScriptRuntime {
+	return wasmRuntime{cache: wazero.NewCompilationCache()}
+}
+
+// The fuel left to an evaluation, found by the listener through the context of the calls
+type fuelKey struct{}
+
+type fuelTank struct {
+	remaining atomic.Int64
+	exhausted atomic.Bool
+	stop      context.CancelFunc
+}
+
+// Spends the fuel of a call of the module, stopping the evaluation when it runs out or its time is
+// over. The runtime only checks the context in the loops, so the call is aborted (the runtime
+// recovers the panic as a trap)
+func (t *fuelTank) spend(ctx context.Context) {
+	if t.remaining.Add(-1) < 0 {
+		t.exhausted.Store(true)
+		t.stop()
+		panic(ErrOutOfFuel)
+	}
+
+	if err := ctx.Err(); err != nil {
+		panic(err)
+	}
+}
+
+// The listener that spends the fuel on every call of a function of the module
+type fuelMeter struct{}
+
+func (fuelMeter) NewFunctionListener(api.FunctionDefinition) experimental.FunctionListener {
+	return experimental.FunctionListenerFunc(func(ctx context.Context, _ api.Module, _ api.FunctionDefinition, _ []uint64, _ experimental.StackIterator) {
+		if tank, ok := ctx.Value(fuelKey{}).(*fuelTank); ok {
+			tank.spend(ctx)
+		}
+	})
+}
+
+func (r wasmRuntime) Evaluate(ctx context.Context, module []byte, input []byte, limits ScriptLimits) (string, error) {
+	pages := limits.Memory / wasmPageSize
+	if pages < 1 {
+		pages = 1
+	} else if pages > maxWasmPages {
+		pages = maxWasmPages
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	fuel := int64(math.MaxInt64)
+	if limits.Fuel > 0 && limits.Fuel < math.MaxInt64 {
+		fuel = int64(limits.Fuel)
+	}
+
+	tank := &fuelTank{stop: cancel}
+	tank.remaining.Store(fuel)
+
+	config := wazero.NewRuntimeConfig().
+		WithMemoryLimitPages(uint32(pages)).
+		WithCloseOnContextDone(true).
+		WithCompilationCache(r.cache)
+
+	runtime := wazero.NewRuntimeWithConfig(ctx, config)
+	defer runtime.Close(context.Background())
+
+	compiled, err := runtime.CompileModule(context.WithValue(ctx, experimental.FunctionListenerFactoryKey{}, fuelMeter{}), module)
+	if err != nil {
+		return "", fmt.Errorf("failed to compile the module: %v", err)
+	}
+
+	ctx = context.WithValue(ctx, fuelKey{}, tank)
+	instance, err := runtime.InstantiateModule(ctx, compiled, wazero.NewModuleConfig().WithName(""))
+	if err != nil {
+		return "", tank.failure(ctx, fmt.Errorf("failed to instantiate the module: %v", err))
+	}
+
+	memory := instance.Memory()
+	alloc, validate := instance.ExportedFunction("alloc"), instance.ExportedFunction("validate")
+	if memory == nil || alloc == nil || validate == nil {
+		return "", fmt.Errorf("the module doesn't export its memory, alloc and validate")
+	}
+
+	results, err := alloc.Call(ctx, uint64(len(input)))
+	if err != nil {
+		return "", tank.failure(ctx, fmt.Errorf("failed to allocate the input: %v", err))
+	}
+
+	pointer := uint32(results[0])
+	if !memory.Write(pointer, input) {
+		return "", fmt.Errorf("the input doesn't fit in the memory of the module")
+	}
+
+	results, err = validate.Call(ctx, uint64(pointer), uint64(len(input)))
+	if err != nil {
+		return "", tank.failure(ctx, fmt.Errorf("failed to validate: %v", err))
+	}
+
+	size := uint32(results[0])
+	if size == 0 {
+		return "", nil
+	}
+
+	reason, ok := memory.Read(0, size)
+	if !ok {
+		return "", fmt.Errorf("the reason of the script is out of its memory")
+	}
+
+	return string(reason), nil
+}
+
+// Gives the error of a failed evaluation, telling when it ran out of fuel or time
+func (t *fuelTank) failure(ctx context.Context, err error) error {
+	if t.exhausted.Load() {
+		return ErrOutOfFuel
+	}
+
+	if ctx.Err() != nil {
+		return fmt.Errorf("the script didn't finish in time: %v", ctx.Err())
+	}
+
+	return err
+}
@@ -0,0 +1,68 @@
+package node
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	config "node/config"
+	timeutil "node/timeutil"
+	"time"
+)
+
+/*
+A webhook event is a warning that the node sends to the operators whenever something
+needs attention (e.g. the disk is running out of space).
+
+The events are posted as JSON to every address listed in WEBHOOK_URLS by the webhook workers (please,
+go to `workers.go`). The delivery is best-effort: a failed delivery is only logged and never blocks
+the node.
+*/
+type WebhookEvent struct {
+	Event     string                 `json:"event"`     // The kind of the event
+	Host      string                 `json:"host"`      // The host address from the node that emitted the event
+	Timestamp int64                  `json:"timestamp"` // The timestamp when the event was emitted
+	Data      map[string]interface{} `json:"data"`      // The details of the event
+}
+
+// Posts an event to all the configured webhooks in background
+func (n Node) NotifyWebhooks(ctx context.Context, event string, data map[string]interface{}) {
+	urls := config.WebhookURLs()
+	if len(urls) == 0 {
+		return
+	}
+
+	payload, err := json.Marshal(WebhookEvent{
+		Event:     event,
+		Host:      n.Host,
+		Timestamp: timeutil.Now(),
+		Data:      data,
+	})
+	if err != nil {
+		Warnf(ctx, "failed to marshal the webhook event: %v", err)
+		return
+	}
+
+	for _, url := range urls {
+		url := url
+		webhookPool.Go(ctx, func(ctx context.Context) {
+			deliverWebhook(ctx, url, payload)
+		})
+	}
+}
+
+// Posts an event to a webhook
+func deliverWebhook(ctx context.Context, url string, payload []byte) {
+	client := http.Client{Timeout: 5 * time.Second}
+
+	res, err := client.Post(url, "application/json", bytes.NewBuffer(payload))
+	if err != nil {
+		Logf(ctx, "failed to deliver the webhook event to %s: %v", url, err)
+		return
+	}
+	res.Body.Close()
+
+	if res.StatusCode >= 300 {
+		Logf(ctx, "failed to deliver the webhook event to %s: %s", url, res.Status)
+	}
+}
@@ -0,0 +1,172 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	backlog "node/backlog"
+	config "node/config"
+	"sync"
+)
+
+/*
+A subsystem is a part of the node that works in the background, apart from the requests (e.g. the
+delivery of the webhooks). The subsystems are started together with the node and stopped in order
+when it shuts down, each one finishing the work it accepted before the next one stops, so the work
+of the first ones (e.g. a block announcement) can still rely on the last ones (e.g. the webhooks).
+*/
+type Subsystem interface {
+	Name() string                   // The name of the subsystem, used in the logs and in the configuration
+	Start(ctx context.Context)      // Starts the subsystem, that works until it's stopped
+	Stop(ctx context.Context) error // Stops accepting work and waits the accepted work to finish, until the context is done
+}
+
+var ErrPoolStopped = errors.New("the worker pool isn't running")
+
+/*
+A worker pool runs the tasks submitted to it with a fixed number of workers, instead of a routine by
+task, so a burst of work (e.g. a block for hundreds of peers) doesn't exhaust the node. The tasks wait
+in a queue while all the workers are busy, and the submission blocks while the queue is full.
+
+The number of workers of every pool can be configured (please, go to `WorkerPoolSize` in the config).
+*/
+type WorkerPool struct {
+	name    string
+	workers int // The number of workers when it isn't configured
+	queue   chan func(ctx context.Context)
+	running sync.WaitGroup
+	mutex   sync.RWMutex
+	started bool
+}
+
+// Creates a worker pool with the default number of workers and the size of its queue
+func NewWorkerPool(name string, workers, queue int) *WorkerPool {
+	return &WorkerPool{name: name, workers: workers, queue: make(chan func(ctx context.Context), queue)}
+}
+
+func (p *WorkerPool) Name() string {
+	return p.name
+}
+
+// Starts the workers. The tasks run with the given context, that must outlive the pool
+func (p *WorkerPool) Start(ctx context.Context) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.started {
+		return
+	}
+
+	p.started = true
+	p.queue = make(chan func(ctx context.Context), cap(p.queue))
+
+	for i := 0; i < config.WorkerPoolSize(p.name, p.workers); i++ {
+		p.running.Add(1)
+
+		go func(queue chan func(ctx context.Context)) {
+			defer p.running.Done()
+
+			for task := range queue {
+				task(ctx)
+			}
+		}(p.queue)
+	}
+}
+
+// Queues a task. Gives ErrPoolStopped when the pool isn't running, and the context error when it's
+// done while the queue is full
+func (p *WorkerPool) Submit(ctx context.Context, task func(ctx context.Context)) error {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	if !p.started {
+		return fmt.Errorf("%w: %s", ErrPoolStopped, p.name)
+	}
+
+	select {
+	case p.queue <- task:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Queues a task that outlives the request, keeping its correlation id (please, go to `Detach`). The
+// task is dropped with a log when the pool refuses it, and right away in a dry run (e.g. a webhook
+// or an announcement to the peers), since it would reach out of the node
+func (p *WorkerPool) Go(ctx context.Context, task func(ctx context.Context)) {
+	if backlog.DryRunOf(ctx) != nil {
+		return
+	}
+
+	id := CorrelationId(ctx)
+
+	err := p.Submit(ctx, func(poolCtx context.Context) {
+		task(WithCorrelationId(poolCtx, id))
+	})
+	if err != nil {
+		Logf(ctx, "failed to queue a task in the %s pool: %v", p.name, err)
+	}
+}
+
+func (p *WorkerPool) Stop(ctx context.Context) error {
+	// A submission waiting for room in the queue holds the read lock, and the workers keep taking the
+	// tasks, so the lock is taken once the queue has room
+	p.mutex.Lock()
+	if !p.started {
+		p.mutex.Unlock()
+		return nil
+	}
+
+	p.started = false
+	close(p.queue)
+	p.mutex.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		p.running.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("failed to drain the %s pool: %v", p.name, ctx.Err())
+	}
+}
+
+// The pools of the node
+var (
+	outboxPool       = NewWorkerPool("outbox", 4, 256)      // Delivers the transactions and the acknowledgments to other nodes and devices
+	gossipPool       = NewWorkerPool("gossip", 8, 256)      // Announces the blocks to the peers
+	verificationPool = NewWorkerPool("verification", 4, 64) // Verifies the signatures of the transactions
+	webhookPool      = NewWorkerPool("webhook", 2, 256)     // Posts the events to the webhooks
+)
+
+// The subsystems in the order they're stopped: the ones that produce work for the others first
+var subsystems = []Subsystem{outboxPool, gossipPool, verificationPool, webhookPool}
+
+// Starts all the subsystems
+func (n Node) StartSubsystems(ctx context.Context) {
+	for i := len(subsystems) - 1; i >= 0; i-- {
+		subsystems[i].Start(ctx)
+	}
+}
+
+// Stops all the subsystems in order, draining the work each one accepted, until the context is done
+func (n Node) StopSubsystems(ctx context.Context) error {
+	var failures []error
+
+	for _, subsystem := range subsystems {
+		if err := subsystem.Stop(ctx); err != nil {
+			failures = append(failures, err)
+		}
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("failed to stop the subsystems: %v", failures)
+	}
+
+	return nil
+}
@@ -0,0 +1,180 @@
+package node
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+A schedule tells when a recurring job runs next. It's written as a cron expression with five
+fields (minute, hour, day of the month, month and day of the week), evaluated in UTC, or as
+`@every <duration>` for the jobs that run in a fixed interval (e.g. `@every 30s`).
+
+The fields accept `*`, single values, ranges (`1-5`), steps (`0-30/10`, over `*` for the whole range
+or from a single value to the end of it, as `5/10`) and lists of them (`1,15,30`). As in cron, when
+both the day of the month and the day of the week are restricted, a day matches when either of them
+does. The shortcuts `@hourly`, `@daily` and `@weekly` are accepted.
+*/
+type Schedule interface {
+	Next(t time.Time) time.Time // Gives the first time after t when the schedule fires (zero when never)
+}
+
+// A schedule that fires in a fixed interval
+type every time.Duration
+
+func (e every) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(e))
+}
+
+// A schedule parsed from a cron expression. Every field is a bit set of the values it matches
+type cron struct {
+	minute, hour, day, month, weekday uint64
+	anyDay, anyWeekday                bool
+}
+
+var cronShortcuts = map[string]string{
+	"@hourly": "0 * * * *",
+	"@daily":  "0 0 * * *",
+	"@weekly": "0 0 * * 0",
+}
+
+// Parses a cron expression or an interval into a schedule
+func ParseSchedule(expression string) (Schedule, error) {
+	expression = strings.TrimSpace(expression)
+
+	if interval, ok := strings.CutPrefix(expression, "@every "); ok {
+		d, err := time.ParseDuration(strings.TrimSpace(interval))
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid schedule %q: the interval must be a positive duration", expression)
+		}
+
+		return every(d), nil
+	}
+
+	if shortcut, ok := cronShortcuts[expression]; ok {
+		expression = shortcut
+	}
+
+	fields := strings.Fields(expression)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid schedule %q: a cron expression has 5 fields", expression)
+	}
+
+	var schedule cron
+	var err error
+
+	bounds := []struct {
+		target   *uint64
+		min, max int
+	}{
+		{&schedule.minute, 0, 59},
+		{&schedule.hour, 0, 23},
+		{&schedule.day, 1, 31},
+		{&schedule.month, 1, 12},
+		{&schedule.weekday, 0, 7},
+	}
+
+	for i, bound := range bounds {
+		if *bound.target, err = parseCronField(fields[i], bound.min, bound.max); err != nil {
+			return nil, fmt.Errorf("invalid schedule %q: %v", expression, err)
+		}
+	}
+
+	// Sunday is both 0 and 7
+	if schedule.weekday&(1<<7) != 0 {
+		schedule.weekday |= 1
+	}
+
+	schedule.anyDay = fields[2] == "*"
+	schedule.anyWeekday = fields[4] == "*"
+
+	return schedule, nil
+}
+
+// Parses a field of a cron expression into the bit set of the values it matches
+func parseCronField(field string, min, max int) (uint64, error) {
+	var bits uint64
+
+	for _, part := range strings.Split(field, ",") {
+		step, stepped := 1, false
+		if base, value, ok := strings.Cut(part, "/"); ok {
+			var err error
+			if step, err = strconv.Atoi(value); err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step in %q", part)
+			}
+			part, stepped = base, true
+		}
+
+		from, to := min, max
+		if part != "*" {
+			first, last, isRange := strings.Cut(part, "-")
+
+			var err error
+			if from, err = strconv.Atoi(first); err != nil {
+				return 0, fmt.Errorf("invalid value in %q", field)
+			}
+
+			// A step from a single value runs to the end of the range, as in cron
+			to = from
+			if stepped && !isRange {
+				to = max
+			}
+			if isRange {
+				if to, err = strconv.Atoi(last); err != nil {
+					return 0, fmt.Errorf("invalid range in %q", field)
+				}
+			}
+		}
+
+		if from < min || to > max || from > to {
+			return 0, fmt.Errorf("the values of %q must be between %d and %d", field, min, max)
+		}
+
+		for value := from; value <= to; value += step {
+			bits |= 1 << value
+		}
+	}
+
+	return bits, nil
+}
+
+// Checks if the day matches the day of the month and the day of the week
+func (c cron) matchesDay(t time.Time) bool {
+	day := c.day&(1<<t.Day()) != 0
+	weekday := c.weekday&(1<<t.Weekday()) != 0
+
+	switch {
+	case c.anyDay && c.anyWeekday:
+		return true
+	case c.anyDay:
+		return weekday
+	case c.anyWeekday:
+		return day
+	default:
+		return day || weekday
+	}
+}
+
+func (c cron) Next(t time.Time) time.Time {
+	t = t.UTC().Truncate(time.Minute).Add(time.Minute)
+
+	// An expression that never matches (e.g. the 31st of February) gives up after some years
+	for limit := t.AddDate(5, 0, 0); t.Before(limit); {
+		switch {
+		case c.month&(1<<t.Month()) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+		case !c.matchesDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC)
+		case c.hour&(1<<t.Hour()) == 0:
+			t = t.Truncate(time.Hour).Add(time.Hour)
+		case c.minute&(1<<t.Minute()) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+
+	return time.Time{}
+}
@@ -0,0 +1,83 @@
+package node
+
+import (
+	"fmt"
+	"time"
+)
+
+/*
+The timeutil standardizes how the node serializes the timestamps.
+
+Every timestamp stored in the backlog or sent through the gRPC API is an integer with the
+milliseconds since the Unix epoch (UTC). The backlog maps those fields as `date` with the
+`epoch_millis` format, so they can be queried by range. When a timestamp must be readable
+by humans (e.g. in logs and exports), it's formatted as RFC3339.
+*/
+
+// The ElasticSearch mapping of a timestamp field
+var Mapping = map[string]interface{}{
+	"type":   "date",
+	"format": "epoch_millis",
+}
+
+// Timestamps below this value were written in seconds by older versions of the node
+const secondsThreshold int64 = 100_000_000_000
+
+// Gives the current timestamp in milliseconds
+func Now() int64 {
+	return time.Now().UnixMilli()
+}
+
+// Converts a time to a timestamp in milliseconds
+func FromTime(t time.Time) int64 {
+	return t.UnixMilli()
+}
+
+// Converts a timestamp in milliseconds to a time
+func ToTime(timestamp int64) time.Time {
+	return time.UnixMilli(timestamp).UTC()
+}
+
+// Gives the timestamp of a duration after now
+func After(d time.Duration) int64 {
+	return time.Now().Add(d).UnixMilli()
+}
+
+// Formats a timestamp in milliseconds as RFC3339
+func Format(timestamp int64) string {
+	return ToTime(timestamp).Format(time.RFC3339Nano)
+}
+
+// Parses a RFC3339 time into a timestamp in milliseconds
+func Parse(value string) (int64, error) {
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return 0, err
+	}
+
+	return t.UnixMilli(), nil
+}
+
+// Converts a timestamp that could have been written in seconds into milliseconds
+func Normalize(timestamp int64) int64 {
+	if timestamp != 0 && timestamp < secondsThreshold && timestamp > -secondsThreshold {
+		return timestamp * 1000
+	}
+
+	return timestamp
+}
+
+// Gives the painless script that migrates some timestamp fields from seconds to milliseconds
+func MigrationScript(fields ...string) string {
+	script := ""
+
+	for _, field := range fields {
+		value := fmt.Sprintf("ctx._source['%s']", field)
+		script += fmt.Sprintf(
+			"if (%s instanceof Number && %s != 0 && Math.abs(%s) < %dL) { %s = ((Number) %s).longValue() * 1000L; } ",
+			value, value, value, secondsThreshold, value, value,
+		)
+	}
+
+	return script
+}
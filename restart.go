@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	config "node/config"
+	"node/node"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+// How long the former process waits for its successor before giving up the restart
+const handoverTimeout time.Duration = 2 * time.Minute
+
+// Gives the listening socket of the server, inherited from the former process when the node is
+// restarting without downtime. Tells whether the socket was inherited
+func listen(cfg *config.Config) (net.Listener, bool, error) {
+	if fd, ok := config.InheritedListenerFd(); ok {
+		listener, err := net.FileListener(os.NewFile(fd, "listener"))
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to inherit the listener: %v", err)
+		}
+
+		return listener, true, nil
+	}
+
+	listener, err := net.Listen("tcp", net.JoinHostPort(cfg.ListenAddress, cfg.Port))
+	return listener, false, err
+}
+
+// Starts a new process of the node binary that inherits the listening socket and the handover token
+func startSuccessor(listener net.Listener, token string) (*os.Process, error) {
+	tcpListener, ok := listener.(*net.TCPListener)
+	if !ok {
+		return nil, fmt.Errorf("the listener can't be handed over")
+	}
+
+	file, err := tcpListener.File()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the listener file: %v", err)
+	}
+	defer file.Close()
+
+	executable, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find the node binary: %v", err)
+	}
+
+	// The extra files start at the descriptor 3 (after stdin, stdout and stderr)
+	cmd := exec.Command(executable, os.Args[1:]...)
+	cmd.Env = append(os.Environ(),
+		fmt.Sprintf("%s=%d", config.ListenerFdEnv, 3),
+		fmt.Sprintf("%s=%s", config.HandoverTokenEnv, token),
+	)
+	cmd.ExtraFiles = []*os.File{file}
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start the successor: %v", err)
+	}
+
+	return cmd.Process, nil
+}
+
+// Restarts the node without downtime whenever the process receives SIGUSR2: the successor takes
+// the listening socket and, once it's ready, the current process finishes its calls and exits
+// without detaching the node
+func registerRestartHandler(ctx context.Context, n *node.Node, listener net.Listener, server *grpc.Server, stop func()) {
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, syscall.SIGUSR2)
+
+	go func() {
+		for range c {
+			token, err := node.NewHandoverToken()
+			if err != nil {
+				fmt.Printf("failed to restart the node: %v\n", err)
+				continue
+			}
+
+			successor, err := startSuccessor(listener, token)
+			if err != nil {
+				fmt.Printf("failed to restart the node: %v\n", err)
+				continue
+			}
+
+			if err := n.AwaitHandover(ctx, token, successor.Pid, handoverTimeout); err != nil {
+				fmt.Printf("failed to restart the node: %v\n", err)
+				successor.Kill()
+				continue
+			}
+
+			fmt.Printf("Handed the node over to the process %d\n", successor.Pid)
+			stop()
+			stopServer(server)
+
+			// The successor takes the duties and the background work right away
+			if err := n.ReleaseLeases(context.Background()); err != nil {
+				fmt.Printf("failed to release the leases: %v\n", err)
+			}
+
+			drain, cancelDrain := context.WithTimeout(context.Background(), subsystemsDrainTimeout)
+			if err := n.StopSubsystems(drain); err != nil {
+				fmt.Printf("%v\n", err)
+			}
+			cancelDrain()
+
+			os.Exit(0)
+		}
+	}()
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"node/node"
+	"os"
+)
+
+// Publishes a module as the next version of a validation script. Gives the exit code of the command
+func runScript(ctx context.Context, args []string) int {
+	if len(args) < 2 {
+		fmt.Println("usage: meander script <name> <module file> [flags]")
+		return 2
+	}
+
+	module, err := os.ReadFile(args[1])
+	if err != nil {
+		fmt.Printf("failed to read the module: %v\n", err)
+		return 1
+	}
+
+	local, err := node.NewLocalNode(ctx, parseFlags(args[2:]))
+	if err != nil {
+		fmt.Printf("failed to create the node: %v\n", err)
+		return 1
+	}
+
+	if err := local.Initialize(ctx); err != nil {
+		fmt.Printf("failed to initialize the backlog: %v\n", err)
+		return 1
+	}
+
+	script, err := local.PublishScript(ctx, args[0], module)
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+
+	fmt.Printf("Published the version %d of the script %s (%s)\n", script.Version, script.Name, script.Digest)
+	return 0
+}
@@ -0,0 +1,228 @@
+package pb
+
+import (
+	"context"
+	node "node/node"
+
+	"google.golang.org/grpc/codes"
+)
+
+/*
+The admin API tunes the node at runtime, without a restart. Its calls are authenticated by the
+ADMIN_TOKEN (or one of the ADMIN_TOKENS of the named admins) of the node, sent in the `x-admin-token`
+metadata (please, go to `authenticateAdmin`). The destructive operations need the approval of a
+second admin (please, go to `approval.go` in the node package).
+*/
+type MeanderAdminServer struct {
+	UnimplementedMeanderAdminIOServer
+}
+
+// Gives the logging settings in use as a response
+func loggingResponse() *Logging {
+	state := node.Logging()
+	return &Logging{Level: state.Level.String(), Debug: state.Debug, TraceSampling: state.TraceSampling}
+}
+
+func (s *MeanderAdminServer) GetLogging(ctx context.Context, p *LoggingQuery) (*Logging, error) {
+	return loggingResponse(), nil
+}
+
+func (s *MeanderAdminServer) SetLogging(ctx context.Context, p *LoggingPayload) (*Logging, error) {
+	// Everything is checked before anything changes, so a bad payload changes nothing
+	level := node.Logging().Level
+	if p.Level != "" {
+		parsed, err := node.ParseLogLevel(p.Level)
+		if err != nil {
+			return nil, statusError(codes.InvalidArgument, ReasonInvalidPayload, "%v", err)
+		}
+		level = parsed
+	}
+
+	if p.TraceSampling != nil && (*p.TraceSampling < 0 || *p.TraceSampling > 1) {
+		return nil, statusError(codes.InvalidArgument, ReasonInvalidPayload, "the trace sampling must be between 0 and 1")
+	}
+
+	for _, subsystem := range append(p.Debug, p.Quiet...) {
+		if !node.IsSubsystem(subsystem) {
+			return nil, statusError(codes.InvalidArgument, ReasonInvalidPayload, "unknown subsystem %q", subsystem)
+		}
+	}
+
+	node.SetLogLevel(level)
+	for _, subsystem := range p.Debug {
+		node.SetSubsystemDebug(subsystem, true)
+	}
+	for _, subsystem := range p.Quiet {
+		node.SetSubsystemDebug(subsystem, false)
+	}
+	if p.TraceSampling != nil {
+		node.SetTraceSampling(*p.TraceSampling)
+	}
+
+	state := loggingResponse()
+	node.Logf(ctx, "the logging changed: level %s, debugging %v, tracing %v of the requests", state.Level, state.Debug, state.TraceSampling)
+
+	return state, nil
+}
+
+func (s *MeanderAdminServer) ImportClients(p *ClientManifest, stream MeanderAdminIO_ImportClientsServer) error {
+	if len(p.Entries) == 0 {
+		return statusError(codes.InvalidArgument, ReasonInvalidPayload, "import clients request requires at least one entry")
+	}
+
+	ctx := stream.Context()
+	local, err := localNode(ctx)
+	if err != nil {
+		return err
+	}
+
+	entries := make([]node.ClientImport, 0, len(p.Entries))
+	for _, entry := range p.Entries {
+		entries = append(entries, node.ClientImport{
+			Alias:        entry.Alias,
+			Password:     entry.Password,
+			Secret:       entry.Secret,
+			PublicKey:    entry.PublicKey,
+			PrivateKey:   entry.PrivateKey,
+			Generate:     entry.Generate,
+			TermsVersion: entry.TermsVersion,
+		})
+	}
+
+	err = local.ImportClients(ctx, entries, func(progress node.ImportProgress) error {
+		message := ImportProgress{Done: int32(progress.Done), Total: int32(progress.Total), Failed: int32(progress.Failed)}
+		for _, result := range progress.Results {
+			message.Results = append(message.Results, &ImportResult{
+				Alias:    result.Alias,
+				UserId:   result.UID,
+				ClientId: result.ClientId,
+				Error:    result.Error,
+			})
+		}
+
+		return stream.Send(&message)
+	})
+	if err != nil {
+		return nodeStatusError(err)
+	}
+
+	return nil
+}
+
+// Converts an admin request of the node into its gRPC message
+func operationRequestMessage(request node.AdminRequest) *OperationRequest {
+	return &OperationRequest{
+		RequestId:   request.Id,
+		Operation:   request.Operation,
+		Args:        request.Args,
+		RequestedBy: request.RequestedBy,
+		RequestedAt: request.RequestedAt,
+		ExpiresAt:   request.ExpiresAt,
+		Status:      request.Status,
+		DecidedBy:   request.DecidedBy,
+		DecidedAt:   request.DecidedAt,
+		Summary:     request.Summary,
+		Error:       request.Error,
+	}
+}
+
+func (s *MeanderAdminServer) RequestOperation(ctx context.Context, p *OperationPayload) (*OperationRequest, error) {
+	if p.Operation == "" {
+		return nil, statusError(codes.InvalidArgument, ReasonInvalidPayload, "request operation requires an operation")
+	}
+
+	local, err := localNode(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	request, err := local.RequestAdminOperation(ctx, authenticatedAdmin(ctx), p.Operation, p.Args)
+	if err != nil {
+		return nil, nodeStatusError(err)
+	}
+
+	return operationRequestMessage(*request), nil
+}
+
+func (s *MeanderAdminServer) ApproveOperation(ctx context.Context, p *OperationDecision) (*OperationRequest, error) {
+	if p.RequestId == "" {
+		return nil, statusError(codes.InvalidArgument, ReasonInvalidPayload, "approve operation requires the request id")
+	}
+
+	local, err := localNode(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	request, err := local.ApproveAdminRequest(ctx, authenticatedAdmin(ctx), p.RequestId)
+	if err != nil {
+		return nil, nodeStatusError(err)
+	}
+
+	return operationRequestMessage(*request), nil
+}
+
+func (s *MeanderAdminServer) RejectOperation(ctx context.Context, p *OperationDecision) (*OperationRequest, error) {
+	if p.RequestId == "" {
+		return nil, statusError(codes.InvalidArgument, ReasonInvalidPayload, "reject operation requires the request id")
+	}
+
+	local, err := localNode(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	request, err := local.RejectAdminRequest(ctx, authenticatedAdmin(ctx), p.RequestId)
+	if err != nil {
+		return nil, nodeStatusError(err)
+	}
+
+	return operationRequestMessage(*request), nil
+}
+
+func (s *MeanderAdminServer) ListOperations(ctx context.Context, p *OperationQuery) (*OperationRequestList, error) {
+	local, err := localNode(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	requests, err := local.AdminRequests(ctx, p.Status)
+	if err != nil {
+		return nil, nodeStatusError(err)
+	}
+
+	response := OperationRequestList{}
+	for _, request := range requests {
+		response.Requests = append(response.Requests, operationRequestMessage(request))
+	}
+
+	return &response, nil
+}
+
+func (s *MeanderAdminServer) ScheduleMaintenance(ctx context.Context, p *Maintenance) (*Maintenance, error) {
+	local, err := localNode(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	window := nodeMaintenance(p)
+	if err := local.ScheduleMaintenance(ctx, *window); err != nil {
+		return nil, nodeStatusError(err)
+	}
+
+	node.Logf(ctx, "the admin %s scheduled a maintenance window until %d", authenticatedAdmin(ctx), window.EndsAt)
+	return maintenanceMessage(window), nil
+}
+
+func (s *MeanderAdminServer) CancelMaintenance(ctx context.Context, p *MaintenanceQuery) (*Commit, error) {
+	local, err := localNode(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := local.CancelMaintenance(ctx); err != nil {
+		return nil, nodeStatusError(err)
+	}
+
+	return &Commit{Status: 0}, nil
+}
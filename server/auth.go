@@ -0,0 +1,288 @@
+package pb
+
+import (
+	"context"
+	"crypto/subtle"
+	backlog "node/backlog"
+	config "node/config"
+	node "node/node"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+)
+
+// The metadata keys that carry the credentials of a local client
+const (
+	userIdHeader string = "x-user-id"
+	secretHeader string = "x-secret"
+	tokenHeader  string = "x-token"
+)
+
+// The metadata key that carries the token of the admin API
+const adminTokenHeader string = "x-admin-token"
+
+// The payloads of the requests made on behalf of a local client, that carry its credentials
+type credentials interface {
+	GetUserId() string
+	GetSecret() string
+	GetToken() string
+}
+
+// The key of the authenticated client in the request context
+type clientKey struct{}
+
+// The key of the name of the authenticated admin in the request context
+type adminKey struct{}
+
+// The methods of the clients API served without the credentials of a client. The credentials of
+// the batches are validated by item, and an expired token is only accepted to be refreshed
+var publicMethods = map[string]bool{
+	MeanderClientIO_CreateClient_FullMethodName:        true,
+	MeanderClientIO_ConnectClient_FullMethodName:       true,
+	MeanderClientIO_ValidateTokens_FullMethodName:      true,
+	MeanderClientIO_RefreshToken_FullMethodName:        true,
+	MeanderClientIO_ReplayEvents_FullMethodName:        true,
+	MeanderClientIO_GetMetrics_FullMethodName:          true,
+	MeanderClientIO_VerifyChain_FullMethodName:         true,
+	MeanderClientIO_ListNodes_FullMethodName:           true,
+	MeanderClientIO_GetAcknowledgment_FullMethodName:   true,
+	MeanderClientIO_ExportCustody_FullMethodName:       true,
+	MeanderClientIO_GetTransactionProof_FullMethodName: true,
+	MeanderClientIO_GetNodeStats_FullMethodName:        true,
+	MeanderClientIO_GetHealth_FullMethodName:           true,
+	MeanderClientIO_GetPublicKey_FullMethodName:        true,
+	MeanderClientIO_GetKeyLog_FullMethodName:           true,
+}
+
+// The methods that are authenticated only when the request carries credentials
+var optionalAuthMethods = map[string]bool{
+	MeanderClientIO_GetBalance_FullMethodName: true,
+}
+
+// Gives the credentials of a request, from the metadata or else from the payload
+func requestCredentials(ctx context.Context, req interface{}) (uid, secret, token string) {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(userIdHeader); len(values) > 0 {
+			uid = values[0]
+		}
+		if values := md.Get(secretHeader); len(values) > 0 {
+			secret = values[0]
+		}
+		if values := md.Get(tokenHeader); len(values) > 0 {
+			token = values[0]
+		}
+	}
+
+	if p, ok := req.(credentials); ok && uid == "" {
+		uid, secret, token = p.GetUserId(), p.GetSecret(), p.GetToken()
+	}
+
+	return uid, secret, token
+}
+
+// Validates the credentials of a request and gives the context with the authenticated client. The
+// error is a gRPC status error ready to be returned
+func authenticate(ctx context.Context, method string, req interface{}) (context.Context, error) {
+	if strings.HasPrefix(method, "/"+MeanderAdminIO_ServiceDesc.ServiceName+"/") {
+		admin, err := authenticateAdmin(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		return context.WithValue(ctx, adminKey{}, admin), nil
+	}
+
+	if !strings.HasPrefix(method, "/"+MeanderClientIO_ServiceDesc.ServiceName+"/") {
+		return ctx, nil
+	}
+
+	// The calls of the clients read their own writes (please, go to `session.go` in the backlog)
+	if publicMethods[method] {
+		return backlog.WithSession(ctx, ""), nil
+	}
+
+	uid, secret, token := requestCredentials(ctx, req)
+	if uid == "" && optionalAuthMethods[method] {
+		return backlog.WithSession(ctx, ""), nil
+	}
+
+	if uid == "" || token == "" {
+		return nil, statusError(codes.Unauthenticated, ReasonInvalidToken, "the request requires the credentials of a client")
+	}
+
+	local, err := localNode(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := verifyToken(ctx, local, uid, secret, token); err != nil {
+		return nil, err
+	}
+
+	owner, err := local.LoadClient(ctx, uid, secret)
+	if err != nil {
+		return nil, statusError(codes.Unauthenticated, ReasonInvalidToken, "failed to load the client: %v", err)
+	}
+
+	return context.WithValue(backlog.WithSession(ctx, uid), clientKey{}, owner), nil
+}
+
+// Checks the admin token of a call to the admin API and gives the name of its admin. The API is
+// disabled when the node has no admin tokens
+func authenticateAdmin(ctx context.Context) (string, error) {
+	tokens := config.AdminTokens()
+	if len(tokens) == 0 {
+		return "", statusError(codes.PermissionDenied, ReasonInvalidToken, "the admin API is disabled: neither %s nor %s is set", config.AdminTokenEnv, config.AdminTokensEnv)
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get(adminTokenHeader)
+
+	if len(values) > 0 {
+		for admin, token := range tokens {
+			if subtle.ConstantTimeCompare([]byte(values[0]), []byte(token)) == 1 {
+				return admin, nil
+			}
+		}
+	}
+
+	return "", statusError(codes.Unauthenticated, ReasonInvalidToken, "the request requires the admin token")
+}
+
+// Gives the name of the admin authenticated by the interceptors (empty out of the admin API)
+func authenticatedAdmin(ctx context.Context) string {
+	admin, _ := ctx.Value(adminKey{}).(string)
+	return admin
+}
+
+// Gives the client authenticated by the interceptors (nil when the method didn't require one)
+func authenticatedClient(ctx context.Context) *node.Client {
+	owner, _ := ctx.Value(clientKey{}).(*node.Client)
+	return owner
+}
+
+// Authenticates every unary call made on behalf of a local client, so the handlers find the client
+// in their context
+func AuthInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, err := authenticate(ctx, info.FullMethod, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return handler(ctx, req)
+}
+
+// A stream authenticated when its first message is received, since the credentials may come in it
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx           context.Context
+	method        string
+	authenticated bool
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *authenticatedStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+
+	if s.authenticated {
+		return nil
+	}
+
+	ctx, err := authenticate(s.ctx, s.method, m)
+	if err != nil {
+		return err
+	}
+
+	s.ctx, s.authenticated = ctx, true
+	return nil
+}
+
+// Authenticates every stream opened on behalf of a local client
+func AuthStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return handler(srv, &authenticatedStream{ServerStream: ss, ctx: ss.Context(), method: info.FullMethod})
+}
+
+// Validates the token of some client. The error is a gRPC status error ready to be returned
+func validateToken(ctx context.Context, uid, secret, token string) error {
+	local, err := localNode(ctx)
+	if err != nil {
+		return err
+	}
+
+	return verifyToken(ctx, local, uid, secret, token)
+}
+
+// Validates the token of some client of the local node
+func verifyToken(ctx context.Context, local *node.Node, uid, secret, token string) error {
+	cache, err := verifyCredentials(ctx, local, uid, secret, token)
+	if err != nil {
+		return err
+	}
+
+	if node.TokenExpired(cache) {
+		return statusError(codes.Unauthenticated, ReasonTokenExpired, "the token has expired: please refresh it")
+	}
+
+	return nil
+}
+
+// Validates that the token carries the current credentials of some client of the local node and its
+// session didn't expire, however old the token is. Gives the cache document of the credentials
+func verifyCredentials(ctx context.Context, local *node.Node, uid, secret, token string) (map[string]interface{}, error) {
+	// The client may have been created by another process of the node
+	if err := local.RestoreClientKeys(ctx, uid); err != nil {
+		return nil, statusError(codes.Unauthenticated, ReasonInvalidToken, "failed to restore the client keys: %v", err)
+	}
+
+	keys, err := local.Keys.Get(ctx, uid)
+	if err != nil {
+		return nil, statusError(codes.Unauthenticated, ReasonInvalidToken, "failed to get the client keys: %v", err)
+	}
+
+	crypto, err := keys.Decode(secret)
+	if err != nil {
+		return nil, statusError(codes.Unauthenticated, ReasonInvalidToken, "failed to decode the client keys: %v", err)
+	}
+
+	payload, err := crypto.DecryptToken(token)
+	if err != nil {
+		return nil, statusError(codes.Unauthenticated, ReasonInvalidToken, "failed to decrypt the token: %v", err)
+	}
+
+	backlog, err := backlog.NewBacklog()
+	if err != nil {
+		return nil, statusError(codes.Unavailable, ReasonBacklog, "%v", err)
+	}
+
+	cache, err := backlog.GetDocument(ctx, "cache", uid)
+	if err != nil {
+		return nil, statusError(codes.Unauthenticated, ReasonInvalidToken, "failed to get cache document: %v", err)
+	}
+
+	if node.SessionExpired(cache) {
+		return nil, statusError(codes.Unauthenticated, ReasonSessionExpired, "the session has expired: please connect again")
+	}
+
+	cacheKeyA, _ := cache["computed_key_a"].(string)
+	tokenKeyA, _ := payload["computed_key_a"].(string)
+
+	if matchA := compareDigest([]byte(cacheKeyA), []byte(tokenKeyA)); !matchA || cacheKeyA == "" {
+		return nil, statusError(codes.Unauthenticated, ReasonInvalidToken, "the computed key A doesn't match")
+	}
+
+	cacheKeyP, _ := cache["computed_key_p"].(string)
+	tokenKeyP, _ := payload["computed_key_p"].(string)
+
+	if matchP := compareDigest([]byte(cacheKeyP), []byte(tokenKeyP)); !matchP || cacheKeyP == "" {
+		return nil, statusError(codes.Unauthenticated, ReasonInvalidToken, "the computed key P doesn't match")
+	}
+
+	return cache, nil
+}
@@ -0,0 +1,77 @@
+package pb
+
+import (
+	"context"
+	"sync"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	maxBatchSize     int   = 100 // The maximum number of items accepted in a single batch call
+	batchConcurrency int   = 8   // The number of items processed at the same time
+	commitSucceeded  int32 = 0   // Every item succeeded
+	commitFailed     int32 = 1   // Every item failed
+	commitPartial    int32 = 2   // Some items succeeded and some failed
+)
+
+// Builds the commit of a batch from the per-item results
+func batchCommit(items []*CommitItem) *Commit {
+	failures := 0
+	for _, item := range items {
+		if item.Status != commitSucceeded {
+			failures++
+		}
+	}
+
+	commit := Commit{Items: items}
+
+	switch {
+	case failures == 0:
+		commit.Status = commitSucceeded
+	case failures == len(items):
+		commit.Status = commitFailed
+	default:
+		commit.Status = commitPartial
+	}
+
+	return &commit
+}
+
+func (s *MeanderServer) ValidateTokens(ctx context.Context, p *ConnectionBatch) (*Commit, error) {
+	if len(p.Connections) == 0 {
+		return nil, statusError(codes.InvalidArgument, ReasonInvalidPayload, "validate tokens request requires at least one connection")
+	}
+
+	if len(p.Connections) > maxBatchSize {
+		return nil, statusError(codes.InvalidArgument, ReasonInvalidPayload, "validate tokens request accepts at most %d connections", maxBatchSize)
+	}
+
+	items := make([]*CommitItem, len(p.Connections))
+	slots := make(chan struct{}, batchConcurrency)
+	var wg sync.WaitGroup
+
+	for i, connection := range p.Connections {
+		wg.Add(1)
+		slots <- struct{}{}
+
+		go func(i int, connection *ConnectionPayload) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			item := CommitItem{Id: connection.UserId}
+			if err := validateToken(ctx, connection.UserId, connection.Secret, connection.Token); err != nil {
+				message := status.Convert(err).Message()
+				item.Status = commitFailed
+				item.Error = &message
+			}
+
+			items[i] = &item
+		}(i, connection)
+	}
+
+	wg.Wait()
+
+	return batchCommit(items), nil
+}
@@ -0,0 +1,92 @@
+package pb
+
+import (
+	"context"
+	"encoding/json"
+
+	"google.golang.org/grpc/codes"
+)
+
+func (s *MeanderServer) VerifyChain(ctx context.Context, p *VerifyPayload) (*ChainReport, error) {
+	if !fromTrustedGateway(ctx) {
+		return nil, statusError(codes.PermissionDenied, ReasonUntrustedPeer, "the chain can only be verified by trusted gateways")
+	}
+
+	node, err := localNode(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := node.VerifyChain(ctx)
+	if err != nil {
+		return nil, statusError(codes.Unavailable, ReasonBacklog, "failed to verify the chain: %v", err)
+	}
+
+	response := ChainReport{
+		Valid:  report.Valid,
+		Blocks: report.Blocks,
+	}
+
+	if !report.Valid {
+		response.CorruptedHeight = &report.CorruptedHeight
+		response.CorruptedHash = report.CorruptedHash
+		response.Reason = report.Reason
+	}
+
+	return &response, nil
+}
+
+func (s *MeanderServer) ExportCustody(ctx context.Context, p *CustodyQuery) (*CustodyFile, error) {
+	if p.TransactionId == "" {
+		return nil, statusError(codes.InvalidArgument, ReasonInvalidPayload, "export custody request requires: transaction_id")
+	}
+
+	if !fromTrustedGateway(ctx) {
+		return nil, statusError(codes.PermissionDenied, ReasonUntrustedPeer, "the custody can only be exported by trusted gateways")
+	}
+
+	node, err := localNode(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	bundle, err := node.ExportCustody(ctx, p.TransactionId)
+	if err != nil {
+		return nil, nodeStatusError(err)
+	}
+
+	content, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		return nil, statusError(codes.Internal, ReasonInternal, "failed to marshal the custody bundle: %v", err)
+	}
+
+	return &CustodyFile{Content: content}, nil
+}
+
+func (s *MeanderServer) GetTransactionProof(ctx context.Context, p *TransactionProofQuery) (*TransactionProof, error) {
+	if p.TransactionId == "" {
+		return nil, statusError(codes.InvalidArgument, ReasonInvalidPayload, "get transaction proof request requires: transaction_id")
+	}
+
+	node, err := localNode(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	proof, err := node.TransactionProof(ctx, p.TransactionId)
+	if err != nil {
+		return nil, nodeStatusError(err)
+	}
+
+	response := TransactionProof{
+		Transaction: blockTransactionMessage(proof.Transaction),
+		Header:      blockMessage(proof.Header),
+		Index:       int32(proof.Proof.Index),
+	}
+
+	for _, step := range proof.Proof.Path {
+		response.Path = append(response.Path, &MerkleStep{Hash: step.Hash, Left: step.Left})
+	}
+
+	return &response, nil
+}
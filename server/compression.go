@@ -0,0 +1,68 @@
+package pb
+
+import (
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/encoding/gzip"
+	"google.golang.org/grpc/status"
+)
+
+// The name of the zstd compressor in the `grpc-encoding` header
+const zstdName string = "zstd"
+
+/*
+The batches fetched by the sync are compressed with zstd (please, go to `compression.go` in the
+node). The peer answers with the compressor of the request, so every node registers it for the
+calls of the others. The nodes built before it only know gzip (still registered by its import), so
+the calls they refuse as unimplemented are made again with gzip.
+*/
+type zstdCompressor struct{}
+
+func init() {
+	encoding.RegisterCompressor(zstdCompressor{})
+}
+
+func (zstdCompressor) Name() string {
+	return zstdName
+}
+
+func (zstdCompressor) Compress(w io.Writer) (io.WriteCloser, error) {
+	return zstd.NewWriter(w, zstd.WithEncoderConcurrency(1))
+}
+
+func (zstdCompressor) Decompress(r io.Reader) (io.Reader, error) {
+	decoder, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+	if err != nil {
+		return nil, err
+	}
+
+	return zstdReader{decoder}, nil
+}
+
+// Releases the decoder once the message is read
+type zstdReader struct {
+	*zstd.Decoder
+}
+
+func (r zstdReader) Read(p []byte) (int, error) {
+	n, err := r.Decoder.Read(p)
+	if err == io.EOF {
+		r.Decoder.Close()
+	}
+
+	return n, err
+}
+
+// Makes a call of the sync compressed with zstd, or with gzip when the peer doesn't know zstd
+func syncCall(call func(compression grpc.CallOption) error) error {
+	err := call(grpc.UseCompressor(zstdName))
+	if status.Code(err) == codes.Unimplemented {
+		return call(grpc.UseCompressor(gzip.Name))
+	}
+
+	return err
+}
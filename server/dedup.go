@@ -0,0 +1,146 @@
+package pb
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	node "node/node"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+/*
+The mutating calls can carry a request id generated by the client in their `x-request-id`, kept
+the same across the retries of the call. A retry within the REQUEST_DEDUP_WINDOW is given the
+response of the first call instead of running again, so a flaky mobile connection never creates a
+client or a transaction twice (please, go to `dedup.go` in the node). A replayed response is flagged
+in the `x-request-replayed` header.
+
+The request ids are scoped by method and caller, so the ids of different clients never collide.
+*/
+var dedupMethods = map[string]bool{
+	MeanderClientIO_CreateClient_FullMethodName:           true,
+	MeanderClientIO_RegisterDevice_FullMethodName:         true,
+	MeanderClientIO_SubmitTransaction_FullMethodName:      true,
+	MeanderClientIO_AcknowledgeTransaction_FullMethodName: true,
+	MeanderClientIO_EraseClient_FullMethodName:            true,
+	MeanderClientIO_LiquidateClient_FullMethodName:        true,
+	MeanderClientIO_UpdateClient_FullMethodName:           true,
+	MeanderClientIO_AcceptTerms_FullMethodName:            true,
+}
+
+const (
+	requestIdHeader       string = "x-request-id"
+	requestReplayedHeader string = "x-request-replayed"
+)
+
+// The longest request id taken
+const maxRequestIdLength int = 128
+
+// Gives the request id of a call (empty when it carries none)
+func requestId(ctx context.Context) string {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(requestIdHeader); len(values) > 0 {
+			return values[0]
+		}
+	}
+
+	return ""
+}
+
+// Gives the hex SHA-256 of the parts, each one ended by a newline
+func digest(parts ...[]byte) string {
+	hash := sha256.New()
+	for _, part := range parts {
+		hash.Write(part)
+		hash.Write([]byte("\n"))
+	}
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// Replays the response of the first call to the retries of a mutating call with the same request id
+func DedupInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	id := requestId(ctx)
+	message, ok := req.(proto.Message)
+	if !dedupMethods[info.FullMethod] || id == "" || !ok {
+		return handler(ctx, req)
+	}
+
+	if len(id) > maxRequestIdLength {
+		return nil, statusError(codes.InvalidArgument, ReasonInvalidPayload, "the request id is longer than %d characters", maxRequestIdLength)
+	}
+
+	payload, err := proto.MarshalOptions{Deterministic: true}.Marshal(message)
+	if err != nil {
+		return nil, statusError(codes.InvalidArgument, ReasonInvalidPayload, "failed to read the request: %v", err)
+	}
+
+	uid, _, _ := requestCredentials(ctx, req)
+	key := digest([]byte(info.FullMethod), []byte(uid), []byte(id))
+
+	local, err := localNode(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	stored, err := local.ReserveRequest(ctx, key, digest(payload))
+	if err != nil {
+		return nil, nodeStatusError(err)
+	}
+
+	if stored != nil {
+		response := &anypb.Any{}
+		if err := proto.Unmarshal(stored, response); err != nil {
+			return nil, statusError(codes.Internal, ReasonInternal, "failed to read the stored response: %v", err)
+		}
+
+		replayed, err := response.UnmarshalNew()
+		if err != nil {
+			return nil, statusError(codes.Internal, ReasonInternal, "failed to read the stored response: %v", err)
+		}
+
+		grpc.SetHeader(ctx, metadata.Pairs(requestReplayedHeader, "true"))
+		return replayed, nil
+	}
+
+	resp, err := handler(ctx, req)
+	if err != nil {
+		if releaseErr := local.ReleaseRequest(ctx, key); releaseErr != nil {
+			node.Logf(ctx, "%s: %v", info.FullMethod, releaseErr)
+		}
+
+		return nil, err
+	}
+
+	if err := storeResponse(ctx, local, key, resp); err != nil {
+		// The call succeeded anyway, its retries are just refused while the id is reserved
+		node.Logf(ctx, "%s: %v", info.FullMethod, err)
+	}
+
+	return resp, nil
+}
+
+// Stores the response of a call for its retries
+func storeResponse(ctx context.Context, local *node.Node, key string, resp interface{}) error {
+	message, ok := resp.(proto.Message)
+	if !ok {
+		return local.ReleaseRequest(ctx, key)
+	}
+
+	response, err := anypb.New(message)
+	if err != nil {
+		return err
+	}
+
+	stored, err := proto.Marshal(response)
+	if err != nil {
+		return err
+	}
+
+	return local.CompleteRequest(ctx, key, stored)
+}
@@ -0,0 +1,33 @@
+package pb
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+)
+
+func (s *MeanderServer) RegisterDevice(ctx context.Context, p *DevicePayload) (*Device, error) {
+	if p.Platform == "" || p.DeviceToken == "" {
+		return nil, statusError(codes.InvalidArgument, ReasonInvalidPayload, "register device request requires: platform, device_token")
+	}
+
+	owner := authenticatedClient(ctx)
+	node := owner.Node
+
+	if err := node.CheckWritable(); err != nil {
+		return nil, statusError(codes.FailedPrecondition, ReasonReadOnly, "failed to register device: %v", err)
+	}
+
+	device, err := node.RegisterDevice(ctx, owner.UID, p.Platform, p.DeviceToken)
+	if err != nil {
+		return nil, statusError(codes.InvalidArgument, ReasonInvalidPayload, "failed to register device: %v", err)
+	}
+
+	response := Device{
+		Platform:     device.Platform,
+		DeviceToken:  device.Token,
+		RegisteredAt: device.RegisteredAt,
+	}
+
+	return &response, nil
+}
@@ -0,0 +1,122 @@
+package pb
+
+import (
+	"errors"
+	"fmt"
+	node "node/node"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// The domain of the typed error details sent by the node
+const errorDomain string = "meander"
+
+// The reasons sent in the typed error details, so the callers can handle the failures
+// without parsing the error messages
+const (
+	ReasonInvalidPayload     string = "INVALID_PAYLOAD"
+	ReasonInvalidAlias       string = "INVALID_ALIAS"
+	ReasonInvalidPassword    string = "INVALID_PASSWORD"
+	ReasonInvalidToken       string = "INVALID_TOKEN"
+	ReasonInvalidCredentials string = "INVALID_CREDENTIALS"
+	ReasonSessionExpired     string = "SESSION_EXPIRED"
+	ReasonTokenExpired       string = "TOKEN_EXPIRED"
+	ReasonNotFound           string = "NOT_FOUND"
+	ReasonInvalidSequence    string = "INVALID_SEQUENCE"
+	ReasonInvalidBlock       string = "INVALID_BLOCK"
+	ReasonReadOnly           string = "READ_ONLY"
+	ReasonUntrustedPeer      string = "UNTRUSTED_PEER"
+	ReasonIncompatiblePeer   string = "INCOMPATIBLE_PEER"
+	ReasonNotRecipient       string = "NOT_RECIPIENT"
+	ReasonSchemaDrift        string = "SCHEMA_DRIFT"
+	ReasonInsufficientFunds  string = "INSUFFICIENT_FUNDS"
+	ReasonFeeTooLow          string = "FEE_TOO_LOW"
+	ReasonRejected           string = "REJECTED"
+	ReasonTermsNotAccepted   string = "TERMS_NOT_ACCEPTED"
+	ReasonOverloaded         string = "OVERLOADED"
+	ReasonRateLimited        string = "RATE_LIMITED"
+	ReasonLiquidated         string = "LIQUIDATED"
+	ReasonApprovalRequired   string = "APPROVAL_REQUIRED"
+	ReasonDuplicateRequest   string = "DUPLICATE_REQUEST"
+	ReasonBacklog            string = "BACKLOG_FAILURE"
+	ReasonInternal           string = "INTERNAL"
+)
+
+// Gives a gRPC status error carrying the reason as an ErrorInfo detail
+func statusError(code codes.Code, reason string, format string, args ...interface{}) error {
+	st := status.New(code, fmt.Sprintf(format, args...))
+
+	detailed, err := st.WithDetails(&errdetails.ErrorInfo{
+		Reason: reason,
+		Domain: errorDomain,
+	})
+	if err != nil {
+		return st.Err()
+	}
+
+	return detailed.Err()
+}
+
+// Converts the known errors of the node into gRPC status errors. Unknown errors are taken as
+// backlog failures, since the backlog is the only external dependency of the node
+func nodeStatusError(err error) error {
+	switch {
+	case errors.Is(err, node.ErrAliasTaken):
+		return statusError(codes.AlreadyExists, ReasonInvalidAlias, "invalid alias: %v", err)
+	case errors.Is(err, node.ErrUnknownClient):
+		return statusError(codes.NotFound, ReasonNotFound, "not found: %v", err)
+	case errors.Is(err, node.ErrUnknownTransaction):
+		return statusError(codes.NotFound, ReasonNotFound, "not found: %v", err)
+	case errors.Is(err, node.ErrNotRecipient):
+		return statusError(codes.PermissionDenied, ReasonNotRecipient, "%v", err)
+	case errors.Is(err, node.ErrInvalidSequence):
+		return statusError(codes.Aborted, ReasonInvalidSequence, "%v", err)
+	case errors.Is(err, node.ErrInvalidFilter), errors.Is(err, node.ErrInvalidMemo), errors.Is(err, node.ErrInvalidPayload), errors.Is(err, node.ErrInvalidMetadata), errors.Is(err, node.ErrOutOfLog):
+		return statusError(codes.InvalidArgument, ReasonInvalidPayload, "%v", err)
+	case errors.Is(err, node.ErrRejected):
+		return statusError(codes.PermissionDenied, ReasonRejected, "%v", err)
+	case errors.Is(err, node.ErrInsufficientFunds):
+		return statusError(codes.FailedPrecondition, ReasonInsufficientFunds, "%v", err)
+	case errors.Is(err, node.ErrFeeTooLow):
+		return statusError(codes.FailedPrecondition, ReasonFeeTooLow, "%v", err)
+	case errors.Is(err, node.ErrRateLimited):
+		return statusError(codes.ResourceExhausted, ReasonRateLimited, "%v", err)
+	case errors.Is(err, node.ErrTermsNotAccepted):
+		return statusError(codes.FailedPrecondition, ReasonTermsNotAccepted, "%v", err)
+	case errors.Is(err, node.ErrReadOnly):
+		return statusError(codes.FailedPrecondition, ReasonReadOnly, "%v", err)
+	case errors.Is(err, node.ErrClientLiquidated):
+		return statusError(codes.FailedPrecondition, ReasonLiquidated, "%v", err)
+	case errors.Is(err, node.ErrInvalidCredentials):
+		return statusError(codes.Unauthenticated, ReasonInvalidCredentials, "%v", err)
+	case errors.Is(err, node.ErrIncompatiblePeer):
+		return statusError(codes.FailedPrecondition, ReasonIncompatiblePeer, "%v", err)
+	case errors.Is(err, node.ErrInvalidOperation), errors.Is(err, node.ErrInvalidWindow):
+		return statusError(codes.InvalidArgument, ReasonInvalidPayload, "%v", err)
+	case errors.Is(err, node.ErrUnknownRequest):
+		return statusError(codes.NotFound, ReasonNotFound, "not found: %v", err)
+	case errors.Is(err, node.ErrRequestDecided), errors.Is(err, node.ErrSelfApproval):
+		return statusError(codes.FailedPrecondition, ReasonApprovalRequired, "%v", err)
+	case errors.Is(err, node.ErrRequestInFlight):
+		return statusError(codes.Aborted, ReasonDuplicateRequest, "%v", err)
+	case errors.Is(err, node.ErrRequestReused):
+		return statusError(codes.InvalidArgument, ReasonDuplicateRequest, "%v", err)
+	case errors.Is(err, node.ErrSchemaDrift):
+		return statusError(codes.DataLoss, ReasonSchemaDrift, "%v", err)
+	default:
+		return statusError(codes.Unavailable, ReasonBacklog, "%v", err)
+	}
+}
+
+// Gives the reason carried by a gRPC status error (empty when it carries none)
+func statusReason(err error) string {
+	for _, detail := range status.Convert(err).Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok && info.Domain == errorDomain {
+			return info.Reason
+		}
+	}
+
+	return ""
+}
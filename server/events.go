@@ -0,0 +1,92 @@
+package pb
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	config "node/config"
+	node "node/node"
+	timeutil "node/timeutil"
+	"time"
+
+	"google.golang.org/grpc/codes"
+)
+
+// Posts a replayed event to a webhook target
+func replayToWebhook(url string, event node.Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal the event: %v", err)
+	}
+
+	client := http.Client{Timeout: 5 * time.Second}
+	res, err := client.Post(url, "application/json", bytes.NewBuffer(payload))
+	if err != nil {
+		return fmt.Errorf("failed to post the event: %v", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 300 {
+		return fmt.Errorf("failed to post the event: %s", res.Status)
+	}
+
+	return nil
+}
+
+func (s *MeanderServer) ReplayEvents(p *ReplayPayload, stream MeanderClientIO_ReplayEventsServer) error {
+	ctx := stream.Context()
+
+	if !fromTrustedGateway(ctx) {
+		return statusError(codes.PermissionDenied, ReasonUntrustedPeer, "events can only be replayed by trusted gateways")
+	}
+
+	to := p.To
+	if to == 0 {
+		to = timeutil.Now()
+	}
+
+	if p.From > to {
+		return statusError(codes.InvalidArgument, ReasonInvalidPayload, "invalid range: from must be before to")
+	}
+
+	if p.Webhook != "" {
+		allowed := false
+		for _, url := range config.WebhookURLs() {
+			allowed = allowed || url == p.Webhook
+		}
+
+		if !allowed {
+			return statusError(codes.InvalidArgument, ReasonInvalidPayload, "the webhook target must be one of the configured webhooks")
+		}
+	}
+
+	local, err := localNode(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = local.ReplayEvents(ctx, p.From, to, p.Kinds, func(event node.Event) error {
+		if p.Webhook != "" {
+			return replayToWebhook(p.Webhook, event)
+		}
+
+		data, err := json.Marshal(event.Data)
+		if err != nil {
+			return fmt.Errorf("failed to marshal the event data: %v", err)
+		}
+
+		return stream.Send(&Event{
+			Id:        event.Id,
+			Kind:      event.Kind,
+			Timestamp: event.Timestamp,
+			Data:      string(data),
+		})
+	})
+
+	if err != nil {
+		return statusError(codes.Aborted, ReasonInternal, "failed to replay the events: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,75 @@
+package pb
+
+import (
+	"context"
+	"net"
+	config "node/config"
+	node "node/node"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// The metadata key that carries the correlation id of a request
+const correlationHeader string = "x-correlation-id"
+
+// Accounts every unary call and its result in the node metrics
+func MetricsInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	resp, err := handler(ctx, req)
+	node.RecordRPC(err)
+
+	return resp, err
+}
+
+// Checks if the request was forwarded by a trusted gateway
+func fromTrustedGateway(ctx context.Context) bool {
+	peer, ok := peer.FromContext(ctx)
+	if !ok {
+		return false
+	}
+
+	host, _, err := net.SplitHostPort(peer.Addr.String())
+	if err != nil {
+		return false
+	}
+
+	for _, gateway := range config.TrustedGateways() {
+		if gateway == host {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Gives a correlation id to every unary call (reusing the one sent by trusted gateways), returns
+// it in the response metadata and logs the call with it
+func CorrelationInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	id := ""
+
+	if md, ok := metadata.FromIncomingContext(ctx); ok && fromTrustedGateway(ctx) {
+		if values := md.Get(correlationHeader); len(values) > 0 {
+			id = values[0]
+		}
+	}
+
+	if id == "" {
+		id = node.NewCorrelationId()
+	}
+
+	ctx = node.SampleTrace(node.WithCorrelationId(ctx, id))
+	grpc.SetHeader(ctx, metadata.Pairs(correlationHeader, id))
+
+	start := time.Now()
+	resp, err := handler(ctx, req)
+
+	if err != nil {
+		node.Logf(ctx, "%s failed after %v: %v", info.FullMethod, time.Since(start), err)
+	} else {
+		node.Logf(ctx, "%s succeeded after %v", info.FullMethod, time.Since(start))
+	}
+
+	return resp, err
+}
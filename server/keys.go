@@ -0,0 +1,92 @@
+package pb
+
+import (
+	"context"
+	"fmt"
+	config "node/config"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+)
+
+// The headers that let the callers cache the public keys, as they would over HTTP
+const (
+	etagHeader         string = "etag"
+	cacheControlHeader string = "cache-control"
+)
+
+func (s *MeanderServer) GetPublicKey(ctx context.Context, p *PublicKeyQuery) (*PublicKey, error) {
+	if p.ClientId == "" {
+		return nil, statusError(codes.InvalidArgument, ReasonInvalidPayload, "get public key request requires: client_id")
+	}
+
+	node, err := localNode(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	record, err := node.PublicKeyOf(ctx, p.ClientId)
+	if err != nil {
+		return nil, nodeStatusError(err)
+	}
+
+	etag := record.ETag()
+	maxAge := config.KeyCacheTTL()
+
+	grpc.SetHeader(ctx, metadata.Pairs(etagHeader, etag, cacheControlHeader, fmt.Sprintf("max-age=%d", int64(maxAge.Seconds()))))
+
+	// The caller already has the key as it is, so it only learns for how long it can keep it
+	if p.IfNoneMatch == etag {
+		return &PublicKey{ClientId: record.ClientId, Etag: etag, MaxAge: int64(maxAge.Seconds()), NotModified: true}, nil
+	}
+
+	publicKey := PublicKey{
+		ClientId:  record.ClientId,
+		Pem:       record.PEM,
+		Algorithm: record.Algorithm,
+		Bits:      int32(record.Bits),
+		Node:      record.NodeAddress,
+		RotatedTo: record.RotatedTo,
+		Revoked:   record.Revoked,
+		Version:   record.Version,
+		Etag:      etag,
+		MaxAge:    int64(maxAge.Seconds()),
+	}
+
+	return &publicKey, nil
+}
+
+func (s *MeanderServer) GetKeyLog(ctx context.Context, p *KeyLogQuery) (*KeyLog, error) {
+	node, err := localNode(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	proof, err := node.KeyLogProof(ctx, p.FromSize, p.ToSize)
+	if err != nil {
+		return nil, nodeStatusError(err)
+	}
+
+	log := KeyLog{
+		FromSize: proof.From.Size,
+		FromHash: proof.From.Hash,
+		ToSize:   proof.To.Size,
+		ToHash:   proof.To.Hash,
+	}
+
+	for _, entry := range proof.Entries {
+		log.Entries = append(log.Entries, &KeyLogEntry{
+			Sequence:     entry.Sequence,
+			Kind:         entry.Kind,
+			ClientId:     entry.ClientId,
+			PreviousId:   entry.PreviousId,
+			NodeId:       entry.NodeId,
+			LoggedAt:     entry.LoggedAt,
+			PreviousHash: entry.PreviousHash,
+			Hash:         entry.Hash,
+		})
+	}
+
+	return &log, nil
+}
@@ -0,0 +1,141 @@
+package pb
+
+import (
+	"context"
+	config "node/config"
+	node "node/node"
+	"path"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+/*
+The expensive RPCs (e.g. CreateClient, that generates the RSA keys of the client) are capped in
+their concurrent calls, so a burst of them can't starve the others (please, go to RPC_CONCURRENCY
+in the config). A call beyond the cap waits for a slot up to the queue timeout, and then it's
+rejected with RESOURCE_EXHAUSTED, so the caller can retry later. The waits and the rejections are
+accounted in the node metrics.
+
+While the node is under backpressure (please, go to `backpressure.go` in the node package), the
+write RPCs are also capped together (please, go to PRESSURED_WRITES in the config).
+*/
+var (
+	rpcSlots      = map[string]chan struct{}{}
+	rpcSlotsMutex sync.Mutex
+)
+
+// The slots shared by the write RPCs while the node is under backpressure
+var pressuredSlots = struct {
+	sync.Once
+	slots chan struct{}
+}{}
+
+// The RPCs that write to the backlog
+var writeRPCs = map[string]bool{
+	"CreateClient":           true,
+	"RegisterDevice":         true,
+	"SubmitTransaction":      true,
+	"AcknowledgeTransaction": true,
+	"EraseClient":            true,
+	"LiquidateClient":        true,
+	"UpdateClient":           true,
+	"AcceptTerms":            true,
+}
+
+// Gives the slots of an RPC (nil when the RPC has no cap)
+func slotsOf(rpc string) chan struct{} {
+	rpcSlotsMutex.Lock()
+	defer rpcSlotsMutex.Unlock()
+
+	slots, ok := rpcSlots[rpc]
+	if !ok {
+		if limit := config.RPCConcurrency(rpc); limit > 0 {
+			slots = make(chan struct{}, limit)
+		}
+		rpcSlots[rpc] = slots
+	}
+
+	return slots
+}
+
+// Takes a slot of the RPC of a method (and a write slot, when the node is under backpressure),
+// waiting for them when they're at the cap. Gives the function that releases the slots. The error
+// is a gRPC status error ready to be returned
+func acquireSlot(ctx context.Context, method string) (func(), error) {
+	rpc := path.Base(method)
+
+	release, err := takeSlot(ctx, rpc, slotsOf(rpc))
+	if err != nil || !writeRPCs[rpc] || !node.UnderBackpressure() {
+		return release, err
+	}
+
+	pressuredSlots.Do(func() {
+		pressuredSlots.slots = make(chan struct{}, config.PressuredWrites())
+	})
+
+	releaseWrite, err := takeSlot(ctx, rpc, pressuredSlots.slots)
+	if err != nil {
+		release()
+		return nil, err
+	}
+
+	return func() {
+		releaseWrite()
+		release()
+	}, nil
+}
+
+// Takes one of the slots (none when they're nil), waiting for it up to the queue timeout
+func takeSlot(ctx context.Context, rpc string, slots chan struct{}) (func(), error) {
+	if slots == nil {
+		return func() {}, nil
+	}
+
+	release := func() { <-slots }
+
+	select {
+	case slots <- struct{}{}:
+		return release, nil
+	default:
+	}
+
+	start := time.Now()
+	timer := time.NewTimer(config.RPCQueueTimeout())
+	defer timer.Stop()
+
+	select {
+	case slots <- struct{}{}:
+		node.RecordRPCQueue(time.Since(start), false)
+		return release, nil
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+
+	node.RecordRPCQueue(time.Since(start), true)
+	return nil, statusError(codes.ResourceExhausted, ReasonOverloaded, "too many concurrent %s calls: please retry later", rpc)
+}
+
+// Caps the concurrent unary calls of every RPC
+func ConcurrencyInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	release, err := acquireSlot(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	return handler(ctx, req)
+}
+
+// Caps the concurrent streams of every RPC
+func ConcurrencyStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	release, err := acquireSlot(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	return handler(srv, ss)
+}
@@ -2,13 +2,12 @@ package pb
 
 import (
 	"context"
-	"fmt"
+	"errors"
 	"net"
-	backlog "node/backlog"
-	client "node/client"
+	config "node/config"
 	node "node/node"
-	"unicode"
 
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/peer"
 )
 
@@ -18,58 +17,50 @@ type MeanderServer struct {
 
 func (s *MeanderServer) CreateClient(ctx context.Context, p *ClientPayload) (*Client, error) {
 	if p.Alias == "" || p.Password == "" || p.Secret == "" {
-		return nil, fmt.Errorf("create client request requires: alias, password, secret")
+		return nil, statusError(codes.InvalidArgument, ReasonInvalidPayload, "create client request requires: alias, password, secret")
+	}
+
+	if err := node.CheckPasswordPolicy(p.Password); err != nil {
+		return nil, statusError(codes.InvalidArgument, ReasonInvalidPassword, "%v", err)
 	}
 
 	peer, ok := peer.FromContext(ctx)
 	if !ok {
-		err := fmt.Errorf("failed to get the peer from context")
+		err := statusError(codes.Internal, ReasonInternal, "failed to get the peer from context")
 		return nil, err
 	}
 
 	clientIP, _, err := net.SplitHostPort(peer.Addr.String())
 	if err != nil {
-		err := fmt.Errorf("failed to get host address from peer: %v", err)
+		err := statusError(codes.Internal, ReasonInternal, "failed to get host address from peer: %v", err)
 		return nil, err
 	}
 
-	node := node.GetLocalNode()
-	results, err := node.Backlog.FindDocument("local_clients", "alias", p.Alias)
-
+	node, err := localNode(ctx)
 	if err != nil {
-		err := fmt.Errorf("failed to verify the existent document: %v", err)
 		return nil, err
 	}
 
-	if len(results) > 0 {
-		err := fmt.Errorf("invalid alias: the alias was found in this node")
-		return nil, err
+	if err := node.CheckWritable(); err != nil {
+		return nil, statusError(codes.FailedPrecondition, ReasonReadOnly, "failed to create client: %v", err)
 	}
 
-	if isValid := func() bool {
-		var hasMin, hasMaj, hasNum bool
-		length := 0
+	results, err := node.Backlog.FindDocument(ctx, "local_clients", "alias", p.Alias)
 
-		for _, char := range p.Password {
-			switch {
-			case unicode.IsLower(char):
-				hasMin = true
-			case unicode.IsUpper(char):
-				hasMaj = true
-			case unicode.IsDigit(char):
-				hasNum = true
-			}
-
-			length++
-		}
+	if err != nil {
+		err := statusError(codes.Unavailable, ReasonBacklog, "failed to verify the existent document: %v", err)
+		return nil, err
+	}
 
-		return length >= 10 && hasMin && hasMaj && hasNum
-	}(); !isValid {
-		err := fmt.Errorf("invalid password: password must have at least 10 chars with major and minor letters and numbers")
+	if len(results) > 0 {
+		err := statusError(codes.AlreadyExists, ReasonInvalidAlias, "invalid alias: the alias was found in this node")
 		return nil, err
 	}
 
-	localClient := node.NewLocalClient(p.Alias, clientIP, p.Secret, p.Password)
+	localClient, err := node.NewLocalClient(ctx, p.Alias, clientIP, p.Secret, p.Password, p.TermsVersion)
+	if err != nil {
+		return nil, nodeStatusError(err)
+	}
 
 	client := Client{
 		Alias:   localClient.Alias,
@@ -82,25 +73,35 @@ func (s *MeanderServer) CreateClient(ctx context.Context, p *ClientPayload) (*Cl
 }
 
 func (s *MeanderServer) ConnectClient(ctx context.Context, p *ClientPayload) (*Connection, error) {
-	node := node.GetLocalNode()
-	results, err := node.Backlog.FindDocument("local_clients", "alias", p.Alias)
+	local, err := localNode(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := local.Backlog.FindDocument(ctx, "local_clients", "alias", p.Alias)
 
 	if err != nil {
-		err := fmt.Errorf("failed to verify the existent document: %v", err)
+		err := statusError(codes.Unavailable, ReasonBacklog, "failed to verify the existent document: %v", err)
 		return nil, err
 	} else if len(results) == 0 {
-		err := fmt.Errorf("not found: the alias was not found inside the server")
+		err := statusError(codes.NotFound, ReasonNotFound, "not found: the alias was not found inside the server")
 		return nil, err
 	}
 
 	client := results
 	uid := client["_id"]
 
-	localClient, cache := node.RetrieveClient(uid.(string), p.Secret)
+	localClient, cache, err := local.RetrieveClient(ctx, uid.(string), p.Secret, p.Password)
+	if errors.Is(err, node.ErrInvalidCredentials) || errors.Is(err, node.ErrClientLiquidated) {
+		return nil, nodeStatusError(err)
+	} else if err != nil {
+		return nil, statusError(codes.Unauthenticated, ReasonInvalidToken, "failed to retrieve the client: %v", err)
+	}
+
 	token, err := cache.Token()
 
 	if err != nil {
-		err := fmt.Errorf("could not generate token: %v", err)
+		err := statusError(codes.Internal, ReasonInternal, "could not generate token: %v", err)
 		return nil, err
 	}
 
@@ -109,93 +110,156 @@ func (s *MeanderServer) ConnectClient(ctx context.Context, p *ClientPayload) (*C
 		Token:  token,
 	}
 
+	// The alternatives are only hints, so the client is connected without them when they can't be read
+	alternatives, err := local.NearestNodes(ctx)
+	if err != nil {
+		node.Logf(ctx, "failed to list the alternative nodes: %v", err)
+	}
+
+	for _, listing := range alternatives {
+		connection.Alternatives = append(connection.Alternatives, nodeListingMessage(listing))
+	}
+
 	return &connection, nil
 }
 
-func (s *MeanderServer) ValidateToken(ctx context.Context, p *ConnectionPayload) (*Commit, error) {
-	uid := p.UserId
-	secret := p.Secret
-	privateKey, err := client.DownloadPrivateKey(secret, uid)
+func (s *MeanderServer) ValidateToken(ctx context.Context, p *ConnectionPayload) (*Validation, error) {
+	return &Validation{UserId: authenticatedClient(ctx).UID}, nil
+}
 
+func (s *MeanderServer) RefreshToken(ctx context.Context, p *ConnectionPayload) (*Connection, error) {
+	local, err := localNode(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to download private key: %v", err)
+		return nil, err
 	}
 
-	publicKey, err := client.DownloadPublicKey(uid)
+	// An expired token can be refreshed while its session is alive, and the refresh kills it
+	uid, secret, token := requestCredentials(ctx, p)
+	if _, err := verifyCredentials(ctx, local, uid, secret, token); err != nil {
+		return nil, err
+	}
 
+	token, err = local.RefreshToken(ctx, uid, secret)
 	if err != nil {
-		return nil, fmt.Errorf("failed to download public key: %v", err)
+		return nil, statusError(codes.Unavailable, ReasonBacklog, "failed to refresh the token: %v", err)
 	}
 
-	crypto := client.CryptoResource{
-		PrivateKey: privateKey,
-		PublicKey:  publicKey,
+	connection := Connection{
+		UserId: uid,
+		Token:  token,
 	}
 
-	payload, err := crypto.DecryptToken(p.Token)
-	if err != nil {
-		return nil, fmt.Errorf("failed to decrypt the token: %v", err)
+	return &connection, nil
+}
+
+func (s *MeanderServer) EraseClient(ctx context.Context, p *ConnectionPayload) (*Commit, error) {
+	owner := authenticatedClient(ctx)
+	if err := owner.Node.CheckWritable(); err != nil {
+		return nil, statusError(codes.FailedPrecondition, ReasonReadOnly, "failed to erase the client: %v", err)
 	}
 
-	backlog := backlog.NewBacklog()
-	cache, err := backlog.GetDocument("cache", uid)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get cache document: %v", err)
+	if _, err := owner.Node.EraseClient(ctx, owner.UID); err != nil {
+		return nil, nodeStatusError(err)
+	}
+
+	return &Commit{Status: commitSucceeded}, nil
+}
+
+func (s *MeanderServer) LiquidateClient(ctx context.Context, p *LiquidationPayload) (*Commit, error) {
+	owner := authenticatedClient(ctx)
+	if err := owner.Node.CheckWritable(); err != nil {
+		return nil, statusError(codes.FailedPrecondition, ReasonReadOnly, "failed to liquidate the client: %v", err)
 	}
 
-	matchA := compareDigest(
-		[]byte(cache["computed_key_a"].(string)),
-		[]byte(payload["computed_key_a"].(string)),
-	)
+	if err := owner.Node.LiquidateClient(ctx, owner.UID, p.Password); err != nil {
+		return nil, nodeStatusError(err)
+	}
+
+	return &Commit{Status: commitSucceeded}, nil
+}
 
-	if !matchA {
-		err := "The computed key A doesn't match"
-		return &Commit{
-			Status: 1,
-			Error:  &err,
-		}, nil
+func (s *MeanderServer) UpdateClient(ctx context.Context, p *ClientUpdate) (*Client, error) {
+	if p.Alias == "" && len(p.Metadata) == 0 {
+		return nil, statusError(codes.InvalidArgument, ReasonInvalidPayload, "update client request requires: alias or metadata")
 	}
 
-	matchP := compareDigest(
-		[]byte(cache["computed_key_p"].(string)),
-		[]byte(payload["computed_key_p"].(string)),
-	)
+	owner := authenticatedClient(ctx)
+	if err := owner.Node.CheckWritable(); err != nil {
+		return nil, statusError(codes.FailedPrecondition, ReasonReadOnly, "failed to update the client: %v", err)
+	}
 
-	if !matchP {
-		err := "The computed key P doesn't match"
-		return &Commit{
-			Status: 1,
-			Error:  &err,
-		}, nil
+	updated, err := owner.Node.UpdateClient(ctx, owner.UID, p.Alias, p.Metadata)
+	if err != nil {
+		return nil, nodeStatusError(err)
 	}
 
-	return &Commit{}, nil
+	client := Client{
+		Alias:    updated.Alias,
+		Node:     updated.NodeAddress,
+		Address:  updated.Address,
+		UserId:   updated.UID,
+		Metadata: updated.Metadata,
+	}
 
+	return &client, nil
 }
 
-// func (s *MeanderServer) RegisterClient(ctx context.Context, c *Client) (*Commit, error) {
-// 	commit := Commit{}
+func (s *MeanderServer) GetTerms(ctx context.Context, p *ConnectionPayload) (*Terms, error) {
+	owner := authenticatedClient(ctx)
 
-// 	client := node.Client{
-// 		Alias:       c.Alias,
-// 		NodeAddress: c.Node,
-// 		Address:     c.Address,
-// 		ClientId:    c.ClientId,
-// 		PublicKey:   c.PublicKey,
-// 	}
+	terms := Terms{
+		CurrentVersion:  config.TermsVersion(),
+		AcceptedVersion: owner.TermsVersion,
+		AcceptedAt:      owner.TermsAcceptedAt,
+		Enforced:        config.TermsEnforced(),
+	}
 
-// 	err := client.SyncWithElastic("clients")
+	return &terms, nil
+}
 
-// 	if err != nil {
-// 		errStr := err.Error()
-// 		commit.Status = 1
-// 		commit.Error = &errStr
-// 	} else {
-// 		commit.Status = 0
-// 	}
+func (s *MeanderServer) AcceptTerms(ctx context.Context, p *TermsPayload) (*Terms, error) {
+	if p.Version == "" {
+		return nil, statusError(codes.InvalidArgument, ReasonInvalidPayload, "accept terms request requires: version")
+	}
 
-// 	return &commit, nil
-// }
+	owner := authenticatedClient(ctx)
+	if err := owner.Node.CheckWritable(); err != nil {
+		return nil, statusError(codes.FailedPrecondition, ReasonReadOnly, "failed to accept the terms: %v", err)
+	}
+
+	consent, err := owner.Node.AcceptTerms(ctx, owner.UID, p.Version)
+	if err != nil {
+		return nil, nodeStatusError(err)
+	}
+
+	terms := Terms{
+		CurrentVersion:  config.TermsVersion(),
+		AcceptedVersion: consent.Version,
+		AcceptedAt:      consent.AcceptedAt,
+		Enforced:        config.TermsEnforced(),
+	}
+
+	return &terms, nil
+}
+
+func (s *MeanderServer) Ping(ctx context.Context, p *ConnectionPayload) (*Heartbeat, error) {
+	owner := authenticatedClient(ctx)
+	node := owner.Node
+
+	expiresAt, err := node.TouchSession(ctx, owner.UID)
+	if err != nil {
+		return nil, statusError(codes.Unavailable, ReasonBacklog, "%v", err)
+	}
+
+	heartbeat := Heartbeat{
+		UserId:     owner.UID,
+		ExpiresAt:  expiresAt,
+		NodeStatus: string(node.Status),
+		ReadOnly:   node.ReadOnly,
+	}
+
+	return &heartbeat, nil
+}
 
 // func (s *MeanderServer) RegisterNode(ctx context.Context, n *Node) (*Commit, error) {
 // 	commit := Commit{}
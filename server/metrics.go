@@ -0,0 +1,174 @@
+package pb
+
+import (
+	"context"
+	node "node/node"
+	timeutil "node/timeutil"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func (s *MeanderServer) GetMetrics(ctx context.Context, p *MetricsPayload) (*MetricsHistory, error) {
+	to := p.To
+	if to == 0 {
+		to = timeutil.Now()
+	}
+
+	if p.From > to {
+		return nil, statusError(codes.InvalidArgument, ReasonInvalidPayload, "invalid range: from must be before to")
+	}
+
+	node, err := localNode(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	history, err := node.MetricsHistory(ctx, p.From, to)
+	if err != nil {
+		return nil, statusError(codes.Unavailable, ReasonBacklog, "failed to get the metrics history: %v", err)
+	}
+
+	response := MetricsHistory{}
+	for _, metrics := range history {
+		response.Metrics = append(response.Metrics, &Metrics{
+			Timestamp:   metrics.Timestamp,
+			Peers:       metrics.Peers,
+			ChainHeight: metrics.ChainHeight,
+			MempoolSize: metrics.MempoolSize,
+			RpcCalls:    metrics.RPCCalls,
+			RpcErrors:   metrics.RPCErrors,
+			RpcQueued:   metrics.RPCQueued,
+			RpcRejected: metrics.RPCRejected,
+			RpcWaitMs:   metrics.RPCWaitMs,
+		})
+	}
+
+	return &response, nil
+}
+
+func (s *MeanderServer) ListNodes(ctx context.Context, p *NodesPayload) (*NodeList, error) {
+	node, err := localNode(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	listings, err := node.ListNodes(ctx)
+	if err != nil {
+		return nil, statusError(codes.Unavailable, ReasonBacklog, "failed to list the nodes: %v", err)
+	}
+
+	response := NodeList{}
+	for _, listing := range listings {
+		response.Nodes = append(response.Nodes, nodeListingMessage(listing))
+	}
+
+	return &response, nil
+}
+
+// Converts a node listing into its message
+func nodeListingMessage(listing node.NodeListing) *NodeListing {
+	return &NodeListing{
+		NodeId:         listing.NodeId,
+		Host:           listing.Host,
+		Version:        listing.Version,
+		SchemaVersion:  int32(listing.SchemaVersion),
+		AcceptsWrites:  listing.AcceptsWrites,
+		MempoolSize:    listing.MempoolSize,
+		RpcCalls:       listing.RPCCalls,
+		LoadMeasuredAt: listing.LoadMeasuredAt,
+		Latency:        listing.Latency,
+		LastSeenAt:     listing.LastSeenAt,
+	}
+}
+
+func (s *MeanderServer) GetNodeStats(ctx context.Context, p *NodeStatsPayload) (*NodeStats, error) {
+	local, err := localNode(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// The stats are given even when some disk can't be read
+	stats, err := local.Stats()
+	if err != nil {
+		node.Logf(ctx, "failed to read the disk usage: %v", err)
+	}
+
+	response := NodeStats{
+		Host:        stats.Host,
+		Version:     stats.Version,
+		Status:      string(stats.Status),
+		ReadOnly:    stats.ReadOnly,
+		Features:    stats.Features,
+		Maintenance: maintenanceMessage(stats.Maintenance),
+		Backpressure: &Backpressure{
+			Active:    stats.Backpressure.Active,
+			LatencyMs: stats.Backpressure.Latency.Milliseconds(),
+			Since:     stats.Backpressure.Since,
+		},
+		Build: &BuildInfo{
+			Version:   stats.Build.Version,
+			Commit:    stats.Build.Commit,
+			BuiltAt:   stats.Build.BuiltAt,
+			Modified:  stats.Build.Modified,
+			GoVersion: stats.Build.GoVersion,
+		},
+	}
+
+	for _, usage := range stats.Disk {
+		response.Disk = append(response.Disk, &DiskUsage{Path: usage.Path, Free: usage.Free, Total: usage.Total})
+	}
+
+	return &response, nil
+}
+
+func (s *MeanderServer) GetHealth(ctx context.Context, p *HealthQuery) (*Health, error) {
+	health, fresh := node.LastHealth()
+	if !fresh {
+		local, err := localNode(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		health = local.Health(ctx)
+	}
+
+	response := Health{Status: string(health.Status), CheckedAt: health.CheckedAt}
+	for _, check := range health.Checks {
+		response.Checks = append(response.Checks, &HealthCheck{
+			Name:      check.Name,
+			Status:    string(check.Status),
+			Detail:    check.Detail,
+			LatencyMs: check.Latency.Milliseconds(),
+			Critical:  check.Critical,
+		})
+	}
+
+	return &response, nil
+}
+
+/*
+The orchestrator probes use the standard gRPC health service: the node is serving unless some check
+of a dependency that it can't work without fails (a degraded node still serves). The status follows
+the reports of the health monitor (please, go to `health.go` in the node package).
+*/
+func RegisterHealthProbe(server *grpc.Server) {
+	probe := health.NewServer()
+	healthpb.RegisterHealthServer(server, probe)
+
+	update := func(report node.Health) {
+		status := healthpb.HealthCheckResponse_SERVING
+		if report.Status == node.HealthFailing {
+			status = healthpb.HealthCheckResponse_NOT_SERVING
+		}
+
+		probe.SetServingStatus("", status)
+	}
+
+	if report, fresh := node.LastHealth(); fresh {
+		update(report)
+	}
+	node.RegisterHealthListener(update)
+}
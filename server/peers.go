@@ -0,0 +1,762 @@
+package pb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	node "node/node"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+type MeanderPeerServer struct {
+	UnimplementedMeanderPeerIOServer
+}
+
+// Converts a transaction of a block into its gRPC message
+func blockTransactionMessage(transaction node.BlockTransaction) *BlockTransaction {
+	return &BlockTransaction{
+		TransactionId: transaction.TransactionId,
+		Sender:        transaction.Sender,
+		Recipient:     transaction.Recipient,
+		Value:         transaction.Value,
+		Fee:           transaction.Fee,
+		Payload:       transaction.Payload,
+		Timestamp:     transaction.Timestamp,
+		Sequence:      transaction.Sequence,
+		Memo:          transaction.Memo,
+		Tag:           transaction.Tag,
+		Signature:     []byte(transaction.Signature),
+	}
+}
+
+// Converts a block of the node into its gRPC message
+func blockMessage(block node.Block) *Block {
+	message := Block{
+		Height:       block.Height,
+		PreviousHash: block.PreviousHash,
+		Timestamp:    block.Timestamp,
+		Nonce:        block.Nonce,
+		Hash:         block.Hash,
+		Producer:     block.Producer,
+		Signature:    block.Signature,
+		MerkleRoot:   block.MerkleRoot,
+		FeeRecipient: block.FeeRecipient,
+	}
+
+	for _, transaction := range block.Transactions {
+		message.Transactions = append(message.Transactions, blockTransactionMessage(transaction))
+	}
+
+	return &message
+}
+
+// Converts the status of a node into its gRPC message
+func peerStatusMessage(status node.Node) *PeerStatus {
+	return &PeerStatus{
+		NodeId:        status.Id,
+		PublicKey:     status.PublicKey,
+		Host:          status.Host,
+		Version:       status.Version,
+		Status:        string(status.Status),
+		ReadOnly:      status.ReadOnly,
+		SchemaVersion: int32(status.SchemaVersion),
+		ResumesAt:     status.ResumesAt,
+		Maintenance:   maintenanceMessage(status.Maintenance),
+		Signature:     []byte(status.Signature),
+	}
+}
+
+// Converts a gRPC message into the status of a node
+func nodeStatus(message *PeerStatus) node.Node {
+	return node.Node{
+		Id:            message.GetNodeId(),
+		PublicKey:     message.GetPublicKey(),
+		Host:          message.GetHost(),
+		Version:       message.GetVersion(),
+		Status:        node.NodeStatus(message.GetStatus()),
+		ReadOnly:      message.GetReadOnly(),
+		SchemaVersion: int(message.GetSchemaVersion()),
+		ResumesAt:     message.GetResumesAt(),
+		Maintenance:   nodeMaintenance(message.GetMaintenance()),
+		Signature:     string(message.GetSignature()),
+	}
+}
+
+// Converts a maintenance window of the node into its gRPC message (nil when there's none)
+func maintenanceMessage(window *node.MaintenanceWindow) *Maintenance {
+	if window == nil {
+		return nil
+	}
+
+	return &Maintenance{StartsAt: window.StartsAt, EndsAt: window.EndsAt, Reason: window.Reason}
+}
+
+func nodeMaintenance(message *Maintenance) *node.MaintenanceWindow {
+	if message == nil {
+		return nil
+	}
+
+	return &node.MaintenanceWindow{StartsAt: message.StartsAt, EndsAt: message.EndsAt, Reason: message.Reason}
+}
+
+// Converts the peers of a node into their gRPC message
+func peerExchangeMessage(self node.Node, peers []node.Node) *PeerExchange {
+	message := PeerExchange{Self: peerStatusMessage(self)}
+	for _, peer := range peers {
+		message.Peers = append(message.Peers, peerStatusMessage(peer))
+	}
+
+	return &message
+}
+
+// Converts a gRPC message into a block of the node
+func handshakeMessage(handshake node.Handshake) *Handshake {
+	return &Handshake{
+		NodeId:          handshake.NodeId,
+		Version:         handshake.Version,
+		ProtocolVersion: int32(handshake.ProtocolVersion),
+		Height:          handshake.Height,
+		GenesisHash:     handshake.GenesisHash,
+		ParamsHash:      handshake.ParamsHash,
+		Features:        handshake.Features,
+	}
+}
+
+func nodeHandshake(message *Handshake) node.Handshake {
+	return node.Handshake{
+		NodeId:          message.NodeId,
+		Version:         message.Version,
+		ProtocolVersion: int(message.ProtocolVersion),
+		Height:          message.Height,
+		GenesisHash:     message.GenesisHash,
+		ParamsHash:      message.ParamsHash,
+		Features:        message.Features,
+	}
+}
+
+func nodeBlock(message *Block) node.Block {
+	block := node.Block{
+		Height:       message.Height,
+		PreviousHash: message.PreviousHash,
+		Timestamp:    message.Timestamp,
+		Nonce:        message.Nonce,
+		Hash:         message.Hash,
+		Producer:     message.Producer,
+		Signature:    message.Signature,
+		MerkleRoot:   message.MerkleRoot,
+		FeeRecipient: message.FeeRecipient,
+	}
+
+	for _, transaction := range message.Transactions {
+		block.Transactions = append(block.Transactions, node.BlockTransaction{
+			TransactionId: transaction.TransactionId,
+			Sender:        transaction.Sender,
+			Recipient:     transaction.Recipient,
+			Value:         transaction.Value,
+			Fee:           transaction.Fee,
+			Payload:       transaction.Payload,
+			Timestamp:     transaction.Timestamp,
+			Sequence:      transaction.Sequence,
+			Memo:          transaction.Memo,
+			Tag:           transaction.Tag,
+			Signature:     string(transaction.Signature),
+		})
+	}
+
+	return block
+}
+
+func (s *MeanderPeerServer) AnnounceBlock(ctx context.Context, p *Block) (*Commit, error) {
+	local, err := localNode(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := local.CheckWritable(); err != nil {
+		return nil, statusError(codes.FailedPrecondition, ReasonReadOnly, "failed to accept the block: %v", err)
+	}
+
+	last, err := node.NewBlockchain(local.Backlog).LastBlock(ctx)
+	if err != nil {
+		return nil, statusError(codes.Unavailable, ReasonBacklog, "%v", err)
+	}
+
+	expected := int64(0)
+	if last != nil {
+		expected = last.Height + 1
+	}
+
+	// Another block at the height of the last one (or after another last block) means the chains
+	// forked, so the fork choice runs against the chain of the announcer
+	forked := last != nil && ((p.Height == last.Height && p.Hash != last.Hash) || (p.Height == expected && p.PreviousHash != last.Hash))
+
+	switch {
+	case p.Height < expected && !forked:
+		// The block is already known
+		return &Commit{Status: 0}, nil
+	case p.Height > expected || forked:
+		// The node missed some blocks (or forked), so the whole gap is fetched from the announcer
+		peer, ok := peer.FromContext(ctx)
+		if !ok {
+			return nil, statusError(codes.Internal, ReasonInternal, "failed to get the peer from context")
+		}
+
+		host, _, err := net.SplitHostPort(peer.Addr.String())
+		if err != nil {
+			return nil, statusError(codes.Internal, ReasonInternal, "failed to get host address from peer: %v", err)
+		}
+
+		if _, err := local.SyncBlocks(ctx, host); err != nil {
+			return nil, statusError(codes.Unavailable, ReasonInvalidBlock, "failed to fetch the missing blocks: %v", err)
+		}
+
+		return &Commit{Status: 0}, nil
+	}
+
+	block := nodeBlock(p)
+	if err := local.AppendBlock(ctx, &block); err != nil {
+		return nil, statusError(codes.InvalidArgument, ReasonInvalidBlock, "%v", err)
+	}
+
+	return &Commit{Status: 0}, nil
+}
+
+func (s *MeanderPeerServer) FetchBlocks(ctx context.Context, p *BlockRange) (*BlockList, error) {
+	if p.FromHeight < 0 {
+		return nil, statusError(codes.InvalidArgument, ReasonInvalidPayload, "invalid range: from_height must not be negative")
+	}
+
+	local, err := localNode(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	blocks, err := node.NewBlockchain(local.Backlog).BlocksFrom(ctx, p.FromHeight)
+	if err != nil {
+		return nil, statusError(codes.Unavailable, ReasonBacklog, "%v", err)
+	}
+
+	response := BlockList{}
+	for _, block := range blocks {
+		response.Blocks = append(response.Blocks, blockMessage(block))
+	}
+
+	return &response, nil
+}
+
+func (s *MeanderPeerServer) FetchDocuments(ctx context.Context, p *DocumentRange) (*DocumentList, error) {
+	local, err := localNode(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	documents, err := local.DocumentsSince(ctx, p.Index, p.Since, p.AfterId)
+	if err != nil {
+		return nil, statusError(codes.InvalidArgument, ReasonInvalidPayload, "%v", err)
+	}
+
+	return documentList(documents)
+}
+
+func (s *MeanderPeerServer) SummarizeIndex(ctx context.Context, p *IndexRange) (*IndexSummary, error) {
+	local, err := localNode(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ranges, err := local.SummarizeIndex(ctx, p.Index, p.Prefix)
+	if err != nil {
+		return nil, statusError(codes.InvalidArgument, ReasonInvalidPayload, "%v", err)
+	}
+
+	response := IndexSummary{}
+	for _, summary := range ranges {
+		response.Ranges = append(response.Ranges, &RangeSummary{Prefix: summary.Prefix, Hash: summary.Hash, Count: int64(summary.Count)})
+	}
+
+	return &response, nil
+}
+
+func (s *MeanderPeerServer) FetchDocumentRange(ctx context.Context, p *IndexRange) (*DocumentList, error) {
+	local, err := localNode(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	documents, err := local.DocumentsInRange(ctx, p.Index, p.Prefix)
+	if err != nil {
+		return nil, statusError(codes.InvalidArgument, ReasonInvalidPayload, "%v", err)
+	}
+
+	return documentList(documents)
+}
+
+// Converts the documents of a mirrored index into their gRPC message
+func documentList(documents []map[string]interface{}) (*DocumentList, error) {
+	response := DocumentList{}
+	for _, document := range documents {
+		id, _ := document["_id"].(string)
+		delete(document, "_id")
+
+		data, err := json.Marshal(document)
+		if err != nil {
+			return nil, statusError(codes.Internal, ReasonInternal, "failed to marshal the document %s: %v", id, err)
+		}
+
+		response.Documents = append(response.Documents, &Document{Id: id, Data: string(data)})
+	}
+
+	return &response, nil
+}
+
+func (s *MeanderPeerServer) AnnounceAddress(ctx context.Context, p *AddressChange) (*Commit, error) {
+	change := node.AddressChange{
+		NodeId:    p.NodeId,
+		Previous:  p.Previous,
+		Host:      p.Host,
+		ChangedAt: p.ChangedAt,
+		Signature: string(p.Signature),
+	}
+
+	local, err := localNode(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := local.AcceptAddressChange(ctx, change); err != nil {
+		return nil, statusError(codes.PermissionDenied, ReasonUntrustedPeer, "%v", err)
+	}
+
+	return &Commit{Status: 0}, nil
+}
+
+func (s *MeanderPeerServer) AnnounceDeparture(ctx context.Context, p *Departure) (*Commit, error) {
+	departure := node.Departure{
+		NodeId:    p.NodeId,
+		ResumesAt: p.ResumesAt,
+		LeftAt:    p.LeftAt,
+		Signature: string(p.Signature),
+	}
+
+	local, err := localNode(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := local.AcceptDeparture(ctx, departure); err != nil {
+		return nil, statusError(codes.PermissionDenied, ReasonUntrustedPeer, "%v", err)
+	}
+
+	return &Commit{Status: 0}, nil
+}
+
+func (s *MeanderPeerServer) AnnounceErasure(ctx context.Context, p *Erasure) (*Commit, error) {
+	erasure := node.Erasure{
+		NodeId:    p.NodeId,
+		ClientId:  p.ClientId,
+		ErasedAt:  p.ErasedAt,
+		Signature: string(p.Signature),
+	}
+
+	local, err := localNode(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := local.AcceptErasure(ctx, erasure); err != nil {
+		return nil, statusError(codes.PermissionDenied, ReasonUntrustedPeer, "%v", err)
+	}
+
+	return &Commit{Status: 0}, nil
+}
+
+func (s *MeanderPeerServer) AnnounceClient(ctx context.Context, p *ClientAnnouncement) (*Commit, error) {
+	announcement := node.ClientAnnouncement{
+		NodeId:      p.NodeId,
+		AnnouncedAt: p.AnnouncedAt,
+		Signature:   string(p.Signature),
+	}
+
+	if err := json.Unmarshal([]byte(p.Client), &announcement.Client); err != nil {
+		return nil, statusError(codes.InvalidArgument, ReasonInvalidPayload, "failed to unmarshal the client: %v", err)
+	}
+
+	local, err := localNode(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := local.AcceptClient(ctx, announcement); err != nil {
+		return nil, statusError(codes.PermissionDenied, ReasonUntrustedPeer, "%v", err)
+	}
+
+	return &Commit{Status: 0}, nil
+}
+
+func (s *MeanderPeerServer) ExchangePeers(ctx context.Context, p *PeerExchange) (*PeerExchange, error) {
+	local, err := localNode(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// The caller is only learned as any other peer, since its status could be replayed
+	if _, err := local.LearnPeer(ctx, nodeStatus(p.Self)); err != nil {
+		return nil, statusError(codes.PermissionDenied, ReasonUntrustedPeer, "%v", err)
+	}
+
+	for _, peer := range p.Peers {
+		if _, err := local.LearnPeer(ctx, nodeStatus(peer)); err != nil {
+			node.Debugf(ctx, node.SubsystemGossip, "refused the peer %s: %v", peer.NodeId, err)
+		}
+	}
+
+	self, err := local.SignedStatus()
+	if err != nil {
+		return nil, statusError(codes.Internal, ReasonInternal, "%v", err)
+	}
+
+	peers, err := local.KnownPeers(ctx)
+	if err != nil {
+		return nil, statusError(codes.Unavailable, ReasonBacklog, "%v", err)
+	}
+
+	return peerExchangeMessage(*self, peers), nil
+}
+
+func (s *MeanderPeerServer) Handshake(ctx context.Context, p *Handshake) (*Handshake, error) {
+	local, err := localNode(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	handshake, err := local.AcceptHandshake(ctx, nodeHandshake(p))
+	if err != nil {
+		return nil, nodeStatusError(err)
+	}
+
+	return handshakeMessage(*handshake), nil
+}
+
+func (s *MeanderPeerServer) RouteTransaction(ctx context.Context, p *RoutedTransaction) (*Commit, error) {
+	routed := node.RoutedTransaction{
+		TransactionId: p.TransactionId,
+		Sender:        p.Sender,
+		Recipient:     p.Recipient,
+		Value:         p.Value,
+		Fee:           p.Fee,
+		Payload:       p.Payload,
+		Timestamp:     p.Timestamp,
+		Sequence:      p.Sequence,
+		Memo:          p.Memo,
+		Tag:           p.Tag,
+		Signature:     string(p.Signature),
+	}
+
+	local, err := localNode(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := local.AcceptRoutedTransaction(ctx, routed); err != nil {
+		return nil, statusError(codes.PermissionDenied, ReasonUntrustedPeer, "%v", err)
+	}
+
+	return &Commit{Status: 0}, nil
+}
+
+func (s *MeanderPeerServer) RouteAcknowledgment(ctx context.Context, p *Acknowledgment) (*Commit, error) {
+	acknowledgment := node.Acknowledgment{
+		TransactionId: p.TransactionId,
+		Recipient:     p.Recipient,
+		Transaction:   string(p.Transaction),
+		SignedAt:      p.SignedAt,
+		Signature:     string(p.Signature),
+	}
+
+	local, err := localNode(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := local.AcceptAcknowledgment(ctx, acknowledgment); err != nil {
+		if errors.Is(err, node.ErrUnknownTransaction) {
+			return nil, nodeStatusError(err)
+		}
+
+		return nil, statusError(codes.PermissionDenied, ReasonUntrustedPeer, "%v", err)
+	}
+
+	return &Commit{Status: 0}, nil
+}
+
+/*
+The PeerClient reaches the MeanderPeerIO service of the peers. It's the transport registered in the
+node to propagate the blocks (please, go to `peers.go` in the node package to see more about it).
+*/
+type PeerClient struct {
+	Port    string        // The port where the peers serve the gRPC API
+	Timeout time.Duration // The limit of each call to a peer
+}
+
+func (c PeerClient) call(ctx context.Context, host string, fn func(ctx context.Context, client MeanderPeerIOClient) error) error {
+	ctx, cancel := context.WithTimeout(ctx, c.Timeout)
+	defer cancel()
+
+	conn, err := grpc.DialContext(ctx, net.JoinHostPort(host, c.Port), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return fmt.Errorf("failed to dial the peer %s: %v", host, err)
+	}
+	defer conn.Close()
+
+	return fn(ctx, NewMeanderPeerIOClient(conn))
+}
+
+func (c PeerClient) Ping(ctx context.Context, host string) error {
+	ctx, cancel := context.WithTimeout(ctx, c.Timeout)
+	defer cancel()
+
+	conn, err := grpc.DialContext(ctx, net.JoinHostPort(host, c.Port), grpc.WithTransportCredentials(insecure.NewCredentials()), grpc.WithBlock())
+	if err != nil {
+		return fmt.Errorf("failed to dial the peer %s: %v", host, err)
+	}
+
+	return conn.Close()
+}
+
+func (c PeerClient) AnnounceBlock(ctx context.Context, host string, block node.Block) error {
+	return c.call(ctx, host, func(ctx context.Context, client MeanderPeerIOClient) error {
+		_, err := client.AnnounceBlock(ctx, blockMessage(block))
+		return err
+	})
+}
+
+func (c PeerClient) FetchBlocks(ctx context.Context, host string, fromHeight int64) ([]node.Block, error) {
+	var blocks []node.Block
+
+	err := c.call(ctx, host, func(ctx context.Context, client MeanderPeerIOClient) error {
+		var response *BlockList
+		err := syncCall(func(compression grpc.CallOption) (err error) {
+			response, err = client.FetchBlocks(ctx, &BlockRange{FromHeight: fromHeight}, compression)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, message := range response.Blocks {
+			blocks = append(blocks, nodeBlock(message))
+		}
+
+		return nil
+	})
+
+	return blocks, err
+}
+
+func (c PeerClient) FetchDocuments(ctx context.Context, host, index string, since int64, afterId string) ([]map[string]interface{}, error) {
+	var documents []map[string]interface{}
+
+	err := c.call(ctx, host, func(ctx context.Context, client MeanderPeerIOClient) error {
+		var response *DocumentList
+		err := syncCall(func(compression grpc.CallOption) (err error) {
+			response, err = client.FetchDocuments(ctx, &DocumentRange{Index: index, Since: since, AfterId: afterId}, compression)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+
+		documents, err = nodeDocuments(response)
+		return err
+	})
+
+	return documents, err
+}
+
+func (c PeerClient) SummarizeIndex(ctx context.Context, host, index, prefix string) ([]node.RangeSummary, error) {
+	var ranges []node.RangeSummary
+
+	err := c.call(ctx, host, func(ctx context.Context, client MeanderPeerIOClient) error {
+		response, err := client.SummarizeIndex(ctx, &IndexRange{Index: index, Prefix: prefix})
+		if err != nil {
+			return err
+		}
+
+		for _, summary := range response.Ranges {
+			ranges = append(ranges, node.RangeSummary{Prefix: summary.Prefix, Hash: summary.Hash, Count: int(summary.Count)})
+		}
+
+		return nil
+	})
+
+	return ranges, err
+}
+
+func (c PeerClient) FetchDocumentRange(ctx context.Context, host, index, prefix string) ([]map[string]interface{}, error) {
+	var documents []map[string]interface{}
+
+	err := c.call(ctx, host, func(ctx context.Context, client MeanderPeerIOClient) error {
+		var response *DocumentList
+		err := syncCall(func(compression grpc.CallOption) (err error) {
+			response, err = client.FetchDocumentRange(ctx, &IndexRange{Index: index, Prefix: prefix}, compression)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+
+		documents, err = nodeDocuments(response)
+		return err
+	})
+
+	return documents, err
+}
+
+// Converts a document list into the documents of a mirrored index, with their ids in `_id`
+func nodeDocuments(response *DocumentList) ([]map[string]interface{}, error) {
+	var documents []map[string]interface{}
+	for _, message := range response.Documents {
+		var document map[string]interface{}
+		if err := json.Unmarshal([]byte(message.Data), &document); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal the document %s: %v", message.Id, err)
+		}
+
+		document["_id"] = message.Id
+		documents = append(documents, document)
+	}
+
+	return documents, nil
+}
+
+func (c PeerClient) AnnounceAddress(ctx context.Context, host string, change node.AddressChange) error {
+	return c.call(ctx, host, func(ctx context.Context, client MeanderPeerIOClient) error {
+		_, err := client.AnnounceAddress(ctx, &AddressChange{
+			NodeId:    change.NodeId,
+			Previous:  change.Previous,
+			Host:      change.Host,
+			ChangedAt: change.ChangedAt,
+			Signature: []byte(change.Signature),
+		})
+		return err
+	})
+}
+
+func (c PeerClient) AnnounceDeparture(ctx context.Context, host string, departure node.Departure) error {
+	return c.call(ctx, host, func(ctx context.Context, client MeanderPeerIOClient) error {
+		_, err := client.AnnounceDeparture(ctx, &Departure{
+			NodeId:    departure.NodeId,
+			ResumesAt: departure.ResumesAt,
+			LeftAt:    departure.LeftAt,
+			Signature: []byte(departure.Signature),
+		})
+		return err
+	})
+}
+
+func (c PeerClient) AnnounceErasure(ctx context.Context, host string, erasure node.Erasure) error {
+	return c.call(ctx, host, func(ctx context.Context, client MeanderPeerIOClient) error {
+		_, err := client.AnnounceErasure(ctx, &Erasure{
+			NodeId:    erasure.NodeId,
+			ClientId:  erasure.ClientId,
+			ErasedAt:  erasure.ErasedAt,
+			Signature: []byte(erasure.Signature),
+		})
+		return err
+	})
+}
+
+func (c PeerClient) AnnounceClient(ctx context.Context, host string, announcement node.ClientAnnouncement) error {
+	client, err := json.Marshal(announcement.Client)
+	if err != nil {
+		return fmt.Errorf("failed to marshal the client: %v", err)
+	}
+
+	return c.call(ctx, host, func(ctx context.Context, peer MeanderPeerIOClient) error {
+		_, err := peer.AnnounceClient(ctx, &ClientAnnouncement{
+			NodeId:      announcement.NodeId,
+			Client:      string(client),
+			AnnouncedAt: announcement.AnnouncedAt,
+			Signature:   []byte(announcement.Signature),
+		})
+		return err
+	})
+}
+
+func (c PeerClient) ExchangePeers(ctx context.Context, host string, self node.Node, peers []node.Node) (*node.Node, []node.Node, error) {
+	var status *node.Node
+	var learned []node.Node
+
+	err := c.call(ctx, host, func(ctx context.Context, client MeanderPeerIOClient) error {
+		response, err := client.ExchangePeers(ctx, peerExchangeMessage(self, peers))
+		if err != nil {
+			return err
+		}
+
+		peer := nodeStatus(response.Self)
+		status = &peer
+
+		for _, message := range response.Peers {
+			learned = append(learned, nodeStatus(message))
+		}
+
+		return nil
+	})
+
+	return status, learned, err
+}
+
+func (c PeerClient) Handshake(ctx context.Context, host string, self node.Handshake) (*node.Handshake, error) {
+	var handshake *node.Handshake
+
+	err := c.call(ctx, host, func(ctx context.Context, client MeanderPeerIOClient) error {
+		response, err := client.Handshake(ctx, handshakeMessage(self))
+		if statusReason(err) == ReasonIncompatiblePeer {
+			// The peer refused the node, so its reason is kept under the error of the node
+			reason := strings.TrimPrefix(status.Convert(err).Message(), node.ErrIncompatiblePeer.Error()+": ")
+			return fmt.Errorf("%w: %s", node.ErrIncompatiblePeer, reason)
+		} else if err != nil {
+			return err
+		}
+
+		peer := nodeHandshake(response)
+		handshake = &peer
+		return nil
+	})
+
+	return handshake, err
+}
+
+func (c PeerClient) RouteTransaction(ctx context.Context, host string, routed node.RoutedTransaction) error {
+	return c.call(ctx, host, func(ctx context.Context, client MeanderPeerIOClient) error {
+		_, err := client.RouteTransaction(ctx, &RoutedTransaction{
+			TransactionId: routed.TransactionId,
+			Sender:        routed.Sender,
+			Recipient:     routed.Recipient,
+			Value:         routed.Value,
+			Fee:           routed.Fee,
+			Payload:       routed.Payload,
+			Timestamp:     routed.Timestamp,
+			Sequence:      routed.Sequence,
+			Memo:          routed.Memo,
+			Tag:           routed.Tag,
+			Signature:     []byte(routed.Signature),
+		})
+		return err
+	})
+}
+
+func (c PeerClient) RouteAcknowledgment(ctx context.Context, host string, acknowledgment node.Acknowledgment) error {
+	return c.call(ctx, host, func(ctx context.Context, client MeanderPeerIOClient) error {
+		_, err := client.RouteAcknowledgment(ctx, acknowledgmentMessage(acknowledgment))
+		return err
+	})
+}
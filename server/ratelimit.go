@@ -0,0 +1,155 @@
+package pb
+
+import (
+	"context"
+	"net"
+	config "node/config"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+/*
+The RPCs that hit the backlog hard without a session (e.g. CreateClient, ConnectClient) are limited
+by caller, so a single misbehaving client can't hammer the ElasticSearch (please, go to RATE_LIMITS
+in the config). Every caller has a token bucket by RPC for its address and another one for its uid,
+when the call carries it, and the call must take a token from both. A call without a token is
+rejected right away with RESOURCE_EXHAUSTED, unlike the concurrency caps (please, go to `limits.go`),
+since waiting wouldn't slow the caller down.
+
+The address of a call forwarded by a trusted gateway is the first one of its `x-forwarded-for`.
+*/
+type rateBucket struct {
+	tokens  float64
+	updated time.Time
+	period  time.Duration // The period of the rate, after which an idle bucket is full again
+}
+
+var rateBuckets = struct {
+	sync.Mutex
+	buckets map[string]*rateBucket
+	pruned  time.Time
+}{buckets: map[string]*rateBucket{}}
+
+// The metadata key that carries the address of the caller behind a gateway
+const forwardedForHeader string = "x-forwarded-for"
+
+// Gives the address of the caller of a request
+func callerAddress(ctx context.Context) string {
+	if md, ok := metadata.FromIncomingContext(ctx); ok && fromTrustedGateway(ctx) {
+		if values := md.Get(forwardedForHeader); len(values) > 0 {
+			forwarded, _, _ := strings.Cut(values[0], ",")
+			return strings.TrimSpace(forwarded)
+		}
+	}
+
+	peer, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+
+	host, _, err := net.SplitHostPort(peer.Addr.String())
+	if err != nil {
+		return peer.Addr.String()
+	}
+
+	return host
+}
+
+// Takes a token from the bucket of every key, or from none when any of them is empty
+func takeTokens(limit config.RateLimit, keys ...string) bool {
+	rateBuckets.Lock()
+	defer rateBuckets.Unlock()
+
+	now := time.Now()
+	pruneBuckets(now)
+
+	capacity := float64(limit.Calls)
+	buckets := make([]*rateBucket, 0, len(keys))
+
+	for _, key := range keys {
+		bucket, ok := rateBuckets.buckets[key]
+		if !ok {
+			bucket = &rateBucket{tokens: capacity, updated: now, period: limit.Period}
+			rateBuckets.buckets[key] = bucket
+		}
+
+		bucket.tokens += capacity * float64(now.Sub(bucket.updated)) / float64(limit.Period)
+		if bucket.tokens > capacity {
+			bucket.tokens = capacity
+		}
+		bucket.updated = now
+
+		if bucket.tokens < 1 {
+			return false
+		}
+
+		buckets = append(buckets, bucket)
+	}
+
+	for _, bucket := range buckets {
+		bucket.tokens--
+	}
+
+	return true
+}
+
+// Forgets the buckets idle for longer than their period, which are full again anyway. The buckets
+// must be locked
+func pruneBuckets(now time.Time) {
+	if now.Sub(rateBuckets.pruned) < time.Minute {
+		return
+	}
+	rateBuckets.pruned = now
+
+	for key, bucket := range rateBuckets.buckets {
+		if now.Sub(bucket.updated) > bucket.period {
+			delete(rateBuckets.buckets, key)
+		}
+	}
+}
+
+// Checks the rate of the caller of a method. The error is a gRPC status error ready to be returned
+func checkRate(ctx context.Context, method string, req interface{}) error {
+	rpc := path.Base(method)
+
+	limit := config.RPCRateLimit(rpc)
+	if limit.Calls <= 0 {
+		return nil
+	}
+
+	keys := []string{rpc + "@" + callerAddress(ctx)}
+	if uid, _, _ := requestCredentials(ctx, req); uid != "" {
+		keys = append(keys, rpc+"#"+uid)
+	}
+
+	if !takeTokens(limit, keys...) {
+		return statusError(codes.ResourceExhausted, ReasonRateLimited, "too many %s calls: %d every %v at most", rpc, limit.Calls, limit.Period)
+	}
+
+	return nil
+}
+
+// Limits the rate of the unary calls of every caller
+func RateLimitInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if err := checkRate(ctx, info.FullMethod, req); err != nil {
+		return nil, err
+	}
+
+	return handler(ctx, req)
+}
+
+// Limits the rate of the streams of every caller
+func RateLimitStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := checkRate(ss.Context(), info.FullMethod, nil); err != nil {
+		return err
+	}
+
+	return handler(srv, ss)
+}
@@ -19,9 +19,35 @@ import (
 const _ = grpc.SupportPackageIsVersion7
 
 const (
-	MeanderClientIO_CreateClient_FullMethodName  = "/MeanderClientIO/CreateClient"
-	MeanderClientIO_ConnectClient_FullMethodName = "/MeanderClientIO/ConnectClient"
-	MeanderClientIO_ValidateToken_FullMethodName = "/MeanderClientIO/ValidateToken"
+	MeanderClientIO_CreateClient_FullMethodName           = "/MeanderClientIO/CreateClient"
+	MeanderClientIO_ConnectClient_FullMethodName          = "/MeanderClientIO/ConnectClient"
+	MeanderClientIO_ValidateToken_FullMethodName          = "/MeanderClientIO/ValidateToken"
+	MeanderClientIO_ValidateTokens_FullMethodName         = "/MeanderClientIO/ValidateTokens"
+	MeanderClientIO_RefreshToken_FullMethodName           = "/MeanderClientIO/RefreshToken"
+	MeanderClientIO_Ping_FullMethodName                   = "/MeanderClientIO/Ping"
+	MeanderClientIO_RegisterDevice_FullMethodName         = "/MeanderClientIO/RegisterDevice"
+	MeanderClientIO_ReplayEvents_FullMethodName           = "/MeanderClientIO/ReplayEvents"
+	MeanderClientIO_GetMetrics_FullMethodName             = "/MeanderClientIO/GetMetrics"
+	MeanderClientIO_SubmitTransaction_FullMethodName      = "/MeanderClientIO/SubmitTransaction"
+	MeanderClientIO_VerifyChain_FullMethodName            = "/MeanderClientIO/VerifyChain"
+	MeanderClientIO_ListNodes_FullMethodName              = "/MeanderClientIO/ListNodes"
+	MeanderClientIO_AcknowledgeTransaction_FullMethodName = "/MeanderClientIO/AcknowledgeTransaction"
+	MeanderClientIO_GetAcknowledgment_FullMethodName      = "/MeanderClientIO/GetAcknowledgment"
+	MeanderClientIO_ExportCustody_FullMethodName          = "/MeanderClientIO/ExportCustody"
+	MeanderClientIO_GetTransactionProof_FullMethodName    = "/MeanderClientIO/GetTransactionProof"
+	MeanderClientIO_GetBalance_FullMethodName             = "/MeanderClientIO/GetBalance"
+	MeanderClientIO_ListTransactions_FullMethodName       = "/MeanderClientIO/ListTransactions"
+	MeanderClientIO_GetActivity_FullMethodName            = "/MeanderClientIO/GetActivity"
+	MeanderClientIO_WatchTransactions_FullMethodName      = "/MeanderClientIO/WatchTransactions"
+	MeanderClientIO_EraseClient_FullMethodName            = "/MeanderClientIO/EraseClient"
+	MeanderClientIO_LiquidateClient_FullMethodName        = "/MeanderClientIO/LiquidateClient"
+	MeanderClientIO_UpdateClient_FullMethodName           = "/MeanderClientIO/UpdateClient"
+	MeanderClientIO_GetPublicKey_FullMethodName           = "/MeanderClientIO/GetPublicKey"
+	MeanderClientIO_GetKeyLog_FullMethodName              = "/MeanderClientIO/GetKeyLog"
+	MeanderClientIO_GetTerms_FullMethodName               = "/MeanderClientIO/GetTerms"
+	MeanderClientIO_AcceptTerms_FullMethodName            = "/MeanderClientIO/AcceptTerms"
+	MeanderClientIO_GetNodeStats_FullMethodName           = "/MeanderClientIO/GetNodeStats"
+	MeanderClientIO_GetHealth_FullMethodName              = "/MeanderClientIO/GetHealth"
 )
 
 // MeanderClientIOClient is the client API for MeanderClientIO service.
@@ -30,7 +56,33 @@ const (
 type MeanderClientIOClient interface {
 	CreateClient(ctx context.Context, in *ClientPayload, opts ...grpc.CallOption) (*Client, error)
 	ConnectClient(ctx context.Context, in *ClientPayload, opts ...grpc.CallOption) (*Connection, error)
-	ValidateToken(ctx context.Context, in *ConnectionPayload, opts ...grpc.CallOption) (*Commit, error)
+	ValidateToken(ctx context.Context, in *ConnectionPayload, opts ...grpc.CallOption) (*Validation, error)
+	ValidateTokens(ctx context.Context, in *ConnectionBatch, opts ...grpc.CallOption) (*Commit, error)
+	RefreshToken(ctx context.Context, in *ConnectionPayload, opts ...grpc.CallOption) (*Connection, error)
+	Ping(ctx context.Context, in *ConnectionPayload, opts ...grpc.CallOption) (*Heartbeat, error)
+	RegisterDevice(ctx context.Context, in *DevicePayload, opts ...grpc.CallOption) (*Device, error)
+	ReplayEvents(ctx context.Context, in *ReplayPayload, opts ...grpc.CallOption) (MeanderClientIO_ReplayEventsClient, error)
+	GetMetrics(ctx context.Context, in *MetricsPayload, opts ...grpc.CallOption) (*MetricsHistory, error)
+	SubmitTransaction(ctx context.Context, in *TransactionPayload, opts ...grpc.CallOption) (*Receipt, error)
+	VerifyChain(ctx context.Context, in *VerifyPayload, opts ...grpc.CallOption) (*ChainReport, error)
+	ListNodes(ctx context.Context, in *NodesPayload, opts ...grpc.CallOption) (*NodeList, error)
+	AcknowledgeTransaction(ctx context.Context, in *AcknowledgmentPayload, opts ...grpc.CallOption) (*Acknowledgment, error)
+	GetAcknowledgment(ctx context.Context, in *AcknowledgmentQuery, opts ...grpc.CallOption) (*Acknowledgment, error)
+	ExportCustody(ctx context.Context, in *CustodyQuery, opts ...grpc.CallOption) (*CustodyFile, error)
+	GetTransactionProof(ctx context.Context, in *TransactionProofQuery, opts ...grpc.CallOption) (*TransactionProof, error)
+	GetBalance(ctx context.Context, in *BalanceQuery, opts ...grpc.CallOption) (*Balance, error)
+	ListTransactions(ctx context.Context, in *TransactionQuery, opts ...grpc.CallOption) (*TransactionList, error)
+	GetActivity(ctx context.Context, in *ActivityQuery, opts ...grpc.CallOption) (*ActivityFeed, error)
+	WatchTransactions(ctx context.Context, in *ConnectionPayload, opts ...grpc.CallOption) (MeanderClientIO_WatchTransactionsClient, error)
+	EraseClient(ctx context.Context, in *ConnectionPayload, opts ...grpc.CallOption) (*Commit, error)
+	LiquidateClient(ctx context.Context, in *LiquidationPayload, opts ...grpc.CallOption) (*Commit, error)
+	UpdateClient(ctx context.Context, in *ClientUpdate, opts ...grpc.CallOption) (*Client, error)
+	GetPublicKey(ctx context.Context, in *PublicKeyQuery, opts ...grpc.CallOption) (*PublicKey, error)
+	GetKeyLog(ctx context.Context, in *KeyLogQuery, opts ...grpc.CallOption) (*KeyLog, error)
+	GetTerms(ctx context.Context, in *ConnectionPayload, opts ...grpc.CallOption) (*Terms, error)
+	AcceptTerms(ctx context.Context, in *TermsPayload, opts ...grpc.CallOption) (*Terms, error)
+	GetNodeStats(ctx context.Context, in *NodeStatsPayload, opts ...grpc.CallOption) (*NodeStats, error)
+	GetHealth(ctx context.Context, in *HealthQuery, opts ...grpc.CallOption) (*Health, error)
 }
 
 type meanderClientIOClient struct {
@@ -59,8 +111,8 @@ func (c *meanderClientIOClient) ConnectClient(ctx context.Context, in *ClientPay
 	return out, nil
 }
 
-func (c *meanderClientIOClient) ValidateToken(ctx context.Context, in *ConnectionPayload, opts ...grpc.CallOption) (*Commit, error) {
-	out := new(Commit)
+func (c *meanderClientIOClient) ValidateToken(ctx context.Context, in *ConnectionPayload, opts ...grpc.CallOption) (*Validation, error) {
+	out := new(Validation)
 	err := c.cc.Invoke(ctx, MeanderClientIO_ValidateToken_FullMethodName, in, out, opts...)
 	if err != nil {
 		return nil, err
@@ -68,13 +120,319 @@ func (c *meanderClientIOClient) ValidateToken(ctx context.Context, in *Connectio
 	return out, nil
 }
 
+func (c *meanderClientIOClient) ValidateTokens(ctx context.Context, in *ConnectionBatch, opts ...grpc.CallOption) (*Commit, error) {
+	out := new(Commit)
+	err := c.cc.Invoke(ctx, MeanderClientIO_ValidateTokens_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *meanderClientIOClient) RefreshToken(ctx context.Context, in *ConnectionPayload, opts ...grpc.CallOption) (*Connection, error) {
+	out := new(Connection)
+	err := c.cc.Invoke(ctx, MeanderClientIO_RefreshToken_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *meanderClientIOClient) Ping(ctx context.Context, in *ConnectionPayload, opts ...grpc.CallOption) (*Heartbeat, error) {
+	out := new(Heartbeat)
+	err := c.cc.Invoke(ctx, MeanderClientIO_Ping_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *meanderClientIOClient) RegisterDevice(ctx context.Context, in *DevicePayload, opts ...grpc.CallOption) (*Device, error) {
+	out := new(Device)
+	err := c.cc.Invoke(ctx, MeanderClientIO_RegisterDevice_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *meanderClientIOClient) ReplayEvents(ctx context.Context, in *ReplayPayload, opts ...grpc.CallOption) (MeanderClientIO_ReplayEventsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &MeanderClientIO_ServiceDesc.Streams[0], MeanderClientIO_ReplayEvents_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &meanderClientIOReplayEventsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type MeanderClientIO_ReplayEventsClient interface {
+	Recv() (*Event, error)
+	grpc.ClientStream
+}
+
+type meanderClientIOReplayEventsClient struct {
+	grpc.ClientStream
+}
+
+func (x *meanderClientIOReplayEventsClient) Recv() (*Event, error) {
+	m := new(Event)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *meanderClientIOClient) GetMetrics(ctx context.Context, in *MetricsPayload, opts ...grpc.CallOption) (*MetricsHistory, error) {
+	out := new(MetricsHistory)
+	err := c.cc.Invoke(ctx, MeanderClientIO_GetMetrics_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *meanderClientIOClient) SubmitTransaction(ctx context.Context, in *TransactionPayload, opts ...grpc.CallOption) (*Receipt, error) {
+	out := new(Receipt)
+	err := c.cc.Invoke(ctx, MeanderClientIO_SubmitTransaction_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *meanderClientIOClient) VerifyChain(ctx context.Context, in *VerifyPayload, opts ...grpc.CallOption) (*ChainReport, error) {
+	out := new(ChainReport)
+	err := c.cc.Invoke(ctx, MeanderClientIO_VerifyChain_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *meanderClientIOClient) ListNodes(ctx context.Context, in *NodesPayload, opts ...grpc.CallOption) (*NodeList, error) {
+	out := new(NodeList)
+	err := c.cc.Invoke(ctx, MeanderClientIO_ListNodes_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *meanderClientIOClient) AcknowledgeTransaction(ctx context.Context, in *AcknowledgmentPayload, opts ...grpc.CallOption) (*Acknowledgment, error) {
+	out := new(Acknowledgment)
+	err := c.cc.Invoke(ctx, MeanderClientIO_AcknowledgeTransaction_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *meanderClientIOClient) GetAcknowledgment(ctx context.Context, in *AcknowledgmentQuery, opts ...grpc.CallOption) (*Acknowledgment, error) {
+	out := new(Acknowledgment)
+	err := c.cc.Invoke(ctx, MeanderClientIO_GetAcknowledgment_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *meanderClientIOClient) ExportCustody(ctx context.Context, in *CustodyQuery, opts ...grpc.CallOption) (*CustodyFile, error) {
+	out := new(CustodyFile)
+	err := c.cc.Invoke(ctx, MeanderClientIO_ExportCustody_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *meanderClientIOClient) GetTransactionProof(ctx context.Context, in *TransactionProofQuery, opts ...grpc.CallOption) (*TransactionProof, error) {
+	out := new(TransactionProof)
+	err := c.cc.Invoke(ctx, MeanderClientIO_GetTransactionProof_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *meanderClientIOClient) GetBalance(ctx context.Context, in *BalanceQuery, opts ...grpc.CallOption) (*Balance, error) {
+	out := new(Balance)
+	err := c.cc.Invoke(ctx, MeanderClientIO_GetBalance_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *meanderClientIOClient) ListTransactions(ctx context.Context, in *TransactionQuery, opts ...grpc.CallOption) (*TransactionList, error) {
+	out := new(TransactionList)
+	err := c.cc.Invoke(ctx, MeanderClientIO_ListTransactions_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *meanderClientIOClient) GetActivity(ctx context.Context, in *ActivityQuery, opts ...grpc.CallOption) (*ActivityFeed, error) {
+	out := new(ActivityFeed)
+	err := c.cc.Invoke(ctx, MeanderClientIO_GetActivity_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *meanderClientIOClient) WatchTransactions(ctx context.Context, in *ConnectionPayload, opts ...grpc.CallOption) (MeanderClientIO_WatchTransactionsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &MeanderClientIO_ServiceDesc.Streams[1], MeanderClientIO_WatchTransactions_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &meanderClientIOWatchTransactionsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type MeanderClientIO_WatchTransactionsClient interface {
+	Recv() (*TransactionEvent, error)
+	grpc.ClientStream
+}
+
+type meanderClientIOWatchTransactionsClient struct {
+	grpc.ClientStream
+}
+
+func (x *meanderClientIOWatchTransactionsClient) Recv() (*TransactionEvent, error) {
+	m := new(TransactionEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *meanderClientIOClient) EraseClient(ctx context.Context, in *ConnectionPayload, opts ...grpc.CallOption) (*Commit, error) {
+	out := new(Commit)
+	err := c.cc.Invoke(ctx, MeanderClientIO_EraseClient_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *meanderClientIOClient) LiquidateClient(ctx context.Context, in *LiquidationPayload, opts ...grpc.CallOption) (*Commit, error) {
+	out := new(Commit)
+	err := c.cc.Invoke(ctx, MeanderClientIO_LiquidateClient_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *meanderClientIOClient) UpdateClient(ctx context.Context, in *ClientUpdate, opts ...grpc.CallOption) (*Client, error) {
+	out := new(Client)
+	err := c.cc.Invoke(ctx, MeanderClientIO_UpdateClient_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *meanderClientIOClient) GetPublicKey(ctx context.Context, in *PublicKeyQuery, opts ...grpc.CallOption) (*PublicKey, error) {
+	out := new(PublicKey)
+	err := c.cc.Invoke(ctx, MeanderClientIO_GetPublicKey_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *meanderClientIOClient) GetKeyLog(ctx context.Context, in *KeyLogQuery, opts ...grpc.CallOption) (*KeyLog, error) {
+	out := new(KeyLog)
+	err := c.cc.Invoke(ctx, MeanderClientIO_GetKeyLog_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *meanderClientIOClient) GetTerms(ctx context.Context, in *ConnectionPayload, opts ...grpc.CallOption) (*Terms, error) {
+	out := new(Terms)
+	err := c.cc.Invoke(ctx, MeanderClientIO_GetTerms_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *meanderClientIOClient) AcceptTerms(ctx context.Context, in *TermsPayload, opts ...grpc.CallOption) (*Terms, error) {
+	out := new(Terms)
+	err := c.cc.Invoke(ctx, MeanderClientIO_AcceptTerms_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *meanderClientIOClient) GetNodeStats(ctx context.Context, in *NodeStatsPayload, opts ...grpc.CallOption) (*NodeStats, error) {
+	out := new(NodeStats)
+	err := c.cc.Invoke(ctx, MeanderClientIO_GetNodeStats_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *meanderClientIOClient) GetHealth(ctx context.Context, in *HealthQuery, opts ...grpc.CallOption) (*Health, error) {
+	out := new(Health)
+	err := c.cc.Invoke(ctx, MeanderClientIO_GetHealth_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // MeanderClientIOServer is the server API for MeanderClientIO service.
 // All implementations must embed UnimplementedMeanderClientIOServer
 // for forward compatibility
 type MeanderClientIOServer interface {
 	CreateClient(context.Context, *ClientPayload) (*Client, error)
 	ConnectClient(context.Context, *ClientPayload) (*Connection, error)
-	ValidateToken(context.Context, *ConnectionPayload) (*Commit, error)
+	ValidateToken(context.Context, *ConnectionPayload) (*Validation, error)
+	ValidateTokens(context.Context, *ConnectionBatch) (*Commit, error)
+	RefreshToken(context.Context, *ConnectionPayload) (*Connection, error)
+	Ping(context.Context, *ConnectionPayload) (*Heartbeat, error)
+	RegisterDevice(context.Context, *DevicePayload) (*Device, error)
+	ReplayEvents(*ReplayPayload, MeanderClientIO_ReplayEventsServer) error
+	GetMetrics(context.Context, *MetricsPayload) (*MetricsHistory, error)
+	SubmitTransaction(context.Context, *TransactionPayload) (*Receipt, error)
+	VerifyChain(context.Context, *VerifyPayload) (*ChainReport, error)
+	ListNodes(context.Context, *NodesPayload) (*NodeList, error)
+	AcknowledgeTransaction(context.Context, *AcknowledgmentPayload) (*Acknowledgment, error)
+	GetAcknowledgment(context.Context, *AcknowledgmentQuery) (*Acknowledgment, error)
+	ExportCustody(context.Context, *CustodyQuery) (*CustodyFile, error)
+	GetTransactionProof(context.Context, *TransactionProofQuery) (*TransactionProof, error)
+	GetBalance(context.Context, *BalanceQuery) (*Balance, error)
+	ListTransactions(context.Context, *TransactionQuery) (*TransactionList, error)
+	GetActivity(context.Context, *ActivityQuery) (*ActivityFeed, error)
+	WatchTransactions(*ConnectionPayload, MeanderClientIO_WatchTransactionsServer) error
+	EraseClient(context.Context, *ConnectionPayload) (*Commit, error)
+	LiquidateClient(context.Context, *LiquidationPayload) (*Commit, error)
+	UpdateClient(context.Context, *ClientUpdate) (*Client, error)
+	GetPublicKey(context.Context, *PublicKeyQuery) (*PublicKey, error)
+	GetKeyLog(context.Context, *KeyLogQuery) (*KeyLog, error)
+	GetTerms(context.Context, *ConnectionPayload) (*Terms, error)
+	AcceptTerms(context.Context, *TermsPayload) (*Terms, error)
+	GetNodeStats(context.Context, *NodeStatsPayload) (*NodeStats, error)
+	GetHealth(context.Context, *HealthQuery) (*Health, error)
 	mustEmbedUnimplementedMeanderClientIOServer()
 }
 
@@ -88,9 +446,87 @@ func (UnimplementedMeanderClientIOServer) CreateClient(context.Context, *ClientP
 func (UnimplementedMeanderClientIOServer) ConnectClient(context.Context, *ClientPayload) (*Connection, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ConnectClient not implemented")
 }
-func (UnimplementedMeanderClientIOServer) ValidateToken(context.Context, *ConnectionPayload) (*Commit, error) {
+func (UnimplementedMeanderClientIOServer) ValidateToken(context.Context, *ConnectionPayload) (*Validation, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ValidateToken not implemented")
 }
+func (UnimplementedMeanderClientIOServer) ValidateTokens(context.Context, *ConnectionBatch) (*Commit, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ValidateTokens not implemented")
+}
+func (UnimplementedMeanderClientIOServer) RefreshToken(context.Context, *ConnectionPayload) (*Connection, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RefreshToken not implemented")
+}
+func (UnimplementedMeanderClientIOServer) Ping(context.Context, *ConnectionPayload) (*Heartbeat, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Ping not implemented")
+}
+func (UnimplementedMeanderClientIOServer) RegisterDevice(context.Context, *DevicePayload) (*Device, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RegisterDevice not implemented")
+}
+func (UnimplementedMeanderClientIOServer) ReplayEvents(*ReplayPayload, MeanderClientIO_ReplayEventsServer) error {
+	return status.Errorf(codes.Unimplemented, "method ReplayEvents not implemented")
+}
+func (UnimplementedMeanderClientIOServer) GetMetrics(context.Context, *MetricsPayload) (*MetricsHistory, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetMetrics not implemented")
+}
+func (UnimplementedMeanderClientIOServer) SubmitTransaction(context.Context, *TransactionPayload) (*Receipt, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SubmitTransaction not implemented")
+}
+func (UnimplementedMeanderClientIOServer) VerifyChain(context.Context, *VerifyPayload) (*ChainReport, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method VerifyChain not implemented")
+}
+func (UnimplementedMeanderClientIOServer) ListNodes(context.Context, *NodesPayload) (*NodeList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListNodes not implemented")
+}
+func (UnimplementedMeanderClientIOServer) AcknowledgeTransaction(context.Context, *AcknowledgmentPayload) (*Acknowledgment, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AcknowledgeTransaction not implemented")
+}
+func (UnimplementedMeanderClientIOServer) GetAcknowledgment(context.Context, *AcknowledgmentQuery) (*Acknowledgment, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAcknowledgment not implemented")
+}
+func (UnimplementedMeanderClientIOServer) ExportCustody(context.Context, *CustodyQuery) (*CustodyFile, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ExportCustody not implemented")
+}
+func (UnimplementedMeanderClientIOServer) GetTransactionProof(context.Context, *TransactionProofQuery) (*TransactionProof, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTransactionProof not implemented")
+}
+func (UnimplementedMeanderClientIOServer) GetBalance(context.Context, *BalanceQuery) (*Balance, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBalance not implemented")
+}
+func (UnimplementedMeanderClientIOServer) ListTransactions(context.Context, *TransactionQuery) (*TransactionList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListTransactions not implemented")
+}
+func (UnimplementedMeanderClientIOServer) GetActivity(context.Context, *ActivityQuery) (*ActivityFeed, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetActivity not implemented")
+}
+func (UnimplementedMeanderClientIOServer) WatchTransactions(*ConnectionPayload, MeanderClientIO_WatchTransactionsServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchTransactions not implemented")
+}
+func (UnimplementedMeanderClientIOServer) EraseClient(context.Context, *ConnectionPayload) (*Commit, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method EraseClient not implemented")
+}
+func (UnimplementedMeanderClientIOServer) LiquidateClient(context.Context, *LiquidationPayload) (*Commit, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LiquidateClient not implemented")
+}
+func (UnimplementedMeanderClientIOServer) UpdateClient(context.Context, *ClientUpdate) (*Client, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateClient not implemented")
+}
+func (UnimplementedMeanderClientIOServer) GetPublicKey(context.Context, *PublicKeyQuery) (*PublicKey, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPublicKey not implemented")
+}
+func (UnimplementedMeanderClientIOServer) GetKeyLog(context.Context, *KeyLogQuery) (*KeyLog, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetKeyLog not implemented")
+}
+func (UnimplementedMeanderClientIOServer) GetTerms(context.Context, *ConnectionPayload) (*Terms, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTerms not implemented")
+}
+func (UnimplementedMeanderClientIOServer) AcceptTerms(context.Context, *TermsPayload) (*Terms, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AcceptTerms not implemented")
+}
+func (UnimplementedMeanderClientIOServer) GetNodeStats(context.Context, *NodeStatsPayload) (*NodeStats, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetNodeStats not implemented")
+}
+func (UnimplementedMeanderClientIOServer) GetHealth(context.Context, *HealthQuery) (*Health, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetHealth not implemented")
+}
 func (UnimplementedMeanderClientIOServer) mustEmbedUnimplementedMeanderClientIOServer() {}
 
 // UnsafeMeanderClientIOServer may be embedded to opt out of forward compatibility for this service.
@@ -158,24 +594,1553 @@ func _MeanderClientIO_ValidateToken_Handler(srv interface{}, ctx context.Context
 	return interceptor(ctx, in, info, handler)
 }
 
-// MeanderClientIO_ServiceDesc is the grpc.ServiceDesc for MeanderClientIO service.
-// It's only intended for direct use with grpc.RegisterService,
-// and not to be introspected or modified (even as a copy)
-var MeanderClientIO_ServiceDesc = grpc.ServiceDesc{
-	ServiceName: "MeanderClientIO",
-	HandlerType: (*MeanderClientIOServer)(nil),
-	Methods: []grpc.MethodDesc{
-		{
-			MethodName: "CreateClient",
-			Handler:    _MeanderClientIO_CreateClient_Handler,
-		},
-		{
-			MethodName: "ConnectClient",
-			Handler:    _MeanderClientIO_ConnectClient_Handler,
-		},
-		{
-			MethodName: "ValidateToken",
-			Handler:    _MeanderClientIO_ValidateToken_Handler,
+func _MeanderClientIO_ValidateTokens_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ConnectionBatch)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MeanderClientIOServer).ValidateTokens(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MeanderClientIO_ValidateTokens_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MeanderClientIOServer).ValidateTokens(ctx, req.(*ConnectionBatch))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MeanderClientIO_RefreshToken_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ConnectionPayload)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MeanderClientIOServer).RefreshToken(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MeanderClientIO_RefreshToken_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MeanderClientIOServer).RefreshToken(ctx, req.(*ConnectionPayload))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MeanderClientIO_Ping_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ConnectionPayload)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MeanderClientIOServer).Ping(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MeanderClientIO_Ping_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MeanderClientIOServer).Ping(ctx, req.(*ConnectionPayload))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MeanderClientIO_RegisterDevice_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DevicePayload)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MeanderClientIOServer).RegisterDevice(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MeanderClientIO_RegisterDevice_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MeanderClientIOServer).RegisterDevice(ctx, req.(*DevicePayload))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MeanderClientIO_ReplayEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ReplayPayload)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(MeanderClientIOServer).ReplayEvents(m, &meanderClientIOReplayEventsServer{stream})
+}
+
+type MeanderClientIO_ReplayEventsServer interface {
+	Send(*Event) error
+	grpc.ServerStream
+}
+
+type meanderClientIOReplayEventsServer struct {
+	grpc.ServerStream
+}
+
+func (x *meanderClientIOReplayEventsServer) Send(m *Event) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _MeanderClientIO_GetMetrics_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MetricsPayload)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MeanderClientIOServer).GetMetrics(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MeanderClientIO_GetMetrics_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MeanderClientIOServer).GetMetrics(ctx, req.(*MetricsPayload))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MeanderClientIO_SubmitTransaction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TransactionPayload)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MeanderClientIOServer).SubmitTransaction(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MeanderClientIO_SubmitTransaction_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MeanderClientIOServer).SubmitTransaction(ctx, req.(*TransactionPayload))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MeanderClientIO_VerifyChain_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VerifyPayload)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MeanderClientIOServer).VerifyChain(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MeanderClientIO_VerifyChain_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MeanderClientIOServer).VerifyChain(ctx, req.(*VerifyPayload))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MeanderClientIO_ListNodes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(NodesPayload)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MeanderClientIOServer).ListNodes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MeanderClientIO_ListNodes_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MeanderClientIOServer).ListNodes(ctx, req.(*NodesPayload))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MeanderClientIO_AcknowledgeTransaction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AcknowledgmentPayload)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MeanderClientIOServer).AcknowledgeTransaction(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MeanderClientIO_AcknowledgeTransaction_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MeanderClientIOServer).AcknowledgeTransaction(ctx, req.(*AcknowledgmentPayload))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MeanderClientIO_GetAcknowledgment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AcknowledgmentQuery)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MeanderClientIOServer).GetAcknowledgment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MeanderClientIO_GetAcknowledgment_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MeanderClientIOServer).GetAcknowledgment(ctx, req.(*AcknowledgmentQuery))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MeanderClientIO_ExportCustody_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CustodyQuery)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MeanderClientIOServer).ExportCustody(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MeanderClientIO_ExportCustody_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MeanderClientIOServer).ExportCustody(ctx, req.(*CustodyQuery))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MeanderClientIO_GetTransactionProof_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TransactionProofQuery)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MeanderClientIOServer).GetTransactionProof(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MeanderClientIO_GetTransactionProof_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MeanderClientIOServer).GetTransactionProof(ctx, req.(*TransactionProofQuery))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MeanderClientIO_GetBalance_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BalanceQuery)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MeanderClientIOServer).GetBalance(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MeanderClientIO_GetBalance_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MeanderClientIOServer).GetBalance(ctx, req.(*BalanceQuery))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MeanderClientIO_ListTransactions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TransactionQuery)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MeanderClientIOServer).ListTransactions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MeanderClientIO_ListTransactions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MeanderClientIOServer).ListTransactions(ctx, req.(*TransactionQuery))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MeanderClientIO_GetActivity_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ActivityQuery)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MeanderClientIOServer).GetActivity(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MeanderClientIO_GetActivity_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MeanderClientIOServer).GetActivity(ctx, req.(*ActivityQuery))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MeanderClientIO_WatchTransactions_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ConnectionPayload)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(MeanderClientIOServer).WatchTransactions(m, &meanderClientIOWatchTransactionsServer{stream})
+}
+
+type MeanderClientIO_WatchTransactionsServer interface {
+	Send(*TransactionEvent) error
+	grpc.ServerStream
+}
+
+type meanderClientIOWatchTransactionsServer struct {
+	grpc.ServerStream
+}
+
+func (x *meanderClientIOWatchTransactionsServer) Send(m *TransactionEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _MeanderClientIO_EraseClient_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ConnectionPayload)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MeanderClientIOServer).EraseClient(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MeanderClientIO_EraseClient_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MeanderClientIOServer).EraseClient(ctx, req.(*ConnectionPayload))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MeanderClientIO_LiquidateClient_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LiquidationPayload)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MeanderClientIOServer).LiquidateClient(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MeanderClientIO_LiquidateClient_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MeanderClientIOServer).LiquidateClient(ctx, req.(*LiquidationPayload))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MeanderClientIO_UpdateClient_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ClientUpdate)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MeanderClientIOServer).UpdateClient(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MeanderClientIO_UpdateClient_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MeanderClientIOServer).UpdateClient(ctx, req.(*ClientUpdate))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MeanderClientIO_GetPublicKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PublicKeyQuery)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MeanderClientIOServer).GetPublicKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MeanderClientIO_GetPublicKey_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MeanderClientIOServer).GetPublicKey(ctx, req.(*PublicKeyQuery))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MeanderClientIO_GetKeyLog_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(KeyLogQuery)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MeanderClientIOServer).GetKeyLog(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MeanderClientIO_GetKeyLog_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MeanderClientIOServer).GetKeyLog(ctx, req.(*KeyLogQuery))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MeanderClientIO_GetTerms_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ConnectionPayload)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MeanderClientIOServer).GetTerms(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MeanderClientIO_GetTerms_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MeanderClientIOServer).GetTerms(ctx, req.(*ConnectionPayload))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MeanderClientIO_AcceptTerms_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TermsPayload)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MeanderClientIOServer).AcceptTerms(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MeanderClientIO_AcceptTerms_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MeanderClientIOServer).AcceptTerms(ctx, req.(*TermsPayload))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MeanderClientIO_GetNodeStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(NodeStatsPayload)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MeanderClientIOServer).GetNodeStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MeanderClientIO_GetNodeStats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MeanderClientIOServer).GetNodeStats(ctx, req.(*NodeStatsPayload))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MeanderClientIO_GetHealth_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HealthQuery)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MeanderClientIOServer).GetHealth(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MeanderClientIO_GetHealth_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MeanderClientIOServer).GetHealth(ctx, req.(*HealthQuery))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// MeanderClientIO_ServiceDesc is the grpc.ServiceDesc for MeanderClientIO service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var MeanderClientIO_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "MeanderClientIO",
+	HandlerType: (*MeanderClientIOServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreateClient",
+			Handler:    _MeanderClientIO_CreateClient_Handler,
+		},
+		{
+			MethodName: "ConnectClient",
+			Handler:    _MeanderClientIO_ConnectClient_Handler,
+		},
+		{
+			MethodName: "ValidateToken",
+			Handler:    _MeanderClientIO_ValidateToken_Handler,
+		},
+		{
+			MethodName: "ValidateTokens",
+			Handler:    _MeanderClientIO_ValidateTokens_Handler,
+		},
+		{
+			MethodName: "RefreshToken",
+			Handler:    _MeanderClientIO_RefreshToken_Handler,
+		},
+		{
+			MethodName: "Ping",
+			Handler:    _MeanderClientIO_Ping_Handler,
+		},
+		{
+			MethodName: "RegisterDevice",
+			Handler:    _MeanderClientIO_RegisterDevice_Handler,
+		},
+		{
+			MethodName: "GetMetrics",
+			Handler:    _MeanderClientIO_GetMetrics_Handler,
+		},
+		{
+			MethodName: "SubmitTransaction",
+			Handler:    _MeanderClientIO_SubmitTransaction_Handler,
+		},
+		{
+			MethodName: "VerifyChain",
+			Handler:    _MeanderClientIO_VerifyChain_Handler,
+		},
+		{
+			MethodName: "ListNodes",
+			Handler:    _MeanderClientIO_ListNodes_Handler,
+		},
+		{
+			MethodName: "AcknowledgeTransaction",
+			Handler:    _MeanderClientIO_AcknowledgeTransaction_Handler,
+		},
+		{
+			MethodName: "GetAcknowledgment",
+			Handler:    _MeanderClientIO_GetAcknowledgment_Handler,
+		},
+		{
+			MethodName: "ExportCustody",
+			Handler:    _MeanderClientIO_ExportCustody_Handler,
+		},
+		{
+			MethodName: "GetTransactionProof",
+			Handler:    _MeanderClientIO_GetTransactionProof_Handler,
+		},
+		{
+			MethodName: "GetBalance",
+			Handler:    _MeanderClientIO_GetBalance_Handler,
+		},
+		{
+			MethodName: "ListTransactions",
+			Handler:    _MeanderClientIO_ListTransactions_Handler,
+		},
+		{
+			MethodName: "GetActivity",
+			Handler:    _MeanderClientIO_GetActivity_Handler,
+		},
+		{
+			MethodName: "EraseClient",
+			Handler:    _MeanderClientIO_EraseClient_Handler,
+		},
+		{
+			MethodName: "LiquidateClient",
+			Handler:    _MeanderClientIO_LiquidateClient_Handler,
+		},
+		{
+			MethodName: "UpdateClient",
+			Handler:    _MeanderClientIO_UpdateClient_Handler,
+		},
+		{
+			MethodName: "GetPublicKey",
+			Handler:    _MeanderClientIO_GetPublicKey_Handler,
+		},
+		{
+			MethodName: "GetKeyLog",
+			Handler:    _MeanderClientIO_GetKeyLog_Handler,
+		},
+		{
+			MethodName: "GetTerms",
+			Handler:    _MeanderClientIO_GetTerms_Handler,
+		},
+		{
+			MethodName: "AcceptTerms",
+			Handler:    _MeanderClientIO_AcceptTerms_Handler,
+		},
+		{
+			MethodName: "GetNodeStats",
+			Handler:    _MeanderClientIO_GetNodeStats_Handler,
+		},
+		{
+			MethodName: "GetHealth",
+			Handler:    _MeanderClientIO_GetHealth_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ReplayEvents",
+			Handler:       _MeanderClientIO_ReplayEvents_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "WatchTransactions",
+			Handler:       _MeanderClientIO_WatchTransactions_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "server.proto",
+}
+
+const (
+	MeanderAdminIO_GetLogging_FullMethodName          = "/MeanderAdminIO/GetLogging"
+	MeanderAdminIO_SetLogging_FullMethodName          = "/MeanderAdminIO/SetLogging"
+	MeanderAdminIO_ImportClients_FullMethodName       = "/MeanderAdminIO/ImportClients"
+	MeanderAdminIO_RequestOperation_FullMethodName    = "/MeanderAdminIO/RequestOperation"
+	MeanderAdminIO_ApproveOperation_FullMethodName    = "/MeanderAdminIO/ApproveOperation"
+	MeanderAdminIO_RejectOperation_FullMethodName     = "/MeanderAdminIO/RejectOperation"
+	MeanderAdminIO_ListOperations_FullMethodName      = "/MeanderAdminIO/ListOperations"
+	MeanderAdminIO_ScheduleMaintenance_FullMethodName = "/MeanderAdminIO/ScheduleMaintenance"
+	MeanderAdminIO_CancelMaintenance_FullMethodName   = "/MeanderAdminIO/CancelMaintenance"
+)
+
+// MeanderAdminIOClient is the client API for MeanderAdminIO service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type MeanderAdminIOClient interface {
+	GetLogging(ctx context.Context, in *LoggingQuery, opts ...grpc.CallOption) (*Logging, error)
+	SetLogging(ctx context.Context, in *LoggingPayload, opts ...grpc.CallOption) (*Logging, error)
+	ImportClients(ctx context.Context, in *ClientManifest, opts ...grpc.CallOption) (MeanderAdminIO_ImportClientsClient, error)
+	RequestOperation(ctx context.Context, in *OperationPayload, opts ...grpc.CallOption) (*OperationRequest, error)
+	ApproveOperation(ctx context.Context, in *OperationDecision, opts ...grpc.CallOption) (*OperationRequest, error)
+	RejectOperation(ctx context.Context, in *OperationDecision, opts ...grpc.CallOption) (*OperationRequest, error)
+	ListOperations(ctx context.Context, in *OperationQuery, opts ...grpc.CallOption) (*OperationRequestList, error)
+	ScheduleMaintenance(ctx context.Context, in *Maintenance, opts ...grpc.CallOption) (*Maintenance, error)
+	CancelMaintenance(ctx context.Context, in *MaintenanceQuery, opts ...grpc.CallOption) (*Commit, error)
+}
+
+type meanderAdminIOClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewMeanderAdminIOClient(cc grpc.ClientConnInterface) MeanderAdminIOClient {
+	return &meanderAdminIOClient{cc}
+}
+
+func (c *meanderAdminIOClient) GetLogging(ctx context.Context, in *LoggingQuery, opts ...grpc.CallOption) (*Logging, error) {
+	out := new(Logging)
+	err := c.cc.Invoke(ctx, MeanderAdminIO_GetLogging_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *meanderAdminIOClient) SetLogging(ctx context.Context, in *LoggingPayload, opts ...grpc.CallOption) (*Logging, error) {
+	out := new(Logging)
+	err := c.cc.Invoke(ctx, MeanderAdminIO_SetLogging_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *meanderAdminIOClient) ImportClients(ctx context.Context, in *ClientManifest, opts ...grpc.CallOption) (MeanderAdminIO_ImportClientsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &MeanderAdminIO_ServiceDesc.Streams[0], MeanderAdminIO_ImportClients_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &meanderAdminIOImportClientsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type MeanderAdminIO_ImportClientsClient interface {
+	Recv() (*ImportProgress, error)
+	grpc.ClientStream
+}
+
+type meanderAdminIOImportClientsClient struct {
+	grpc.ClientStream
+}
+
+func (x *meanderAdminIOImportClientsClient) Recv() (*ImportProgress, error) {
+	m := new(ImportProgress)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *meanderAdminIOClient) RequestOperation(ctx context.Context, in *OperationPayload, opts ...grpc.CallOption) (*OperationRequest, error) {
+	out := new(OperationRequest)
+	err := c.cc.Invoke(ctx, MeanderAdminIO_RequestOperation_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *meanderAdminIOClient) ApproveOperation(ctx context.Context, in *OperationDecision, opts ...grpc.CallOption) (*OperationRequest, error) {
+	out := new(OperationRequest)
+	err := c.cc.Invoke(ctx, MeanderAdminIO_ApproveOperation_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *meanderAdminIOClient) RejectOperation(ctx context.Context, in *OperationDecision, opts ...grpc.CallOption) (*OperationRequest, error) {
+	out := new(OperationRequest)
+	err := c.cc.Invoke(ctx, MeanderAdminIO_RejectOperation_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *meanderAdminIOClient) ListOperations(ctx context.Context, in *OperationQuery, opts ...grpc.CallOption) (*OperationRequestList, error) {
+	out := new(OperationRequestList)
+	err := c.cc.Invoke(ctx, MeanderAdminIO_ListOperations_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *meanderAdminIOClient) ScheduleMaintenance(ctx context.Context, in *Maintenance, opts ...grpc.CallOption) (*Maintenance, error) {
+	out := new(Maintenance)
+	err := c.cc.Invoke(ctx, MeanderAdminIO_ScheduleMaintenance_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *meanderAdminIOClient) CancelMaintenance(ctx context.Context, in *MaintenanceQuery, opts ...grpc.CallOption) (*Commit, error) {
+	out := new(Commit)
+	err := c.cc.Invoke(ctx, MeanderAdminIO_CancelMaintenance_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// MeanderAdminIOServer is the server API for MeanderAdminIO service.
+// All implementations must embed UnimplementedMeanderAdminIOServer
+// for forward compatibility
+type MeanderAdminIOServer interface {
+	GetLogging(context.Context, *LoggingQuery) (*Logging, error)
+	SetLogging(context.Context, *LoggingPayload) (*Logging, error)
+	ImportClients(*ClientManifest, MeanderAdminIO_ImportClientsServer) error
+	RequestOperation(context.Context, *OperationPayload) (*OperationRequest, error)
+	ApproveOperation(context.Context, *OperationDecision) (*OperationRequest, error)
+	RejectOperation(context.Context, *OperationDecision) (*OperationRequest, error)
+	ListOperations(context.Context, *OperationQuery) (*OperationRequestList, error)
+	ScheduleMaintenance(context.Context, *Maintenance) (*Maintenance, error)
+	CancelMaintenance(context.Context, *MaintenanceQuery) (*Commit, error)
+	mustEmbedUnimplementedMeanderAdminIOServer()
+}
+
+// UnimplementedMeanderAdminIOServer must be embedded to have forward compatible implementations.
+type UnimplementedMeanderAdminIOServer struct {
+}
+
+func (UnimplementedMeanderAdminIOServer) GetLogging(context.Context, *LoggingQuery) (*Logging, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetLogging not implemented")
+}
+func (UnimplementedMeanderAdminIOServer) SetLogging(context.Context, *LoggingPayload) (*Logging, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetLogging not implemented")
+}
+func (UnimplementedMeanderAdminIOServer) ImportClients(*ClientManifest, MeanderAdminIO_ImportClientsServer) error {
+	return status.Errorf(codes.Unimplemented, "method ImportClients not implemented")
+}
+func (UnimplementedMeanderAdminIOServer) RequestOperation(context.Context, *OperationPayload) (*OperationRequest, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RequestOperation not implemented")
+}
+func (UnimplementedMeanderAdminIOServer) ApproveOperation(context.Context, *OperationDecision) (*OperationRequest, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ApproveOperation not implemented")
+}
+func (UnimplementedMeanderAdminIOServer) RejectOperation(context.Context, *OperationDecision) (*OperationRequest, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RejectOperation not implemented")
+}
+func (UnimplementedMeanderAdminIOServer) ListOperations(context.Context, *OperationQuery) (*OperationRequestList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListOperations not implemented")
+}
+func (UnimplementedMeanderAdminIOServer) ScheduleMaintenance(context.Context, *Maintenance) (*Maintenance, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ScheduleMaintenance not implemented")
+}
+func (UnimplementedMeanderAdminIOServer) CancelMaintenance(context.Context, *MaintenanceQuery) (*Commit, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CancelMaintenance not implemented")
+}
+func (UnimplementedMeanderAdminIOServer) mustEmbedUnimplementedMeanderAdminIOServer() {}
+
+// UnsafeMeanderAdminIOServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to MeanderAdminIOServer will
+// result in compilation errors.
+type UnsafeMeanderAdminIOServer interface {
+	mustEmbedUnimplementedMeanderAdminIOServer()
+}
+
+func RegisterMeanderAdminIOServer(s grpc.ServiceRegistrar, srv MeanderAdminIOServer) {
+	s.RegisterService(&MeanderAdminIO_ServiceDesc, srv)
+}
+
+func _MeanderAdminIO_GetLogging_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LoggingQuery)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MeanderAdminIOServer).GetLogging(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MeanderAdminIO_GetLogging_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MeanderAdminIOServer).GetLogging(ctx, req.(*LoggingQuery))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MeanderAdminIO_SetLogging_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LoggingPayload)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MeanderAdminIOServer).SetLogging(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MeanderAdminIO_SetLogging_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MeanderAdminIOServer).SetLogging(ctx, req.(*LoggingPayload))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MeanderAdminIO_ImportClients_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ClientManifest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(MeanderAdminIOServer).ImportClients(m, &meanderAdminIOImportClientsServer{stream})
+}
+
+type MeanderAdminIO_ImportClientsServer interface {
+	Send(*ImportProgress) error
+	grpc.ServerStream
+}
+
+type meanderAdminIOImportClientsServer struct {
+	grpc.ServerStream
+}
+
+func (x *meanderAdminIOImportClientsServer) Send(m *ImportProgress) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _MeanderAdminIO_RequestOperation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(OperationPayload)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MeanderAdminIOServer).RequestOperation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MeanderAdminIO_RequestOperation_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MeanderAdminIOServer).RequestOperation(ctx, req.(*OperationPayload))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MeanderAdminIO_ApproveOperation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(OperationDecision)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MeanderAdminIOServer).ApproveOperation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MeanderAdminIO_ApproveOperation_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MeanderAdminIOServer).ApproveOperation(ctx, req.(*OperationDecision))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MeanderAdminIO_RejectOperation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(OperationDecision)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MeanderAdminIOServer).RejectOperation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MeanderAdminIO_RejectOperation_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MeanderAdminIOServer).RejectOperation(ctx, req.(*OperationDecision))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MeanderAdminIO_ListOperations_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(OperationQuery)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MeanderAdminIOServer).ListOperations(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MeanderAdminIO_ListOperations_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MeanderAdminIOServer).ListOperations(ctx, req.(*OperationQuery))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MeanderAdminIO_ScheduleMaintenance_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Maintenance)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MeanderAdminIOServer).ScheduleMaintenance(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MeanderAdminIO_ScheduleMaintenance_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MeanderAdminIOServer).ScheduleMaintenance(ctx, req.(*Maintenance))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MeanderAdminIO_CancelMaintenance_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MaintenanceQuery)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MeanderAdminIOServer).CancelMaintenance(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MeanderAdminIO_CancelMaintenance_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MeanderAdminIOServer).CancelMaintenance(ctx, req.(*MaintenanceQuery))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// MeanderAdminIO_ServiceDesc is the grpc.ServiceDesc for MeanderAdminIO service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var MeanderAdminIO_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "MeanderAdminIO",
+	HandlerType: (*MeanderAdminIOServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetLogging",
+			Handler:    _MeanderAdminIO_GetLogging_Handler,
+		},
+		{
+			MethodName: "SetLogging",
+			Handler:    _MeanderAdminIO_SetLogging_Handler,
+		},
+		{
+			MethodName: "RequestOperation",
+			Handler:    _MeanderAdminIO_RequestOperation_Handler,
+		},
+		{
+			MethodName: "ApproveOperation",
+			Handler:    _MeanderAdminIO_ApproveOperation_Handler,
+		},
+		{
+			MethodName: "RejectOperation",
+			Handler:    _MeanderAdminIO_RejectOperation_Handler,
+		},
+		{
+			MethodName: "ListOperations",
+			Handler:    _MeanderAdminIO_ListOperations_Handler,
+		},
+		{
+			MethodName: "ScheduleMaintenance",
+			Handler:    _MeanderAdminIO_ScheduleMaintenance_Handler,
+		},
+		{
+			MethodName: "CancelMaintenance",
+			Handler:    _MeanderAdminIO_CancelMaintenance_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ImportClients",
+			Handler:       _MeanderAdminIO_ImportClients_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "server.proto",
+}
+
+const (
+	MeanderPeerIO_AnnounceBlock_FullMethodName       = "/MeanderPeerIO/AnnounceBlock"
+	MeanderPeerIO_FetchBlocks_FullMethodName         = "/MeanderPeerIO/FetchBlocks"
+	MeanderPeerIO_FetchDocuments_FullMethodName      = "/MeanderPeerIO/FetchDocuments"
+	MeanderPeerIO_SummarizeIndex_FullMethodName      = "/MeanderPeerIO/SummarizeIndex"
+	MeanderPeerIO_FetchDocumentRange_FullMethodName  = "/MeanderPeerIO/FetchDocumentRange"
+	MeanderPeerIO_AnnounceAddress_FullMethodName     = "/MeanderPeerIO/AnnounceAddress"
+	MeanderPeerIO_AnnounceDeparture_FullMethodName   = "/MeanderPeerIO/AnnounceDeparture"
+	MeanderPeerIO_AnnounceErasure_FullMethodName     = "/MeanderPeerIO/AnnounceErasure"
+	MeanderPeerIO_AnnounceClient_FullMethodName      = "/MeanderPeerIO/AnnounceClient"
+	MeanderPeerIO_ExchangePeers_FullMethodName       = "/MeanderPeerIO/ExchangePeers"
+	MeanderPeerIO_Handshake_FullMethodName           = "/MeanderPeerIO/Handshake"
+	MeanderPeerIO_RouteTransaction_FullMethodName    = "/MeanderPeerIO/RouteTransaction"
+	MeanderPeerIO_RouteAcknowledgment_FullMethodName = "/MeanderPeerIO/RouteAcknowledgment"
+)
+
+// MeanderPeerIOClient is the client API for MeanderPeerIO service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type MeanderPeerIOClient interface {
+	AnnounceBlock(ctx context.Context, in *Block, opts ...grpc.CallOption) (*Commit, error)
+	FetchBlocks(ctx context.Context, in *BlockRange, opts ...grpc.CallOption) (*BlockList, error)
+	FetchDocuments(ctx context.Context, in *DocumentRange, opts ...grpc.CallOption) (*DocumentList, error)
+	SummarizeIndex(ctx context.Context, in *IndexRange, opts ...grpc.CallOption) (*IndexSummary, error)
+	FetchDocumentRange(ctx context.Context, in *IndexRange, opts ...grpc.CallOption) (*DocumentList, error)
+	AnnounceAddress(ctx context.Context, in *AddressChange, opts ...grpc.CallOption) (*Commit, error)
+	AnnounceDeparture(ctx context.Context, in *Departure, opts ...grpc.CallOption) (*Commit, error)
+	AnnounceErasure(ctx context.Context, in *Erasure, opts ...grpc.CallOption) (*Commit, error)
+	AnnounceClient(ctx context.Context, in *ClientAnnouncement, opts ...grpc.CallOption) (*Commit, error)
+	ExchangePeers(ctx context.Context, in *PeerExchange, opts ...grpc.CallOption) (*PeerExchange, error)
+	Handshake(ctx context.Context, in *Handshake, opts ...grpc.CallOption) (*Handshake, error)
+	RouteTransaction(ctx context.Context, in *RoutedTransaction, opts ...grpc.CallOption) (*Commit, error)
+	RouteAcknowledgment(ctx context.Context, in *Acknowledgment, opts ...grpc.CallOption) (*Commit, error)
+}
+
+type meanderPeerIOClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewMeanderPeerIOClient(cc grpc.ClientConnInterface) MeanderPeerIOClient {
+	return &meanderPeerIOClient{cc}
+}
+
+func (c *meanderPeerIOClient) AnnounceBlock(ctx context.Context, in *Block, opts ...grpc.CallOption) (*Commit, error) {
+	out := new(Commit)
+	err := c.cc.Invoke(ctx, MeanderPeerIO_AnnounceBlock_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *meanderPeerIOClient) FetchBlocks(ctx context.Context, in *BlockRange, opts ...grpc.CallOption) (*BlockList, error) {
+	out := new(BlockList)
+	err := c.cc.Invoke(ctx, MeanderPeerIO_FetchBlocks_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *meanderPeerIOClient) FetchDocuments(ctx context.Context, in *DocumentRange, opts ...grpc.CallOption) (*DocumentList, error) {
+	out := new(DocumentList)
+	err := c.cc.Invoke(ctx, MeanderPeerIO_FetchDocuments_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *meanderPeerIOClient) SummarizeIndex(ctx context.Context, in *IndexRange, opts ...grpc.CallOption) (*IndexSummary, error) {
+	out := new(IndexSummary)
+	err := c.cc.Invoke(ctx, MeanderPeerIO_SummarizeIndex_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *meanderPeerIOClient) FetchDocumentRange(ctx context.Context, in *IndexRange, opts ...grpc.CallOption) (*DocumentList, error) {
+	out := new(DocumentList)
+	err := c.cc.Invoke(ctx, MeanderPeerIO_FetchDocumentRange_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *meanderPeerIOClient) AnnounceAddress(ctx context.Context, in *AddressChange, opts ...grpc.CallOption) (*Commit, error) {
+	out := new(Commit)
+	err := c.cc.Invoke(ctx, MeanderPeerIO_AnnounceAddress_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *meanderPeerIOClient) AnnounceDeparture(ctx context.Context, in *Departure, opts ...grpc.CallOption) (*Commit, error) {
+	out := new(Commit)
+	err := c.cc.Invoke(ctx, MeanderPeerIO_AnnounceDeparture_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *meanderPeerIOClient) AnnounceErasure(ctx context.Context, in *Erasure, opts ...grpc.CallOption) (*Commit, error) {
+	out := new(Commit)
+	err := c.cc.Invoke(ctx, MeanderPeerIO_AnnounceErasure_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *meanderPeerIOClient) AnnounceClient(ctx context.Context, in *ClientAnnouncement, opts ...grpc.CallOption) (*Commit, error) {
+	out := new(Commit)
+	err := c.cc.Invoke(ctx, MeanderPeerIO_AnnounceClient_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *meanderPeerIOClient) ExchangePeers(ctx context.Context, in *PeerExchange, opts ...grpc.CallOption) (*PeerExchange, error) {
+	out := new(PeerExchange)
+	err := c.cc.Invoke(ctx, MeanderPeerIO_ExchangePeers_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *meanderPeerIOClient) Handshake(ctx context.Context, in *Handshake, opts ...grpc.CallOption) (*Handshake, error) {
+	out := new(Handshake)
+	err := c.cc.Invoke(ctx, MeanderPeerIO_Handshake_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *meanderPeerIOClient) RouteTransaction(ctx context.Context, in *RoutedTransaction, opts ...grpc.CallOption) (*Commit, error) {
+	out := new(Commit)
+	err := c.cc.Invoke(ctx, MeanderPeerIO_RouteTransaction_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *meanderPeerIOClient) RouteAcknowledgment(ctx context.Context, in *Acknowledgment, opts ...grpc.CallOption) (*Commit, error) {
+	out := new(Commit)
+	err := c.cc.Invoke(ctx, MeanderPeerIO_RouteAcknowledgment_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// MeanderPeerIOServer is the server API for MeanderPeerIO service.
+// All implementations must embed UnimplementedMeanderPeerIOServer
+// for forward compatibility
+type MeanderPeerIOServer interface {
+	AnnounceBlock(context.Context, *Block) (*Commit, error)
+	FetchBlocks(context.Context, *BlockRange) (*BlockList, error)
+	FetchDocuments(context.Context, *DocumentRange) (*DocumentList, error)
+	SummarizeIndex(context.Context, *IndexRange) (*IndexSummary, error)
+	FetchDocumentRange(context.Context, *IndexRange) (*DocumentList, error)
+	AnnounceAddress(context.Context, *AddressChange) (*Commit, error)
+	AnnounceDeparture(context.Context, *Departure) (*Commit, error)
+	AnnounceErasure(context.Context, *Erasure) (*Commit, error)
+	AnnounceClient(context.Context, *ClientAnnouncement) (*Commit, error)
+	ExchangePeers(context.Context, *PeerExchange) (*PeerExchange, error)
+	Handshake(context.Context, *Handshake) (*Handshake, error)
+	RouteTransaction(context.Context, *RoutedTransaction) (*Commit, error)
+	RouteAcknowledgment(context.Context, *Acknowledgment) (*Commit, error)
+	mustEmbedUnimplementedMeanderPeerIOServer()
+}
+
+// UnimplementedMeanderPeerIOServer must be embedded to have forward compatible implementations.
+type UnimplementedMeanderPeerIOServer struct {
+}
+
+func (UnimplementedMeanderPeerIOServer) AnnounceBlock(context.Context, *Block) (*Commit, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AnnounceBlock not implemented")
+}
+func (UnimplementedMeanderPeerIOServer) FetchBlocks(context.Context, *BlockRange) (*BlockList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FetchBlocks not implemented")
+}
+func (UnimplementedMeanderPeerIOServer) FetchDocuments(context.Context, *DocumentRange) (*DocumentList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FetchDocuments not implemented")
+}
+func (UnimplementedMeanderPeerIOServer) SummarizeIndex(context.Context, *IndexRange) (*IndexSummary, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SummarizeIndex not implemented")
+}
+func (UnimplementedMeanderPeerIOServer) FetchDocumentRange(context.Context, *IndexRange) (*DocumentList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FetchDocumentRange not implemented")
+}
+func (UnimplementedMeanderPeerIOServer) AnnounceAddress(context.Context, *AddressChange) (*Commit, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AnnounceAddress not implemented")
+}
+func (UnimplementedMeanderPeerIOServer) AnnounceDeparture(context.Context, *Departure) (*Commit, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AnnounceDeparture not implemented")
+}
+func (UnimplementedMeanderPeerIOServer) AnnounceErasure(context.Context, *Erasure) (*Commit, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AnnounceErasure not implemented")
+}
+func (UnimplementedMeanderPeerIOServer) AnnounceClient(context.Context, *ClientAnnouncement) (*Commit, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AnnounceClient not implemented")
+}
+func (UnimplementedMeanderPeerIOServer) ExchangePeers(context.Context, *PeerExchange) (*PeerExchange, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ExchangePeers not implemented")
+}
+func (UnimplementedMeanderPeerIOServer) Handshake(context.Context, *Handshake) (*Handshake, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Handshake not implemented")
+}
+func (UnimplementedMeanderPeerIOServer) RouteTransaction(context.Context, *RoutedTransaction) (*Commit, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RouteTransaction not implemented")
+}
+func (UnimplementedMeanderPeerIOServer) RouteAcknowledgment(context.Context, *Acknowledgment) (*Commit, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RouteAcknowledgment not implemented")
+}
+func (UnimplementedMeanderPeerIOServer) mustEmbedUnimplementedMeanderPeerIOServer() {}
+
+// UnsafeMeanderPeerIOServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to MeanderPeerIOServer will
+// result in compilation errors.
+type UnsafeMeanderPeerIOServer interface {
+	mustEmbedUnimplementedMeanderPeerIOServer()
+}
+
+func RegisterMeanderPeerIOServer(s grpc.ServiceRegistrar, srv MeanderPeerIOServer) {
+	s.RegisterService(&MeanderPeerIO_ServiceDesc, srv)
+}
+
+func _MeanderPeerIO_AnnounceBlock_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Block)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MeanderPeerIOServer).AnnounceBlock(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MeanderPeerIO_AnnounceBlock_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MeanderPeerIOServer).AnnounceBlock(ctx, req.(*Block))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MeanderPeerIO_FetchBlocks_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BlockRange)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MeanderPeerIOServer).FetchBlocks(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MeanderPeerIO_FetchBlocks_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MeanderPeerIOServer).FetchBlocks(ctx, req.(*BlockRange))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MeanderPeerIO_FetchDocuments_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DocumentRange)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MeanderPeerIOServer).FetchDocuments(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MeanderPeerIO_FetchDocuments_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MeanderPeerIOServer).FetchDocuments(ctx, req.(*DocumentRange))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MeanderPeerIO_SummarizeIndex_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IndexRange)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MeanderPeerIOServer).SummarizeIndex(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MeanderPeerIO_SummarizeIndex_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MeanderPeerIOServer).SummarizeIndex(ctx, req.(*IndexRange))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MeanderPeerIO_FetchDocumentRange_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IndexRange)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MeanderPeerIOServer).FetchDocumentRange(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MeanderPeerIO_FetchDocumentRange_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MeanderPeerIOServer).FetchDocumentRange(ctx, req.(*IndexRange))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MeanderPeerIO_AnnounceAddress_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddressChange)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MeanderPeerIOServer).AnnounceAddress(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MeanderPeerIO_AnnounceAddress_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MeanderPeerIOServer).AnnounceAddress(ctx, req.(*AddressChange))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MeanderPeerIO_AnnounceDeparture_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Departure)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MeanderPeerIOServer).AnnounceDeparture(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MeanderPeerIO_AnnounceDeparture_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MeanderPeerIOServer).AnnounceDeparture(ctx, req.(*Departure))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MeanderPeerIO_AnnounceErasure_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Erasure)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MeanderPeerIOServer).AnnounceErasure(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MeanderPeerIO_AnnounceErasure_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MeanderPeerIOServer).AnnounceErasure(ctx, req.(*Erasure))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MeanderPeerIO_AnnounceClient_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ClientAnnouncement)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MeanderPeerIOServer).AnnounceClient(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MeanderPeerIO_AnnounceClient_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MeanderPeerIOServer).AnnounceClient(ctx, req.(*ClientAnnouncement))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MeanderPeerIO_ExchangePeers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PeerExchange)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MeanderPeerIOServer).ExchangePeers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MeanderPeerIO_ExchangePeers_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MeanderPeerIOServer).ExchangePeers(ctx, req.(*PeerExchange))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MeanderPeerIO_Handshake_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Handshake)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MeanderPeerIOServer).Handshake(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MeanderPeerIO_Handshake_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MeanderPeerIOServer).Handshake(ctx, req.(*Handshake))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MeanderPeerIO_RouteTransaction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RoutedTransaction)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MeanderPeerIOServer).RouteTransaction(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MeanderPeerIO_RouteTransaction_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MeanderPeerIOServer).RouteTransaction(ctx, req.(*RoutedTransaction))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MeanderPeerIO_RouteAcknowledgment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Acknowledgment)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MeanderPeerIOServer).RouteAcknowledgment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MeanderPeerIO_RouteAcknowledgment_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MeanderPeerIOServer).RouteAcknowledgment(ctx, req.(*Acknowledgment))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// MeanderPeerIO_ServiceDesc is the grpc.ServiceDesc for MeanderPeerIO service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var MeanderPeerIO_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "MeanderPeerIO",
+	HandlerType: (*MeanderPeerIOServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "AnnounceBlock",
+			Handler:    _MeanderPeerIO_AnnounceBlock_Handler,
+		},
+		{
+			MethodName: "FetchBlocks",
+			Handler:    _MeanderPeerIO_FetchBlocks_Handler,
+		},
+		{
+			MethodName: "FetchDocuments",
+			Handler:    _MeanderPeerIO_FetchDocuments_Handler,
+		},
+		{
+			MethodName: "SummarizeIndex",
+			Handler:    _MeanderPeerIO_SummarizeIndex_Handler,
+		},
+		{
+			MethodName: "FetchDocumentRange",
+			Handler:    _MeanderPeerIO_FetchDocumentRange_Handler,
+		},
+		{
+			MethodName: "AnnounceAddress",
+			Handler:    _MeanderPeerIO_AnnounceAddress_Handler,
+		},
+		{
+			MethodName: "AnnounceDeparture",
+			Handler:    _MeanderPeerIO_AnnounceDeparture_Handler,
+		},
+		{
+			MethodName: "AnnounceErasure",
+			Handler:    _MeanderPeerIO_AnnounceErasure_Handler,
+		},
+		{
+			MethodName: "AnnounceClient",
+			Handler:    _MeanderPeerIO_AnnounceClient_Handler,
+		},
+		{
+			MethodName: "ExchangePeers",
+			Handler:    _MeanderPeerIO_ExchangePeers_Handler,
+		},
+		{
+			MethodName: "Handshake",
+			Handler:    _MeanderPeerIO_Handshake_Handler,
+		},
+		{
+			MethodName: "RouteTransaction",
+			Handler:    _MeanderPeerIO_RouteTransaction_Handler,
+		},
+		{
+			MethodName: "RouteAcknowledgment",
+			Handler:    _MeanderPeerIO_RouteAcknowledgment_Handler,
 		},
 	},
 	Streams:  []grpc.StreamDesc{},
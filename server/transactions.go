@@ -0,0 +1,229 @@
+package pb
+
+import (
+	"context"
+	"math"
+	node "node/node"
+
+	"google.golang.org/grpc/codes"
+)
+
+func (s *MeanderServer) SubmitTransaction(ctx context.Context, p *TransactionPayload) (*Receipt, error) {
+	// The comparisons are negated, so the NaN (that fails all of them) is refused too
+	if p.Recipient == "" || !(p.Value > 0) || math.IsInf(p.Value, 0) || !(p.Fee >= 0) || math.IsInf(p.Fee, 0) {
+		return nil, statusError(codes.InvalidArgument, ReasonInvalidPayload, "submit transaction request requires: recipient, a finite positive value, a finite fee that isn't negative")
+	}
+
+	sender := authenticatedClient(ctx)
+	if err := sender.Node.CheckWritable(); err != nil {
+		return nil, statusError(codes.FailedPrecondition, ReasonReadOnly, "failed to submit transaction: %v", err)
+	}
+
+	transaction, err := sender.NewTransaction(ctx, p.Recipient, p.Value, p.Fee)
+	if err != nil {
+		return nil, nodeStatusError(err)
+	}
+
+	if p.Memo != "" {
+		if err := transaction.SealMemo(p.Memo); err != nil {
+			return nil, nodeStatusError(err)
+		}
+	}
+
+	if len(p.Payload) > 0 {
+		if err := transaction.AttachPayload(p.Payload); err != nil {
+			return nil, nodeStatusError(err)
+		}
+	}
+
+	if p.Stealth {
+		if err := transaction.UseStealthAddress(ctx); err != nil {
+			return nil, nodeStatusError(err)
+		}
+	}
+
+	if err := transaction.SignTransaction(ctx); err != nil {
+		return nil, nodeStatusError(err)
+	}
+
+	receipt := Receipt{
+		TransactionId: transaction.TransactionId,
+		Status:        string(transaction.Status()),
+		Sequence:      transaction.Sequence,
+		Timestamp:     transaction.Timestamp,
+		Fee:           transaction.Fee,
+	}
+
+	return &receipt, nil
+}
+
+// Converts an acknowledgment of the node into its message
+func acknowledgmentMessage(acknowledgment node.Acknowledgment) *Acknowledgment {
+	return &Acknowledgment{
+		TransactionId: acknowledgment.TransactionId,
+		Recipient:     acknowledgment.Recipient,
+		Transaction:   []byte(acknowledgment.Transaction),
+		SignedAt:      acknowledgment.SignedAt,
+		Signature:     []byte(acknowledgment.Signature),
+	}
+}
+
+func (s *MeanderServer) AcknowledgeTransaction(ctx context.Context, p *AcknowledgmentPayload) (*Acknowledgment, error) {
+	if p.TransactionId == "" {
+		return nil, statusError(codes.InvalidArgument, ReasonInvalidPayload, "acknowledge transaction request requires: transaction_id")
+	}
+
+	recipient := authenticatedClient(ctx)
+	if err := recipient.Node.CheckWritable(); err != nil {
+		return nil, statusError(codes.FailedPrecondition, ReasonReadOnly, "failed to acknowledge transaction: %v", err)
+	}
+
+	acknowledgment, err := recipient.AcknowledgeTransaction(ctx, p.TransactionId)
+	if err != nil {
+		return nil, nodeStatusError(err)
+	}
+
+	return acknowledgmentMessage(*acknowledgment), nil
+}
+
+func (s *MeanderServer) GetAcknowledgment(ctx context.Context, p *AcknowledgmentQuery) (*Acknowledgment, error) {
+	if p.TransactionId == "" {
+		return nil, statusError(codes.InvalidArgument, ReasonInvalidPayload, "get acknowledgment request requires: transaction_id")
+	}
+
+	local, err := localNode(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	acknowledgment, err := local.TransactionAcknowledgment(ctx, p.TransactionId)
+	if err != nil {
+		return nil, nodeStatusError(err)
+	}
+
+	if acknowledgment == nil {
+		return nil, statusError(codes.NotFound, ReasonNotFound, "the transaction %s wasn't acknowledged yet", p.TransactionId)
+	}
+
+	return acknowledgmentMessage(*acknowledgment), nil
+}
+
+func (s *MeanderServer) GetBalance(ctx context.Context, p *BalanceQuery) (*Balance, error) {
+	owner := authenticatedClient(ctx)
+	if owner == nil && p.ClientId == "" {
+		return nil, statusError(codes.InvalidArgument, ReasonInvalidPayload, "get balance request requires: user_id and token, or client_id")
+	}
+
+	var local *node.Node
+	var clientId string
+	var err error
+
+	if owner != nil {
+		local, clientId = owner.Node, owner.ClientId
+	} else {
+		if local, err = localNode(ctx); err != nil {
+			return nil, err
+		}
+
+		if _, err := local.RetrieveForeignClient(ctx, p.ClientId); err != nil {
+			return nil, nodeStatusError(err)
+		}
+		clientId = p.ClientId
+	}
+
+	summary, err := local.SummarizeBalance(ctx, clientId)
+	if err != nil {
+		return nil, nodeStatusError(err)
+	}
+
+	balance := Balance{
+		ClientId:  summary.ClientId,
+		Confirmed: summary.Confirmed,
+		Pending:   summary.Pending,
+		Available: summary.Available,
+		Height:    summary.Height,
+	}
+
+	return &balance, nil
+}
+
+func (s *MeanderServer) ListTransactions(ctx context.Context, p *TransactionQuery) (*TransactionList, error) {
+	owner := authenticatedClient(ctx)
+	filter := node.TransactionFilter{
+		Since:        p.Since,
+		Until:        p.Until,
+		Counterparty: p.Counterparty,
+		Status:       p.Status,
+		Direction:    p.Direction,
+	}
+
+	records, total, err := owner.Node.TransactionHistory(ctx, owner.ClientId, filter, int(p.Offset), int(p.Size))
+	if err != nil {
+		return nil, nodeStatusError(err)
+	}
+
+	response := TransactionList{Total: total}
+	for _, record := range records {
+		response.Transactions = append(response.Transactions, &TransactionEntry{
+			TransactionId: record.TransactionId,
+			Sender:        record.Sender,
+			Recipient:     record.Recipient,
+			Value:         record.Value,
+			Fee:           record.Fee,
+			Payload:       record.Payload,
+			Timestamp:     record.Timestamp,
+			Sequence:      record.Sequence,
+			Status:        string(record.Status()),
+			BlockHash:     record.BlockHash,
+			Memo:          owner.ReadMemo(record),
+		})
+	}
+
+	return &response, nil
+}
+
+func (s *MeanderServer) GetActivity(ctx context.Context, p *ActivityQuery) (*ActivityFeed, error) {
+	owner := authenticatedClient(ctx)
+
+	activity, next, err := owner.Node.ClientActivity(ctx, owner.UID, p.Cursor, int(p.Size))
+	if err != nil {
+		return nil, nodeStatusError(err)
+	}
+
+	feed := ActivityFeed{NextCursor: next}
+	for _, item := range activity {
+		feed.Items = append(feed.Items, &ActivityItem{
+			Kind:         item.Kind,
+			Timestamp:    item.Timestamp,
+			Reference:    item.Reference,
+			Counterparty: item.Counterparty,
+			Value:        item.Value,
+			Details:      item.Details,
+		})
+	}
+
+	return &feed, nil
+}
+
+func (s *MeanderServer) WatchTransactions(p *ConnectionPayload, stream MeanderClientIO_WatchTransactionsServer) error {
+	ctx := stream.Context()
+	owner := authenticatedClient(ctx)
+
+	err := owner.Node.WatchTransactions(ctx, owner.ClientId, func(event node.FeedEvent) error {
+		return stream.Send(&TransactionEvent{
+			Kind:          event.Kind,
+			TransactionId: event.TransactionId,
+			Sender:        event.Sender,
+			Recipient:     event.Recipient,
+			Value:         event.Value,
+			Timestamp:     event.Timestamp,
+			BlockHash:     event.BlockHash,
+			Height:        event.Height,
+		})
+	})
+	if err != nil {
+		return statusError(codes.Aborted, ReasonInternal, "failed to watch the transactions: %v", err)
+	}
+
+	return nil
+}
@@ -1,59 +1,23 @@
 package pb
 
 import (
+	"context"
 	"crypto/subtle"
-	"fmt"
-	backlog "node/backlog"
-	client "node/client"
+	node "node/node"
+
+	"google.golang.org/grpc/codes"
 )
 
 func compareDigest(a, b []byte) bool {
 	return subtle.ConstantTimeCompare(a, b) == 1
 }
 
-func validateToken(uid, secret, token string) bool {
-	privateKey, err := client.DownloadPrivateKey(secret, uid)
-
+// Gives the local node. The error is a gRPC status error ready to be returned
+func localNode(ctx context.Context) (*node.Node, error) {
+	local, err := node.GetLocalNode(ctx)
 	if err != nil {
-		fmt.Printf("failed to download private key: %v\n", err)
-		return false
+		return nil, statusError(codes.Unavailable, ReasonBacklog, "failed to load the node: %v", err)
 	}
 
-	publicKey, err := client.DownloadPublicKey(uid)
-
-	if err != nil {
-		fmt.Printf("failed to download public key: %v\n", err)
-		return false
-	}
-
-	crypto := client.CryptoResource{
-		PrivateKey: privateKey,
-		PublicKey:  publicKey,
-	}
-
-	payload, err := crypto.DecryptToken(token)
-	if err != nil {
-		fmt.Printf("failed to decrypt the token: %v\n", err)
-		return false
-	}
-
-	backlog := backlog.NewBacklog()
-	cache, err := backlog.GetDocument("cache", uid)
-	if err != nil {
-		fmt.Printf("failed to get cache document: %v\n", err)
-		return false
-	}
-
-	matchA := compareDigest(
-		[]byte(cache["computed_key_a"].(string)),
-		[]byte(payload["computed_key_a"].(string)),
-	)
-
-	matchP := compareDigest(
-		[]byte(cache["computed_key_p"].(string)),
-		[]byte(payload["computed_key_p"].(string)),
-	)
-
-	return matchA && matchP
-
+	return local, nil
 }
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"node/node"
+	"os"
+)
+
+// Checks a custody bundle offline and prints the findings. Gives the exit code of the command
+func runVerify(args []string) int {
+	if len(args) != 1 {
+		fmt.Println("usage: meander verify <custody file>")
+		return 2
+	}
+
+	content, err := os.ReadFile(args[0])
+	if err != nil {
+		fmt.Printf("failed to read the custody file: %v\n", err)
+		return 1
+	}
+
+	bundle, err := node.ParseCustody(content)
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+
+	failures := 0
+	for _, finding := range bundle.Verify() {
+		mark := "ok"
+		if !finding.Ok {
+			mark = "FAIL"
+			failures++
+		}
+
+		fmt.Printf("[%4s] %-24s %s\n", mark, finding.Check, finding.Detail)
+	}
+
+	if failures > 0 {
+		fmt.Printf("\n%d check(s) failed\n", failures)
+		return 1
+	}
+
+	fmt.Printf("\nThe transaction %s is verified\n", bundle.TransactionId)
+	return 0
+}